@@ -0,0 +1,54 @@
+// analyze runs the pcap-analyzer engine on a single local capture file and
+// writes the results as JSON, without the HTTP server, sessions or SQLite.
+// Useful for air-gapped machines and CI pipelines.
+//
+// Usage:
+//
+//	analyze [-o results.json] [-pretty] capture.pcap
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+)
+
+func main() {
+	output := flag.String("o", "", "write results to this file instead of stdout")
+	pretty := flag.Bool("pretty", false, "indent the JSON output")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: analyze [-o results.json] [-pretty] capture.pcap")
+		os.Exit(2)
+	}
+
+	results, err := analyzer.AnalyzeFile(0, flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "analyze:", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "analyze:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	if *pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(results); err != nil {
+		fmt.Fprintln(os.Stderr, "analyze:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,406 @@
+// pcapctl is a command-line client for the pcap-analyzer REST API,
+// enabling scripted workflows: upload a capture, wait for it to finish,
+// pull the results as JSON or CSV.
+//
+// Usage:
+//
+//	pcapctl -server http://localhost:8080 login -user alice
+//	pcapctl upload capture.pcap [-wait]
+//	pcapctl list
+//	pcapctl status <id>
+//	pcapctl results <id> [-what connections|assets|findings|dns] [-format json|csv]
+//	pcapctl delete <id>
+//
+// The session token is cached in ~/.pcapctl.json after login, or can be
+// supplied via the PCAPCTL_TOKEN environment variable.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func main() {
+	server := flag.String("server", envOr("PCAPCTL_SERVER", "http://localhost:8080"),
+		"base URL of the pcap-analyzer server")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	c := &client{
+		server: strings.TrimRight(*server, "/"),
+		token:  loadToken(),
+		http:   &http.Client{Timeout: 10 * time.Minute},
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	var err error
+	switch cmd {
+	case "login":
+		err = c.cmdLogin(args)
+	case "upload":
+		err = c.cmdUpload(args)
+	case "list":
+		err = c.cmdList()
+	case "status":
+		err = c.cmdStatus(args)
+	case "results":
+		err = c.cmdResults(args)
+	case "delete":
+		err = c.cmdDelete(args)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pcapctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr,
+		"usage: pcapctl [-server URL] <login|upload|list|status|results|delete> [args]")
+}
+
+func (c *client) cmdLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	user := fs.String("user", "", "username")
+	fs.Parse(args)
+	if *user == "" {
+		return fmt.Errorf("login: -user is required")
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]string{"username": *user, "password": string(pw)})
+	resp, err := c.http.Post(c.server+"/api/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return err
+	}
+	if err := saveToken(out.Token); err != nil {
+		return err
+	}
+	fmt.Println("logged in")
+	return nil
+}
+
+func (c *client) cmdUpload(args []string) error {
+	fs := flag.NewFlagSet("upload", flag.ExitOnError)
+	wait := fs.Bool("wait", false, "poll until the analysis finishes")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("upload: exactly one capture file expected")
+	}
+	path := fs.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return err
+	}
+	mw.Close()
+
+	req, err := http.NewRequest(http.MethodPost, c.server+"/api/upload", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return apiError(resp)
+	}
+	var analysis struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
+		return err
+	}
+	fmt.Printf("analysis %d queued\n", analysis.ID)
+	if *wait {
+		return c.waitFor(analysis.ID)
+	}
+	return nil
+}
+
+func (c *client) waitFor(id int64) error {
+	for {
+		status, errMsg, err := c.fetchStatus(id)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case "completed":
+			fmt.Printf("analysis %d completed\n", id)
+			return nil
+		case "failed":
+			return fmt.Errorf("analysis %d failed: %s", id, errMsg)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func (c *client) fetchStatus(id int64) (status, errMsg string, err error) {
+	resp, err := c.get(fmt.Sprintf("%s/api/analysis/%d", c.server, id))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", apiError(resp)
+	}
+	var analysis struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
+		return "", "", err
+	}
+	return analysis.Status, analysis.Error, nil
+}
+
+func (c *client) cmdList() error {
+	resp, err := c.get(c.server + "/api/analyses")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	var analyses []struct {
+		ID          int64  `json:"id"`
+		Filename    string `json:"filename"`
+		Status      string `json:"status"`
+		PacketCount int64  `json:"packetCount"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&analyses); err != nil {
+		return err
+	}
+	for _, a := range analyses {
+		fmt.Printf("%d\t%s\t%s\t%d packets\n", a.ID, a.Status, a.Filename, a.PacketCount)
+	}
+	return nil
+}
+
+func (c *client) cmdStatus(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("status: analysis id expected")
+	}
+	status, errMsg, err := c.fetchStatus(atoi64(args[0]))
+	if err != nil {
+		return err
+	}
+	if errMsg != "" {
+		fmt.Printf("%s: %s\n", status, errMsg)
+	} else {
+		fmt.Println(status)
+	}
+	return nil
+}
+
+func (c *client) cmdResults(args []string) error {
+	fs := flag.NewFlagSet("results", flag.ExitOnError)
+	what := fs.String("what", "connections", "connections, assets, findings or dns")
+	format := fs.String("format", "json", "json or csv")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("results: analysis id expected")
+	}
+
+	resp, err := c.get(fmt.Sprintf("%s/api/analysis/%s/%s", c.server, fs.Arg(0), *what))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+
+	switch *format {
+	case "json":
+		_, err = io.Copy(os.Stdout, resp.Body)
+		return err
+	case "csv":
+		return jsonToCSV(resp.Body, os.Stdout)
+	default:
+		return fmt.Errorf("results: unknown format %q", *format)
+	}
+}
+
+func (c *client) cmdDelete(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("delete: analysis id expected")
+	}
+	req, err := http.NewRequest(http.MethodDelete,
+		fmt.Sprintf("%s/api/analysis/%s", c.server, args[0]), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	fmt.Println("deleted")
+	return nil
+}
+
+func (c *client) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("not logged in; run pcapctl login or set PCAPCTL_TOKEN")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return c.http.Do(req)
+}
+
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+		return fmt.Errorf("server: %s (%s)", body.Error, resp.Status)
+	}
+	return fmt.Errorf("server returned %s", resp.Status)
+}
+
+// jsonToCSV flattens a JSON array of objects into CSV with a header row
+// built from the first element's keys.
+func jsonToCSV(r io.Reader, w io.Writer) error {
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var cols []string
+	for k := range rows[0] {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		vals := make([]string, len(cols))
+		for i, col := range cols {
+			if v := row[col]; v != nil {
+				vals[i] = fmt.Sprintf("%v", v)
+			}
+		}
+		if err := cw.Write(vals); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func atoi64(s string) int64 {
+	var n int64
+	fmt.Sscanf(s, "%d", &n)
+	return n
+}
+
+func tokenPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".pcapctl.json"
+	}
+	return filepath.Join(home, ".pcapctl.json")
+}
+
+func loadToken() string {
+	if t := os.Getenv("PCAPCTL_TOKEN"); t != "" {
+		return t
+	}
+	data, err := os.ReadFile(tokenPath())
+	if err != nil {
+		return ""
+	}
+	var stored struct {
+		Token string `json:"token"`
+	}
+	if json.Unmarshal(data, &stored) != nil {
+		return ""
+	}
+	return stored.Token
+}
+
+func saveToken(token string) error {
+	data, _ := json.Marshal(map[string]string{"token": token})
+	return os.WriteFile(tokenPath(), data, 0o600)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// anomalyThreshold is the score at which a host gets its own finding.
+const anomalyThreshold = 70
+
+// hostAnomaly is the anomaly assessment of one source host.
+type hostAnomaly struct {
+	score   int
+	reasons []string
+}
+
+// hostProfile is the within-capture baseline of one source host.
+type hostProfile struct {
+	ports    map[int]bool
+	dests    map[string]bool
+	byMinute map[int64]int64 // unix minute -> bytes sent
+}
+
+// anomalyScores baselines every source host against its own traffic and
+// the rest of the capture, scoring 0-100: rare ports nobody else touches,
+// an unusually wide destination set, and per-minute volume spikes all add
+// to the score.
+func anomalyScores(conns []models.Connection) map[string]hostAnomaly {
+	profiles := make(map[string]*hostProfile)
+	srcsPerPort := make(map[int]map[string]bool)
+
+	for _, c := range conns {
+		p, ok := profiles[c.SrcIP]
+		if !ok {
+			p = &hostProfile{
+				ports:    make(map[int]bool),
+				dests:    make(map[string]bool),
+				byMinute: make(map[int64]int64),
+			}
+			profiles[c.SrcIP] = p
+		}
+		p.ports[c.DstPort] = true
+		p.dests[c.DstIP] = true
+		p.byMinute[c.FirstSeen.Truncate(time.Minute).Unix()] += c.BytesSent
+
+		if srcsPerPort[c.DstPort] == nil {
+			srcsPerPort[c.DstPort] = make(map[string]bool)
+		}
+		srcsPerPort[c.DstPort][c.SrcIP] = true
+	}
+
+	// Capture-wide average destination spread, for relative comparison.
+	var totalDests int
+	for _, p := range profiles {
+		totalDests += len(p.dests)
+	}
+	avgDests := float64(totalDests) / float64(len(profiles))
+
+	out := make(map[string]hostAnomaly, len(profiles))
+	for ip, p := range profiles {
+		var a hostAnomaly
+
+		rare := 0
+		for port := range p.ports {
+			if len(srcsPerPort[port]) == 1 && ServiceForPort("tcp", uint16(port)) == "" &&
+				ServiceForPort("udp", uint16(port)) == "" {
+				rare++
+			}
+		}
+		if rare > 0 {
+			pts := rare * 8
+			if pts > 40 {
+				pts = 40
+			}
+			a.score += pts
+			a.reasons = append(a.reasons, fmt.Sprintf("%d port(s) no other host uses", rare))
+		}
+
+		if avgDests > 0 && len(p.dests) >= 5 {
+			ratio := float64(len(p.dests)) / avgDests
+			if ratio > 1.5 {
+				pts := int((ratio - 1) * 10)
+				if pts > 30 {
+					pts = 30
+				}
+				a.score += pts
+				a.reasons = append(a.reasons,
+					fmt.Sprintf("%d destinations vs capture average of %.1f", len(p.dests), avgDests))
+			}
+		}
+
+		if pts, peak := volumeSpike(p.byMinute); pts > 0 {
+			a.score += pts
+			a.reasons = append(a.reasons, fmt.Sprintf("per-minute volume peaked at %.1fx its norm", peak))
+		}
+
+		if a.score > 100 {
+			a.score = 100
+		}
+		out[ip] = a
+	}
+	return out
+}
+
+// volumeSpike scores how far a host's busiest minute exceeds its typical
+// minute (the mean of the remaining minutes), requiring at least three
+// active minutes to avoid flagging bursts that are just short captures.
+func volumeSpike(byMinute map[int64]int64) (points int, peakRatio float64) {
+	if len(byMinute) < 3 {
+		return 0, 0
+	}
+	var sum, max int64
+	for _, b := range byMinute {
+		sum += b
+		if b > max {
+			max = b
+		}
+	}
+	meanOthers := float64(sum-max) / float64(len(byMinute)-1)
+	if meanOthers <= 0 {
+		return 0, 0
+	}
+	ratio := float64(max) / meanOthers
+	if ratio < 10 {
+		return 0, 0
+	}
+	points = int(ratio - 9)
+	if points > 30 {
+		points = 30
+	}
+	return points, ratio
+}
+
+// anomalyFindings raises one finding per host above the threshold.
+func anomalyFindings(scores map[string]hostAnomaly) []models.Finding {
+	ips := make([]string, 0, len(scores))
+	for ip, a := range scores {
+		if a.score >= anomalyThreshold {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		if scores[ips[i]].score != scores[ips[j]].score {
+			return scores[ips[i]].score > scores[ips[j]].score
+		}
+		return ips[i] < ips[j]
+	})
+
+	var out []models.Finding
+	for _, ip := range ips {
+		a := scores[ip]
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "traffic-anomaly",
+			Title:    fmt.Sprintf("Anomalous traffic profile for %s (score %d)", ip, a.score),
+			Description: fmt.Sprintf(
+				"%s deviates strongly from its within-capture baseline: %s.",
+				ip, strings.Join(a.reasons, "; ")),
+			SrcIP:     ip,
+			Timestamp: time.Now(),
+		})
+	}
+	return out
+}
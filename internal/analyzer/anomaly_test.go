@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func TestAnomalyScoresFlagsWideScanner(t *testing.T) {
+	base := time.Now().Truncate(time.Minute)
+	var conns []models.Connection
+	// A normal host talking to two services.
+	conns = append(conns,
+		models.Connection{SrcIP: "10.0.0.2", DstIP: "10.0.0.50", DstPort: 443, FirstSeen: base},
+		models.Connection{SrcIP: "10.0.0.2", DstIP: "10.0.0.51", DstPort: 53, FirstSeen: base},
+	)
+	// A host fanning out to many destinations on ports nobody else uses.
+	for i := 0; i < 30; i++ {
+		conns = append(conns, models.Connection{
+			SrcIP:     "10.0.0.66",
+			DstIP:     fmt.Sprintf("10.0.1.%d", i),
+			DstPort:   20000 + i,
+			FirstSeen: base,
+		})
+	}
+
+	scores := anomalyScores(conns)
+	if scores["10.0.0.66"].score <= scores["10.0.0.2"].score {
+		t.Errorf("scanner score %d should exceed normal host score %d",
+			scores["10.0.0.66"].score, scores["10.0.0.2"].score)
+	}
+	if len(scores["10.0.0.66"].reasons) < 2 {
+		t.Errorf("scanner reasons = %v, want rare ports and destination spread", scores["10.0.0.66"].reasons)
+	}
+}
+
+func TestAnomalyFindingsThreshold(t *testing.T) {
+	scores := map[string]hostAnomaly{
+		"10.0.0.66": {score: 85, reasons: []string{"many rare ports"}},
+		"10.0.0.2":  {score: 10},
+	}
+	findings := anomalyFindings(scores)
+	if len(findings) != 1 || findings[0].SrcIP != "10.0.0.66" {
+		t.Fatalf("findings = %+v, want one for 10.0.0.66", findings)
+	}
+	if findings[0].Type != "traffic-anomaly" {
+		t.Errorf("type = %s", findings[0].Type)
+	}
+}
+
+func TestVolumeSpike(t *testing.T) {
+	quiet := map[int64]int64{0: 100, 60: 110, 120: 95}
+	if pts, _ := volumeSpike(quiet); pts != 0 {
+		t.Errorf("steady traffic scored %d", pts)
+	}
+	spiky := map[int64]int64{0: 10, 60: 10, 120: 10, 180: 5000}
+	if pts, _ := volumeSpike(spiky); pts == 0 {
+		t.Error("50x spike should score")
+	}
+}
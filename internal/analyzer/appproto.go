@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// h2Preface is the fixed client connection preface of HTTP/2.
+var h2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// appProtoTracker recognizes application protocols that hide inside HTTP
+// traffic — WebSocket upgrades and gRPC over HTTP/2 — and counts their
+// messages per connection where the framing parses cleanly.
+type appProtoTracker struct {
+	labels map[string]string // canonical flow -> websocket | http2 | grpc
+	msgs   map[string]int64
+}
+
+func newAppProtoTracker() *appProtoTracker {
+	return &appProtoTracker{
+		labels: make(map[string]string),
+		msgs:   make(map[string]int64),
+	}
+}
+
+// flowPairKey canonicalizes a TCP flow to one string regardless of
+// direction.
+func flowPairKey(srcIP string, srcPort uint16, dstIP string, dstPort uint16) string {
+	a := fmt.Sprintf("%s:%d", srcIP, srcPort)
+	b := fmt.Sprintf("%s:%d", dstIP, dstPort)
+	if a > b {
+		a, b = b, a
+	}
+	return a + "<>" + b
+}
+
+// process inspects one TCP payload segment in either direction.
+func (t *appProtoTracker) process(srcIP string, srcPort uint16, dstIP string, dstPort uint16, payload []byte) {
+	key := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	switch t.labels[key] {
+	case "websocket":
+		t.msgs[key] += int64(countWSMessages(payload))
+		return
+	case "grpc":
+		t.msgs[key] += int64(countH2DataFrames(payload))
+		return
+	case "http2":
+		// gRPC announces itself in the content-type of the first
+		// HEADERS exchange.
+		if bytes.Contains(payload, []byte("application/grpc")) {
+			t.labels[key] = "grpc"
+		}
+		t.msgs[key] += int64(countH2DataFrames(payload))
+		return
+	}
+
+	if bytes.HasPrefix(payload, h2Preface) {
+		t.labels[key] = "http2"
+		rest := payload[len(h2Preface):]
+		if bytes.Contains(rest, []byte("application/grpc")) {
+			t.labels[key] = "grpc"
+		}
+		t.msgs[key] += int64(countH2DataFrames(rest))
+		return
+	}
+	if isHTTPRequest(payload) && strings.EqualFold(httpHeader(payload, "Upgrade"), "websocket") {
+		t.labels[key] = "websocket"
+	}
+}
+
+// flowInfo returns the label and message count for a flow, or "" when the
+// flow carried neither WebSocket nor gRPC traffic.
+func (t *appProtoTracker) flowInfo(key flowKey) (string, int64) {
+	if key.protocol != "tcp" {
+		return "", 0
+	}
+	k := flowPairKey(key.srcIP, key.srcPort, key.dstIP, key.dstPort)
+	label := t.labels[k]
+	if label != "websocket" && label != "grpc" {
+		return "", 0
+	}
+	return label, t.msgs[k]
+}
+
+// countWSMessages counts the WebSocket frames in one segment, skipping
+// continuation frames so fragmented messages count once. It stops at the
+// first byte sequence that does not look like a frame header.
+func countWSMessages(p []byte) int {
+	n := 0
+	for len(p) >= 2 {
+		opcode := p[0] & 0x0f
+		if (opcode > 0x2 && opcode < 0x8) || opcode > 0xA {
+			return n
+		}
+		masked := p[1]&0x80 != 0
+		ln := int(p[1] & 0x7f)
+		hdr := 2
+		switch ln {
+		case 126:
+			if len(p) < 4 {
+				return n
+			}
+			ln = int(binary.BigEndian.Uint16(p[2:4]))
+			hdr = 4
+		case 127:
+			if len(p) < 10 {
+				return n
+			}
+			v := binary.BigEndian.Uint64(p[2:10])
+			if v > 1<<31 {
+				return n
+			}
+			ln = int(v)
+			hdr = 10
+		}
+		if masked {
+			hdr += 4
+		}
+		if opcode != 0 {
+			n++
+		}
+		if len(p) < hdr+ln {
+			return n
+		}
+		p = p[hdr+ln:]
+	}
+	return n
+}
+
+// countH2DataFrames counts non-empty HTTP/2 DATA frames, which carry one
+// length-prefixed gRPC message each in the common case.
+func countH2DataFrames(p []byte) int {
+	n := 0
+	for len(p) >= 9 {
+		ln := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		typ := p[3]
+		if typ > 0x9 {
+			return n
+		}
+		if typ == 0x0 && ln > 0 {
+			n++
+		}
+		if len(p) < 9+ln {
+			return n
+		}
+		p = p[9+ln:]
+	}
+	return n
+}
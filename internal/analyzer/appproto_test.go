@@ -0,0 +1,47 @@
+package analyzer
+
+import "testing"
+
+func TestAppProtoWebSocket(t *testing.T) {
+	at := newAppProtoTracker()
+	upgrade := []byte("GET /chat HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+	at.process("10.0.0.1", 51000, "10.0.0.2", 80, upgrade)
+
+	// Two small masked text frames in one segment from the client.
+	frame := []byte{0x81, 0x83, 0x01, 0x02, 0x03, 0x04, 'a', 'b', 'c'}
+	seg := append(append([]byte{}, frame...), frame...)
+	at.process("10.0.0.1", 51000, "10.0.0.2", 80, seg)
+	// One unmasked frame back from the server.
+	at.process("10.0.0.2", 80, "10.0.0.1", 51000, []byte{0x81, 0x02, 'o', 'k'})
+
+	label, msgs := at.flowInfo(flowKey{"tcp", "10.0.0.1", 51000, "10.0.0.2", 80})
+	if label != "websocket" || msgs != 3 {
+		t.Fatalf("flowInfo = %q, %d, want websocket, 3", label, msgs)
+	}
+}
+
+func TestAppProtoGRPC(t *testing.T) {
+	at := newAppProtoTracker()
+	// Client preface followed by a HEADERS frame that mentions the gRPC
+	// content type in cleartext.
+	hdr := []byte("application/grpc")
+	frame := append([]byte{byte(len(hdr) >> 16), byte(len(hdr) >> 8), byte(len(hdr)), 0x1, 0x4, 0, 0, 0, 1}, hdr...)
+	at.process("10.0.0.1", 52000, "10.0.0.2", 8080, append(append([]byte{}, h2Preface...), frame...))
+
+	// One DATA frame carrying a message.
+	data := []byte{0, 0, 5, 0x0, 0x0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+	at.process("10.0.0.2", 8080, "10.0.0.1", 52000, data)
+
+	label, msgs := at.flowInfo(flowKey{"tcp", "10.0.0.1", 52000, "10.0.0.2", 8080})
+	if label != "grpc" || msgs != 1 {
+		t.Fatalf("flowInfo = %q, %d, want grpc, 1", label, msgs)
+	}
+}
+
+func TestAppProtoIgnoresPlainHTTP(t *testing.T) {
+	at := newAppProtoTracker()
+	at.process("10.0.0.1", 53000, "10.0.0.2", 80, []byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	if label, _ := at.flowInfo(flowKey{"tcp", "10.0.0.1", 53000, "10.0.0.2", 80}); label != "" {
+		t.Fatalf("label = %q, want none", label)
+	}
+}
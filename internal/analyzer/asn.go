@@ -0,0 +1,150 @@
+package analyzer
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// asnEntry labels one routed prefix with its origin AS.
+type asnEntry struct {
+	prefix *net.IPNet
+	asn    int
+	name   string
+}
+
+// asnTable answers IP-to-ASN lookups by longest prefix match. The builtin
+// table covers the networks of the largest operators so common "outbound
+// to AS15169 (Google)" summaries work with no external data; a full
+// routeviews-style dump can be supplied via ASN_TABLE for complete
+// coverage, still fully offline.
+type asnTable struct {
+	entries []asnEntry
+}
+
+var (
+	asnOnce   sync.Once
+	asnShared *asnTable
+)
+
+// asnData returns the process-wide ASN table, loading the external dump
+// on first use when ASN_TABLE is set.
+func asnData() *asnTable {
+	asnOnce.Do(func() {
+		asnShared = builtinASNTable()
+		if path := os.Getenv("ASN_TABLE"); path != "" {
+			if err := asnShared.loadFile(path); err != nil {
+				slog.Warn("load ASN table", "path", path, "err", err)
+			}
+		}
+	})
+	return asnShared
+}
+
+// builtinASNs is a curated list of prefixes for well-known operators:
+// "prefix asn name", the same layout the external table uses.
+var builtinASNs = []string{
+	"8.8.8.0/24 15169 Google",
+	"8.8.4.0/24 15169 Google",
+	"142.250.0.0/15 15169 Google",
+	"172.217.0.0/16 15169 Google",
+	"216.58.192.0/19 15169 Google",
+	"34.64.0.0/10 396982 Google Cloud",
+	"35.192.0.0/12 396982 Google Cloud",
+	"1.1.1.0/24 13335 Cloudflare",
+	"1.0.0.0/24 13335 Cloudflare",
+	"104.16.0.0/13 13335 Cloudflare",
+	"172.64.0.0/13 13335 Cloudflare",
+	"162.158.0.0/15 13335 Cloudflare",
+	"157.240.0.0/16 32934 Facebook",
+	"31.13.64.0/18 32934 Facebook",
+	"17.0.0.0/8 714 Apple",
+	"13.104.0.0/14 8075 Microsoft",
+	"20.33.0.0/16 8075 Microsoft",
+	"20.34.0.0/15 8075 Microsoft",
+	"40.74.0.0/15 8075 Microsoft",
+	"52.96.0.0/12 8075 Microsoft",
+	"204.79.197.0/24 8068 Microsoft",
+	"3.0.0.0/9 16509 Amazon",
+	"18.32.0.0/11 14618 Amazon",
+	"52.0.0.0/10 16509 Amazon",
+	"54.64.0.0/11 16509 Amazon",
+	"23.32.0.0/11 20940 Akamai",
+	"23.192.0.0/11 20940 Akamai",
+	"104.64.0.0/10 16625 Akamai",
+	"151.101.0.0/16 54113 Fastly",
+	"199.232.0.0/16 54113 Fastly",
+	"13.107.0.0/16 8068 Microsoft",
+	"9.9.9.0/24 19281 Quad9",
+	"208.67.220.0/22 36692 OpenDNS",
+	"185.199.108.0/22 54113 GitHub Pages",
+	"140.82.112.0/20 36459 GitHub",
+}
+
+func builtinASNTable() *asnTable {
+	t := &asnTable{}
+	for _, line := range builtinASNs {
+		t.addLine(line)
+	}
+	return t
+}
+
+// addLine parses "prefix asn name..." (whitespace separated, routeviews
+// pfx2as style); malformed lines are skipped.
+func (t *asnTable) addLine(line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+	_, prefix, err := net.ParseCIDR(fields[0])
+	if err != nil {
+		return
+	}
+	asn, err := strconv.Atoi(strings.TrimPrefix(fields[1], "AS"))
+	if err != nil {
+		return
+	}
+	var name string
+	if len(fields) > 2 {
+		name = strings.Join(fields[2:], " ")
+	}
+	t.entries = append(t.entries, asnEntry{prefix: prefix, asn: asn, name: name})
+}
+
+// loadFile merges a prefix-to-AS dump into the table.
+func (t *asnTable) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t.addLine(line)
+	}
+	return sc.Err()
+}
+
+// Lookup returns the origin AS of ip by longest prefix match.
+func (t *asnTable) Lookup(ip net.IP) (asn int, name string, ok bool) {
+	bestBits := -1
+	for _, e := range t.entries {
+		if !e.prefix.Contains(ip) {
+			continue
+		}
+		if bits, _ := e.prefix.Mask.Size(); bits > bestBits {
+			bestBits = bits
+			asn, name = e.asn, e.name
+		}
+	}
+	return asn, name, bestBits >= 0
+}
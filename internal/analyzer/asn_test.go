@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"net"
+	"testing"
+)
+
+func TestASNLookupBuiltin(t *testing.T) {
+	table := builtinASNTable()
+
+	asn, name, ok := table.Lookup(net.ParseIP("8.8.8.8"))
+	if !ok || asn != 15169 || name != "Google" {
+		t.Errorf("8.8.8.8 = AS%d %q ok=%v, want AS15169 Google", asn, name, ok)
+	}
+	if _, _, ok := table.Lookup(net.ParseIP("192.168.1.1")); ok {
+		t.Error("192.168.1.1 should not resolve to an AS")
+	}
+}
+
+func TestASNLookupLongestPrefixWins(t *testing.T) {
+	table := &asnTable{}
+	table.addLine("10.0.0.0/8 100 Coarse")
+	table.addLine("10.1.0.0/16 200 Specific")
+
+	asn, _, ok := table.Lookup(net.ParseIP("10.1.2.3"))
+	if !ok || asn != 200 {
+		t.Errorf("10.1.2.3 = AS%d ok=%v, want AS200", asn, ok)
+	}
+	asn, _, _ = table.Lookup(net.ParseIP("10.2.0.1"))
+	if asn != 100 {
+		t.Errorf("10.2.0.1 = AS%d, want AS100", asn)
+	}
+}
+
+func TestASNTableSkipsMalformedLines(t *testing.T) {
+	table := &asnTable{}
+	table.addLine("not-a-prefix 100 X")
+	table.addLine("10.0.0.0/8 not-a-number X")
+	if len(table.entries) != 0 {
+		t.Errorf("malformed lines produced %d entries", len(table.entries))
+	}
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// bandwidthBucketSize is the resolution of the bandwidth report.
+const bandwidthBucketSize = time.Minute
+
+// BandwidthBucket is one time slice of a service's utilization.
+type BandwidthBucket struct {
+	Time  time.Time `json:"time"`
+	Bytes int64     `json:"bytes"`
+	Mbps  float64   `json:"mbps"`
+}
+
+// ServiceBandwidth is the utilization of one service over the capture,
+// with its peak called out for capacity planning.
+type ServiceBandwidth struct {
+	Service  string            `json:"service"`
+	Bytes    int64             `json:"bytes"`
+	PeakMbps float64           `json:"peakMbps"`
+	PeakAt   time.Time         `json:"peakAt"`
+	Buckets  []BandwidthBucket `json:"buckets"`
+}
+
+// BandwidthReport estimates per-service bandwidth over time from the
+// stored flows. Each flow's bytes are spread evenly across the minute
+// buckets it spans; flows without a recognized service fall under
+// "other".
+func BandwidthReport(conns []models.Connection) []ServiceBandwidth {
+	type bucketKey struct {
+		service string
+		t       time.Time
+	}
+	buckets := make(map[bucketKey]int64)
+	totals := make(map[string]int64)
+
+	for _, c := range conns {
+		if c.FirstSeen.IsZero() {
+			continue
+		}
+		service := c.Service
+		if service == "" {
+			service = "other"
+		}
+		bytes := c.BytesSent + c.BytesRecv
+		totals[service] += bytes
+
+		start := c.FirstSeen.Truncate(bandwidthBucketSize)
+		end := c.LastSeen.Truncate(bandwidthBucketSize)
+		if end.Before(start) {
+			end = start
+		}
+		n := int64(end.Sub(start)/bandwidthBucketSize) + 1
+		share := bytes / n
+		rem := bytes - share*n
+		for t := start; !t.After(end); t = t.Add(bandwidthBucketSize) {
+			b := share
+			if t.Equal(start) {
+				b += rem // keep totals exact
+			}
+			buckets[bucketKey{service, t}] += b
+		}
+	}
+
+	byService := make(map[string][]BandwidthBucket)
+	for key, bytes := range buckets {
+		byService[key.service] = append(byService[key.service], BandwidthBucket{
+			Time:  key.t,
+			Bytes: bytes,
+			Mbps:  float64(bytes) * 8 / bandwidthBucketSize.Seconds() / 1e6,
+		})
+	}
+
+	out := make([]ServiceBandwidth, 0, len(byService))
+	for service, series := range byService {
+		sort.Slice(series, func(i, j int) bool { return series[i].Time.Before(series[j].Time) })
+		sb := ServiceBandwidth{Service: service, Bytes: totals[service], Buckets: series}
+		for _, b := range series {
+			if b.Mbps > sb.PeakMbps {
+				sb.PeakMbps = b.Mbps
+				sb.PeakAt = b.Time
+			}
+		}
+		out = append(out, sb)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bytes > out[j].Bytes })
+	return out
+}
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func TestBandwidthReportSpreadsBytes(t *testing.T) {
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	conns := []models.Connection{
+		{
+			Service: "https", BytesSent: 60_000_000, BytesRecv: 60_000_000,
+			FirstSeen: base, LastSeen: base.Add(2 * time.Minute),
+		},
+		{
+			BytesSent: 1000,
+			FirstSeen: base, LastSeen: base,
+		},
+	}
+
+	report := BandwidthReport(conns)
+	if len(report) != 2 {
+		t.Fatalf("report = %+v, want https and other", report)
+	}
+	https := report[0]
+	if https.Service != "https" || https.Bytes != 120_000_000 {
+		t.Fatalf("first entry = %+v, want https with full byte total", https)
+	}
+	if len(https.Buckets) != 3 {
+		t.Fatalf("buckets = %+v, want three minutes", https.Buckets)
+	}
+	// 40 MB per minute bucket is 40e6*8/60/1e6 ≈ 5.33 Mbps.
+	if https.PeakMbps < 5 || https.PeakMbps > 6 {
+		t.Errorf("peak = %f Mbps, want about 5.33", https.PeakMbps)
+	}
+	var sum int64
+	for _, b := range https.Buckets {
+		sum += b.Bytes
+	}
+	if sum != 120_000_000 {
+		t.Errorf("bucket bytes sum = %d, want exact total", sum)
+	}
+	if report[1].Service != "other" {
+		t.Errorf("second entry = %+v, want other", report[1])
+	}
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// bindingTracker records IP-to-MAC bindings learned from ARP and DHCP so
+// conflicts and churn within a capture are visible afterwards.
+type bindingTracker struct {
+	bindings map[string]*models.IPMACBinding // keyed by ip|mac|source
+	macsPer  map[string]map[string]bool      // ip -> set of MACs
+}
+
+func newBindingTracker() *bindingTracker {
+	return &bindingTracker{
+		bindings: make(map[string]*models.IPMACBinding),
+		macsPer:  make(map[string]map[string]bool),
+	}
+}
+
+// observe records one sighting of ip bound to mac via source.
+func (bt *bindingTracker) observe(ip, mac, source string, ts time.Time) {
+	if ip == "" || ip == "0.0.0.0" || mac == "" || mac == "00:00:00:00:00:00" {
+		return
+	}
+	key := ip + "|" + mac + "|" + source
+	b, ok := bt.bindings[key]
+	if !ok {
+		b = &models.IPMACBinding{IP: ip, MAC: mac, Source: source, FirstSeen: ts}
+		bt.bindings[key] = b
+	}
+	b.LastSeen = ts
+	b.PacketCount++
+
+	if bt.macsPer[ip] == nil {
+		bt.macsPer[ip] = make(map[string]bool)
+	}
+	bt.macsPer[ip][mac] = true
+}
+
+// processARP learns the sender binding of an ARP packet.
+func (bt *bindingTracker) processARP(arp *layers.ARP, ts time.Time) {
+	ip := net.IP(arp.SourceProtAddress).String()
+	mac := net.HardwareAddr(arp.SourceHwAddress).String()
+	bt.observe(ip, mac, "arp", ts)
+}
+
+// processDHCP learns bindings from DHCP ACKs, where the server commits a
+// lease for the client's hardware address.
+func (bt *bindingTracker) processDHCP(dhcp *layers.DHCPv4, ts time.Time) {
+	for _, opt := range dhcp.Options {
+		if opt.Type == layers.DHCPOptMessageType && len(opt.Data) == 1 &&
+			layers.DHCPMsgType(opt.Data[0]) == layers.DHCPMsgTypeAck {
+			bt.observe(dhcp.YourClientIP.String(), dhcp.ClientHWAddr.String(), "dhcp", ts)
+			return
+		}
+	}
+}
+
+// rows returns the binding history sorted for stable storage.
+func (bt *bindingTracker) rows() []models.IPMACBinding {
+	out := make([]models.IPMACBinding, 0, len(bt.bindings))
+	for _, b := range bt.bindings {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].IP != out[j].IP {
+			return out[i].IP < out[j].IP
+		}
+		return out[i].FirstSeen.Before(out[j].FirstSeen)
+	})
+	return out
+}
+
+// findings raises a conflict finding for every IP claimed by multiple
+// MACs, a classic sign of address churn or ARP spoofing.
+func (bt *bindingTracker) findings() []models.Finding {
+	var out []models.Finding
+	for ip, macs := range bt.macsPer {
+		if len(macs) < 2 {
+			continue
+		}
+		var list []string
+		for mac := range macs {
+			list = append(list, mac)
+		}
+		sort.Strings(list)
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "ip-mac-conflict",
+			Title:    fmt.Sprintf("IP %s claimed by %d different MACs", ip, len(list)),
+			Description: fmt.Sprintf(
+				"%s was bound to multiple hardware addresses during the capture: %s. "+
+					"This can indicate DHCP churn or ARP spoofing.",
+				ip, strings.Join(list, ", ")),
+			SrcIP:     ip,
+			Timestamp: time.Now(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"net"
+	"sort"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// broadcastTracker totals broadcast and multicast traffic per source host
+// and protocol, so chatty talkers (mDNS storms, SSDP floods, NetBIOS
+// chatter) show up in their own summary instead of polluting the unicast
+// asset counts.
+type broadcastTracker struct {
+	counts map[string]map[string]*broadcastCount // src -> protocol -> totals
+}
+
+type broadcastCount struct {
+	packets, bytes int64
+}
+
+func newBroadcastTracker() *broadcastTracker {
+	return &broadcastTracker{counts: make(map[string]map[string]*broadcastCount)}
+}
+
+// isBroadcastTarget reports whether an IP destination is multicast or the
+// limited broadcast address, i.e. not a real unicast target.
+func isBroadcastTarget(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	return parsed.IsMulticast() || parsed.Equal(net.IPv4bcast)
+}
+
+// process classifies one packet. Only ARP broadcasts, IGMP and packets to
+// multicast/broadcast destinations are counted.
+func (bt *broadcastTracker) process(ctx *PacketContext) {
+	var src, proto string
+	switch {
+	case ctx.Packet.Layer(layers.LayerTypeARP) != nil:
+		if ctx.DstMAC != "ff:ff:ff:ff:ff:ff" {
+			return
+		}
+		src, proto = ctx.SrcMAC, "arp"
+	case ctx.Protocol == "igmp":
+		src, proto = ctx.SrcIP, "igmp"
+	case isBroadcastTarget(ctx.DstIP):
+		src = ctx.SrcIP
+		switch {
+		case ctx.DstPort == 5353:
+			proto = "mdns"
+		case ctx.DstPort == 1900:
+			proto = "ssdp"
+		case ctx.DstPort == 137 || ctx.DstPort == 138:
+			proto = "netbios"
+		case ctx.DstPort == 5355:
+			proto = "llmnr"
+		case ctx.DstPort == 67 || ctx.DstPort == 68:
+			proto = "dhcp"
+		default:
+			proto = "other"
+		}
+	default:
+		return
+	}
+	if src == "" {
+		return
+	}
+	byProto, ok := bt.counts[src]
+	if !ok {
+		byProto = make(map[string]*broadcastCount)
+		bt.counts[src] = byProto
+	}
+	c, ok := byProto[proto]
+	if !ok {
+		c = &broadcastCount{}
+		byProto[proto] = c
+	}
+	c.packets++
+	c.bytes += ctx.Size
+}
+
+// rows flattens the totals, chattiest source first.
+func (bt *broadcastTracker) rows() []models.BroadcastRow {
+	var out []models.BroadcastRow
+	for src, byProto := range bt.counts {
+		for proto, c := range byProto {
+			out = append(out, models.BroadcastRow{
+				SrcIP:    src,
+				Protocol: proto,
+				Packets:  c.packets,
+				Bytes:    c.bytes,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Packets != out[j].Packets {
+			return out[i].Packets > out[j].Packets
+		}
+		if out[i].SrcIP != out[j].SrcIP {
+			return out[i].SrcIP < out[j].SrcIP
+		}
+		return out[i].Protocol < out[j].Protocol
+	})
+	return out
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Thresholds for repeated-authentication findings. They are attempt
+// counts per source/target pair over the whole capture.
+const (
+	sshBruteThreshold     = 20
+	rdpBruteThreshold     = 20
+	http401Threshold      = 15
+	smbLogonFailThreshold = 10
+)
+
+// statusLogonFailure is the NT status SMB servers return for a bad
+// username or password.
+const statusLogonFailure = 0xC000006D
+
+// bruteForceTracker spots repeated failed authentication: SSH and RDP
+// connection bursts, HTTP 401 storms and SMB logon failures, keeping any
+// account names it can see along the way.
+type bruteForceTracker struct {
+	sshAttempts map[string]int             // "src|dst" -> SYN count to :22
+	rdpAttempts map[string]int             // "src|dst" -> SYN count to :3389
+	http401s    map[string]int             // "client|server" -> 401 responses seen
+	smbFailures map[string]int             // "client|server" -> logon failures seen
+	accounts    map[string]map[string]bool // "client|server" -> usernames tried
+}
+
+func newBruteForceTracker() *bruteForceTracker {
+	return &bruteForceTracker{
+		sshAttempts: make(map[string]int),
+		rdpAttempts: make(map[string]int),
+		http401s:    make(map[string]int),
+		smbFailures: make(map[string]int),
+		accounts:    make(map[string]map[string]bool),
+	}
+}
+
+// processSYN counts new connection attempts toward authentication ports.
+func (t *bruteForceTracker) processSYN(srcIP, dstIP string, dstPort uint16) {
+	switch dstPort {
+	case 22:
+		t.sshAttempts[srcIP+"|"+dstIP]++
+	case rdpPort:
+		t.rdpAttempts[srcIP+"|"+dstIP]++
+	}
+}
+
+// processPayload inspects cleartext TCP payloads for authentication
+// failures and attempted account names.
+func (t *bruteForceTracker) processPayload(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte) {
+	if srcPort == 445 {
+		t.processSMBResponse(srcIP, dstIP, payload)
+		return
+	}
+	if bytes.HasPrefix(payload, []byte("HTTP/1.")) {
+		// Response: "HTTP/1.1 401 Unauthorized". The client is the
+		// destination of a response.
+		if len(payload) >= 12 && bytes.Equal(payload[9:12], []byte("401")) {
+			t.http401s[dstIP+"|"+srcIP]++
+		}
+		return
+	}
+	if user := basicAuthUser(payload); user != "" {
+		key := srcIP + "|" + dstIP
+		if t.accounts[key] == nil {
+			t.accounts[key] = make(map[string]bool)
+		}
+		t.accounts[key][user] = true
+	}
+}
+
+// processSMBResponse counts SESSION_SETUP responses carrying
+// STATUS_LOGON_FAILURE. srcIP is the server side of port 445.
+func (t *bruteForceTracker) processSMBResponse(srcIP, dstIP string, payload []byte) {
+	// SMB2 over TCP 445 has a 4-byte NetBIOS length prefix.
+	if len(payload) < 4+16 || !bytes.Equal(payload[4:8], []byte("\xfeSMB")) {
+		return
+	}
+	hdr := payload[4:]
+	status := binary.LittleEndian.Uint32(hdr[8:12])
+	command := binary.LittleEndian.Uint16(hdr[12:14])
+	if command == 1 && status == statusLogonFailure { // SESSION_SETUP
+		t.smbFailures[dstIP+"|"+srcIP]++
+	}
+}
+
+// basicAuthUser pulls the username out of an HTTP Basic Authorization
+// header, if the payload is a request carrying one.
+func basicAuthUser(payload []byte) string {
+	if !isHTTPRequest(payload) {
+		return ""
+	}
+	v := httpHeader(payload, "Authorization")
+	if !strings.HasPrefix(v, "Basic ") {
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v[len("Basic "):]))
+	if err != nil {
+		return ""
+	}
+	if i := bytes.IndexByte(decoded, ':'); i > 0 {
+		return string(decoded[:i])
+	}
+	return ""
+}
+
+func (t *bruteForceTracker) findings() []models.Finding {
+	var out []models.Finding
+	out = append(out, t.pairFindings(t.sshAttempts, sshBruteThreshold,
+		"SSH brute force", "connection attempts to port 22")...)
+	out = append(out, t.pairFindings(t.rdpAttempts, rdpBruteThreshold,
+		"RDP brute force", "connection attempts to port 3389")...)
+	out = append(out, t.pairFindings(t.http401s, http401Threshold,
+		"HTTP password guessing", "401 Unauthorized responses")...)
+	out = append(out, t.pairFindings(t.smbFailures, smbLogonFailThreshold,
+		"SMB password guessing", "logon failures")...)
+	return out
+}
+
+// pairFindings converts one "src|dst" counter map into findings, adding
+// attempted account names when any were captured.
+func (t *bruteForceTracker) pairFindings(counts map[string]int, threshold int, label, unit string) []models.Finding {
+	var out []models.Finding
+	for key, n := range counts {
+		if n < threshold {
+			continue
+		}
+		src, dst, _ := strings.Cut(key, "|")
+		desc := fmt.Sprintf("%s produced %d %s against %s", src, n, unit, dst)
+		if users := t.accountList(key); len(users) > 0 {
+			desc += fmt.Sprintf("; accounts tried: %s", strings.Join(users, ", "))
+		}
+		out = append(out, models.Finding{
+			Severity:    "high",
+			Type:        "brute-force",
+			Title:       fmt.Sprintf("Possible %s from %s", label, src),
+			Description: desc,
+			SrcIP:       src,
+			DstIP:       dst,
+			Timestamp:   time.Now(),
+		})
+	}
+	return out
+}
+
+func (t *bruteForceTracker) accountList(key string) []string {
+	users := make([]string, 0, len(t.accounts[key]))
+	for u := range t.accounts[key] {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+	return users
+}
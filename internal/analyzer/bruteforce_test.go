@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestBruteForceSSHAttempts(t *testing.T) {
+	bt := newBruteForceTracker()
+	for i := 0; i < sshBruteThreshold; i++ {
+		bt.processSYN("10.0.0.9", "10.0.0.2", 22)
+	}
+	bt.processSYN("10.0.0.9", "10.0.0.2", 80) // not an auth port
+
+	findings := bt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one", findings)
+	}
+	f := findings[0]
+	if f.Type != "brute-force" || f.SrcIP != "10.0.0.9" || f.DstIP != "10.0.0.2" {
+		t.Errorf("finding = %+v", f)
+	}
+	if !strings.Contains(f.Title, "SSH") {
+		t.Errorf("title = %q, want SSH label", f.Title)
+	}
+}
+
+func TestBruteForceHTTP401WithAccounts(t *testing.T) {
+	bt := newBruteForceTracker()
+	client, server := "10.0.0.9", "10.0.0.80"
+	for _, user := range []string{"admin", "root"} {
+		creds := base64.StdEncoding.EncodeToString([]byte(user + ":guess"))
+		req := []byte("GET /login HTTP/1.1\r\nAuthorization: Basic " + creds + "\r\n\r\n")
+		bt.processPayload(client, server, 51000, 80, req)
+	}
+	for i := 0; i < http401Threshold; i++ {
+		bt.processPayload(server, client, 80, 51000, []byte("HTTP/1.1 401 Unauthorized\r\n\r\n"))
+	}
+
+	findings := bt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one", findings)
+	}
+	desc := findings[0].Description
+	if !strings.Contains(desc, "admin") || !strings.Contains(desc, "root") {
+		t.Errorf("description = %q, want tried accounts", desc)
+	}
+}
+
+func TestBruteForceSMBLogonFailures(t *testing.T) {
+	bt := newBruteForceTracker()
+	// SMB2 SESSION_SETUP response with STATUS_LOGON_FAILURE, behind the
+	// 4-byte NetBIOS prefix.
+	resp := make([]byte, 4+64)
+	copy(resp[4:8], "\xfeSMB")
+	binary.LittleEndian.PutUint32(resp[12:16], statusLogonFailure)
+	binary.LittleEndian.PutUint16(resp[16:18], 1)
+	for i := 0; i < smbLogonFailThreshold; i++ {
+		bt.processPayload("10.0.0.44", "10.0.0.9", 445, 52000, resp)
+	}
+
+	findings := bt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one", findings)
+	}
+	if f := findings[0]; f.SrcIP != "10.0.0.9" || f.DstIP != "10.0.0.44" {
+		t.Errorf("finding = %+v, want client flagged against server", f)
+	}
+}
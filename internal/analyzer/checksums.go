@@ -0,0 +1,174 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+const (
+	// checksumFindingMin is how many bad checksums a host must emit
+	// before it is reported; a handful happens in any real capture.
+	checksumFindingMin = 50
+	// checksumFindingRatio is the bad fraction of a host's packets that
+	// triggers a finding alongside the absolute minimum.
+	checksumFindingRatio = 0.05
+	// malformedFindingMin is how many undecodable packets a host must
+	// emit before it is reported.
+	malformedFindingMin = 20
+)
+
+// checksumStats counts validation failures for one sending host.
+type checksumStats struct {
+	packets   int64
+	badIP     int64
+	badTCP    int64
+	badUDP    int64
+	malformed int64
+}
+
+// checksumTracker validates IP/TCP/UDP checksums per packet and counts
+// failures per sending host. Consistent failures from one host usually
+// mean checksum offload at the capture point; sporadic garbage or
+// undecodable packets point at a crafted-traffic source.
+type checksumTracker struct {
+	hosts map[string]*checksumStats
+}
+
+func newChecksumTracker() *checksumTracker {
+	return &checksumTracker{hosts: make(map[string]*checksumStats)}
+}
+
+// process validates the checksums of one decoded packet from srcIP.
+func (ct *checksumTracker) process(packet gopacket.Packet, srcIP string) {
+	st := ct.hosts[srcIP]
+	if st == nil {
+		st = &checksumStats{}
+		ct.hosts[srcIP] = st
+	}
+	st.packets++
+
+	if packet.ErrorLayer() != nil {
+		st.malformed++
+	}
+
+	ip4, _ := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	if ip4 != nil && !validIPv4Checksum(ip4) {
+		st.badIP++
+	}
+	// Transport checksums cover the whole datagram; fragments cannot be
+	// validated in isolation.
+	if ip4 != nil && (ip4.Flags&layers.IPv4MoreFragments != 0 || ip4.FragOffset != 0) {
+		return
+	}
+
+	if tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP); ok {
+		if !validTransportChecksum(packet, tcp.Contents, tcp.Payload) {
+			st.badTCP++
+		}
+	} else if udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+		// Checksum zero over IPv4 means "not computed" and is legal.
+		if udp.Checksum == 0 && ip4 != nil {
+			return
+		}
+		if !validTransportChecksum(packet, udp.Contents, udp.Payload) {
+			st.badUDP++
+		}
+	}
+}
+
+// validIPv4Checksum verifies the header checksum of an IPv4 packet.
+func validIPv4Checksum(ip4 *layers.IPv4) bool {
+	if len(ip4.Contents) < 20 {
+		return false
+	}
+	return foldChecksum(onesComplementSum(0, ip4.Contents)) == 0
+}
+
+// validTransportChecksum verifies a TCP or UDP checksum including the
+// IPv4/IPv6 pseudo-header. Header and payload are the layer's Contents
+// and Payload slices.
+func validTransportChecksum(packet gopacket.Packet, header, payload []byte) bool {
+	var sum uint32
+	length := uint32(len(header) + len(payload))
+	if ip4, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+		sum = onesComplementSum(sum, ip4.SrcIP.To4())
+		sum = onesComplementSum(sum, ip4.DstIP.To4())
+		sum += uint32(ip4.Protocol)
+		sum += length
+	} else if ip6, ok := packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6); ok {
+		sum = onesComplementSum(sum, ip6.SrcIP.To16())
+		sum = onesComplementSum(sum, ip6.DstIP.To16())
+		sum += uint32(ip6.NextHeader)
+		sum += length
+	} else {
+		return true
+	}
+	sum = onesComplementSum(sum, header)
+	sum = onesComplementSum(sum, payload)
+	return foldChecksum(sum) == 0
+}
+
+// onesComplementSum adds b to a running 16-bit one's-complement sum.
+func onesComplementSum(sum uint32, b []byte) uint32 {
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	return sum
+}
+
+// foldChecksum folds the carries and complements; a valid checksummed
+// buffer folds to zero.
+func foldChecksum(sum uint32) uint16 {
+	for sum > 0xffff {
+		sum = (sum & 0xffff) + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// findings reports hosts whose traffic consistently fails validation.
+func (ct *checksumTracker) findings() []models.Finding {
+	var out []models.Finding
+	ips := make([]string, 0, len(ct.hosts))
+	for ip := range ct.hosts {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		st := ct.hosts[ip]
+		bad := st.badIP + st.badTCP + st.badUDP
+		if bad >= checksumFindingMin && float64(bad) >= checksumFindingRatio*float64(st.packets) {
+			out = append(out, models.Finding{
+				Severity: "low",
+				Type:     "checksum-errors",
+				Title:    fmt.Sprintf("Host %s emits packets with invalid checksums", ip),
+				Description: fmt.Sprintf(
+					"%d of %d packets from %s failed checksum validation (%d IP, %d TCP, %d UDP); this usually means checksum offload at the capture point, but can also indicate crafted packets",
+					bad, st.packets, ip, st.badIP, st.badTCP, st.badUDP),
+				SrcIP:     ip,
+				Timestamp: time.Now(),
+			})
+		}
+		if st.malformed >= malformedFindingMin {
+			out = append(out, models.Finding{
+				Severity: "medium",
+				Type:     "malformed-packets",
+				Title:    fmt.Sprintf("Host %s emits malformed packets", ip),
+				Description: fmt.Sprintf(
+					"%d of %d packets from %s could not be fully decoded; truncation at capture time or a fuzzing/crafted-traffic source",
+					st.malformed, st.packets, ip),
+				SrcIP:     ip,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestChecksumValidation(t *testing.T) {
+	// tcpPacket serializes with ComputeChecksums, so both the IP header
+	// and the TCP checksum must validate.
+	ct := newChecksumTracker()
+	pkt := tcpPacket(t)
+	ct.process(pkt, "10.0.0.1")
+	st := ct.hosts["10.0.0.1"]
+	if st == nil || st.packets != 1 {
+		t.Fatalf("stats not recorded: %+v", st)
+	}
+	if st.badIP != 0 || st.badTCP != 0 {
+		t.Errorf("valid packet counted as bad: %+v", st)
+	}
+}
+
+func TestChecksumDetectsCorruption(t *testing.T) {
+	ct := newChecksumTracker()
+	pkt := tcpPacket(t)
+	// Flip a payload byte after serialization; the TCP checksum no
+	// longer matches while the IP header checksum still does.
+	raw := pkt.Data()
+	raw[len(raw)-1] ^= 0xff
+	ct.process(pkt, "10.0.0.1")
+	st := ct.hosts["10.0.0.1"]
+	if st.badTCP != 1 {
+		t.Errorf("corrupted TCP payload not detected: %+v", st)
+	}
+	if st.badIP != 0 {
+		t.Errorf("IP header wrongly flagged: %+v", st)
+	}
+}
+
+func TestChecksumFindings(t *testing.T) {
+	ct := newChecksumTracker()
+	st := &checksumStats{packets: 100, badTCP: 60}
+	ct.hosts["10.0.0.9"] = st
+	ct.hosts["10.0.0.8"] = &checksumStats{packets: 1000, badTCP: 3}
+
+	fs := ct.findings()
+	if len(fs) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(fs), fs)
+	}
+	if fs[0].Type != "checksum-errors" || fs[0].SrcIP != "10.0.0.9" {
+		t.Errorf("unexpected finding: %+v", fs[0])
+	}
+
+	ct.hosts["10.0.0.9"].malformed = malformedFindingMin
+	if fs = ct.findings(); len(fs) != 2 || fs[1].Type != "malformed-packets" {
+		t.Errorf("malformed finding missing: %+v", fs)
+	}
+}
@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// tlsExpectedPorts are services whose port implies TLS from the first
+// byte; cleartext traffic there is a misconfiguration.
+var tlsExpectedPorts = map[uint16]string{
+	443:  "https",
+	465:  "smtps",
+	636:  "ldaps",
+	990:  "ftps",
+	993:  "imaps",
+	995:  "pop3s",
+	8443: "https-alt",
+}
+
+// cleartextExpectedPorts are well-known plaintext services; TLS showing
+// up there usually means something is tunneling or misconfigured.
+var cleartextExpectedPorts = map[uint16]string{
+	21:  "ftp",
+	23:  "telnet",
+	25:  "smtp",
+	80:  "http",
+	110: "pop3",
+	143: "imap",
+	389: "ldap",
+}
+
+// mismatchTracker flags traffic whose encryption does not match its
+// port: plaintext protocols on TLS ports and TLS handshakes on
+// plaintext ports, with a payload snippet as evidence.
+type mismatchTracker struct {
+	inspected map[string]bool // flows whose first data segment was judged
+	reported  map[string]bool
+	finds     []models.Finding
+}
+
+func newMismatchTracker() *mismatchTracker {
+	return &mismatchTracker{
+		inspected: make(map[string]bool),
+		reported:  make(map[string]bool),
+	}
+}
+
+// processTCP inspects a client-to-server payload segment. Only the first
+// data segment of a flow is judged: a STARTTLS upgrade legitimately puts
+// TLS records on a plaintext port mid-session.
+func (mt *mismatchTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	if len(payload) == 0 {
+		return
+	}
+	if _, ok := tlsExpectedPorts[dstPort]; !ok {
+		if _, ok := cleartextExpectedPorts[dstPort]; !ok {
+			return
+		}
+	}
+	flow := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	if mt.inspected[flow] {
+		return
+	}
+	mt.inspected[flow] = true
+	if service, ok := tlsExpectedPorts[dstPort]; ok && !looksLikeTLS(payload) {
+		proto := classifyCleartext(payload)
+		if proto == "" {
+			return
+		}
+		mt.report(fmt.Sprintf("cleartext|%s|%s|%d", srcIP, dstIP, dstPort), models.Finding{
+			Severity: "medium",
+			Type:     "cleartext-on-tls-port",
+			Title:    fmt.Sprintf("Plaintext %s on port %d (%s)", proto, dstPort, service),
+			Description: fmt.Sprintf("%s sent unencrypted %s to %s:%d, a port reserved for %s; payload starts %s",
+				srcIP, proto, dstIP, dstPort, service, payloadSnippet(payload)),
+			SrcIP:     srcIP,
+			DstIP:     dstIP,
+			Timestamp: ts,
+		})
+		return
+	}
+	if service, ok := cleartextExpectedPorts[dstPort]; ok && looksLikeTLS(payload) {
+		mt.report(fmt.Sprintf("tls|%s|%s|%d", srcIP, dstIP, dstPort), models.Finding{
+			Severity: "low",
+			Type:     "tls-on-cleartext-port",
+			Title:    fmt.Sprintf("TLS on port %d (%s)", dstPort, service),
+			Description: fmt.Sprintf("%s started a TLS handshake with %s:%d, a port normally carrying plaintext %s",
+				srcIP, dstIP, dstPort, service),
+			SrcIP:     srcIP,
+			DstIP:     dstIP,
+			Timestamp: ts,
+		})
+	}
+}
+
+// classifyCleartext names the plaintext protocol in a payload, or ""
+// when the bytes are not recognizably cleartext (could be a cipher we
+// do not parse, or binary framing).
+func classifyCleartext(payload []byte) string {
+	if isHTTPRequest(payload) {
+		return "HTTP"
+	}
+	// LDAP messages are BER: a SEQUENCE tag followed by a message ID.
+	if payload[0] == 0x30 && len(payload) > 4 {
+		return "LDAP"
+	}
+	for _, verb := range []string{"USER ", "PASS ", "EHLO ", "HELO ", "AUTH ", "LOGIN "} {
+		if len(payload) >= len(verb) && string(payload[:len(verb)]) == verb {
+			return "command-line protocol"
+		}
+	}
+	if printableRatio(payload) > 0.9 {
+		return "plaintext"
+	}
+	return ""
+}
+
+// printableRatio is the fraction of bytes that are printable ASCII or
+// common whitespace.
+func printableRatio(payload []byte) float64 {
+	if len(payload) == 0 {
+		return 0
+	}
+	printable := 0
+	for _, b := range payload {
+		if (b >= 0x20 && b < 0x7f) || b == '\r' || b == '\n' || b == '\t' {
+			printable++
+		}
+	}
+	return float64(printable) / float64(len(payload))
+}
+
+// payloadSnippet quotes the first bytes of a payload for inclusion in a
+// finding description.
+func payloadSnippet(payload []byte) string {
+	const max = 48
+	if len(payload) > max {
+		payload = payload[:max]
+	}
+	return strconv.Quote(string(payload))
+}
+
+func (mt *mismatchTracker) report(key string, f models.Finding) {
+	if mt.reported[key] {
+		return
+	}
+	mt.reported[key] = true
+	mt.finds = append(mt.finds, f)
+}
+
+func (mt *mismatchTracker) findings() []models.Finding {
+	return mt.finds
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMismatchTrackerFlagsPlaintextHTTPOn443(t *testing.T) {
+	mt := newMismatchTracker()
+	req := []byte("GET /admin HTTP/1.1\r\nHost: internal\r\n\r\n")
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49152, 443, req, time.Unix(1000, 0))
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49153, 443, req, time.Unix(1001, 0))
+
+	finds := mt.findings()
+	if len(finds) != 1 {
+		t.Fatalf("findings = %d, want 1 (deduped per host pair)", len(finds))
+	}
+	f := finds[0]
+	if f.Type != "cleartext-on-tls-port" || f.Severity != "medium" {
+		t.Errorf("finding = %s/%s, want cleartext-on-tls-port/medium", f.Type, f.Severity)
+	}
+	if !strings.Contains(f.Description, "GET /admin") {
+		t.Errorf("description lacks payload snippet: %s", f.Description)
+	}
+}
+
+func TestMismatchTrackerIgnoresTLSOn443(t *testing.T) {
+	mt := newMismatchTracker()
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49152, 443, clientHelloSegment("ok.example.com"), time.Unix(1000, 0))
+	if finds := mt.findings(); len(finds) != 0 {
+		t.Errorf("unexpected findings: %+v", finds)
+	}
+}
+
+func TestMismatchTrackerFlagsTLSOnTelnetPort(t *testing.T) {
+	mt := newMismatchTracker()
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49152, 23, clientHelloSegment("odd.example.com"), time.Unix(1000, 0))
+
+	finds := mt.findings()
+	if len(finds) != 1 || finds[0].Type != "tls-on-cleartext-port" {
+		t.Fatalf("findings = %+v, want one tls-on-cleartext-port", finds)
+	}
+}
+
+func TestMismatchTrackerJudgesOnlyFirstSegment(t *testing.T) {
+	mt := newMismatchTracker()
+	// STARTTLS: the SMTP session begins in cleartext, then upgrades.
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49152, 25, []byte("EHLO client\r\n"), time.Unix(1000, 0))
+	mt.processTCP("10.0.0.5", "10.0.0.9", 49152, 25, clientHelloSegment("mail.example.com"), time.Unix(1001, 0))
+
+	if finds := mt.findings(); len(finds) != 0 {
+		t.Errorf("STARTTLS upgrade flagged: %+v", finds)
+	}
+}
+
+func TestClassifyCleartext(t *testing.T) {
+	cases := []struct {
+		payload []byte
+		want    string
+	}{
+		{[]byte("GET / HTTP/1.1\r\n\r\n"), "HTTP"},
+		{[]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x60}, "LDAP"},
+		{[]byte("USER admin\r\n"), "command-line protocol"},
+		{[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}, ""},
+	}
+	for _, c := range cases {
+		if got := classifyCleartext(c.payload); got != c.want {
+			t.Errorf("classifyCleartext(%q) = %q, want %q", c.payload, got, c.want)
+		}
+	}
+}
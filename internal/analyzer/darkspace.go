@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Dark-space thresholds: a destination must draw this much traffic
+// without ever answering before it is reported, and at most this many
+// destinations are reported so a swept /16 does not flood the findings.
+const (
+	darkSpaceMinPackets  = 10
+	darkSpaceMaxFindings = 20
+	darkSpaceMaxSources  = 5
+)
+
+// darkTarget is one silent destination and who keeps knocking on it.
+type darkTarget struct {
+	sources map[string]int64 // srcIP -> packets sent
+	packets int64
+	first   time.Time
+}
+
+// darkSpaceTracker finds destinations that never transmit a single packet
+// across the whole capture — dead hosts, unused address space being
+// scanned, or traffic misdirected by stale configuration.
+type darkSpaceTracker struct {
+	responders map[string]bool        // every address seen as a source
+	targets    map[string]*darkTarget // dstIP -> who keeps trying
+}
+
+func newDarkSpaceTracker() *darkSpaceTracker {
+	return &darkSpaceTracker{
+		responders: make(map[string]bool),
+		targets:    make(map[string]*darkTarget),
+	}
+}
+
+// process accounts one IP packet. Multicast and broadcast destinations
+// are not expected to answer and are skipped.
+func (dt *darkSpaceTracker) process(ctx *PacketContext) {
+	dt.responders[ctx.SrcIP] = true
+	if isBroadcastTarget(ctx.DstIP) {
+		return
+	}
+	t, ok := dt.targets[ctx.DstIP]
+	if !ok {
+		t = &darkTarget{sources: make(map[string]int64), first: ctx.Timestamp}
+		dt.targets[ctx.DstIP] = t
+	}
+	t.sources[ctx.SrcIP]++
+	t.packets++
+}
+
+// findings reports the most-contacted destinations that stayed silent.
+func (dt *darkSpaceTracker) findings() []models.Finding {
+	type silent struct {
+		ip string
+		t  *darkTarget
+	}
+	var dark []silent
+	for ip, t := range dt.targets {
+		if dt.responders[ip] || t.packets < darkSpaceMinPackets {
+			continue
+		}
+		dark = append(dark, silent{ip, t})
+	}
+	sort.Slice(dark, func(i, j int) bool {
+		if dark[i].t.packets != dark[j].t.packets {
+			return dark[i].t.packets > dark[j].t.packets
+		}
+		return dark[i].ip < dark[j].ip
+	})
+	if len(dark) > darkSpaceMaxFindings {
+		dark = dark[:darkSpaceMaxFindings]
+	}
+
+	var out []models.Finding
+	for _, d := range dark {
+		srcs := make([]string, 0, len(d.t.sources))
+		for src := range d.t.sources {
+			srcs = append(srcs, src)
+		}
+		sort.Slice(srcs, func(i, j int) bool {
+			if d.t.sources[srcs[i]] != d.t.sources[srcs[j]] {
+				return d.t.sources[srcs[i]] > d.t.sources[srcs[j]]
+			}
+			return srcs[i] < srcs[j]
+		})
+		listed := srcs
+		if len(listed) > darkSpaceMaxSources {
+			listed = listed[:darkSpaceMaxSources]
+		}
+		out = append(out, models.Finding{
+			Severity: "low",
+			Type:     "dark-space",
+			Title:    fmt.Sprintf("%s received traffic but never responded", d.ip),
+			Description: fmt.Sprintf(
+				"%d packets from %d sources reached %s with no reply in the entire capture. "+
+					"The host may be down, the address unused, or traffic misdirected. Top sources: %s.",
+				d.t.packets, len(d.t.sources), d.ip, strings.Join(listed, ", ")),
+			DstIP:     d.ip,
+			Timestamp: d.t.first,
+		})
+	}
+	return out
+}
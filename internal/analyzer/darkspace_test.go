@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func darkPacket(src, dst string, ts time.Time) *PacketContext {
+	return &PacketContext{SrcIP: src, DstIP: dst, Timestamp: ts}
+}
+
+func TestDarkSpaceTrackerFlagsSilentDestination(t *testing.T) {
+	dt := newDarkSpaceTracker()
+	base := time.Now()
+
+	for i := 0; i < 12; i++ {
+		dt.process(darkPacket("10.0.0.5", "10.0.0.200", base))
+	}
+	// A destination that answers is not dark, no matter the volume.
+	for i := 0; i < 12; i++ {
+		dt.process(darkPacket("10.0.0.5", "10.0.0.9", base))
+	}
+	dt.process(darkPacket("10.0.0.9", "10.0.0.5", base))
+
+	findings := dt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %d, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != "dark-space" || f.DstIP != "10.0.0.200" {
+		t.Fatalf("finding = %+v, want dark-space for 10.0.0.200", f)
+	}
+	if !strings.Contains(f.Description, "10.0.0.5") {
+		t.Errorf("description %q does not list the source", f.Description)
+	}
+}
+
+func TestDarkSpaceTrackerIgnoresLowVolumeAndBroadcast(t *testing.T) {
+	dt := newDarkSpaceTracker()
+	base := time.Now()
+
+	// Below the packet threshold.
+	for i := 0; i < darkSpaceMinPackets-1; i++ {
+		dt.process(darkPacket("10.0.0.5", "10.0.0.201", base))
+	}
+	// Multicast never answers by design.
+	for i := 0; i < 50; i++ {
+		dt.process(darkPacket("10.0.0.5", "239.255.255.250", base))
+	}
+
+	if findings := dt.findings(); len(findings) != 0 {
+		t.Fatalf("findings = %d, want 0: %+v", len(findings), findings)
+	}
+}
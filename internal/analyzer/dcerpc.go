@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// DCERPC PDU types we care about.
+const (
+	dcerpcRequest = 0
+	dcerpcBind    = 11
+)
+
+// dcerpcInterfaces maps well-known abstract syntax UUIDs to their short
+// names. Binds to anything else are ignored.
+var dcerpcInterfaces = map[string]string{
+	"367abb81-9844-35f1-ad32-98f038001003": "svcctl",
+	"1ff70682-0a51-30e8-076d-740be8cee98b": "atsvc",
+	"86d35949-83c9-4044-b424-db363231fd0c": "taskschd",
+	"338cd001-2244-31f1-aaaa-900038001003": "winreg",
+	"12345778-1234-abcd-ef00-0123456789ac": "samr",
+	"12345778-1234-abcd-ef00-0123456789ab": "lsarpc",
+	"4b324fc8-1670-01d3-1278-5a47bf6ee188": "srvsvc",
+	"e3514235-4b06-11d1-ab04-00c04fc2dcd2": "drsuapi",
+}
+
+// sensitiveInterfaces are the remote-management endpoints whose mere use
+// between workstations is worth a look, even without a flagged operation.
+var sensitiveInterfaces = map[string]bool{
+	"svcctl":   true,
+	"atsvc":    true,
+	"taskschd": true,
+	"winreg":   true,
+	"drsuapi":  true,
+}
+
+// dcerpcOp describes one operation number worth flagging on its own.
+type dcerpcOp struct {
+	name     string
+	severity string
+	desc     string
+}
+
+// dcerpcOps maps interface name and opnum to lateral-movement operations:
+// remote service installation (the PsExec pattern), scheduled task
+// creation and directory replication requests.
+var dcerpcOps = map[string]map[uint16]dcerpcOp{
+	"svcctl": {
+		12: {"CreateServiceW", "high", "remote service installation"},
+		19: {"StartServiceW", "medium", "remote service start"},
+		2:  {"DeleteService", "medium", "remote service removal"},
+	},
+	"atsvc": {
+		0: {"NetrJobAdd", "high", "remote AT job creation"},
+	},
+	"taskschd": {
+		1: {"SchRpcRegisterTask", "high", "remote scheduled task registration"},
+	},
+	"drsuapi": {
+		3: {"DRSGetNCChanges", "high", "directory replication request (DCSync technique)"},
+	},
+}
+
+// dcerpcCall is one flagged operation, deduplicated per client, server
+// and operation.
+type dcerpcCall struct {
+	src, dst string
+	iface    string
+	op       dcerpcOp
+	ts       time.Time
+}
+
+// dcerpcTracker decodes DCERPC binds and requests — direct over TCP 135
+// and dynamic ports, or carried in SMB2 WRITEs to IPC$ pipes — to
+// surface the operations lateral movement leans on.
+type dcerpcTracker struct {
+	bound map[string]string               // flow -> interface name from the last bind
+	binds map[string]map[string]time.Time // "src|dst" -> interface -> first bind
+	calls map[string]dcerpcCall           // "src|dst|iface|opname" -> call
+}
+
+func newDCERPCTracker() *dcerpcTracker {
+	return &dcerpcTracker{
+		bound: make(map[string]string),
+		binds: make(map[string]map[string]time.Time),
+		calls: make(map[string]dcerpcCall),
+	}
+}
+
+// processTCP inspects one TCP segment for a DCERPC PDU, unwrapping the
+// SMB2 WRITE framing used by named-pipe transports.
+func (t *dcerpcTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	if len(payload) == 0 || srcPort == 445 {
+		return // responses from the server carry no binds or requests
+	}
+	pdu := payload
+	if dstPort == 445 {
+		pdu = smbWriteData(payload)
+	}
+	pdu = dcerpcPDU(pdu)
+	if pdu == nil {
+		return
+	}
+
+	flow := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	switch pdu[2] {
+	case dcerpcBind:
+		t.processBind(flow, srcIP, dstIP, pdu, ts)
+	case dcerpcRequest:
+		t.processRequest(flow, srcIP, dstIP, pdu, ts)
+	}
+}
+
+// processBind records which interface the client asked for, walking the
+// presentation context list of the bind PDU.
+func (t *dcerpcTracker) processBind(flow, src, dst string, pdu []byte, ts time.Time) {
+	if len(pdu) < 28 {
+		return
+	}
+	items := int(pdu[24])
+	off := 28
+	for i := 0; i < items && off+24 <= len(pdu); i++ {
+		name, known := dcerpcInterfaces[dcerpcUUID(pdu[off+4:off+20])]
+		if known {
+			t.bound[flow] = name
+			pair := src + "|" + dst
+			if t.binds[pair] == nil {
+				t.binds[pair] = make(map[string]time.Time)
+			}
+			if _, ok := t.binds[pair][name]; !ok {
+				t.binds[pair][name] = ts
+			}
+		}
+		off += 24 + int(pdu[off+2])*20
+	}
+}
+
+// processRequest matches an operation number against the interface the
+// flow last bound.
+func (t *dcerpcTracker) processRequest(flow, src, dst string, pdu []byte, ts time.Time) {
+	if len(pdu) < 24 {
+		return
+	}
+	iface, ok := t.bound[flow]
+	if !ok {
+		return
+	}
+	op, ok := dcerpcOps[iface][binary.LittleEndian.Uint16(pdu[22:24])]
+	if !ok {
+		return
+	}
+	key := src + "|" + dst + "|" + iface + "|" + op.name
+	if _, ok := t.calls[key]; !ok {
+		t.calls[key] = dcerpcCall{src: src, dst: dst, iface: iface, op: op, ts: ts}
+	}
+}
+
+// smbWriteData returns the payload of an SMB2 WRITE request, the framing
+// DCERPC uses when it rides an IPC$ named pipe.
+func smbWriteData(p []byte) []byte {
+	// 4-byte NetBIOS length prefix, 64-byte SMB2 header, then the WRITE
+	// request body with its own offset/length into the message.
+	if len(p) < 4+64+8 || !bytes.Equal(p[4:8], []byte("\xfeSMB")) {
+		return nil
+	}
+	hdr := p[4:]
+	if binary.LittleEndian.Uint16(hdr[12:14]) != 9 { // WRITE
+		return nil
+	}
+	body := hdr[64:]
+	start := int(binary.LittleEndian.Uint16(body[2:4]))
+	length := int(binary.LittleEndian.Uint32(body[4:8]))
+	if start < 64 || length < 16 || start+length > len(hdr) {
+		return nil
+	}
+	return hdr[start : start+length]
+}
+
+// dcerpcPDU validates the common PDU header and returns the PDU, or nil
+// when the bytes are not little-endian DCERPC.
+func dcerpcPDU(p []byte) []byte {
+	if len(p) < 16 || p[0] != 5 || p[1] != 0 || p[4]&0xf0 != 0x10 {
+		return nil
+	}
+	switch p[2] {
+	case dcerpcRequest, dcerpcBind:
+	default:
+		return nil
+	}
+	fragLen := int(binary.LittleEndian.Uint16(p[8:10]))
+	if fragLen < 16 || fragLen > len(p) {
+		return nil
+	}
+	return p
+}
+
+// dcerpcUUID formats the little-endian on-wire UUID encoding.
+func dcerpcUUID(b []byte) string {
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%02x%02x-%x",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8], b[9], b[10:16])
+}
+
+// findings reports flagged operations individually and, per client and
+// server pair, the sensitive management interfaces that were bound.
+func (t *dcerpcTracker) findings() []models.Finding {
+	var out []models.Finding
+
+	keys := make([]string, 0, len(t.calls))
+	for k := range t.calls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c := t.calls[k]
+		out = append(out, models.Finding{
+			Severity: c.op.severity,
+			Type:     "lateral-movement",
+			Title:    fmt.Sprintf("%s from %s against %s", capitalizeFirst(c.op.desc), c.src, c.dst),
+			Description: fmt.Sprintf("%s called %s::%s on %s over DCERPC, an operation used for %s.",
+				c.src, c.iface, c.op.name, c.dst, c.op.desc),
+			SrcIP:     c.src,
+			DstIP:     c.dst,
+			Timestamp: c.ts,
+		})
+	}
+
+	pairs := make([]string, 0, len(t.binds))
+	for p := range t.binds {
+		pairs = append(pairs, p)
+	}
+	sort.Strings(pairs)
+	for _, pair := range pairs {
+		var ifaces []string
+		first := time.Time{}
+		for name, ts := range t.binds[pair] {
+			if !sensitiveInterfaces[name] {
+				continue
+			}
+			ifaces = append(ifaces, name)
+			if first.IsZero() || ts.Before(first) {
+				first = ts
+			}
+		}
+		if len(ifaces) == 0 {
+			continue
+		}
+		sort.Strings(ifaces)
+		src, dst, _ := strings.Cut(pair, "|")
+		out = append(out, models.Finding{
+			Severity: "low",
+			Type:     "dcerpc-bind",
+			Title:    fmt.Sprintf("Remote management RPC use from %s", src),
+			Description: fmt.Sprintf("%s bound the %s interface(s) on %s; these endpoints drive services, tasks and the registry remotely.",
+				src, strings.Join(ifaces, ", "), dst),
+			SrcIP:     src,
+			DstIP:     dst,
+			Timestamp: first,
+		})
+	}
+	return out
+}
+
+// capitalizeFirst upper-cases the first letter of an ASCII phrase for use
+// as a title.
+func capitalizeFirst(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
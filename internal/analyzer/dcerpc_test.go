@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// svcctlUUID is the on-wire (little-endian) encoding of
+// 367abb81-9844-35f1-ad32-98f038001003.
+var svcctlUUID = []byte{
+	0x81, 0xbb, 0x7a, 0x36, 0x44, 0x98, 0xf1, 0x35,
+	0xad, 0x32, 0x98, 0xf0, 0x38, 0x00, 0x10, 0x03,
+}
+
+func dcerpcBindPDU(uuid []byte) []byte {
+	p := make([]byte, 72)
+	p[0], p[1], p[2] = 5, 0, dcerpcBind
+	p[4] = 0x10
+	binary.LittleEndian.PutUint16(p[8:10], uint16(len(p)))
+	p[24] = 1   // one context item
+	p[28+2] = 1 // one transfer syntax
+	copy(p[32:48], uuid)
+	return p
+}
+
+func dcerpcRequestPDU(opnum uint16) []byte {
+	p := make([]byte, 24)
+	p[0], p[1], p[2] = 5, 0, dcerpcRequest
+	p[4] = 0x10
+	binary.LittleEndian.PutUint16(p[8:10], uint16(len(p)))
+	binary.LittleEndian.PutUint16(p[22:24], opnum)
+	return p
+}
+
+// smbWrite wraps a DCERPC PDU in a minimal SMB2 WRITE request.
+func smbWrite(pdu []byte) []byte {
+	msg := make([]byte, 64+48, 64+48+len(pdu))
+	copy(msg[0:4], "\xfeSMB")
+	binary.LittleEndian.PutUint16(msg[12:14], 9) // WRITE
+	binary.LittleEndian.PutUint16(msg[64+2:64+4], 112)
+	binary.LittleEndian.PutUint32(msg[64+4:64+8], uint32(len(pdu)))
+	msg = append(msg, pdu...)
+	return append([]byte{0, 0, 0, 0}, msg...)
+}
+
+func TestDCERPCTrackerFlagsServiceCreation(t *testing.T) {
+	tr := newDCERPCTracker()
+	now := time.Now()
+
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 135, dcerpcBindPDU(svcctlUUID), now)
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 135, dcerpcRequestPDU(12), now)
+
+	findings := tr.findings()
+	var hit bool
+	for _, f := range findings {
+		if f.Type == "lateral-movement" && f.Severity == "high" &&
+			strings.Contains(f.Description, "svcctl::CreateServiceW") {
+			hit = true
+			if f.SrcIP != "10.0.0.5" || f.DstIP != "10.0.0.9" {
+				t.Errorf("finding addressed %s -> %s, want 10.0.0.5 -> 10.0.0.9", f.SrcIP, f.DstIP)
+			}
+		}
+	}
+	if !hit {
+		t.Fatalf("no service-creation finding in %+v", findings)
+	}
+}
+
+func TestDCERPCTrackerDecodesSMBPipeTransport(t *testing.T) {
+	tr := newDCERPCTracker()
+	now := time.Now()
+
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 445, smbWrite(dcerpcBindPDU(svcctlUUID)), now)
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 445, smbWrite(dcerpcRequestPDU(12)), now)
+
+	var hit bool
+	for _, f := range tr.findings() {
+		if f.Type == "lateral-movement" && strings.Contains(f.Description, "CreateServiceW") {
+			hit = true
+		}
+	}
+	if !hit {
+		t.Fatal("service creation over an SMB pipe was not flagged")
+	}
+}
+
+func TestDCERPCTrackerReportsSensitiveBinds(t *testing.T) {
+	tr := newDCERPCTracker()
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 135, dcerpcBindPDU(svcctlUUID), time.Now())
+
+	findings := tr.findings()
+	if len(findings) != 1 || findings[0].Type != "dcerpc-bind" || findings[0].Severity != "low" {
+		t.Fatalf("findings = %+v, want one low dcerpc-bind", findings)
+	}
+	if !strings.Contains(findings[0].Description, "svcctl") {
+		t.Errorf("description %q should name svcctl", findings[0].Description)
+	}
+}
+
+func TestDCERPCTrackerIgnoresUnknownInterfaces(t *testing.T) {
+	tr := newDCERPCTracker()
+	unknown := make([]byte, 16)
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 135, dcerpcBindPDU(unknown), time.Now())
+	tr.processTCP("10.0.0.5", "10.0.0.9", 49200, 135, dcerpcRequestPDU(12), time.Now())
+	if findings := tr.findings(); len(findings) != 0 {
+		t.Fatalf("findings = %+v, want none", findings)
+	}
+}
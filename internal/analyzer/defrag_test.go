@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// udpDatagram is a complete 24-byte UDP packet: 8-byte header plus 16
+// bytes of payload, split across fragments by the tests below.
+func udpDatagram() []byte {
+	d := []byte{0x00, 0x35, 0x00, 0x35, 0x00, 0x18, 0x00, 0x00}
+	return append(d, []byte("0123456789abcdef")...)
+}
+
+func ip4Fragment(t *testing.T, id uint16, offsetUnits uint16, more bool, payload []byte) gopacket.Packet {
+	t.Helper()
+	ip := &layers.IPv4{
+		Version:    4,
+		IHL:        5,
+		TTL:        64,
+		Protocol:   layers.IPProtocolUDP,
+		SrcIP:      net.IP{10, 0, 0, 1},
+		DstIP:      net.IP{10, 0, 0, 2},
+		Id:         id,
+		FragOffset: offsetUnits,
+	}
+	if more {
+		ip.Flags = layers.IPv4MoreFragments
+	}
+	buf := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf,
+		gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+		ip, gopacket.Payload(payload))
+	if err != nil {
+		t.Fatalf("serialize fragment: %v", err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+func ip6Fragment(t *testing.T, ident uint32, offsetUnits uint16, more bool, payload []byte) gopacket.Packet {
+	t.Helper()
+	hdr := make([]byte, 48)
+	hdr[0] = 0x60
+	binary.BigEndian.PutUint16(hdr[4:6], uint16(8+len(payload)))
+	hdr[6] = uint8(layers.IPProtocolIPv6Fragment)
+	hdr[7] = 64
+	copy(hdr[8:24], net.ParseIP("fd00::1").To16())
+	copy(hdr[24:40], net.ParseIP("fd00::2").To16())
+	hdr[40] = uint8(layers.IPProtocolUDP)
+	frag := offsetUnits << 3
+	if more {
+		frag |= 1
+	}
+	binary.BigEndian.PutUint16(hdr[42:44], frag)
+	binary.BigEndian.PutUint32(hdr[44:48], ident)
+	return gopacket.NewPacket(append(hdr, payload...), layers.LayerTypeIPv6, gopacket.Default)
+}
+
+func TestIPDefragmenterReassemblesIPv4(t *testing.T) {
+	f := newIPDefragmenter()
+	now := time.Now()
+	datagram := udpDatagram()
+
+	first := ip4Fragment(t, 7, 0, true, datagram[:16])
+	if _, done := f.process(first, now); done {
+		t.Fatal("first fragment should be held")
+	}
+	last := ip4Fragment(t, 7, 2, false, datagram[16:])
+	size, done := f.process(last, now)
+	if !done {
+		t.Fatal("final fragment should complete the datagram")
+	}
+	if size != 20+24 {
+		t.Errorf("full size = %d, want 44", size)
+	}
+	udp, ok := last.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if !ok {
+		t.Fatal("reassembled packet has no UDP layer")
+	}
+	if !bytes.Equal(udp.Payload, datagram[8:]) {
+		t.Errorf("udp payload = %q, want %q", udp.Payload, datagram[8:])
+	}
+	if len(f.findings()) != 1 {
+		t.Errorf("findings = %d, want 1", len(f.findings()))
+	}
+}
+
+func TestIPDefragmenterReassemblesIPv6OutOfOrder(t *testing.T) {
+	f := newIPDefragmenter()
+	now := time.Now()
+	datagram := udpDatagram()
+
+	tail := ip6Fragment(t, 42, 2, false, datagram[16:])
+	if _, done := f.process(tail, now); done {
+		t.Fatal("tail fragment should be held until the head arrives")
+	}
+	head := ip6Fragment(t, 42, 0, true, datagram[:16])
+	size, done := f.process(head, now)
+	if !done {
+		t.Fatal("head fragment should complete the datagram")
+	}
+	if size != 40+24 {
+		t.Errorf("full size = %d, want 64", size)
+	}
+	udp, ok := head.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if !ok {
+		t.Fatal("reassembled packet has no UDP layer")
+	}
+	if !bytes.Equal(udp.Payload, datagram[8:]) {
+		t.Errorf("udp payload = %q, want %q", udp.Payload, datagram[8:])
+	}
+}
+
+func TestIPDefragmenterPassesThroughWholePackets(t *testing.T) {
+	f := newIPDefragmenter()
+	pkt := ip4Fragment(t, 9, 0, false, udpDatagram())
+	size, done := f.process(pkt, time.Now())
+	if !done || size != 0 {
+		t.Fatalf("process = (%d, %v), want unfragmented pass-through", size, done)
+	}
+	if f.findings() != nil {
+		t.Errorf("no findings expected, got %+v", f.findings())
+	}
+}
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// PacketContext is the decoded view of one packet, filled once per packet
+// and shared by every handler during dispatch. Handlers read the common
+// fields here and type-assert their own layer for anything protocol
+// specific.
+type PacketContext struct {
+	AnalysisID int64
+	Packet     gopacket.Packet
+	Timestamp  time.Time
+	Size       int64
+
+	SrcMAC, DstMAC string
+
+	// SrcIP is empty when the packet has no IPv4 or IPv6 layer.
+	SrcIP, DstIP string
+	Protocol     string
+
+	// Transport fields; zero for packets without TCP or UDP.
+	SrcPort, DstPort uint16
+	Payload          []byte
+	Flags            *tcpFlags // nil for non-TCP traffic
+}
+
+// packetHandler processes one decoded packet.
+type packetHandler func(*PacketContext)
+
+// handlerEntry pairs a handler with its match condition. A zero layer
+// means no layer constraint.
+type handlerEntry struct {
+	layer  gopacket.LayerType
+	ipOnly bool
+	fn     packetHandler
+}
+
+// dispatcher fans each decoded packet out to handlers registered per
+// layer, in registration order, so a new protocol module hooks into the
+// pipeline with one registration instead of another branch in the read
+// loop.
+type dispatcher struct {
+	entries []handlerEntry
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{}
+}
+
+// onEvery registers a handler that runs for every packet.
+func (d *dispatcher) onEvery(fn packetHandler) {
+	d.entries = append(d.entries, handlerEntry{fn: fn})
+}
+
+// onIP registers a handler that runs for packets with an IP layer.
+func (d *dispatcher) onIP(fn packetHandler) {
+	d.entries = append(d.entries, handlerEntry{ipOnly: true, fn: fn})
+}
+
+// on registers a handler that runs for packets carrying the given layer.
+func (d *dispatcher) on(layer gopacket.LayerType, fn packetHandler) {
+	d.entries = append(d.entries, handlerEntry{layer: layer, fn: fn})
+}
+
+// dispatch decodes the packet into ctx and runs every matching handler.
+func (d *dispatcher) dispatch(ctx *PacketContext) {
+	decodePacket(ctx)
+	for _, e := range d.entries {
+		if e.ipOnly && ctx.SrcIP == "" {
+			continue
+		}
+		if e.layer != 0 && ctx.Packet.Layer(e.layer) == nil {
+			continue
+		}
+		e.fn(ctx)
+	}
+}
+
+// decodePacket fills the shared context fields from the packet's layers.
+func decodePacket(ctx *PacketContext) {
+	packet := ctx.Packet
+
+	if eth, ok := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet); ok {
+		ctx.SrcMAC = eth.SrcMAC.String()
+		ctx.DstMAC = eth.DstMAC.String()
+	} else if sll, ok := packet.Layer(layers.LayerTypeLinuxSLL).(*layers.LinuxSLL); ok && len(sll.Addr) == 6 {
+		// Cooked captures only carry the sender's address.
+		ctx.SrcMAC = sll.Addr.String()
+	}
+
+	if ip4, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+		ctx.SrcIP, ctx.DstIP = ip4.SrcIP.String(), ip4.DstIP.String()
+		ctx.Protocol = strings.ToLower(ip4.Protocol.String())
+	} else if ip6, ok := packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6); ok {
+		ctx.SrcIP, ctx.DstIP = ip6.SrcIP.String(), ip6.DstIP.String()
+		ctx.Protocol = strings.ToLower(ip6.NextHeader.String())
+	}
+
+	if tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP); ok {
+		ctx.Protocol = "tcp"
+		ctx.SrcPort, ctx.DstPort = uint16(tcp.SrcPort), uint16(tcp.DstPort)
+		ctx.Payload = tcp.Payload
+		ctx.Flags = &tcpFlags{syn: tcp.SYN, ack: tcp.ACK, fin: tcp.FIN, rst: tcp.RST,
+			seq: tcp.Seq, payloadLen: len(tcp.Payload)}
+	} else if udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+		ctx.Protocol = "udp"
+		ctx.SrcPort, ctx.DstPort = uint16(udp.SrcPort), uint16(udp.DstPort)
+		ctx.Payload = udp.Payload
+	}
+}
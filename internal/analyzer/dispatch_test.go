@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// buildPacket serializes the given layers into a decoded Ethernet packet.
+func buildPacket(t *testing.T, ls ...gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.NoCopy)
+}
+
+func tcpPacket(t *testing.T) gopacket.Packet {
+	t.Helper()
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP,
+		SrcIP: net.IPv4(10, 0, 0, 1), DstIP: net.IPv4(10, 0, 0, 2),
+	}
+	tcp := &layers.TCP{SrcPort: 40000, DstPort: 80, SYN: true}
+	tcp.SetNetworkLayerForChecksum(ip)
+	return buildPacket(t, eth, ip, tcp, gopacket.Payload([]byte("GET / HTTP/1.1\r\n\r\n")))
+}
+
+func arpPacket(t *testing.T) gopacket.Packet {
+	t.Helper()
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		EthernetType: layers.EthernetTypeARP,
+	}
+	arp := &layers.ARP{
+		AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4,
+		HwAddressSize: 6, ProtAddressSize: 4, Operation: layers.ARPRequest,
+		SourceHwAddress: eth.SrcMAC, SourceProtAddress: []byte{10, 0, 0, 1},
+		DstHwAddress: make([]byte, 6), DstProtAddress: []byte{10, 0, 0, 2},
+	}
+	return buildPacket(t, eth, arp)
+}
+
+func TestDecodePacket(t *testing.T) {
+	ctx := &PacketContext{Packet: tcpPacket(t)}
+	decodePacket(ctx)
+
+	if ctx.SrcMAC != "02:00:00:00:00:01" || ctx.DstMAC != "02:00:00:00:00:02" {
+		t.Errorf("MACs = %q/%q", ctx.SrcMAC, ctx.DstMAC)
+	}
+	if ctx.SrcIP != "10.0.0.1" || ctx.DstIP != "10.0.0.2" {
+		t.Errorf("IPs = %q/%q", ctx.SrcIP, ctx.DstIP)
+	}
+	if ctx.Protocol != "tcp" || ctx.SrcPort != 40000 || ctx.DstPort != 80 {
+		t.Errorf("transport = %s %d->%d", ctx.Protocol, ctx.SrcPort, ctx.DstPort)
+	}
+	if string(ctx.Payload) != "GET / HTTP/1.1\r\n\r\n" {
+		t.Errorf("payload = %q", ctx.Payload)
+	}
+	if ctx.Flags == nil || !ctx.Flags.syn || ctx.Flags.ack {
+		t.Errorf("flags = %+v", ctx.Flags)
+	}
+}
+
+func TestDispatchMatching(t *testing.T) {
+	d := newDispatcher()
+	var calls []string
+	d.onEvery(func(*PacketContext) { calls = append(calls, "every") })
+	d.onIP(func(*PacketContext) { calls = append(calls, "ip") })
+	d.on(layers.LayerTypeARP, func(*PacketContext) { calls = append(calls, "arp") })
+	d.on(layers.LayerTypeTCP, func(*PacketContext) { calls = append(calls, "tcp") })
+
+	d.dispatch(&PacketContext{Packet: tcpPacket(t)})
+	if got, want := len(calls), 3; got != want {
+		t.Fatalf("tcp packet ran %d handlers (%v), want %d", got, calls, want)
+	}
+	if calls[0] != "every" || calls[1] != "ip" || calls[2] != "tcp" {
+		t.Errorf("tcp packet handler order = %v", calls)
+	}
+
+	calls = nil
+	d.dispatch(&PacketContext{Packet: arpPacket(t)})
+	// No IP layer, so the IP and TCP handlers must not fire.
+	if len(calls) != 2 || calls[0] != "every" || calls[1] != "arp" {
+		t.Errorf("arp packet handlers = %v", calls)
+	}
+}
@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// dnsServerStat accumulates health signals for one DNS server address.
+type dnsServerStat struct {
+	queries   int64
+	responses int64
+	servfail  int64
+	nxdomain  int64
+	clients   map[string]bool
+	latencies []float64 // milliseconds, matched query->response
+}
+
+// dnsHealthTracker measures per-server query volume, response latency and
+// error rates — infrastructure troubleshooting rather than detection.
+type dnsHealthTracker struct {
+	servers map[string]*dnsServerStat
+	pending map[string]time.Time // client|server|txid -> query time
+}
+
+func newDNSHealthTracker() *dnsHealthTracker {
+	return &dnsHealthTracker{
+		servers: make(map[string]*dnsServerStat),
+		pending: make(map[string]time.Time),
+	}
+}
+
+func (dh *dnsHealthTracker) server(ip string) *dnsServerStat {
+	s, ok := dh.servers[ip]
+	if !ok {
+		s = &dnsServerStat{clients: make(map[string]bool)}
+		dh.servers[ip] = s
+	}
+	return s
+}
+
+func dnsTxnKey(client, server string, txid uint16) string {
+	return fmt.Sprintf("%s|%s|%d", client, server, txid)
+}
+
+// processQuery accounts one query from client to server.
+func (dh *dnsHealthTracker) processQuery(client, server string, txid uint16, ts time.Time) {
+	s := dh.server(server)
+	s.queries++
+	s.clients[client] = true
+	dh.pending[dnsTxnKey(client, server, txid)] = ts
+}
+
+// processResponse accounts one response from server back to client,
+// matching it to its query by transaction ID for latency.
+func (dh *dnsHealthTracker) processResponse(server, client string, txid uint16, rcode layers.DNSResponseCode, ts time.Time) {
+	s := dh.server(server)
+	s.responses++
+	switch rcode {
+	case layers.DNSResponseCodeServFail:
+		s.servfail++
+	case layers.DNSResponseCodeNXDomain:
+		s.nxdomain++
+	}
+	key := dnsTxnKey(client, server, txid)
+	if sent, ok := dh.pending[key]; ok {
+		delete(dh.pending, key)
+		if d := ts.Sub(sent); d >= 0 {
+			s.latencies = append(s.latencies, float64(d)/float64(time.Millisecond))
+		}
+	}
+}
+
+// percentile reads the pth percentile from an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// rows renders the per-server health report, busiest server first.
+func (dh *dnsHealthTracker) rows() []models.DNSServerHealth {
+	var out []models.DNSServerHealth
+	for ip, s := range dh.servers {
+		sort.Float64s(s.latencies)
+		out = append(out, models.DNSServerHealth{
+			ServerIP:  ip,
+			Queries:   s.queries,
+			Responses: s.responses,
+			Servfail:  s.servfail,
+			Nxdomain:  s.nxdomain,
+			Clients:   int64(len(s.clients)),
+			MedianMs:  percentile(s.latencies, 0.5),
+			P95Ms:     percentile(s.latencies, 0.95),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Queries != out[j].Queries {
+			return out[i].Queries > out[j].Queries
+		}
+		return out[i].ServerIP < out[j].ServerIP
+	})
+	return out
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestDNSHealthTrackerAggregatesPerServer(t *testing.T) {
+	dh := newDNSHealthTracker()
+	base := time.Now()
+
+	// Two clients query the same server; one answer is SERVFAIL.
+	dh.processQuery("10.0.0.5", "10.0.0.53", 1, base)
+	dh.processResponse("10.0.0.53", "10.0.0.5", 1, layers.DNSResponseCodeNoErr, base.Add(10*time.Millisecond))
+	dh.processQuery("10.0.0.6", "10.0.0.53", 2, base)
+	dh.processResponse("10.0.0.53", "10.0.0.6", 2, layers.DNSResponseCodeServFail, base.Add(30*time.Millisecond))
+	// A query to a second server goes unanswered.
+	dh.processQuery("10.0.0.5", "10.0.0.54", 3, base)
+
+	rows := dh.rows()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	r := rows[0]
+	if r.ServerIP != "10.0.0.53" {
+		t.Fatalf("busiest server = %s, want 10.0.0.53", r.ServerIP)
+	}
+	if r.Queries != 2 || r.Responses != 2 || r.Servfail != 1 || r.Clients != 2 {
+		t.Errorf("row = %+v, want queries=2 responses=2 servfail=1 clients=2", r)
+	}
+	if r.MedianMs < 10 || r.MedianMs > 30 {
+		t.Errorf("median = %v ms, want within [10, 30]", r.MedianMs)
+	}
+	if rows[1].Responses != 0 || rows[1].Queries != 1 {
+		t.Errorf("unanswered server row = %+v, want queries=1 responses=0", rows[1])
+	}
+}
+
+func TestDNSHealthTrackerMatchesByTransactionID(t *testing.T) {
+	dh := newDNSHealthTracker()
+	base := time.Now()
+
+	dh.processQuery("10.0.0.5", "10.0.0.53", 7, base)
+	// Response for a transaction never queried: counted, but no latency.
+	dh.processResponse("10.0.0.53", "10.0.0.5", 99, layers.DNSResponseCodeNoErr, base.Add(5*time.Millisecond))
+
+	r := dh.rows()[0]
+	if r.Responses != 1 {
+		t.Fatalf("responses = %d, want 1", r.Responses)
+	}
+	if r.MedianMs != 0 {
+		t.Errorf("median = %v ms, want 0 for unmatched response", r.MedianMs)
+	}
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// defaultLongSessionThreshold is the connection duration above which a
+// session is reported as abnormally long-lived. Override with
+// LONG_SESSION_SECONDS (0 disables the absolute rule; the span-relative
+// rule below still applies).
+const defaultLongSessionThreshold = 6 * time.Hour
+
+// longSessionSpanFraction flags sessions that cover most of the capture
+// even when the absolute threshold is not reached, provided the capture
+// is long enough for that to mean anything.
+const (
+	longSessionSpanFraction = 0.9
+	longSessionMinSpan      = time.Hour
+)
+
+// longSessionThreshold returns the configured absolute duration limit.
+func longSessionThreshold() time.Duration {
+	if v := os.Getenv("LONG_SESSION_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultLongSessionThreshold
+}
+
+// serviceDurations aggregates connection durations per service into
+// percentile rows, busiest service first.
+func serviceDurations(conns []models.Connection) []models.ServiceDuration {
+	byService := make(map[string][]float64)
+	for _, c := range conns {
+		service := c.Service
+		if service == "" {
+			service = "other"
+		}
+		byService[service] = append(byService[service],
+			c.LastSeen.Sub(c.FirstSeen).Seconds())
+	}
+
+	out := make([]models.ServiceDuration, 0, len(byService))
+	for service, durations := range byService {
+		sort.Float64s(durations)
+		out = append(out, models.ServiceDuration{
+			Service:  service,
+			Sessions: int64(len(durations)),
+			MedianS:  percentile(durations, 0.5),
+			P95S:     percentile(durations, 0.95),
+			MaxS:     durations[len(durations)-1],
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Sessions != out[j].Sessions {
+			return out[i].Sessions > out[j].Sessions
+		}
+		return out[i].Service < out[j].Service
+	})
+	return out
+}
+
+// longLivedFindings flags sessions that ran far longer than sessions of
+// their kind normally do — a whole-capture SSH session or a 12-hour
+// HTTPS connection is more likely a tunnel or persistence channel than
+// ordinary use.
+func longLivedFindings(conns []models.Connection, captureStart, captureEnd time.Time) []models.Finding {
+	threshold := longSessionThreshold()
+	span := captureEnd.Sub(captureStart)
+	spanLimit := time.Duration(float64(span) * longSessionSpanFraction)
+
+	var out []models.Finding
+	for _, c := range conns {
+		duration := c.LastSeen.Sub(c.FirstSeen)
+		long := threshold > 0 && duration >= threshold
+		if !long && span >= longSessionMinSpan && duration >= spanLimit {
+			long = true
+		}
+		if !long {
+			continue
+		}
+		service := c.Service
+		if service == "" {
+			service = fmt.Sprintf("%s/%d", c.Protocol, c.DstPort)
+		}
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "long-lived-session",
+			Title: fmt.Sprintf("Long-lived %s session from %s to %s",
+				service, c.SrcIP, c.DstIP),
+			Description: fmt.Sprintf(
+				"The %s session %s:%d -> %s:%d stayed active for %s (%d bytes sent, %d received). "+
+					"Sessions of this length often indicate tunnels, C2 channels or persistence.",
+				service, c.SrcIP, c.SrcPort, c.DstIP, c.DstPort,
+				duration.Round(time.Second), c.BytesSent, c.BytesRecv),
+			SrcIP:     c.SrcIP,
+			DstIP:     c.DstIP,
+			Timestamp: c.FirstSeen,
+		})
+	}
+	return out
+}
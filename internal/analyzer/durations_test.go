@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func durConn(service string, src, dst string, dstPort int, start time.Time, d time.Duration) models.Connection {
+	return models.Connection{
+		Protocol: "tcp", SrcIP: src, SrcPort: 50000, DstIP: dst, DstPort: dstPort,
+		Service: service, FirstSeen: start, LastSeen: start.Add(d),
+	}
+}
+
+func TestServiceDurations(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	conns := []models.Connection{
+		durConn("https", "10.0.0.1", "10.0.1.1", 443, base, 10*time.Second),
+		durConn("https", "10.0.0.2", "10.0.1.1", 443, base, 30*time.Second),
+		durConn("https", "10.0.0.3", "10.0.1.1", 443, base, 20*time.Second),
+		durConn("", "10.0.0.1", "10.0.1.2", 9999, base, 5*time.Second),
+	}
+
+	rows := serviceDurations(conns)
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	https := rows[0]
+	if https.Service != "https" || https.Sessions != 3 {
+		t.Fatalf("first row = %+v, want https with 3 sessions", https)
+	}
+	if https.MedianS != 20 || https.MaxS != 30 {
+		t.Errorf("median/max = %v/%v, want 20/30", https.MedianS, https.MaxS)
+	}
+	if rows[1].Service != "other" || rows[1].Sessions != 1 {
+		t.Errorf("second row = %+v, want other with 1 session", rows[1])
+	}
+}
+
+func TestLongLivedFindingsAbsoluteThreshold(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := base.Add(24 * time.Hour)
+	conns := []models.Connection{
+		durConn("ssh", "10.0.0.5", "10.0.1.1", 22, base, 12*time.Hour),
+		durConn("https", "10.0.0.6", "10.0.1.2", 443, base, 5*time.Minute),
+	}
+
+	out := longLivedFindings(conns, base, end)
+	if len(out) != 1 {
+		t.Fatalf("findings = %d, want 1", len(out))
+	}
+	f := out[0]
+	if f.Type != "long-lived-session" || f.SrcIP != "10.0.0.5" {
+		t.Errorf("finding = %+v, want long-lived-session from 10.0.0.5", f)
+	}
+}
+
+func TestLongLivedFindingsWholeCaptureSession(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	end := base.Add(2 * time.Hour)
+	// Under the 6h absolute threshold, but spans nearly the whole capture.
+	conns := []models.Connection{
+		durConn("ssh", "10.0.0.5", "10.0.1.1", 22, base, 2*time.Hour),
+	}
+
+	if out := longLivedFindings(conns, base, end); len(out) != 1 {
+		t.Fatalf("findings = %d, want 1 for a whole-capture session", len(out))
+	}
+
+	// A short capture proves nothing about session length.
+	shortEnd := base.Add(10 * time.Minute)
+	short := []models.Connection{
+		durConn("ssh", "10.0.0.5", "10.0.1.1", 22, base, 10*time.Minute),
+	}
+	if out := longLivedFindings(short, base, shortEnd); len(out) != 0 {
+		t.Errorf("findings = %d, want none for a 10-minute capture", len(out))
+	}
+}
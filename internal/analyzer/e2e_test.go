@@ -0,0 +1,218 @@
+package analyzer
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// captureBuilder writes a synthetic PCAP file packet by packet, so tests
+// can describe traffic in terms of hosts and flags instead of shipping
+// binary fixtures.
+type captureBuilder struct {
+	t    *testing.T
+	file *os.File
+	w    *pcapgo.Writer
+}
+
+func newCapture(t *testing.T) *captureBuilder {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "synthetic.pcap"))
+	if err != nil {
+		t.Fatalf("create capture: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatalf("write pcap header: %v", err)
+	}
+	return &captureBuilder{t: t, file: f, w: w}
+}
+
+func (cb *captureBuilder) path() string { return cb.file.Name() }
+
+// write serializes the given layers and appends them at ts.
+func (cb *captureBuilder) write(ts time.Time, ls ...gopacket.SerializableLayer) {
+	cb.t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		cb.t.Fatalf("serialize packet: %v", err)
+	}
+	data := buf.Bytes()
+	ci := gopacket.CaptureInfo{Timestamp: ts, CaptureLength: len(data), Length: len(data)}
+	if err := cb.w.WritePacket(ci, data); err != nil {
+		cb.t.Fatalf("write packet: %v", err)
+	}
+}
+
+// synthMAC derives a stable MAC from an IP's last octet so assets get
+// consistent hardware addresses across packets.
+func synthMAC(ip string) net.HardwareAddr {
+	last := net.ParseIP(ip).To4()[3]
+	return net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, last}
+}
+
+// tcp appends one TCP segment from src to dst.
+func (cb *captureBuilder) tcp(ts time.Time, src, dst string, sport, dport uint16, syn, ack bool, payload []byte) {
+	eth := &layers.Ethernet{SrcMAC: synthMAC(src), DstMAC: synthMAC(dst), EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolTCP,
+		SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(sport), DstPort: layers.TCPPort(dport),
+		SYN: syn, ACK: ack, Window: 65535}
+	tcp.SetNetworkLayerForChecksum(ip)
+	cb.write(ts, eth, ip, tcp, gopacket.Payload(payload))
+}
+
+// dnsQuery appends an A-record lookup from src to the server at dst.
+func (cb *captureBuilder) dnsQuery(ts time.Time, src, dst, name string) {
+	eth := &layers.Ethernet{SrcMAC: synthMAC(src), DstMAC: synthMAC(dst), EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP,
+		SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ip)
+	dns := &layers.DNS{ID: 1, RD: true, OpCode: layers.DNSOpCodeQuery,
+		Questions: []layers.DNSQuestion{{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN}}}
+	cb.write(ts, eth, ip, udp, dns)
+}
+
+// webSession writes a TCP handshake followed by a plaintext HTTP exchange.
+func (cb *captureBuilder) webSession(start time.Time, client, server string, sport uint16) {
+	cb.tcp(start, client, server, sport, 80, true, false, nil)
+	cb.tcp(start.Add(time.Millisecond), server, client, 80, sport, true, true, nil)
+	cb.tcp(start.Add(2*time.Millisecond), client, server, sport, 80, false, true, nil)
+	cb.tcp(start.Add(3*time.Millisecond), client, server, sport, 80, false, true,
+		[]byte("GET / HTTP/1.1\r\nHost: files.example.com\r\nUser-Agent: curl/8.0\r\n\r\n"))
+	cb.tcp(start.Add(5*time.Millisecond), server, client, 80, sport, false, true,
+		[]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok"))
+}
+
+func TestSyntheticCaptureAnalysis(t *testing.T) {
+	cb := newCapture(t)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cb.dnsQuery(start, "10.0.0.10", "10.0.0.53", "files.example.com")
+	cb.webSession(start.Add(10*time.Millisecond), "10.0.0.10", "10.0.0.20", 49152)
+
+	res, err := AnalyzeFile(0, cb.path())
+	if err != nil {
+		t.Fatalf("AnalyzeFile: %v", err)
+	}
+	if res.PacketCount != 6 {
+		t.Errorf("packet count = %d, want 6", res.PacketCount)
+	}
+
+	var found bool
+	for _, c := range res.Connections {
+		if c.Protocol == "tcp" && c.SrcIP == "10.0.0.10" && c.DstIP == "10.0.0.20" && c.DstPort == 80 {
+			found = true
+			if c.Metadata["http.host"] != "files.example.com" {
+				t.Errorf("http.host = %q, want files.example.com", c.Metadata["http.host"])
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no tcp/80 connection in results: %+v", res.Connections)
+	}
+
+	if len(res.DNSQueries) != 1 || res.DNSQueries[0].Query != "files.example.com" {
+		t.Errorf("dns queries = %+v, want one for files.example.com", res.DNSQueries)
+	}
+	ips := make(map[string]bool)
+	for _, a := range res.Assets {
+		ips[a.IP] = true
+	}
+	for _, want := range []string{"10.0.0.10", "10.0.0.20", "10.0.0.53"} {
+		if !ips[want] {
+			t.Errorf("asset %s missing from results", want)
+		}
+	}
+}
+
+func TestSyntheticScanRaisesFinding(t *testing.T) {
+	cb := newCapture(t)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for port := 1; port <= portScanThreshold; port++ {
+		cb.tcp(start.Add(time.Duration(port)*time.Millisecond),
+			"10.0.0.66", "10.0.0.20", 50000, uint16(port), true, false, nil)
+	}
+
+	res, err := AnalyzeFile(0, cb.path())
+	if err != nil {
+		t.Fatalf("AnalyzeFile: %v", err)
+	}
+	var scan bool
+	for _, f := range res.Findings {
+		if f.Type == "port-scan" && f.SrcIP == "10.0.0.66" {
+			scan = true
+		}
+	}
+	if !scan {
+		t.Errorf("no port-scan finding for 10.0.0.66 in %+v", res.Findings)
+	}
+}
+
+func TestAnalyzePCAPPersistsToDatabase(t *testing.T) {
+	cb := newCapture(t)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cb.dnsQuery(start, "10.0.0.10", "10.0.0.53", "files.example.com")
+	cb.webSession(start.Add(10*time.Millisecond), "10.0.0.10", "10.0.0.20", 49152)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "e2e.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	user, err := db.CreateUser(ctx, "e2e", "x")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	analysisID, err := db.CreateAnalysis(ctx, user.ID, "synthetic.pcap", cb.path(), 0)
+	if err != nil {
+		t.Fatalf("create analysis: %v", err)
+	}
+
+	if err := AnalyzePCAP(ctx, db, analysisID, cb.path()); err != nil {
+		t.Fatalf("AnalyzePCAP: %v", err)
+	}
+
+	conns, err := db.GetConnections(ctx, analysisID)
+	if err != nil {
+		t.Fatalf("get connections: %v", err)
+	}
+	var web bool
+	for _, c := range conns {
+		if c.Protocol == "tcp" && c.DstPort == 80 && c.Metadata["http.host"] == "files.example.com" {
+			web = true
+		}
+	}
+	if !web {
+		t.Errorf("persisted connections missing web flow metadata: %+v", conns)
+	}
+
+	queries, err := db.GetDNSQueries(ctx, analysisID)
+	if err != nil {
+		t.Fatalf("get dns queries: %v", err)
+	}
+	if len(queries) != 1 {
+		t.Errorf("persisted dns queries = %d, want 1", len(queries))
+	}
+
+	analysis, err := db.GetAnalysis(ctx, analysisID)
+	if err != nil || analysis == nil {
+		t.Fatalf("get analysis: %v", err)
+	}
+	if analysis.PacketCount != 6 {
+		t.Errorf("persisted packet count = %d, want 6", analysis.PacketCount)
+	}
+}
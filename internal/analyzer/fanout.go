@@ -0,0 +1,50 @@
+package analyzer
+
+import "github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+
+// fanStat collects one host's connection-graph degree: which peers it
+// dialed, which dialed it, and the distinct ports on either side.
+type fanStat struct {
+	out    map[string]struct{}
+	in     map[string]struct{}
+	dialed map[int]struct{}
+	served map[int]struct{}
+}
+
+// applyFanMetrics fills each asset's fan-in/fan-out and distinct-port
+// counts from the aggregated connection list, so the UI can rank the most
+// connected hosts without walking flows. Connections record the initiator
+// as SrcIP, so "out" and "dialed" describe behavior as a client and "in"
+// and "served" behavior as a server.
+func applyFanMetrics(assets []models.Asset, conns []models.Connection) {
+	stats := make(map[string]*fanStat)
+	stat := func(ip string) *fanStat {
+		s, ok := stats[ip]
+		if !ok {
+			s = &fanStat{
+				out:    make(map[string]struct{}),
+				in:     make(map[string]struct{}),
+				dialed: make(map[int]struct{}),
+				served: make(map[int]struct{}),
+			}
+			stats[ip] = s
+		}
+		return s
+	}
+	for _, c := range conns {
+		src, dst := stat(c.SrcIP), stat(c.DstIP)
+		src.out[c.DstIP] = struct{}{}
+		src.dialed[c.DstPort] = struct{}{}
+		dst.in[c.SrcIP] = struct{}{}
+		dst.served[c.DstPort] = struct{}{}
+	}
+
+	for i := range assets {
+		if s, ok := stats[assets[i].IP]; ok {
+			assets[i].FanOut = len(s.out)
+			assets[i].FanIn = len(s.in)
+			assets[i].PortsDialed = len(s.dialed)
+			assets[i].PortsServed = len(s.served)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func fanConn(src, dst string, dstPort int) models.Connection {
+	return models.Connection{
+		Protocol: "tcp", SrcIP: src, SrcPort: 50000, DstIP: dst, DstPort: dstPort,
+	}
+}
+
+func TestApplyFanMetrics(t *testing.T) {
+	conns := []models.Connection{
+		// 10.0.0.1 dials three peers on two distinct ports.
+		fanConn("10.0.0.1", "10.0.1.1", 443),
+		fanConn("10.0.0.1", "10.0.1.2", 443),
+		fanConn("10.0.0.1", "10.0.1.3", 22),
+		// 10.0.1.1 is also dialed by a second client, on another port.
+		fanConn("10.0.0.2", "10.0.1.1", 8443),
+	}
+	assets := []models.Asset{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.1.1"},
+		{IP: "10.0.9.9"}, // no connections at all
+	}
+
+	applyFanMetrics(assets, conns)
+
+	client := assets[0]
+	if client.FanOut != 3 || client.FanIn != 0 {
+		t.Errorf("client fan out/in = %d/%d, want 3/0", client.FanOut, client.FanIn)
+	}
+	if client.PortsDialed != 2 || client.PortsServed != 0 {
+		t.Errorf("client ports dialed/served = %d/%d, want 2/0", client.PortsDialed, client.PortsServed)
+	}
+	server := assets[1]
+	if server.FanIn != 2 || server.FanOut != 0 {
+		t.Errorf("server fan in/out = %d/%d, want 2/0", server.FanIn, server.FanOut)
+	}
+	if server.PortsServed != 2 || server.PortsDialed != 0 {
+		t.Errorf("server ports served/dialed = %d/%d, want 2/0", server.PortsServed, server.PortsDialed)
+	}
+	if quiet := assets[2]; quiet.FanOut != 0 || quiet.FanIn != 0 {
+		t.Errorf("quiet host fan out/in = %d/%d, want 0/0", quiet.FanOut, quiet.FanIn)
+	}
+}
+
+func TestApplyFanMetricsDeduplicatesPeers(t *testing.T) {
+	// Two flows to the same peer and port count once each way.
+	conns := []models.Connection{
+		fanConn("10.0.0.1", "10.0.1.1", 443),
+		fanConn("10.0.0.1", "10.0.1.1", 443),
+	}
+	assets := []models.Asset{{IP: "10.0.0.1"}, {IP: "10.0.1.1"}}
+
+	applyFanMetrics(assets, conns)
+
+	if assets[0].FanOut != 1 || assets[0].PortsDialed != 1 {
+		t.Errorf("fan out/ports dialed = %d/%d, want 1/1", assets[0].FanOut, assets[0].PortsDialed)
+	}
+	if assets[1].FanIn != 1 || assets[1].PortsServed != 1 {
+		t.Errorf("fan in/ports served = %d/%d, want 1/1", assets[1].FanIn, assets[1].PortsServed)
+	}
+}
@@ -0,0 +1,259 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+)
+
+// osEvidence accumulates passive fingerprinting observations for one host.
+type osEvidence struct {
+	guess      string
+	confidence int
+	Signals    []string
+}
+
+// OSFingerprinter performs passive OS guessing from TCP SYN
+// characteristics (initial TTL, window size and option layout).
+type OSFingerprinter struct {
+	hosts map[string]*osEvidence
+}
+
+// NewOSFingerprinter returns an empty fingerprinter.
+func NewOSFingerprinter() *OSFingerprinter {
+	return &OSFingerprinter{hosts: make(map[string]*osEvidence)}
+}
+
+// ProcessSYN records the TTL, window size and option layout of a SYN
+// packet sent by ip.
+func (fp *OSFingerprinter) ProcessSYN(ip string, ttl uint8, window uint16, opts []layers.TCPOption) {
+	ev, ok := fp.hosts[ip]
+	if !ok {
+		ev = &osEvidence{}
+		fp.hosts[ip] = ev
+	}
+
+	guess, confidence := classifySYN(ttl, window)
+	ev.Signals = append(ev.Signals,
+		fmt.Sprintf("syn ttl=%d window=%d => %s", ttl, window, guess))
+	if confidence > ev.confidence {
+		ev.guess = guess
+		ev.confidence = confidence
+	}
+
+	if sig := optionSignature(opts); sig != "" {
+		optGuess, optConfidence := classifyOptions(sig)
+		ev.Signals = append(ev.Signals,
+			fmt.Sprintf("syn options=%s => %s", sig, optGuess))
+		if optConfidence > ev.confidence {
+			ev.guess = optGuess
+			ev.confidence = optConfidence
+		}
+	}
+}
+
+// classifySYN maps SYN characteristics to an OS family. Initial TTLs are
+// almost always 64 (unix-likes), 128 (Windows) or 255 (network gear); the
+// window size narrows things further.
+func classifySYN(ttl uint8, window uint16) (string, int) {
+	switch {
+	case ttl > 128:
+		return "Network Device", 50
+	case ttl > 64:
+		switch window {
+		case 8192, 64240, 65535:
+			return "Windows", 80
+		default:
+			return "Windows", 60
+		}
+	default:
+		switch window {
+		case 5840, 29200, 64240:
+			return "Linux", 80
+		case 65535:
+			return "macOS/iOS", 70
+		default:
+			return "Linux", 50
+		}
+	}
+}
+
+// optionSignature renders the SYN's option list as an ordered, comma
+// separated string of short option names, e.g. "mss,sack,ts,nop,ws".
+// Option values are deliberately left out: it is the ordering and padding
+// choices that differ between network stacks.
+func optionSignature(opts []layers.TCPOption) string {
+	if len(opts) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(opts))
+	for _, o := range opts {
+		switch o.OptionType {
+		case layers.TCPOptionKindEndList:
+			names = append(names, "eol")
+		case layers.TCPOptionKindNop:
+			names = append(names, "nop")
+		case layers.TCPOptionKindMSS:
+			names = append(names, "mss")
+		case layers.TCPOptionKindWindowScale:
+			names = append(names, "ws")
+		case layers.TCPOptionKindSACKPermitted:
+			names = append(names, "sack")
+		case layers.TCPOptionKindTimestamps:
+			names = append(names, "ts")
+		default:
+			names = append(names, fmt.Sprintf("%d", o.OptionType))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// synOptionSignatures maps well-known SYN option layouts to OS families.
+// Option ordering is one of the strongest passive signals: the layouts
+// below are hard-coded in the respective stacks and rarely change between
+// releases.
+var synOptionSignatures = map[string]string{
+	"mss,sack,ts,nop,ws":             "Linux",
+	"mss,nop,ws,nop,nop,sack":        "Windows",
+	"mss,nop,ws,sack,ts":             "FreeBSD",
+	"mss,nop,ws,nop,nop,ts,sack,eol": "macOS/iOS",
+	"mss":                            "Embedded/Legacy",
+	"mss,nop,nop,sack":               "Windows (legacy)",
+}
+
+// classifyOptions maps an option signature to an OS family. An exact
+// layout match outranks any TTL/window heuristic; an unknown layout still
+// says the stack negotiates modern options, which is weak evidence of a
+// general-purpose OS.
+func classifyOptions(sig string) (string, int) {
+	if os, ok := synOptionSignatures[sig]; ok {
+		return os, 90
+	}
+	return "Unknown", 0
+}
+
+// observe credits one fingerprinting signal to a host, keeping the
+// highest-confidence guess seen so far.
+func (fp *OSFingerprinter) observe(ip, signal, guess string, confidence int) {
+	ev, ok := fp.hosts[ip]
+	if !ok {
+		ev = &osEvidence{}
+		fp.hosts[ip] = ev
+	}
+	ev.Signals = append(ev.Signals, signal)
+	if confidence > ev.confidence {
+		ev.guess = guess
+		ev.confidence = confidence
+	}
+}
+
+// ProcessSYNACK fingerprints the server side of a handshake from its
+// SYN-ACK, so hosts that never initiate connections still get a guess.
+func (fp *OSFingerprinter) ProcessSYNACK(ip string, ttl uint8, window uint16, opts []layers.TCPOption) {
+	guess, confidence := classifySYN(ttl, window)
+	fp.observe(ip, fmt.Sprintf("syn-ack ttl=%d window=%d => %s", ttl, window, guess),
+		guess, confidence)
+	if sig := optionSignature(opts); sig != "" {
+		if optGuess, optConfidence := classifyOptions(sig); optConfidence > 0 {
+			fp.observe(ip, fmt.Sprintf("syn-ack options=%s => %s", sig, optGuess),
+				optGuess, optConfidence)
+		}
+	}
+}
+
+// ProcessHTTPResponse reads the Server header of an HTTP response; many
+// servers advertise their distribution or platform there.
+func (fp *OSFingerprinter) ProcessHTTPResponse(ip string, payload []byte) {
+	if !bytes.HasPrefix(payload, []byte("HTTP/")) {
+		return
+	}
+	server := httpHeader(payload, "Server")
+	if server == "" {
+		return
+	}
+	guess, confidence := classifyServerHeader(server)
+	if confidence == 0 {
+		return
+	}
+	fp.observe(ip, fmt.Sprintf("http server=%q => %s", server, guess), guess, confidence)
+}
+
+// classifyServerHeader maps Server header fragments to an OS.
+func classifyServerHeader(server string) (string, int) {
+	switch {
+	case strings.Contains(server, "Microsoft-IIS"):
+		return "Windows", 85
+	case strings.Contains(server, "Ubuntu"):
+		return "Linux (Ubuntu)", 85
+	case strings.Contains(server, "Debian"):
+		return "Linux (Debian)", 85
+	case strings.Contains(server, "CentOS") || strings.Contains(server, "Red Hat"):
+		return "Linux", 85
+	case strings.Contains(server, "FreeBSD"):
+		return "FreeBSD", 85
+	case strings.Contains(server, "Win64") || strings.Contains(server, "Win32"):
+		return "Windows", 80
+	}
+	return "", 0
+}
+
+// ntlmsspMagic starts every NTLMSSP message, whatever carries it (SMB
+// session setup, RDP, HTTP auth).
+var ntlmsspMagic = []byte("NTLMSSP\x00")
+
+// ProcessNTLM extracts the OS version a server discloses in an NTLM
+// challenge (message type 2). The version field pins Windows releases
+// more precisely than any stack heuristic.
+func (fp *OSFingerprinter) ProcessNTLM(ip string, payload []byte) {
+	i := bytes.Index(payload, ntlmsspMagic)
+	if i < 0 || len(payload)-i < 56 {
+		return
+	}
+	msg := payload[i:]
+	if binary.LittleEndian.Uint32(msg[8:12]) != 2 {
+		return
+	}
+	major, minor := msg[48], msg[49]
+	build := binary.LittleEndian.Uint16(msg[50:52])
+	name := windowsVersionName(major, minor)
+	if name == "" {
+		// Version disclosure is optional; the challenge itself still
+		// proves a Windows authentication stack.
+		fp.observe(ip, "ntlm challenge => Windows", "Windows", 70)
+		return
+	}
+	fp.observe(ip, fmt.Sprintf("ntlm challenge version=%d.%d build=%d => %s",
+		major, minor, build, name), name, 95)
+}
+
+// windowsVersionName maps an NTLM version pair to the marketing name.
+func windowsVersionName(major, minor uint8) string {
+	switch {
+	case major == 10:
+		return "Windows 10/11 or Server 2016+"
+	case major == 6 && minor == 3:
+		return "Windows 8.1/Server 2012 R2"
+	case major == 6 && minor == 2:
+		return "Windows 8/Server 2012"
+	case major == 6 && minor == 1:
+		return "Windows 7/Server 2008 R2"
+	case major == 6 && minor == 0:
+		return "Windows Vista/Server 2008"
+	case major == 5:
+		return "Windows XP/Server 2003"
+	}
+	return ""
+}
+
+// Guess returns the best OS guess for ip with a confidence percentage and
+// the raw signals that produced it.
+func (fp *OSFingerprinter) Guess(ip string) (string, int, []string) {
+	ev, ok := fp.hosts[ip]
+	if !ok {
+		return "", 0, nil
+	}
+	return ev.guess, ev.confidence, ev.Signals
+}
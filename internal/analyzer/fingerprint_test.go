@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// ntlmChallenge builds a minimal NTLMSSP type-2 message carrying the
+// given version.
+func ntlmChallenge(major, minor uint8, build uint16) []byte {
+	msg := make([]byte, 56)
+	copy(msg, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(msg[8:12], 2)
+	msg[48], msg[49] = major, minor
+	binary.LittleEndian.PutUint16(msg[50:52], build)
+	return msg
+}
+
+func TestProcessNTLMVersion(t *testing.T) {
+	fp := NewOSFingerprinter()
+	fp.ProcessNTLM("10.0.0.9", ntlmChallenge(6, 1, 7601))
+
+	os, confidence, signals := fp.Guess("10.0.0.9")
+	if os != "Windows 7/Server 2008 R2" || confidence != 95 {
+		t.Errorf("guess = %q/%d, want Windows 7/Server 2008 R2 at 95", os, confidence)
+	}
+	if len(signals) != 1 {
+		t.Errorf("signals = %v, want one", signals)
+	}
+}
+
+func TestProcessNTLMWithoutVersion(t *testing.T) {
+	fp := NewOSFingerprinter()
+	fp.ProcessNTLM("10.0.0.9", ntlmChallenge(0, 0, 0))
+
+	if os, confidence, _ := fp.Guess("10.0.0.9"); os != "Windows" || confidence != 70 {
+		t.Errorf("guess = %q/%d, want generic Windows at 70", os, confidence)
+	}
+}
+
+func TestProcessNTLMIgnoresNegotiate(t *testing.T) {
+	fp := NewOSFingerprinter()
+	msg := ntlmChallenge(10, 0, 19041)
+	binary.LittleEndian.PutUint32(msg[8:12], 1) // type 1: client negotiate
+	fp.ProcessNTLM("10.0.0.9", msg)
+
+	if os, _, _ := fp.Guess("10.0.0.9"); os != "" {
+		t.Errorf("negotiate message produced guess %q", os)
+	}
+}
+
+func TestProcessHTTPResponseServerHeader(t *testing.T) {
+	fp := NewOSFingerprinter()
+	fp.ProcessHTTPResponse("10.0.0.9",
+		[]byte("HTTP/1.1 200 OK\r\nServer: Apache/2.4.41 (Ubuntu)\r\n\r\n"))
+
+	if os, confidence, _ := fp.Guess("10.0.0.9"); os != "Linux (Ubuntu)" || confidence != 85 {
+		t.Errorf("guess = %q/%d, want Linux (Ubuntu) at 85", os, confidence)
+	}
+
+	// Requests and unrevealing headers add nothing.
+	fp.ProcessHTTPResponse("10.0.0.10", []byte("GET / HTTP/1.1\r\nHost: a\r\n\r\n"))
+	fp.ProcessHTTPResponse("10.0.0.10", []byte("HTTP/1.1 200 OK\r\nServer: nginx\r\n\r\n"))
+	if os, _, _ := fp.Guess("10.0.0.10"); os != "" {
+		t.Errorf("unrevealing traffic produced guess %q", os)
+	}
+}
+
+func TestProcessSYNACKFingerprintsServer(t *testing.T) {
+	fp := NewOSFingerprinter()
+	fp.ProcessSYNACK("10.0.0.9", 128, 8192, nil)
+
+	os, confidence, _ := fp.Guess("10.0.0.9")
+	if os != "Windows" || confidence == 0 {
+		t.Errorf("guess = %q/%d, want Windows", os, confidence)
+	}
+}
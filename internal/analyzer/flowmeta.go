@@ -0,0 +1,78 @@
+package analyzer
+
+// flowMetaTracker collects per-flow application attributes as a generic
+// key/value bag that rides along on the connection row. Protocol modules
+// attach whatever they can name about a flow — HTTP Host, TLS SNI, DNS
+// qname — via set, so a new protocol never needs a schema change to
+// surface its attributes.
+type flowMetaTracker struct {
+	attrs map[string]map[string]string // protocol|flow pair -> key -> value
+}
+
+func newFlowMetaTracker() *flowMetaTracker {
+	return &flowMetaTracker{attrs: make(map[string]map[string]string)}
+}
+
+// set records one attribute on a flow, in either direction. The first
+// value observed for a key wins: attributes describe what the flow is,
+// not every request inside it.
+func (m *flowMetaTracker) set(protocol, srcIP string, srcPort uint16, dstIP string, dstPort uint16, key, value string) {
+	if value == "" {
+		return
+	}
+	k := protocol + "|" + flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	bag := m.attrs[k]
+	if bag == nil {
+		bag = make(map[string]string)
+		m.attrs[k] = bag
+	}
+	if _, ok := bag[key]; !ok {
+		bag[key] = value
+	}
+}
+
+// processTCP extracts the attributes this tracker can read on its own:
+// the Host header of a plaintext HTTP request and the SNI of a TLS
+// ClientHello.
+func (m *flowMetaTracker) processTCP(srcIP string, srcPort uint16, dstIP string, dstPort uint16, payload []byte) {
+	if isHTTPRequest(payload) {
+		m.set("tcp", srcIP, srcPort, dstIP, dstPort, "http.host", httpHeader(payload, "Host"))
+		return
+	}
+	if sni := segmentSNI(payload); sni != "" {
+		m.set("tcp", srcIP, srcPort, dstIP, dstPort, "tls.sni", sni)
+	}
+}
+
+// forFlow returns the attribute bag for a session's flow key, or nil
+// when none of the modules attached anything.
+func (m *flowMetaTracker) forFlow(key flowKey) map[string]string {
+	return m.attrs[key.protocol+"|"+flowPairKey(key.srcIP, key.srcPort, key.dstIP, key.dstPort)]
+}
+
+// segmentSNI walks the TLS records in one TCP segment and returns the
+// server_name of the first ClientHello, or "".
+func segmentSNI(payload []byte) string {
+	for len(payload) >= 5 {
+		if payload[0] != tlsRecordHandshake || payload[1] != 3 || payload[2] > 4 {
+			return ""
+		}
+		recLen := int(payload[3])<<8 | int(payload[4])
+		if recLen == 0 || 5+recLen > len(payload) {
+			return ""
+		}
+		data := payload[5 : 5+recLen]
+		for len(data) >= 4 {
+			hsLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+			if 4+hsLen > len(data) {
+				break
+			}
+			if data[0] == tlsHandshakeClientHello {
+				return parseClientHelloSNI(data[4 : 4+hsLen])
+			}
+			data = data[4+hsLen:]
+		}
+		payload = payload[5+recLen:]
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import "testing"
+
+// clientHelloSegment builds one TLS record carrying a minimal ClientHello
+// with the given server_name.
+func clientHelloSegment(sni string) []byte {
+	name := []byte(sni)
+	ext := []byte{0, 0} // extension type server_name
+	ext = append(ext, byte((len(name)+5)>>8), byte(len(name)+5))
+	ext = append(ext, byte((len(name)+3)>>8), byte(len(name)+3)) // list length
+	ext = append(ext, 0)                                         // name type host_name
+	ext = append(ext, byte(len(name)>>8), byte(len(name)))
+	ext = append(ext, name...)
+
+	body := []byte{3, 3}                     // client version
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0)                   // session id length
+	body = append(body, 0, 2, 0x13, 0x01)    // cipher suites
+	body = append(body, 1, 0)                // compression methods
+	body = append(body, byte(len(ext)>>8), byte(len(ext)))
+	body = append(body, ext...)
+
+	hs := []byte{tlsHandshakeClientHello, 0, byte(len(body) >> 8), byte(len(body))}
+	hs = append(hs, body...)
+
+	rec := []byte{tlsRecordHandshake, 3, 3, byte(len(hs) >> 8), byte(len(hs))}
+	return append(rec, hs...)
+}
+
+func TestFlowMetaTrackerExtractsHTTPHost(t *testing.T) {
+	m := newFlowMetaTracker()
+	m.processTCP("10.0.0.1", 49152, "10.0.0.2", 80,
+		[]byte("GET /index.html HTTP/1.1\r\nHost: intranet.example.com\r\n\r\n"))
+
+	key := flowKey{"tcp", "10.0.0.1", 49152, "10.0.0.2", 80}
+	got := m.forFlow(key)
+	if got["http.host"] != "intranet.example.com" {
+		t.Fatalf("http.host = %q, want intranet.example.com", got["http.host"])
+	}
+	// The reverse direction resolves to the same bag.
+	if rev := m.forFlow(key.reversed()); rev["http.host"] != "intranet.example.com" {
+		t.Errorf("reversed lookup missed the bag: %v", rev)
+	}
+}
+
+func TestFlowMetaTrackerExtractsSNI(t *testing.T) {
+	m := newFlowMetaTracker()
+	m.processTCP("10.0.0.1", 49152, "10.0.0.2", 443, clientHelloSegment("vault.example.com"))
+
+	got := m.forFlow(flowKey{"tcp", "10.0.0.1", 49152, "10.0.0.2", 443})
+	if got["tls.sni"] != "vault.example.com" {
+		t.Fatalf("tls.sni = %q, want vault.example.com", got["tls.sni"])
+	}
+}
+
+func TestFlowMetaTrackerFirstValueWins(t *testing.T) {
+	m := newFlowMetaTracker()
+	m.set("udp", "10.0.0.1", 40000, "10.0.0.53", 53, "dns.qname", "first.example.com")
+	m.set("udp", "10.0.0.1", 40000, "10.0.0.53", 53, "dns.qname", "second.example.com")
+	m.set("udp", "10.0.0.1", 40000, "10.0.0.53", 53, "dns.qname", "")
+
+	got := m.forFlow(flowKey{"udp", "10.0.0.1", 40000, "10.0.0.53", 53})
+	if got["dns.qname"] != "first.example.com" {
+		t.Fatalf("dns.qname = %q, want first.example.com", got["dns.qname"])
+	}
+	// A TCP flow on the same ports keeps its own bag.
+	if tcp := m.forFlow(flowKey{"tcp", "10.0.0.1", 40000, "10.0.0.53", 53}); tcp != nil {
+		t.Errorf("tcp flow unexpectedly has metadata: %v", tcp)
+	}
+}
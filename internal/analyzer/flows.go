@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// tcpIdleTimeout is the gap after which a silent TCP 5-tuple is treated
+// as a new logical session rather than a continuation of the old one.
+const tcpIdleTimeout = 5 * time.Minute
+
+// defaultUDPIdleTimeout splits UDP 5-tuples that go quiet. Without it a
+// DNS burst at 09:00 and another at 17:00 would be reported as one giant
+// eight-hour "connection". Override with UDP_IDLE_TIMEOUT (seconds).
+const defaultUDPIdleTimeout = 60 * time.Second
+
+// udpIdleTimeout returns the configured UDP idle split threshold.
+func udpIdleTimeout() time.Duration {
+	if v := os.Getenv("UDP_IDLE_TIMEOUT"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultUDPIdleTimeout
+}
+
+// tcpFlags carries the TCP flag bits the flow table cares about, plus the
+// sequence number and payload length used to recognize retransmissions.
+type tcpFlags struct {
+	syn, ack, fin, rst bool
+	seq                uint32
+	payloadLen         int
+}
+
+// flowRecord pairs a finished (or still-open) session with its key.
+type flowRecord struct {
+	key  flowKey
+	stat *flowStat
+}
+
+// flowTable tracks live sessions per 5-tuple and splits reused tuples into
+// separate sessions. Without this, a port reused hours after a FIN would
+// merge two distinct sessions into one connection row.
+type flowTable struct {
+	active  map[flowKey]*flowStat
+	closed  []flowRecord
+	udpIdle time.Duration
+
+	// spill holds sessions evicted to disk once the in-memory count
+	// passes spillThreshold; nil until first needed.
+	spill          *flowSpill
+	spillThreshold int
+
+	// onClose, when set, is invoked as sessions end (and from finish for
+	// sessions still open at EOF). Plugins hook in here.
+	onClose func(flowKey, *flowStat)
+}
+
+func newFlowTable() *flowTable {
+	return &flowTable{
+		active:         make(map[flowKey]*flowStat),
+		udpIdle:        udpIdleTimeout(),
+		spillThreshold: flowSpillThreshold(),
+	}
+}
+
+// record accounts one packet. flags is nil for non-TCP traffic.
+func (ft *flowTable) record(key flowKey, ts time.Time, size int64, flags *tcpFlags) {
+	stat, matchKey, reversed := ft.lookup(key)
+
+	if stat != nil && ft.shouldSplit(key.protocol, stat, ts, flags) {
+		ft.close(matchKey)
+		stat = nil
+	}
+
+	if stat == nil {
+		stat = &flowStat{firstSeen: ts}
+		ft.active[key] = stat
+		reversed = false
+	}
+
+	// Retransmitted segments are kept out of the byte and packet totals —
+	// lossy links would otherwise inflate them — and accounted separately.
+	if flags != nil && flags.payloadLen > 0 && stat.noteSeq(reversed, flags.seq, flags.payloadLen) {
+		stat.retransPackets++
+		stat.retransBytes += size
+	} else if reversed {
+		stat.packetsRecv++
+		stat.bytesRecv += size
+	} else {
+		stat.packetsSent++
+		stat.bytesSent += size
+	}
+	stat.lastSeen = ts
+	if flags != nil && (flags.fin || flags.rst) {
+		stat.sawClose = true
+	}
+	ft.maybeSpill()
+}
+
+// noteSeq advances the expected sequence number for one direction and
+// reports whether the segment ends at or behind it, i.e. carries only
+// bytes already counted. Serial comparison tolerates 32-bit wraparound;
+// a partial overlap that extends past the expectation counts as new data.
+func (s *flowStat) noteSeq(reversed bool, seq uint32, payloadLen int) bool {
+	next, seen := &s.nextSeqSent, &s.seqSeenSent
+	if reversed {
+		next, seen = &s.nextSeqRecv, &s.seqSeenRecv
+	}
+	end := seq + uint32(payloadLen)
+	if *seen && int32(end-*next) <= 0 {
+		return true
+	}
+	*seen, *next = true, end
+	return false
+}
+
+// lookup finds the live session for key in either direction.
+func (ft *flowTable) lookup(key flowKey) (*flowStat, flowKey, bool) {
+	if stat, ok := ft.active[key]; ok {
+		return stat, key, false
+	}
+	rev := key.reversed()
+	if stat, ok := ft.active[rev]; ok {
+		return stat, rev, true
+	}
+	return nil, key, false
+}
+
+// shouldSplit decides whether the packet at ts starts a new logical
+// session on a tuple that already has one.
+func (ft *flowTable) shouldSplit(protocol string, stat *flowStat, ts time.Time, flags *tcpFlags) bool {
+	if protocol == "udp" {
+		return ts.Sub(stat.lastSeen) > ft.udpIdle
+	}
+	if flags == nil {
+		return false
+	}
+	// A fresh SYN after the previous session saw FIN/RST means the port
+	// was reused.
+	if flags.syn && !flags.ack && stat.sawClose {
+		return true
+	}
+	return ts.Sub(stat.lastSeen) > tcpIdleTimeout
+}
+
+// close moves a session from the active table into the closed list.
+func (ft *flowTable) close(key flowKey) {
+	if stat, ok := ft.active[key]; ok {
+		ft.closed = append(ft.closed, flowRecord{key: key, stat: stat})
+		delete(ft.active, key)
+		if ft.onClose != nil {
+			ft.onClose(key, stat)
+		}
+	}
+}
+
+// finish invokes the close callback for sessions still open at EOF.
+func (ft *flowTable) finish() {
+	if ft.onClose == nil {
+		return
+	}
+	for key, stat := range ft.active {
+		ft.onClose(key, stat)
+	}
+}
+
+// all returns every session seen: spilled, closed and still open.
+func (ft *flowTable) all() []flowRecord {
+	out := make([]flowRecord, 0, len(ft.closed)+len(ft.active))
+	if ft.spill != nil {
+		spilled, err := ft.spill.all()
+		if err != nil {
+			slog.Error("reading spilled flows", "err", err)
+		}
+		out = append(out, spilled...)
+	}
+	out = append(out, ft.closed...)
+	for key, stat := range ft.active {
+		out = append(out, flowRecord{key: key, stat: stat})
+	}
+	return out
+}
+
+// release deletes the on-disk spill store, if one was created.
+func (ft *flowTable) release() {
+	if ft.spill != nil {
+		ft.spill.release()
+		ft.spill = nil
+	}
+}
@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+var testKey = flowKey{"tcp", "10.0.0.1", 51000, "10.0.0.2", 80}
+
+func TestFlowTableSplitsOnSYNAfterClose(t *testing.T) {
+	ft := newFlowTable()
+	base := time.Now()
+
+	ft.record(testKey, base, 100, &tcpFlags{syn: true})
+	ft.record(testKey, base.Add(time.Second), 100, &tcpFlags{fin: true, ack: true})
+	// Port reused for a brand new session.
+	ft.record(testKey, base.Add(2*time.Second), 100, &tcpFlags{syn: true})
+
+	if got := len(ft.all()); got != 2 {
+		t.Fatalf("sessions = %d, want 2", got)
+	}
+}
+
+func TestFlowTableSplitsOnIdleGap(t *testing.T) {
+	ft := newFlowTable()
+	base := time.Now()
+
+	ft.record(testKey, base, 100, &tcpFlags{syn: true})
+	ft.record(testKey, base.Add(tcpIdleTimeout+time.Second), 100, &tcpFlags{ack: true})
+
+	if got := len(ft.all()); got != 2 {
+		t.Fatalf("sessions = %d, want 2", got)
+	}
+}
+
+func TestFlowTableSplitsIdleUDP(t *testing.T) {
+	ft := newFlowTable()
+	base := time.Now()
+	key := flowKey{"udp", "10.0.0.1", 40000, "10.0.0.53", 53}
+
+	ft.record(key, base, 80, nil)
+	ft.record(key, base.Add(ft.udpIdle+time.Second), 80, nil)
+	// Within the idle window of the second session.
+	ft.record(key, base.Add(ft.udpIdle+2*time.Second), 80, nil)
+
+	if got := len(ft.all()); got != 2 {
+		t.Fatalf("sessions = %d, want 2", got)
+	}
+}
+
+func TestFlowTableMergesBidirectional(t *testing.T) {
+	ft := newFlowTable()
+	base := time.Now()
+
+	ft.record(testKey, base, 100, &tcpFlags{syn: true})
+	ft.record(testKey.reversed(), base.Add(time.Millisecond), 60, &tcpFlags{syn: true, ack: true})
+	ft.record(testKey, base.Add(2*time.Millisecond), 40, &tcpFlags{ack: true})
+
+	all := ft.all()
+	if len(all) != 1 {
+		t.Fatalf("sessions = %d, want 1", len(all))
+	}
+	stat := all[0].stat
+	if stat.packetsSent != 2 || stat.packetsRecv != 1 {
+		t.Errorf("packets sent/recv = %d/%d, want 2/1", stat.packetsSent, stat.packetsRecv)
+	}
+	if stat.bytesSent != 140 || stat.bytesRecv != 60 {
+		t.Errorf("bytes sent/recv = %d/%d, want 140/60", stat.bytesSent, stat.bytesRecv)
+	}
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"database/sql"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// defaultFlowSpillThreshold is the number of sessions the flow table keeps
+// in memory before spilling the least-recently-active ones to a temporary
+// on-disk store. Captures with tens of millions of flows stay within a
+// bounded heap; everything is merged back at finalize. Override with
+// FLOW_SPILL_THRESHOLD (0 disables spilling).
+const defaultFlowSpillThreshold = 500000
+
+// flowSpillThreshold returns the configured spill threshold.
+func flowSpillThreshold() int {
+	if v := os.Getenv("FLOW_SPILL_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultFlowSpillThreshold
+}
+
+// flowSpill is a temporary SQLite store for finished sessions. It lives in
+// the system temp directory for the duration of one analysis; durability
+// settings are off since the file is discarded either way.
+type flowSpill struct {
+	path string
+	conn *sql.DB
+}
+
+func newFlowSpill() (*flowSpill, error) {
+	f, err := os.CreateTemp("", "flowspill-*.db")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+
+	conn, err := sql.Open("sqlite3", path+"?_journal_mode=OFF&_synchronous=OFF")
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	if _, err := conn.Exec(`CREATE TABLE flows (
+		protocol TEXT, src_ip TEXT, src_port INTEGER, dst_ip TEXT, dst_port INTEGER,
+		packets_sent INTEGER, packets_recv INTEGER, bytes_sent INTEGER, bytes_recv INTEGER,
+		retrans_packets INTEGER, retrans_bytes INTEGER,
+		first_seen INTEGER, last_seen INTEGER, saw_close INTEGER
+	)`); err != nil {
+		conn.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return &flowSpill{path: path, conn: conn}, nil
+}
+
+// add appends finished sessions to the store.
+func (sp *flowSpill) add(recs []flowRecord) error {
+	tx, err := sp.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO flows VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rec := range recs {
+		k, s := rec.key, rec.stat
+		sawClose := 0
+		if s.sawClose {
+			sawClose = 1
+		}
+		if _, err := stmt.Exec(k.protocol, k.srcIP, k.srcPort, k.dstIP, k.dstPort,
+			s.packetsSent, s.packetsRecv, s.bytesSent, s.bytesRecv,
+			s.retransPackets, s.retransBytes,
+			s.firstSeen.UnixNano(), s.lastSeen.UnixNano(), sawClose); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// all reads every spilled session back.
+func (sp *flowSpill) all() ([]flowRecord, error) {
+	rows, err := sp.conn.Query(`SELECT protocol, src_ip, src_port, dst_ip, dst_port,
+		packets_sent, packets_recv, bytes_sent, bytes_recv, retrans_packets, retrans_bytes,
+		first_seen, last_seen, saw_close
+		FROM flows`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []flowRecord
+	for rows.Next() {
+		var k flowKey
+		var s flowStat
+		var first, last int64
+		var sawClose int
+		if err := rows.Scan(&k.protocol, &k.srcIP, &k.srcPort, &k.dstIP, &k.dstPort,
+			&s.packetsSent, &s.packetsRecv, &s.bytesSent, &s.bytesRecv,
+			&s.retransPackets, &s.retransBytes,
+			&first, &last, &sawClose); err != nil {
+			return nil, err
+		}
+		s.firstSeen = time.Unix(0, first)
+		s.lastSeen = time.Unix(0, last)
+		s.sawClose = sawClose == 1
+		out = append(out, flowRecord{key: k, stat: &s})
+	}
+	return out, rows.Err()
+}
+
+// release closes and deletes the store.
+func (sp *flowSpill) release() {
+	sp.conn.Close()
+	os.Remove(sp.path)
+}
+
+// maybeSpill bounds the flow table's memory. Once the threshold is
+// reached, finished sessions move to disk; if the active table alone
+// exceeds the budget, the least-recently-active quarter is force-closed
+// first. An evicted session that receives more packets later starts a new
+// logical session, the same outcome as an idle split.
+func (ft *flowTable) maybeSpill() {
+	if ft.spillThreshold <= 0 || len(ft.active)+len(ft.closed) < ft.spillThreshold {
+		return
+	}
+
+	if keep := ft.spillThreshold * 3 / 4; len(ft.active) > keep {
+		recs := make([]flowRecord, 0, len(ft.active))
+		for key, stat := range ft.active {
+			recs = append(recs, flowRecord{key: key, stat: stat})
+		}
+		sort.Slice(recs, func(i, j int) bool {
+			return recs[i].stat.lastSeen.Before(recs[j].stat.lastSeen)
+		})
+		for _, rec := range recs[:len(recs)-keep] {
+			ft.close(rec.key)
+		}
+	}
+
+	if ft.spill == nil {
+		sp, err := newFlowSpill()
+		if err != nil {
+			// No temp store available; keep everything in memory.
+			ft.spillThreshold = 0
+			return
+		}
+		ft.spill = sp
+	}
+	if err := ft.spill.add(ft.closed); err != nil {
+		ft.spillThreshold = 0
+		return
+	}
+	ft.closed = nil
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFlowTableSpillsAndMergesAtFinalize(t *testing.T) {
+	ft := newFlowTable()
+	ft.spillThreshold = 8
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		key := flowKey{"udp", fmt.Sprintf("10.0.0.%d", i), 40000, "10.0.1.1", 53}
+		ft.record(key, base.Add(time.Duration(i)*time.Second), 100, nil)
+		ft.record(key, base.Add(time.Duration(i)*time.Second+time.Millisecond), 100, nil)
+	}
+	defer ft.release()
+
+	if n := len(ft.active) + len(ft.closed); n >= 20 {
+		t.Errorf("in-memory sessions = %d, want fewer than the 20 recorded", n)
+	}
+	if ft.spill == nil {
+		t.Fatal("no spill store was created")
+	}
+
+	recs := ft.all()
+	if len(recs) != 20 {
+		t.Fatalf("all() = %d sessions, want 20", len(recs))
+	}
+	seen := make(map[string]bool)
+	for _, rec := range recs {
+		seen[rec.key.srcIP] = true
+		if rec.stat.packetsSent != 2 || rec.stat.bytesSent != 200 {
+			t.Errorf("session %s: packets=%d bytes=%d, want 2/200",
+				rec.key.srcIP, rec.stat.packetsSent, rec.stat.bytesSent)
+		}
+	}
+	if len(seen) != 20 {
+		t.Errorf("distinct sources = %d, want 20", len(seen))
+	}
+}
+
+func TestFlowSpillPreservesStats(t *testing.T) {
+	sp, err := newFlowSpill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.release()
+
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	last := first.Add(42 * time.Second)
+	in := flowRecord{
+		key: flowKey{"tcp", "10.0.0.5", 49800, "10.0.1.1", 443},
+		stat: &flowStat{
+			packetsSent: 10, packetsRecv: 8, bytesSent: 1500, bytesRecv: 9000,
+			firstSeen: first, lastSeen: last, sawClose: true,
+		},
+	}
+	if err := sp.add([]flowRecord{in}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := sp.all()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("all() = %d records, want 1", len(out))
+	}
+	got := out[0]
+	if got.key != in.key {
+		t.Errorf("key = %+v, want %+v", got.key, in.key)
+	}
+	if got.stat.packetsSent != 10 || got.stat.packetsRecv != 8 ||
+		got.stat.bytesSent != 1500 || got.stat.bytesRecv != 9000 || !got.stat.sawClose {
+		t.Errorf("stat = %+v, want %+v", *got.stat, *in.stat)
+	}
+	if !got.stat.firstSeen.Equal(first) || !got.stat.lastSeen.Equal(last) {
+		t.Errorf("times = %v/%v, want %v/%v",
+			got.stat.firstSeen, got.stat.lastSeen, first, last)
+	}
+}
+
+func TestFlowSpillReleaseRemovesFile(t *testing.T) {
+	sp, err := newFlowSpill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := sp.path
+	sp.release()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("spill file %s still exists after release", path)
+	}
+}
+
+func TestFlowTableSpillDisabled(t *testing.T) {
+	ft := newFlowTable()
+	ft.spillThreshold = 0
+
+	for i := 0; i < 50; i++ {
+		key := flowKey{"udp", fmt.Sprintf("10.0.0.%d", i), 40000, "10.0.1.1", 53}
+		ft.record(key, time.Now(), 100, nil)
+	}
+	if ft.spill != nil {
+		t.Error("spill store created with spilling disabled")
+	}
+	if len(ft.all()) != 50 {
+		t.Errorf("all() = %d, want 50", len(ft.all()))
+	}
+}
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// GTP well-known UDP ports.
+const (
+	gtpCPort = 2123
+	gtpUPort = 2152
+)
+
+// mobileCore accumulates mobile-core (GTP) observations across a capture.
+type mobileCore struct {
+	gtpuPackets int64
+	gtpcPackets int64
+	imsis       map[string]bool
+	apns        map[string]bool
+}
+
+func newMobileCore() *mobileCore {
+	return &mobileCore{imsis: make(map[string]bool), apns: make(map[string]bool)}
+}
+
+// decapGTPU unwraps a GTP-U G-PDU and returns the subscriber packet inside
+// it, or nil when the payload is not a decodable user-plane tunnel.
+func decapGTPU(payload []byte) gopacket.Packet {
+	p := gopacket.NewPacket(payload, layers.LayerTypeGTPv1U, gopacket.NoCopy)
+	gtp, ok := p.Layer(layers.LayerTypeGTPv1U).(*layers.GTPv1U)
+	if !ok || gtp.MessageType != 255 { // 255 = G-PDU (encapsulated user data)
+		return nil
+	}
+	inner := gtp.LayerPayload()
+	if len(inner) == 0 {
+		return nil
+	}
+	switch inner[0] >> 4 {
+	case 4:
+		return gopacket.NewPacket(inner, layers.LayerTypeIPv4, gopacket.NoCopy)
+	case 6:
+		return gopacket.NewPacket(inner, layers.LayerTypeIPv6, gopacket.NoCopy)
+	}
+	return nil
+}
+
+// processGTPC skims a GTPv2-C message for IMSI and APN information
+// elements. Only the fixed parts of the header are interpreted; anything
+// unparseable is simply skipped.
+func (m *mobileCore) processGTPC(payload []byte) {
+	if len(payload) < 8 || payload[0]>>5 != 2 {
+		return
+	}
+	m.gtpcPackets++
+
+	// Header: flags, message type, length(2), [TEID(4)], sequence(3), spare.
+	offset := 8
+	if payload[0]&0x08 != 0 { // T flag: TEID present
+		offset = 12
+	}
+	for offset+4 <= len(payload) {
+		ieType := payload[offset]
+		ieLen := int(payload[offset+1])<<8 | int(payload[offset+2])
+		valStart := offset + 4
+		if valStart+ieLen > len(payload) {
+			return
+		}
+		val := payload[valStart : valStart+ieLen]
+		switch ieType {
+		case 1: // IMSI, TBCD encoded
+			if imsi := decodeTBCD(val); len(imsi) >= 6 {
+				m.imsis[imsi] = true
+			}
+		case 71: // APN, length-prefixed labels
+			if apn := decodeAPN(val); apn != "" {
+				m.apns[apn] = true
+			}
+		}
+		offset = valStart + ieLen
+	}
+}
+
+// decodeTBCD expands telephony BCD digits (nibble swapped, 0xf filler).
+func decodeTBCD(b []byte) string {
+	var sb strings.Builder
+	for _, octet := range b {
+		lo, hi := octet&0x0f, octet>>4
+		if lo > 9 {
+			break
+		}
+		sb.WriteByte('0' + lo)
+		if hi > 9 {
+			break
+		}
+		sb.WriteByte('0' + hi)
+	}
+	return sb.String()
+}
+
+// decodeAPN joins the DNS-style length-prefixed labels of an APN IE.
+func decodeAPN(b []byte) string {
+	var labels []string
+	for i := 0; i < len(b); {
+		l := int(b[i])
+		i++
+		if l == 0 || i+l > len(b) {
+			break
+		}
+		labels = append(labels, string(b[i:i+l]))
+		i += l
+	}
+	return strings.Join(labels, ".")
+}
+
+// maskIMSI keeps the MCC/MNC prefix and hides the subscriber part, so
+// findings stay anonymizable.
+func maskIMSI(imsi string) string {
+	if len(imsi) <= 6 {
+		return imsi
+	}
+	return imsi[:6] + strings.Repeat("*", len(imsi)-6)
+}
+
+// findings summarizes mobile-core activity as informational findings.
+func (m *mobileCore) findings() []models.Finding {
+	var out []models.Finding
+	if m.gtpuPackets > 0 {
+		out = append(out, models.Finding{
+			Severity:    "info",
+			Type:        "gtp-tunnel",
+			Title:       "GTP-U tunneled traffic decapsulated",
+			Description: fmt.Sprintf("%d GTP-U packets were unwrapped and analyzed as subscriber flows", m.gtpuPackets),
+			Timestamp:   time.Now(),
+		})
+	}
+	if m.gtpcPackets > 0 {
+		var subs []string
+		for imsi := range m.imsis {
+			subs = append(subs, maskIMSI(imsi))
+		}
+		sort.Strings(subs)
+		var apns []string
+		for apn := range m.apns {
+			apns = append(apns, apn)
+		}
+		sort.Strings(apns)
+
+		desc := fmt.Sprintf("%d GTP-C signaling packets observed", m.gtpcPackets)
+		if len(subs) > 0 {
+			desc += "; IMSIs: " + strings.Join(subs, ", ")
+		}
+		if len(apns) > 0 {
+			desc += "; APNs: " + strings.Join(apns, ", ")
+		}
+		out = append(out, models.Finding{
+			Severity:    "info",
+			Type:        "gtp-signaling",
+			Title:       "Mobile-core GTP-C signaling observed",
+			Description: desc,
+			Timestamp:   time.Now(),
+		})
+	}
+	return out
+}
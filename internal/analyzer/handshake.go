@@ -0,0 +1,165 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Handshake-failure thresholds: a few refused connections are everyday
+// noise; dozens from one source or against one destination are a scan or
+// an outage.
+const (
+	handshakeFailMin   = 20
+	handshakeFailRatio = 0.5
+)
+
+// hsState tracks the three-way handshake of one TCP flow.
+type hsState struct {
+	client, server string
+	serverPort     uint16
+	synAck         bool
+	refused        bool // RST answered the SYN
+	established    bool
+	first          time.Time
+}
+
+// handshakeTracker counts, per source and destination, SYNs that were
+// never answered, refused with RST, or left half-open.
+type handshakeTracker struct {
+	flows map[string]*hsState
+}
+
+func newHandshakeTracker() *handshakeTracker {
+	return &handshakeTracker{flows: make(map[string]*hsState)}
+}
+
+// processTCP advances the handshake state of one segment's flow.
+func (ht *handshakeTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, flags *tcpFlags, ts time.Time) {
+	if flags == nil {
+		return
+	}
+	key := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	s, ok := ht.flows[key]
+
+	if flags.syn && !flags.ack {
+		if !ok {
+			ht.flows[key] = &hsState{client: srcIP, server: dstIP, serverPort: dstPort, first: ts}
+		}
+		return
+	}
+	if !ok {
+		// Mid-stream traffic whose handshake predates the capture.
+		return
+	}
+	switch {
+	case flags.syn && flags.ack:
+		if srcIP == s.server {
+			s.synAck = true
+		}
+	case flags.rst:
+		if srcIP == s.server && !s.established {
+			s.refused = true
+		}
+	default:
+		// Any further client segment after the SYN-ACK completes the
+		// handshake.
+		if srcIP == s.client && s.synAck {
+			s.established = true
+		}
+	}
+}
+
+// hsCounts aggregates handshake outcomes for one host.
+type hsCounts struct {
+	attempts   int
+	unanswered int
+	refused    int
+	halfOpen   int
+	first      time.Time
+}
+
+// tally groups flow outcomes by the given key (client or server side).
+func (ht *handshakeTracker) tally(key func(*hsState) string) map[string]*hsCounts {
+	out := make(map[string]*hsCounts)
+	for _, s := range ht.flows {
+		k := key(s)
+		c, ok := out[k]
+		if !ok {
+			c = &hsCounts{first: s.first}
+			out[k] = c
+		}
+		if s.first.Before(c.first) {
+			c.first = s.first
+		}
+		c.attempts++
+		switch {
+		case s.refused:
+			c.refused++
+		case !s.synAck:
+			c.unanswered++
+		case !s.established:
+			c.halfOpen++
+		}
+	}
+	return out
+}
+
+// findings flags sources whose handshakes mostly fail (scan behavior) and
+// destinations refusing or ignoring many connections (outage or hardened
+// filtering).
+func (ht *handshakeTracker) findings() []models.Finding {
+	var out []models.Finding
+
+	bySrc := ht.tally(func(s *hsState) string { return s.client })
+	ips := make([]string, 0, len(bySrc))
+	for ip := range bySrc {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		c := bySrc[ip]
+		failed := c.unanswered + c.refused + c.halfOpen
+		if failed < handshakeFailMin || float64(failed)/float64(c.attempts) < handshakeFailRatio {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "handshake-failures",
+			Title:    fmt.Sprintf("Failed connection attempts from %s", ip),
+			Description: fmt.Sprintf(
+				"%s completed only %d of %d TCP handshakes (%d unanswered, %d refused, %d half-open). "+
+					"This pattern indicates scanning or a broken path.",
+				ip, c.attempts-failed, c.attempts, c.unanswered, c.refused, c.halfOpen),
+			SrcIP:     ip,
+			Timestamp: c.first,
+		})
+	}
+
+	byDst := ht.tally(func(s *hsState) string { return s.server })
+	ips = ips[:0]
+	for ip := range byDst {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		c := byDst[ip]
+		if c.refused < handshakeFailMin {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "low",
+			Type:     "connection-refusals",
+			Title:    fmt.Sprintf("%s refused %d connections", ip, c.refused),
+			Description: fmt.Sprintf(
+				"%s answered %d of %d connection attempts with RST. "+
+					"A service may be down or a host firewall is rejecting traffic.",
+				ip, c.refused, c.attempts),
+			DstIP:     ip,
+			Timestamp: c.first,
+		})
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandshakeTrackerFlagsFailingSource(t *testing.T) {
+	ht := newHandshakeTracker()
+	base := time.Now()
+
+	// 25 SYNs to distinct ports, none answered.
+	for i := 0; i < 25; i++ {
+		ht.processTCP("10.0.0.5", "10.0.0.9", uint16(40000+i), uint16(1000+i),
+			&tcpFlags{syn: true}, base)
+	}
+	// One healthy handshake from the same host.
+	ht.processTCP("10.0.0.5", "10.0.0.9", 50000, 443, &tcpFlags{syn: true}, base)
+	ht.processTCP("10.0.0.9", "10.0.0.5", 443, 50000, &tcpFlags{syn: true, ack: true}, base)
+	ht.processTCP("10.0.0.5", "10.0.0.9", 50000, 443, &tcpFlags{ack: true}, base)
+
+	var found bool
+	for _, f := range ht.findings() {
+		if f.Type == "handshake-failures" && f.SrcIP == "10.0.0.5" {
+			found = true
+			if !strings.Contains(f.Description, "25 unanswered") {
+				t.Errorf("description = %q, want 25 unanswered", f.Description)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected handshake-failures finding for 10.0.0.5")
+	}
+}
+
+func TestHandshakeTrackerCountsRefusals(t *testing.T) {
+	ht := newHandshakeTracker()
+	base := time.Now()
+
+	// 20 clients each refused by the same server with RST.
+	for i := 0; i < 20; i++ {
+		client := fmt.Sprintf("10.0.1.%d", i+1)
+		ht.processTCP(client, "10.0.0.80", 40000, 8080, &tcpFlags{syn: true}, base)
+		ht.processTCP("10.0.0.80", client, 8080, 40000, &tcpFlags{rst: true, ack: true}, base)
+	}
+
+	var found bool
+	for _, f := range ht.findings() {
+		if f.Type == "connection-refusals" && f.DstIP == "10.0.0.80" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected connection-refusals finding for 10.0.0.80")
+	}
+}
+
+func TestHandshakeTrackerIgnoresHealthyTraffic(t *testing.T) {
+	ht := newHandshakeTracker()
+	base := time.Now()
+
+	for i := 0; i < 30; i++ {
+		port := uint16(40000 + i)
+		ht.processTCP("10.0.0.5", "10.0.0.9", port, 443, &tcpFlags{syn: true}, base)
+		ht.processTCP("10.0.0.9", "10.0.0.5", 443, port, &tcpFlags{syn: true, ack: true}, base)
+		ht.processTCP("10.0.0.5", "10.0.0.9", port, 443, &tcpFlags{ack: true}, base)
+	}
+	// Mid-stream segments with no recorded SYN must not count as attempts.
+	ht.processTCP("10.0.0.7", "10.0.0.9", 33000, 443, &tcpFlags{ack: true}, base)
+
+	if got := ht.findings(); len(got) != 0 {
+		t.Fatalf("findings = %d, want 0: %+v", len(got), got)
+	}
+}
@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// protoHierarchy accumulates a Wireshark-style protocol hierarchy: every
+// packet contributes its size to each level of its layer stack, so
+// "eth/ip/tcp/http" and all of its prefixes count the same HTTP packet.
+type protoHierarchy struct {
+	counts map[string]*protoCount // slash-joined path -> totals
+}
+
+type protoCount struct {
+	packets, bytes int64
+}
+
+func newProtoHierarchy() *protoHierarchy {
+	return &protoHierarchy{counts: make(map[string]*protoCount)}
+}
+
+// process walks one packet's decoded layers from the link layer up.
+func (ph *protoHierarchy) process(ctx *PacketContext) {
+	if ctx.Packet == nil {
+		return
+	}
+	var path, last string
+	add := func(token string) {
+		last = token
+		if path == "" {
+			path = token
+		} else {
+			path += "/" + token
+		}
+		c := ph.counts[path]
+		if c == nil {
+			c = &protoCount{}
+			ph.counts[path] = c
+		}
+		c.packets++
+		c.bytes += ctx.Size
+	}
+	for _, l := range ctx.Packet.Layers() {
+		lt := l.LayerType()
+		if lt == gopacket.LayerTypePayload || lt == gopacket.LayerTypeDecodeFailure {
+			continue
+		}
+		add(protoToken(lt))
+	}
+	// gopacket stops at the transport layer for protocols it has no
+	// decoder for; classify the payload so HTTP and TLS show up. Packets
+	// whose application layer decoded (DNS, DHCP, ...) are already done.
+	if len(ctx.Payload) > 0 && (last == "tcp" || last == "udp") {
+		add(payloadToken(ctx.Payload))
+	}
+}
+
+// protoToken maps a gopacket layer type onto the short name Wireshark
+// users expect.
+func protoToken(lt gopacket.LayerType) string {
+	switch lt {
+	case layers.LayerTypeEthernet:
+		return "eth"
+	case layers.LayerTypeIPv4:
+		return "ip"
+	case layers.LayerTypeIPv6:
+		return "ipv6"
+	case layers.LayerTypeLinuxSLL:
+		return "sll"
+	default:
+		return strings.ToLower(lt.String())
+	}
+}
+
+// payloadToken names the application protocol riding in a transport
+// payload, or "data" when it is nothing we recognize.
+func payloadToken(payload []byte) string {
+	if isHTTPRequest(payload) || bytes.HasPrefix(payload, []byte("HTTP/")) {
+		return "http"
+	}
+	if looksLikeTLS(payload) {
+		return "tls"
+	}
+	return "data"
+}
+
+// rows flattens the hierarchy for persistence, sorted by path so parents
+// precede their children.
+func (ph *protoHierarchy) rows() []models.ProtocolCount {
+	out := make([]models.ProtocolCount, 0, len(ph.counts))
+	for path, c := range ph.counts {
+		out = append(out, models.ProtocolCount{
+			Path:    path,
+			Packets: c.packets,
+			Bytes:   c.bytes,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// hierCtx decodes a serialized Ethernet frame into the PacketContext
+// shape the hierarchy tracker sees.
+func hierCtx(t *testing.T, size int64, ls ...gopacket.SerializableLayer) *PacketContext {
+	t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	pkt := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.NoCopy)
+	ctx := &PacketContext{Packet: pkt, Size: size}
+	if tcp, ok := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP); ok {
+		ctx.Protocol = "tcp"
+		ctx.Payload = tcp.Payload
+	}
+	if udp, ok := pkt.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+		ctx.Protocol = "udp"
+		ctx.Payload = udp.Payload
+	}
+	return ctx
+}
+
+func TestProtoHierarchyCountsEachLevel(t *testing.T) {
+	eth := &layers.Ethernet{
+		SrcMAC: net.HardwareAddr{2, 0, 0, 0, 0, 1}, DstMAC: net.HardwareAddr{2, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolTCP,
+		SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+	tcp := &layers.TCP{SrcPort: 49152, DstPort: 80, ACK: true, Window: 65535}
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	ph := newProtoHierarchy()
+	ph.process(hierCtx(t, 100, eth, ip, tcp,
+		gopacket.Payload("GET / HTTP/1.1\r\nHost: a\r\n\r\n")))
+	ph.process(hierCtx(t, 60, eth, ip, tcp))
+
+	got := make(map[string][2]int64)
+	for _, r := range ph.rows() {
+		got[r.Path] = [2]int64{r.Packets, r.Bytes}
+	}
+	for path, want := range map[string][2]int64{
+		"eth":             {2, 160},
+		"eth/ip":          {2, 160},
+		"eth/ip/tcp":      {2, 160},
+		"eth/ip/tcp/http": {1, 100},
+	} {
+		if got[path] != want {
+			t.Errorf("%s = %v, want %v", path, got[path], want)
+		}
+	}
+}
+
+func TestProtoHierarchyStopsAtDecodedAppLayer(t *testing.T) {
+	eth := &layers.Ethernet{
+		SrcMAC: net.HardwareAddr{2, 0, 0, 0, 0, 1}, DstMAC: net.HardwareAddr{2, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP,
+		SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.53")}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ip)
+	dns := &layers.DNS{ID: 7, OpCode: layers.DNSOpCodeQuery,
+		Questions: []layers.DNSQuestion{{Name: []byte("example.com"), Type: layers.DNSTypeA, Class: layers.DNSClassIN}}}
+
+	ph := newProtoHierarchy()
+	ph.process(hierCtx(t, 80, eth, ip, udp, dns))
+
+	paths := make(map[string]bool)
+	for _, r := range ph.rows() {
+		paths[r.Path] = true
+	}
+	if !paths["eth/ip/udp/dns"] {
+		t.Errorf("missing eth/ip/udp/dns in %v", paths)
+	}
+	if paths["eth/ip/udp/dns/data"] || paths["eth/ip/udp/data"] {
+		t.Errorf("decoded DNS packet also classified as data: %v", paths)
+	}
+}
@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// groupMembership is one host's relationship with one multicast group.
+type groupMembership struct {
+	joins, leaves       int64
+	joined              bool
+	firstSeen, lastSeen time.Time
+}
+
+// multicastTracker follows IGMP joins and leaves so the capture's
+// multicast group memberships (IPTV channels, industrial pub/sub) can be
+// reported per host.
+type multicastTracker struct {
+	hosts map[string]map[string]*groupMembership // host -> group -> state
+}
+
+func newMulticastTracker() *multicastTracker {
+	return &multicastTracker{hosts: make(map[string]map[string]*groupMembership)}
+}
+
+func (mt *multicastTracker) membership(host, group string) *groupMembership {
+	groups, ok := mt.hosts[host]
+	if !ok {
+		groups = make(map[string]*groupMembership)
+		mt.hosts[host] = groups
+	}
+	m, ok := groups[group]
+	if !ok {
+		m = &groupMembership{}
+		groups[group] = m
+	}
+	return m
+}
+
+func (mt *multicastTracker) record(host, group string, join bool, ts time.Time) {
+	if host == "" || group == "" || group == "0.0.0.0" {
+		return
+	}
+	m := mt.membership(host, group)
+	if m.firstSeen.IsZero() {
+		m.firstSeen = ts
+	}
+	m.lastSeen = ts
+	m.joined = join
+	if join {
+		m.joins++
+	} else {
+		m.leaves++
+	}
+}
+
+// processV1or2 handles classic IGMPv1/v2 messages. Queries come from
+// routers, not members, and are ignored.
+func (mt *multicastTracker) processV1or2(host string, msg *layers.IGMPv1or2, ts time.Time) {
+	switch msg.Type {
+	case layers.IGMPMembershipReportV1, layers.IGMPMembershipReportV2:
+		mt.record(host, msg.GroupAddress.String(), true, ts)
+	case layers.IGMPLeaveGroup:
+		mt.record(host, msg.GroupAddress.String(), false, ts)
+	}
+}
+
+// processV3 handles IGMPv3 membership reports, which carry one record per
+// group. EXCLUDE-mode records (exclude nothing) are joins; a switch to
+// INCLUDE with no sources is a leave.
+func (mt *multicastTracker) processV3(host string, msg *layers.IGMP, ts time.Time) {
+	if msg.Type != layers.IGMPMembershipReportV3 {
+		return
+	}
+	for _, rec := range msg.GroupRecords {
+		group := rec.MulticastAddress.String()
+		switch rec.Type {
+		case layers.IGMPIsEx, layers.IGMPToEx:
+			mt.record(host, group, true, ts)
+		case layers.IGMPToIn:
+			if rec.NumberOfSources == 0 {
+				mt.record(host, group, false, ts)
+			}
+		}
+	}
+}
+
+// rows renders the membership report, grouped by multicast address.
+func (mt *multicastTracker) rows() []models.MulticastMembership {
+	var out []models.MulticastMembership
+	for host, groups := range mt.hosts {
+		for group, m := range groups {
+			state := "left"
+			if m.joined {
+				state = "joined"
+			}
+			out = append(out, models.MulticastMembership{
+				HostIP:    host,
+				Group:     group,
+				Joins:     m.joins,
+				Leaves:    m.leaves,
+				State:     state,
+				FirstSeen: m.firstSeen,
+				LastSeen:  m.lastSeen,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Group != out[j].Group {
+			return out[i].Group < out[j].Group
+		}
+		return out[i].HostIP < out[j].HostIP
+	})
+	return out
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestMulticastTrackerJoinLeaveV2(t *testing.T) {
+	mt := newMulticastTracker()
+	base := time.Now()
+
+	mt.processV1or2("10.0.0.5", &layers.IGMPv1or2{
+		Type:         layers.IGMPMembershipReportV2,
+		GroupAddress: net.ParseIP("239.1.1.1"),
+	}, base)
+	mt.processV1or2("10.0.0.5", &layers.IGMPv1or2{
+		Type:         layers.IGMPLeaveGroup,
+		GroupAddress: net.ParseIP("239.1.1.1"),
+	}, base.Add(time.Minute))
+	// Queries come from the router and must not create memberships.
+	mt.processV1or2("10.0.0.1", &layers.IGMPv1or2{
+		Type:         layers.IGMPMembershipQuery,
+		GroupAddress: net.ParseIP("239.1.1.1"),
+	}, base)
+
+	rows := mt.rows()
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(rows))
+	}
+	r := rows[0]
+	if r.HostIP != "10.0.0.5" || r.Group != "239.1.1.1" {
+		t.Fatalf("row = %+v, want 10.0.0.5 in 239.1.1.1", r)
+	}
+	if r.Joins != 1 || r.Leaves != 1 || r.State != "left" {
+		t.Errorf("row = %+v, want joins=1 leaves=1 state=left", r)
+	}
+}
+
+func TestMulticastTrackerV3Records(t *testing.T) {
+	mt := newMulticastTracker()
+	base := time.Now()
+
+	mt.processV3("10.0.0.7", &layers.IGMP{
+		Type: layers.IGMPMembershipReportV3,
+		GroupRecords: []layers.IGMPv3GroupRecord{
+			{Type: layers.IGMPToEx, MulticastAddress: net.ParseIP("239.2.2.2")},
+			{Type: layers.IGMPToIn, MulticastAddress: net.ParseIP("239.3.3.3")},
+		},
+	}, base)
+
+	rows := mt.rows()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	if rows[0].Group != "239.2.2.2" || rows[0].State != "joined" {
+		t.Errorf("row = %+v, want 239.2.2.2 joined", rows[0])
+	}
+	if rows[1].Group != "239.3.3.3" || rows[1].State != "left" {
+		t.Errorf("row = %+v, want 239.3.3.3 left", rows[1])
+	}
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"net"
+
+	"github.com/google/gopacket/layers"
+)
+
+// gatewayExternalIPs is how many distinct public addresses one MAC must
+// front before it is considered a default gateway. Inside a LAN every
+// packet to the internet carries the router's MAC, so the gateway MAC
+// accumulates remote IPs far faster than any host.
+const gatewayExternalIPs = 10
+
+// infraTracker identifies the network infrastructure in a capture — the
+// default gateway(s), DNS servers and DHCP servers — so their assets can
+// be marked and the topology read at a glance.
+type infraTracker struct {
+	macExternal map[string]map[string]bool // local MAC -> distinct public IPs it fronted
+	dnsServers  map[string]bool
+	dhcpServers map[string]bool
+}
+
+func newInfraTracker() *infraTracker {
+	return &infraTracker{
+		macExternal: make(map[string]map[string]bool),
+		dnsServers:  make(map[string]bool),
+		dhcpServers: make(map[string]bool),
+	}
+}
+
+// process credits each MAC with the public IPs that appeared behind it,
+// in either direction.
+func (it *infraTracker) process(ctx *PacketContext) {
+	it.credit(ctx.SrcMAC, ctx.SrcIP)
+	it.credit(ctx.DstMAC, ctx.DstIP)
+}
+
+func (it *infraTracker) credit(mac, ip string) {
+	if mac == "" || ip == "" {
+		return
+	}
+	hw, err := net.ParseMAC(mac)
+	if err != nil || hw[0]&1 != 0 { // skip broadcast and multicast MACs
+		return
+	}
+	if ClassifyIP(net.ParseIP(ip)) != "public" {
+		return
+	}
+	ips := it.macExternal[mac]
+	if ips == nil {
+		ips = make(map[string]bool)
+		it.macExternal[mac] = ips
+	}
+	ips[ip] = true
+}
+
+// processDNS marks the server side of a DNS exchange.
+func (it *infraTracker) processDNS(serverIP string) {
+	if serverIP != "" {
+		it.dnsServers[serverIP] = true
+	}
+}
+
+// processDHCP marks hosts that answered DHCP requests.
+func (it *infraTracker) processDHCP(dhcp *layers.DHCPv4, srcIP string) {
+	if dhcp.Operation != layers.DHCPOpReply {
+		return
+	}
+	if srcIP != "" && srcIP != "0.0.0.0" {
+		it.dhcpServers[srcIP] = true
+	}
+}
+
+// deviceType labels an asset's infrastructure role, or "" for ordinary
+// hosts. The gateway label binds to the router's local address: remote
+// IPs share the gateway MAC but are not the gateway.
+func (it *infraTracker) deviceType(ip, mac string) string {
+	if len(it.macExternal[mac]) >= gatewayExternalIPs &&
+		ClassifyIP(net.ParseIP(ip)) != "public" {
+		return "gateway"
+	}
+	if it.dnsServers[ip] {
+		return "dns-server"
+	}
+	if it.dhcpServers[ip] {
+		return "dhcp-server"
+	}
+	return ""
+}
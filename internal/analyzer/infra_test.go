@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestInfraTrackerIdentifiesGateway(t *testing.T) {
+	it := newInfraTracker()
+	gwMAC := "02:00:00:00:00:01"
+	// The router's MAC fronts a dozen distinct internet hosts.
+	for i := 0; i < 12; i++ {
+		it.process(&PacketContext{
+			SrcMAC: "02:00:00:00:00:10", SrcIP: "192.168.1.50",
+			DstMAC: gwMAC, DstIP: fmt.Sprintf("93.184.216.%d", i),
+		})
+	}
+
+	if got := it.deviceType("192.168.1.1", gwMAC); got != "gateway" {
+		t.Errorf("router local IP = %q, want gateway", got)
+	}
+	// The remote IPs share the gateway MAC but are not the gateway.
+	if got := it.deviceType("93.184.216.1", gwMAC); got != "" {
+		t.Errorf("remote IP behind gateway MAC = %q, want empty", got)
+	}
+	if got := it.deviceType("192.168.1.50", "02:00:00:00:00:10"); got != "" {
+		t.Errorf("ordinary host = %q, want empty", got)
+	}
+}
+
+func TestInfraTrackerIgnoresBroadcastMACs(t *testing.T) {
+	it := newInfraTracker()
+	for i := 0; i < 12; i++ {
+		it.process(&PacketContext{
+			SrcMAC: "02:00:00:00:00:10", SrcIP: "192.168.1.50",
+			DstMAC: "ff:ff:ff:ff:ff:ff", DstIP: fmt.Sprintf("93.184.216.%d", i),
+		})
+	}
+	if got := it.deviceType("192.168.1.255", "ff:ff:ff:ff:ff:ff"); got != "" {
+		t.Errorf("broadcast MAC labeled %q, want empty", got)
+	}
+}
+
+func TestInfraTrackerLabelsServers(t *testing.T) {
+	it := newInfraTracker()
+	it.processDNS("192.168.1.53")
+	it.processDHCP(&layers.DHCPv4{Operation: layers.DHCPOpReply}, "192.168.1.1")
+	it.processDHCP(&layers.DHCPv4{Operation: layers.DHCPOpRequest}, "192.168.1.60")
+
+	if got := it.deviceType("192.168.1.53", "02:00:00:00:00:53"); got != "dns-server" {
+		t.Errorf("dns server = %q, want dns-server", got)
+	}
+	if got := it.deviceType("192.168.1.1", "02:00:00:00:00:01"); got != "dhcp-server" {
+		t.Errorf("dhcp server = %q, want dhcp-server", got)
+	}
+	if got := it.deviceType("192.168.1.60", "02:00:00:00:00:60"); got != "" {
+		t.Errorf("dhcp client = %q, want empty", got)
+	}
+}
@@ -0,0 +1,85 @@
+package analyzer
+
+import "net"
+
+// specialRanges is the special-purpose address registry used to label
+// targets. First match wins, so more specific ranges come first.
+var specialRanges = []struct {
+	cidr  string
+	class string
+}{
+	{"100.64.0.0/10", "cgnat"},
+	{"192.0.2.0/24", "documentation"},
+	{"198.51.100.0/24", "documentation"},
+	{"203.0.113.0/24", "documentation"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"192.88.99.0/24", "6to4"},
+	{"240.0.0.0/4", "reserved"},
+	{"2001:db8::/32", "documentation"},
+	{"2002::/16", "6to4"},
+	{"2001::/32", "teredo"},
+	{"64:ff9b::/96", "nat64"},
+	{"100::/64", "reserved"},
+}
+
+var specialNets = func() []struct {
+	net   *net.IPNet
+	class string
+} {
+	out := make([]struct {
+		net   *net.IPNet
+		class string
+	}, len(specialRanges))
+	for i, r := range specialRanges {
+		_, n, err := net.ParseCIDR(r.cidr)
+		if err != nil {
+			panic("analyzer: bad special range " + r.cidr)
+		}
+		out[i].net, out[i].class = n, r.class
+	}
+	return out
+}()
+
+// ClassifyIP labels an address against the special-purpose registry:
+// private, cgnat, multicast, loopback, linklocal, documentation,
+// benchmarking, 6to4, teredo, nat64, reserved, unspecified or public.
+// Anything matching no special range is public.
+func ClassifyIP(ip net.IP) string {
+	switch {
+	case ip == nil:
+		return ""
+	case ip.IsUnspecified():
+		return "unspecified"
+	case ip.IsLoopback():
+		return "loopback"
+	case ip.IsLinkLocalUnicast():
+		return "linklocal"
+	case ip.IsMulticast():
+		return "multicast"
+	case ip.IsPrivate():
+		return "private"
+	}
+	for _, r := range specialNets {
+		if r.net.Contains(ip) {
+			return r.class
+		}
+	}
+	return "public"
+}
+
+// isPublicIP reports whether ip is a publicly routable address, i.e. in
+// no special-purpose range at all.
+func isPublicIP(ip net.IP) bool {
+	return ClassifyIP(ip) == "public"
+}
+
+// isExternalIP reports whether ip lies outside the local network. Unlike
+// isPublicIP this includes CGNAT, NAT64 and other special ranges that
+// still arrive from beyond the broadcast domain.
+func isExternalIP(ip net.IP) bool {
+	switch ClassifyIP(ip) {
+	case "", "unspecified", "loopback", "linklocal", "private":
+		return false
+	}
+	return true
+}
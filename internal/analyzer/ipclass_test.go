@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"net"
+	"testing"
+)
+
+func TestClassifyIP(t *testing.T) {
+	cases := []struct {
+		ip    string
+		class string
+	}{
+		{"8.8.8.8", "public"},
+		{"10.1.2.3", "private"},
+		{"192.168.0.1", "private"},
+		{"100.64.0.1", "cgnat"},
+		{"100.127.255.255", "cgnat"},
+		{"100.128.0.1", "public"},
+		{"198.18.0.1", "benchmarking"},
+		{"203.0.113.9", "documentation"},
+		{"224.0.0.251", "multicast"},
+		{"255.255.255.255", "reserved"},
+		{"240.0.0.1", "reserved"},
+		{"127.0.0.1", "loopback"},
+		{"169.254.1.1", "linklocal"},
+		{"0.0.0.0", "unspecified"},
+		{"2606:4700::1111", "public"},
+		{"fd00::1", "private"},
+		{"fe80::1", "linklocal"},
+		{"2001:db8::1", "documentation"},
+		{"2002:c000:0204::1", "6to4"},
+		{"2001:0:53aa:64c:0:bfff:b90c:fafd", "teredo"},
+		{"64:ff9b::808:808", "nat64"},
+		{"ff02::1", "multicast"},
+	}
+	for _, tc := range cases {
+		if got := ClassifyIP(net.ParseIP(tc.ip)); got != tc.class {
+			t.Errorf("ClassifyIP(%s) = %q, want %q", tc.ip, got, tc.class)
+		}
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	if !isPublicIP(net.ParseIP("1.1.1.1")) {
+		t.Error("1.1.1.1 not public")
+	}
+	for _, ip := range []string{"100.64.0.1", "198.18.0.1", "10.0.0.1", ""} {
+		if isPublicIP(net.ParseIP(ip)) {
+			t.Errorf("%q treated as public", ip)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Legacy file-transfer protocol constants. TFTP and NFSv2/v3 remain common
+// in OT and legacy environments, where they often expose files with no
+// authentication at all.
+const (
+	tftpPort    = 69
+	nfsPort     = 2049
+	portmapPort = 111
+
+	tftpOpRead  = 1
+	tftpOpWrite = 2
+
+	rpcMsgCall   = 0
+	rpcVersion   = 2
+	rpcProgNFS   = 100003
+	rpcProgMount = 100005
+
+	// legacyFileMaxExamples caps how many filenames a finding lists.
+	legacyFileMaxExamples = 5
+)
+
+// tftpPeer tracks requests from one client to one TFTP server.
+type tftpPeer struct {
+	reads, writes int64
+	files         []string
+	firstSeen     time.Time
+}
+
+// nfsPeer tracks RPC activity from one client to one NFS server.
+type nfsPeer struct {
+	ops       map[string]int64
+	mounts    []string
+	authNull  bool
+	firstSeen time.Time
+}
+
+// legacyFileTracker watches TFTP requests and NFS/mount RPC calls so
+// unauthenticated file transfers show up as findings.
+type legacyFileTracker struct {
+	tftp map[string]*tftpPeer // "client>server"
+	nfs  map[string]*nfsPeer  // "client>server"
+}
+
+func newLegacyFileTracker() *legacyFileTracker {
+	return &legacyFileTracker{
+		tftp: make(map[string]*tftpPeer),
+		nfs:  make(map[string]*nfsPeer),
+	}
+}
+
+// processUDP inspects one UDP payload for TFTP requests or NFS RPC calls.
+func (lt *legacyFileTracker) processUDP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	if dstPort == tftpPort {
+		lt.processTFTP(srcIP, dstIP, payload, ts)
+		return
+	}
+	if dstPort == nfsPort || dstPort == portmapPort {
+		lt.processRPC(srcIP, dstIP, payload, ts)
+	}
+}
+
+// processTCP inspects one TCP segment for NFS RPC calls. TCP transports
+// prefix each RPC message with a four-byte record mark.
+func (lt *legacyFileTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	if dstPort != nfsPort && dstPort != portmapPort {
+		return
+	}
+	if len(payload) < 4 {
+		return
+	}
+	lt.processRPC(srcIP, dstIP, payload[4:], ts)
+}
+
+// processTFTP parses a read or write request: opcode, filename, NUL, mode.
+func (lt *legacyFileTracker) processTFTP(srcIP, dstIP string, payload []byte, ts time.Time) {
+	if len(payload) < 4 {
+		return
+	}
+	op := binary.BigEndian.Uint16(payload)
+	if op != tftpOpRead && op != tftpOpWrite {
+		return
+	}
+	end := 2
+	for end < len(payload) && payload[end] != 0 {
+		end++
+	}
+	filename := string(payload[2:end])
+	if filename == "" || !printableASCII(filename) {
+		return
+	}
+
+	key := srcIP + ">" + dstIP
+	p, ok := lt.tftp[key]
+	if !ok {
+		p = &tftpPeer{firstSeen: ts}
+		lt.tftp[key] = p
+	}
+	if op == tftpOpRead {
+		p.reads++
+	} else {
+		p.writes++
+	}
+	if len(p.files) < legacyFileMaxExamples && !containsString(p.files, filename) {
+		p.files = append(p.files, filename)
+	}
+}
+
+// processRPC parses an ONC RPC call header and records NFS and mount
+// procedures. Replies and fragments that do not start a call are skipped.
+func (lt *legacyFileTracker) processRPC(srcIP, dstIP string, b []byte, ts time.Time) {
+	// xid, msgtype, rpcvers, prog, vers, proc = 24 bytes minimum.
+	if len(b) < 24 {
+		return
+	}
+	if binary.BigEndian.Uint32(b[4:]) != rpcMsgCall ||
+		binary.BigEndian.Uint32(b[8:]) != rpcVersion {
+		return
+	}
+	prog := binary.BigEndian.Uint32(b[12:])
+	vers := binary.BigEndian.Uint32(b[16:])
+	proc := binary.BigEndian.Uint32(b[20:])
+	if prog != rpcProgNFS && prog != rpcProgMount {
+		return
+	}
+
+	key := srcIP + ">" + dstIP
+	p, ok := lt.nfs[key]
+	if !ok {
+		p = &nfsPeer{ops: make(map[string]int64), firstSeen: ts}
+		lt.nfs[key] = p
+	}
+
+	// Credential: flavor, length, opaque body. AUTH_NULL (0) means the
+	// client presented no identity at all; AUTH_UNIX (1) is client-asserted.
+	args := b[24:]
+	if len(args) >= 8 {
+		if binary.BigEndian.Uint32(args) == 0 {
+			p.authNull = true
+		}
+		credLen := binary.BigEndian.Uint32(args[4:])
+		if credLen <= uint32(len(args))-8 {
+			args = args[8+credLen:]
+		} else {
+			args = nil
+		}
+	}
+	// Verifier: flavor, length, opaque body.
+	if len(args) >= 8 {
+		verfLen := binary.BigEndian.Uint32(args[4:])
+		if verfLen <= uint32(len(args))-8 {
+			args = args[8+verfLen:]
+		} else {
+			args = nil
+		}
+	}
+
+	if prog == rpcProgMount {
+		p.ops["MNT"]++
+		// MNT carries the exported directory as an XDR string.
+		if proc == 1 && len(args) >= 4 {
+			n := binary.BigEndian.Uint32(args)
+			if n > 0 && n <= uint32(len(args))-4 {
+				dir := string(args[4 : 4+n])
+				if printableASCII(dir) && len(p.mounts) < legacyFileMaxExamples &&
+					!containsString(p.mounts, dir) {
+					p.mounts = append(p.mounts, dir)
+				}
+			}
+		}
+		return
+	}
+	p.ops[nfsProcName(vers, proc)]++
+}
+
+// nfsProcName names the common NFSv3 procedures; anything else is shown
+// with its version and number.
+func nfsProcName(vers, proc uint32) string {
+	if vers == 3 {
+		names := map[uint32]string{
+			0: "NULL", 1: "GETATTR", 3: "LOOKUP", 4: "ACCESS",
+			6: "READ", 7: "WRITE", 8: "CREATE", 9: "MKDIR",
+			12: "REMOVE", 13: "RMDIR", 14: "RENAME", 16: "READDIR",
+		}
+		if name, ok := names[proc]; ok {
+			return name
+		}
+	}
+	return fmt.Sprintf("v%d/%d", vers, proc)
+}
+
+// printableASCII reports whether s is entirely printable ASCII, which
+// filters out binary noise mistaken for a filename.
+func printableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// findings reports one finding per client/server pair that moved files
+// over a legacy protocol.
+func (lt *legacyFileTracker) findings() []models.Finding {
+	var out []models.Finding
+
+	keys := make([]string, 0, len(lt.tftp))
+	for k := range lt.tftp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := lt.tftp[k]
+		client, server, _ := strings.Cut(k, ">")
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "tftp-transfer",
+			Title:    fmt.Sprintf("TFTP file transfer between %s and %s", client, server),
+			Description: fmt.Sprintf(
+				"%s requested %d read(s) and %d write(s) over TFTP from %s (files: %s). "+
+					"TFTP is unauthenticated and cleartext, so these files are readable "+
+					"and writable by anyone on the network.",
+				client, p.reads, p.writes, server, strings.Join(p.files, ", ")),
+			SrcIP:     client,
+			DstIP:     server,
+			Timestamp: p.firstSeen,
+		})
+	}
+
+	keys = keys[:0]
+	for k := range lt.nfs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := lt.nfs[k]
+		client, server, _ := strings.Cut(k, ">")
+
+		ops := make([]string, 0, len(p.ops))
+		for op, n := range p.ops {
+			ops = append(ops, fmt.Sprintf("%s×%d", op, n))
+		}
+		sort.Strings(ops)
+
+		desc := fmt.Sprintf("%s issued NFS operations against %s (%s).",
+			client, server, strings.Join(ops, ", "))
+		if len(p.mounts) > 0 {
+			desc += fmt.Sprintf(" Mounted exports: %s.", strings.Join(p.mounts, ", "))
+		}
+		severity := "low"
+		if p.authNull {
+			severity = "medium"
+			desc += " Calls used AUTH_NULL credentials, so the export is effectively world-readable."
+		}
+		out = append(out, models.Finding{
+			Severity:    severity,
+			Type:        "nfs-activity",
+			Title:       fmt.Sprintf("NFS traffic between %s and %s", client, server),
+			Description: desc,
+			SrcIP:       client,
+			DstIP:       server,
+			Timestamp:   p.firstSeen,
+		})
+	}
+	return out
+}
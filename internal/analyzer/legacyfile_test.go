@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rpcCall builds an ONC RPC call header for prog/vers/proc with an
+// AUTH_NULL credential and verifier, followed by args.
+func rpcCall(prog, vers, proc uint32, args []byte) []byte {
+	b := make([]byte, 40, 40+len(args))
+	binary.BigEndian.PutUint32(b[0:], 0x1234)     // xid
+	binary.BigEndian.PutUint32(b[4:], rpcMsgCall) // msg type
+	binary.BigEndian.PutUint32(b[8:], rpcVersion) // rpc version
+	binary.BigEndian.PutUint32(b[12:], prog)
+	binary.BigEndian.PutUint32(b[16:], vers)
+	binary.BigEndian.PutUint32(b[20:], proc)
+	// AUTH_NULL credential and verifier: flavor 0, length 0 each.
+	return append(b, args...)
+}
+
+func TestTFTPRequestParsing(t *testing.T) {
+	lt := newLegacyFileTracker()
+	now := time.Now()
+
+	req := append([]byte{0, tftpOpRead}, []byte("router-config.bin\x00octet\x00")...)
+	lt.processUDP("10.0.0.5", "10.0.0.1", 40000, tftpPort, req, now)
+	wrq := append([]byte{0, tftpOpWrite}, []byte("backup.cfg\x00octet\x00")...)
+	lt.processUDP("10.0.0.5", "10.0.0.1", 40001, tftpPort, wrq, now)
+	// A data packet (opcode 3) must not count as a request.
+	lt.processUDP("10.0.0.5", "10.0.0.1", 40000, tftpPort, []byte{0, 3, 0, 1, 'x'}, now)
+
+	findings := lt.findings()
+	if len(findings) != 1 || findings[0].Type != "tftp-transfer" {
+		t.Fatalf("findings = %+v, want one tftp-transfer", findings)
+	}
+	if !strings.Contains(findings[0].Description, "router-config.bin") ||
+		!strings.Contains(findings[0].Description, "1 read(s) and 1 write(s)") {
+		t.Errorf("filenames or counts missing from %q", findings[0].Description)
+	}
+}
+
+func TestNFSMountParsing(t *testing.T) {
+	lt := newLegacyFileTracker()
+	now := time.Now()
+
+	// MNT call with the export path as an XDR string.
+	dir := []byte{0, 0, 0, 9}
+	dir = append(dir, []byte("/srv/data")...)
+	lt.processUDP("10.0.0.5", "10.0.0.1", 40000, portmapPort, rpcCall(rpcProgMount, 3, 1, dir), now)
+	// NFSv3 READ and WRITE over TCP, with the record mark prefix.
+	read := append([]byte{0x80, 0, 0, 0}, rpcCall(rpcProgNFS, 3, 6, nil)...)
+	lt.processTCP("10.0.0.5", "10.0.0.1", 40001, nfsPort, read, now)
+	write := append([]byte{0x80, 0, 0, 0}, rpcCall(rpcProgNFS, 3, 7, nil)...)
+	lt.processTCP("10.0.0.5", "10.0.0.1", 40001, nfsPort, write, now)
+
+	findings := lt.findings()
+	if len(findings) != 1 || findings[0].Type != "nfs-activity" {
+		t.Fatalf("findings = %+v, want one nfs-activity", findings)
+	}
+	f := findings[0]
+	if f.Severity != "medium" {
+		t.Errorf("severity = %s, want medium for AUTH_NULL", f.Severity)
+	}
+	for _, want := range []string{"/srv/data", "READ×1", "WRITE×1", "AUTH_NULL"} {
+		if !strings.Contains(f.Description, want) {
+			t.Errorf("description missing %q: %q", want, f.Description)
+		}
+	}
+}
+
+func TestRPCRepliesIgnored(t *testing.T) {
+	lt := newLegacyFileTracker()
+	reply := rpcCall(rpcProgNFS, 3, 6, nil)
+	binary.BigEndian.PutUint32(reply[4:], 1) // msg type REPLY
+	lt.processUDP("10.0.0.1", "10.0.0.5", 40000, nfsPort, reply, time.Now())
+	if len(lt.findings()) != 0 {
+		t.Error("RPC reply counted as a call")
+	}
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// resourceCheckEvery is how many packets pass between resource-limit
+// checks; reading memory stats per packet would dominate the loop.
+const resourceCheckEvery = 4096
+
+// analysisLimits enforces the per-job wall-clock and heap budgets from
+// ANALYSIS_TIMEOUT_SECONDS and ANALYSIS_MEM_SOFT_LIMIT_MB. When a budget
+// runs out the analysis stops where it is and keeps everything gathered so
+// far, instead of the whole process being OOM-killed or wedged on one
+// pathological capture.
+type analysisLimits struct {
+	wall      time.Duration
+	heapBytes uint64
+	started   time.Time
+}
+
+// limitsFromEnv returns nil when no limit is configured.
+func limitsFromEnv() *analysisLimits {
+	var l analysisLimits
+	if n := envLimit("ANALYSIS_TIMEOUT_SECONDS"); n > 0 {
+		l.wall = time.Duration(n) * time.Second
+	}
+	if n := envLimit("ANALYSIS_MEM_SOFT_LIMIT_MB"); n > 0 {
+		l.heapBytes = uint64(n) * 1024 * 1024
+	}
+	if l.wall == 0 && l.heapBytes == 0 {
+		return nil
+	}
+	l.started = time.Now()
+	return &l
+}
+
+// exceeded reports whether a budget ran out, with a human-readable reason.
+func (l *analysisLimits) exceeded() (string, bool) {
+	if l.wall > 0 {
+		if elapsed := time.Since(l.started); elapsed > l.wall {
+			return fmt.Sprintf("wall-clock limit of %s reached after %s",
+				l.wall, elapsed.Round(time.Second)), true
+		}
+	}
+	if l.heapBytes > 0 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		if ms.HeapAlloc > l.heapBytes {
+			return fmt.Sprintf("memory limit of %d MB exceeded (heap at %d MB)",
+				l.heapBytes/1024/1024, ms.HeapAlloc/1024/1024), true
+		}
+	}
+	return "", false
+}
+
+// envLimit reads a positive integer environment variable, 0 otherwise.
+func envLimit(key string) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestLinkTypeDecoder(t *testing.T) {
+	cases := []struct {
+		lt   layers.LinkType
+		want gopacket.Decoder
+	}{
+		{layers.LinkTypeEthernet, layers.LinkTypeEthernet},
+		{layers.LinkTypeLinuxSLL, layers.LinkTypeLinuxSLL},
+		{layers.LinkTypePPP, layers.LinkTypePPP},
+		{layers.LinkTypeRaw, layers.LinkTypeRaw},
+		{layers.LinkType(222), layers.LayerTypeEthernet}, // unknown DLT
+	}
+	for _, tc := range cases {
+		if got := linkTypeDecoder(tc.lt); got != tc.want {
+			t.Errorf("linkTypeDecoder(%v) = %v, want %v", tc.lt, got, tc.want)
+		}
+	}
+}
+
+func TestRawIPDecodes(t *testing.T) {
+	// A minimal IPv4/UDP datagram with no link layer, as a raw-IP DLT
+	// capture would carry it.
+	pkt := []byte{
+		0x45, 0x00, 0x00, 0x1d, 0x00, 0x00, 0x00, 0x00,
+		0x40, 0x11, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x01,
+		0x0a, 0x00, 0x00, 0x02,
+		0x30, 0x39, 0x00, 0x35, 0x00, 0x09, 0x00, 0x00, // UDP 12345 -> 53
+		0x00,
+	}
+	p := gopacket.NewPacket(pkt, linkTypeDecoder(layers.LinkTypeRaw), gopacket.NoCopy)
+	ip4, _ := p.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	if ip4 == nil || ip4.SrcIP.String() != "10.0.0.1" {
+		t.Fatalf("IPv4 layer not decoded: %v", p)
+	}
+	if udp, _ := p.Layer(layers.LayerTypeUDP).(*layers.UDP); udp == nil || udp.DstPort != 53 {
+		t.Fatalf("UDP layer not decoded: %v", p)
+	}
+}
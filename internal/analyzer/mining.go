@@ -0,0 +1,272 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Keepalive-pattern thresholds. A miner holds one long connection to its
+// pool and submits small share messages at a steady cadence; the cadence
+// test only applies to flows on conventional Stratum ports, since the
+// payload itself may be TLS-wrapped.
+const (
+	miningMinMessages  = 20
+	miningMinDuration  = 10 * time.Minute
+	miningMaxMsgSize   = 256
+	miningMinCadence   = 5.0   // seconds between messages
+	miningMaxCadence   = 120.0 //
+	miningPoolLookups  = 3
+	miningJitterFactor = 3.0 // p95 interval no worse than 3x the median
+)
+
+// stratumPorts are the ports mining pools conventionally listen on.
+var stratumPorts = map[uint16]bool{
+	3333: true, 3334: true, 3335: true, 4444: true, 5555: true,
+	7777: true, 8888: true, 9999: true, 14433: true, 14444: true,
+}
+
+// miningPoolDomains are well-known public mining pool domains, matched as
+// suffixes against DNS query names.
+var miningPoolDomains = []string{
+	"nanopool.org", "ethermine.org", "f2pool.com", "antpool.com",
+	"slushpool.com", "braiins.com", "minexmr.com", "supportxmr.com",
+	"hashvault.pro", "nicehash.com", "moneroocean.stream", "2miners.com",
+	"poolin.com", "viabtc.com", "dwarfpool.com", "c3pool.com",
+	"herominers.com", "miningpoolhub.com",
+}
+
+// miningSession is one suspected miner-to-pool connection.
+type miningSession struct {
+	srcIP   string // the mining host
+	dstIP   string // the pool
+	dstPort uint16
+	first   time.Time
+	last    time.Time
+	stratum bool // cleartext Stratum methods observed
+	submits int64
+	jobs    int64
+	// Cadence of small miner-to-pool messages, for the keepalive test.
+	msgs      int64
+	lastMsg   time.Time
+	intervals []float64 // seconds between consecutive messages
+}
+
+// poolLookup tracks one host's DNS interest in known mining pools.
+type poolLookup struct {
+	count   int64
+	example string
+	first   time.Time
+}
+
+// miningTracker spots cryptomining three ways: cleartext Stratum method
+// calls, DNS lookups of known pool domains, and the characteristic
+// steady small-message keepalive cadence on Stratum ports even when the
+// protocol itself is encrypted.
+type miningTracker struct {
+	sessions map[string]*miningSession
+	lookups  map[string]*poolLookup
+}
+
+func newMiningTracker() *miningTracker {
+	return &miningTracker{
+		sessions: make(map[string]*miningSession),
+		lookups:  make(map[string]*poolLookup),
+	}
+}
+
+// processTCP inspects one TCP packet in either direction.
+func (mt *miningTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	miner, pool, poolPort, fromMiner, stratum := classifyMining(srcIP, dstIP, srcPort, dstPort, payload)
+	if miner == "" {
+		return
+	}
+	key := fmt.Sprintf("%s|%s|%d", miner, pool, poolPort)
+	s, ok := mt.sessions[key]
+	if !ok {
+		s = &miningSession{srcIP: miner, dstIP: pool, dstPort: poolPort, first: ts}
+		mt.sessions[key] = s
+	}
+	s.last = ts
+	if stratum {
+		s.stratum = true
+	}
+	if fromMiner && stratumSubmit(payload) {
+		s.submits++
+	}
+	if !fromMiner && stratumJob(payload) {
+		s.jobs++
+	}
+	if fromMiner && len(payload) > 0 && len(payload) <= miningMaxMsgSize {
+		if !s.lastMsg.IsZero() {
+			s.intervals = append(s.intervals, ts.Sub(s.lastMsg).Seconds())
+		}
+		s.lastMsg = ts
+		s.msgs++
+	}
+}
+
+// processDNSQuery records lookups of known mining pool domains.
+func (mt *miningTracker) processDNSQuery(srcIP, name string, ts time.Time) {
+	if !isMiningPoolDomain(name) {
+		return
+	}
+	p, ok := mt.lookups[srcIP]
+	if !ok {
+		p = &poolLookup{example: name, first: ts}
+		mt.lookups[srcIP] = p
+	}
+	p.count++
+}
+
+// classifyMining maps a packet to a suspected mining session and decides
+// which endpoint is the miner. An empty miner means the packet is
+// neither Stratum nor on a Stratum port.
+func classifyMining(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte) (miner, pool string, poolPort uint16, fromMiner, stratum bool) {
+	switch {
+	case stratumFromMiner(payload):
+		return srcIP, dstIP, dstPort, true, true
+	case stratumFromPool(payload):
+		return dstIP, srcIP, srcPort, false, true
+	case stratumPorts[dstPort]:
+		return srcIP, dstIP, dstPort, true, false
+	case stratumPorts[srcPort]:
+		return dstIP, srcIP, srcPort, false, false
+	}
+	return "", "", 0, false, false
+}
+
+// stratumFromMiner matches the JSON-RPC methods only a miner sends:
+// classic Stratum mining.* calls and the login/submit pair of the
+// CryptoNote (xmrig-style) dialect.
+func stratumFromMiner(p []byte) bool {
+	return bytes.Contains(p, []byte(`"mining.subscribe"`)) ||
+		bytes.Contains(p, []byte(`"mining.authorize"`)) ||
+		bytes.Contains(p, []byte(`"mining.submit"`)) ||
+		bytes.Contains(p, []byte(`"mining.extranonce.subscribe"`)) ||
+		(bytes.Contains(p, []byte(`"method":"login"`)) && bytes.Contains(p, []byte(`"agent":`))) ||
+		(bytes.Contains(p, []byte(`"method":"submit"`)) && bytes.Contains(p, []byte(`"job_id":`)))
+}
+
+// stratumFromPool matches the methods only a pool sends: work
+// notifications and difficulty changes, or a CryptoNote job blob.
+func stratumFromPool(p []byte) bool {
+	return bytes.Contains(p, []byte(`"mining.notify"`)) ||
+		bytes.Contains(p, []byte(`"mining.set_difficulty"`)) ||
+		(bytes.Contains(p, []byte(`"blob":`)) && bytes.Contains(p, []byte(`"job_id":`)))
+}
+
+// stratumSubmit reports whether a miner payload carries a share.
+func stratumSubmit(p []byte) bool {
+	return bytes.Contains(p, []byte(`"mining.submit"`)) ||
+		(bytes.Contains(p, []byte(`"method":"submit"`)) && bytes.Contains(p, []byte(`"job_id":`)))
+}
+
+// stratumJob reports whether a pool payload carries new work.
+func stratumJob(p []byte) bool {
+	return bytes.Contains(p, []byte(`"mining.notify"`)) ||
+		(bytes.Contains(p, []byte(`"blob":`)) && bytes.Contains(p, []byte(`"job_id":`)))
+}
+
+// isMiningPoolDomain matches a DNS query name against the pool list;
+// pool.supportxmr.com matches supportxmr.com.
+func isMiningPoolDomain(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, d := range miningPoolDomains {
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepalivePattern reports whether the session's miner-side messages
+// show the steady small-message cadence of share submissions: enough
+// messages over enough time, at a regular interval in the plausible
+// share-rate band.
+func (s *miningSession) keepalivePattern() bool {
+	if s.msgs < miningMinMessages || s.last.Sub(s.first) < miningMinDuration {
+		return false
+	}
+	sorted := append([]float64(nil), s.intervals...)
+	sort.Float64s(sorted)
+	median := percentile(sorted, 0.5)
+	if median < miningMinCadence || median > miningMaxCadence {
+		return false
+	}
+	return percentile(sorted, 0.95) <= median*miningJitterFactor
+}
+
+// findings reports each host that appears to be mining, with the pool it
+// worked for and how long it was at it.
+func (mt *miningTracker) findings() []models.Finding {
+	var out []models.Finding
+
+	keys := make([]string, 0, len(mt.sessions))
+	for k := range mt.sessions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := mt.sessions[k]
+		duration := s.last.Sub(s.first).Round(time.Second)
+		switch {
+		case s.stratum:
+			desc := fmt.Sprintf(
+				"%s spoke the Stratum mining protocol with pool %s:%d for an estimated %s, submitting %d shares and receiving %d jobs. "+
+					"Stratum traffic means the host is actively mining cryptocurrency.",
+				s.srcIP, s.dstIP, s.dstPort, duration, s.submits, s.jobs)
+			out = append(out, models.Finding{
+				Severity:    "high",
+				Type:        "cryptomining",
+				Title:       fmt.Sprintf("Cryptomining by %s via pool %s", s.srcIP, s.dstIP),
+				Description: desc,
+				SrcIP:       s.srcIP,
+				DstIP:       s.dstIP,
+				Timestamp:   s.first,
+			})
+		case s.keepalivePattern():
+			desc := fmt.Sprintf(
+				"%s held a connection to %s:%d (a conventional mining pool port) for an estimated %s, sending %d small messages at a steady cadence. "+
+					"This matches the share-submission keepalive pattern of a miner on an encrypted Stratum channel.",
+				s.srcIP, s.dstIP, s.dstPort, duration, s.msgs)
+			out = append(out, models.Finding{
+				Severity:    "medium",
+				Type:        "cryptomining",
+				Title:       fmt.Sprintf("Suspected cryptomining by %s via %s", s.srcIP, s.dstIP),
+				Description: desc,
+				SrcIP:       s.srcIP,
+				DstIP:       s.dstIP,
+				Timestamp:   s.first,
+			})
+		}
+	}
+
+	ips := make([]string, 0, len(mt.lookups))
+	for ip := range mt.lookups {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		p := mt.lookups[ip]
+		if p.count < miningPoolLookups {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "cryptomining",
+			Title:    fmt.Sprintf("Mining pool lookups by %s", ip),
+			Description: fmt.Sprintf(
+				"%s resolved known mining pool domains %d times (e.g. %s). "+
+					"Repeated pool lookups indicate mining software choosing a server.",
+				ip, p.count, p.example),
+			SrcIP:     ip,
+			Timestamp: p.first,
+		})
+	}
+	return out
+}
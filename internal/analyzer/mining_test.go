@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiningTrackerStratumSession(t *testing.T) {
+	mt := newMiningTracker()
+	start := time.Now()
+
+	mt.processTCP("10.0.0.5", "203.0.113.10", 50000, 3333,
+		[]byte(`{"id":1,"method":"mining.subscribe","params":["cpuminer/2.5"]}`), start)
+	mt.processTCP("203.0.113.10", "10.0.0.5", 3333, 50000,
+		[]byte(`{"id":null,"method":"mining.notify","params":["job1"]}`), start.Add(time.Second))
+	for i := 0; i < 3; i++ {
+		mt.processTCP("10.0.0.5", "203.0.113.10", 50000, 3333,
+			[]byte(`{"id":4,"method":"mining.submit","params":["worker","job1","nonce"]}`),
+			start.Add(time.Duration(i+1)*time.Minute))
+	}
+
+	findings := mt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != "cryptomining" || f.Severity != "high" {
+		t.Errorf("finding = %s/%s, want cryptomining/high", f.Type, f.Severity)
+	}
+	if f.SrcIP != "10.0.0.5" || f.DstIP != "203.0.113.10" {
+		t.Errorf("endpoints = %s -> %s, want miner -> pool", f.SrcIP, f.DstIP)
+	}
+	if !strings.Contains(f.Description, "203.0.113.10:3333") {
+		t.Errorf("pool missing from %q", f.Description)
+	}
+	if !strings.Contains(f.Description, "3m0s") {
+		t.Errorf("duration missing from %q", f.Description)
+	}
+	if !strings.Contains(f.Description, "submitting 3 shares") {
+		t.Errorf("share count missing from %q", f.Description)
+	}
+}
+
+func TestMiningTrackerCryptoNoteDialect(t *testing.T) {
+	mt := newMiningTracker()
+	start := time.Now()
+
+	// xmrig-style login from the miner, job blob back from the pool.
+	mt.processTCP("10.0.0.8", "198.51.100.4", 41000, 18081,
+		[]byte(`{"method":"login","params":{"login":"wallet","agent":"XMRig/6.21"}}`), start)
+	mt.processTCP("198.51.100.4", "10.0.0.8", 18081, 41000,
+		[]byte(`{"result":{"job":{"blob":"0c0c","job_id":"j1","target":"b88d0600"}}}`), start.Add(time.Second))
+
+	findings := mt.findings()
+	if len(findings) != 1 || findings[0].Severity != "high" {
+		t.Fatalf("CryptoNote stratum should raise one high finding: %+v", findings)
+	}
+	if findings[0].SrcIP != "10.0.0.8" {
+		t.Errorf("miner = %s, want 10.0.0.8", findings[0].SrcIP)
+	}
+}
+
+func TestMiningTrackerKeepalivePattern(t *testing.T) {
+	mt := newMiningTracker()
+	start := time.Now()
+
+	// Thirty small messages every 30s to a Stratum port, with no
+	// readable payload markers — an encrypted miner.
+	for i := 0; i < 30; i++ {
+		mt.processTCP("10.0.0.9", "203.0.113.20", 51000, 4444,
+			[]byte{0x17, 0x03, 0x03, 0x00, 0x20}, start.Add(time.Duration(i)*30*time.Second))
+	}
+
+	findings := mt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != "cryptomining" || f.Severity != "medium" {
+		t.Errorf("finding = %s/%s, want cryptomining/medium", f.Type, f.Severity)
+	}
+	if !strings.Contains(f.Description, "steady cadence") {
+		t.Errorf("cadence missing from %q", f.Description)
+	}
+}
+
+func TestMiningTrackerKeepaliveNeedsRegularity(t *testing.T) {
+	mt := newMiningTracker()
+	start := time.Now()
+
+	// Same port and volume, but wildly irregular timing: ordinary
+	// bursty traffic that happens to use port 3333.
+	gaps := []time.Duration{time.Second, time.Second, 10 * time.Minute, time.Second, time.Second}
+	ts := start
+	for i := 0; i < 30; i++ {
+		ts = ts.Add(gaps[i%len(gaps)])
+		mt.processTCP("10.0.0.9", "203.0.113.20", 51000, 3333, []byte{0x01, 0x02}, ts)
+	}
+
+	if findings := mt.findings(); len(findings) != 0 {
+		t.Fatalf("irregular traffic should not be flagged: %+v", findings)
+	}
+}
+
+func TestMiningTrackerPoolLookups(t *testing.T) {
+	mt := newMiningTracker()
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		mt.processDNSQuery("10.0.0.7", "pool.supportxmr.com", start)
+	}
+	mt.processDNSQuery("10.0.0.7", "example.com", start)
+	mt.processDNSQuery("10.0.0.6", "nanopool.org", start) // below threshold
+
+	findings := mt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.SrcIP != "10.0.0.7" || f.Severity != "medium" {
+		t.Errorf("finding = %s/%s, want 10.0.0.7/medium", f.SrcIP, f.Severity)
+	}
+	if !strings.Contains(f.Description, "pool.supportxmr.com") {
+		t.Errorf("example domain missing from %q", f.Description)
+	}
+}
+
+func TestIsMiningPoolDomain(t *testing.T) {
+	cases := map[string]bool{
+		"supportxmr.com":       true,
+		"pool.supportxmr.com.": true,
+		"Eu1.Nanopool.ORG":     true,
+		"notnanopool.org.evil": false,
+		"example.com":          false,
+	}
+	for name, want := range cases {
+		if got := isMiningPoolDomain(name); got != want {
+			t.Errorf("isMiningPoolDomain(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package analyzer
+
+// AttackTechnique is one MITRE ATT&CK technique reference.
+type AttackTechnique struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Tactic string `json:"tactic"`
+}
+
+// attackMap ties finding types to the ATT&CK techniques they evidence.
+// Detection types without an entry are hygiene or informational findings
+// with no adversary-technique equivalent.
+var attackMap = map[string][]AttackTechnique{
+	"port-scan": {
+		{"T1046", "Network Service Discovery", "Discovery"},
+		{"T1595.001", "Active Scanning: Scanning IP Blocks", "Reconnaissance"},
+	},
+	"ip-mac-conflict": {
+		{"T1557.002", "Adversary-in-the-Middle: ARP Cache Poisoning", "Credential Access"},
+	},
+	"tls-interception": {
+		{"T1557", "Adversary-in-the-Middle", "Credential Access"},
+	},
+	"tls-issuer-anomaly": {
+		{"T1557", "Adversary-in-the-Middle", "Credential Access"},
+	},
+	"tls-fingerprint-variance": {
+		{"T1557", "Adversary-in-the-Middle", "Credential Access"},
+	},
+	"cleartext-sensitive-data": {
+		{"T1552.001", "Unsecured Credentials: Credentials In Files", "Credential Access"},
+		{"T1048.003", "Exfiltration Over Unencrypted Non-C2 Protocol", "Exfiltration"},
+	},
+	"brute-force": {
+		{"T1110", "Brute Force", "Credential Access"},
+	},
+	"beaconing": {
+		{"T1071", "Application Layer Protocol", "Command and Control"},
+	},
+	"dns-tunneling": {
+		{"T1071.004", "Application Layer Protocol: DNS", "Command and Control"},
+		{"T1048", "Exfiltration Over Alternative Protocol", "Exfiltration"},
+	},
+	"icmp-tunneling": {
+		{"T1095", "Non-Application Layer Protocol", "Command and Control"},
+	},
+	"remote-desktop": {
+		{"T1021.001", "Remote Services: Remote Desktop Protocol", "Lateral Movement"},
+	},
+	"cryptomining": {
+		{"T1496", "Resource Hijacking", "Impact"},
+	},
+}
+
+// TechniquesFor returns the ATT&CK techniques evidenced by a finding
+// type, or nil when the type has no mapping.
+func TechniquesFor(findingType string) []AttackTechnique {
+	return attackMap[findingType]
+}
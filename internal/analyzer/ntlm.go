@@ -0,0 +1,150 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// ntlmAuthTracker extracts the account fields NTLM authentication leaks
+// in cleartext — domain, username, workstation — from SMB session setups
+// and HTTP Authorization headers, building a who-logged-in-where map.
+// No credential material is touched; the NTLM responses stay opaque.
+type ntlmAuthTracker struct {
+	usage map[string]*accountUse
+}
+
+type accountUse struct {
+	domain, username, workstation string
+	srcIP, dstIP                  string
+	protocol                      string
+	count                         int64
+	firstSeen, lastSeen           time.Time
+}
+
+func newNTLMAuthTracker() *ntlmAuthTracker {
+	return &ntlmAuthTracker{usage: make(map[string]*accountUse)}
+}
+
+// processTCP inspects one client-to-server payload segment.
+func (nt *ntlmAuthTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	if dstPort == 445 || dstPort == 139 {
+		if domain, user, ws, ok := parseNTLMAuthenticate(payload); ok {
+			nt.record(srcIP, dstIP, "smb", domain, user, ws, ts)
+		}
+		return
+	}
+	if !isHTTPRequest(payload) {
+		return
+	}
+	auth := httpHeader(payload, "Authorization")
+	for _, scheme := range []string{"NTLM ", "Negotiate "} {
+		if !strings.HasPrefix(auth, scheme) {
+			continue
+		}
+		msg, err := base64.StdEncoding.DecodeString(auth[len(scheme):])
+		if err != nil {
+			return
+		}
+		if domain, user, ws, ok := parseNTLMAuthenticate(msg); ok {
+			nt.record(srcIP, dstIP, "http", domain, user, ws, ts)
+		}
+		return
+	}
+}
+
+func (nt *ntlmAuthTracker) record(srcIP, dstIP, protocol, domain, user, ws string, ts time.Time) {
+	key := strings.Join([]string{domain, user, srcIP, dstIP, protocol}, "|")
+	u, ok := nt.usage[key]
+	if !ok {
+		u = &accountUse{
+			domain: domain, username: user, workstation: ws,
+			srcIP: srcIP, dstIP: dstIP, protocol: protocol,
+			firstSeen: ts,
+		}
+		nt.usage[key] = u
+	}
+	u.count++
+	u.lastSeen = ts
+	if u.workstation == "" {
+		u.workstation = ws
+	}
+}
+
+// rows flattens the usage map, sorted by account then source.
+func (nt *ntlmAuthTracker) rows() []models.AccountUsage {
+	out := make([]models.AccountUsage, 0, len(nt.usage))
+	for _, u := range nt.usage {
+		out = append(out, models.AccountUsage{
+			Domain:      u.domain,
+			Username:    u.username,
+			Workstation: u.workstation,
+			SrcIP:       u.srcIP,
+			DstIP:       u.dstIP,
+			Protocol:    u.protocol,
+			Count:       u.count,
+			FirstSeen:   u.firstSeen,
+			LastSeen:    u.lastSeen,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Domain != out[j].Domain {
+			return out[i].Domain < out[j].Domain
+		}
+		if out[i].Username != out[j].Username {
+			return out[i].Username < out[j].Username
+		}
+		return out[i].SrcIP < out[j].SrcIP
+	})
+	return out
+}
+
+// parseNTLMAuthenticate pulls the domain, username and workstation out
+// of an NTLMSSP AUTHENTICATE (type 3) message anywhere in the payload.
+func parseNTLMAuthenticate(payload []byte) (domain, user, workstation string, ok bool) {
+	i := bytes.Index(payload, ntlmsspMagic)
+	if i < 0 {
+		return "", "", "", false
+	}
+	msg := payload[i:]
+	if len(msg) < 64 || binary.LittleEndian.Uint32(msg[8:12]) != 3 {
+		return "", "", "", false
+	}
+	unicode := binary.LittleEndian.Uint32(msg[60:64])&1 != 0
+	domain = ntlmField(msg, 28, unicode)
+	user = ntlmField(msg, 36, unicode)
+	workstation = ntlmField(msg, 44, unicode)
+	if user == "" {
+		// Anonymous sessions carry no account; nothing to map.
+		return "", "", "", false
+	}
+	return domain, user, workstation, true
+}
+
+// ntlmField reads one security buffer (length, max length, offset) from
+// an NTLM message.
+func ntlmField(msg []byte, off int, unicode bool) string {
+	length := int(binary.LittleEndian.Uint16(msg[off : off+2]))
+	start := int(binary.LittleEndian.Uint32(msg[off+4 : off+8]))
+	if length == 0 || start+length > len(msg) {
+		return ""
+	}
+	raw := msg[start : start+length]
+	if !unicode {
+		return string(raw)
+	}
+	if length%2 != 0 {
+		return ""
+	}
+	codes := make([]uint16, length/2)
+	for i := range codes {
+		codes[i] = binary.LittleEndian.Uint16(raw[2*i : 2*i+2])
+	}
+	return string(utf16.Decode(codes))
+}
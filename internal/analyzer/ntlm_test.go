@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// ntlmAuthenticate builds a minimal NTLMSSP type-3 message carrying the
+// given account fields as UTF-16LE security buffers.
+func ntlmAuthenticate(domain, user, workstation string) []byte {
+	payload := []byte{}
+	header := make([]byte, 64)
+	copy(header, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(header[8:12], 3)
+	binary.LittleEndian.PutUint32(header[60:64], 1) // unicode flag
+
+	put := func(off int, s string) {
+		raw := []byte{}
+		for _, c := range utf16.Encode([]rune(s)) {
+			raw = binary.LittleEndian.AppendUint16(raw, c)
+		}
+		binary.LittleEndian.PutUint16(header[off:off+2], uint16(len(raw)))
+		binary.LittleEndian.PutUint16(header[off+2:off+4], uint16(len(raw)))
+		binary.LittleEndian.PutUint32(header[off+4:off+8], uint32(64+len(payload)))
+		payload = append(payload, raw...)
+	}
+	put(28, domain)
+	put(36, user)
+	put(44, workstation)
+	return append(header, payload...)
+}
+
+func TestNTLMAuthTrackerSMB(t *testing.T) {
+	nt := newNTLMAuthTracker()
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	msg := append([]byte("\x00\x00\x00\x60smb-framing"), ntlmAuthenticate("CORP", "alice", "WS01")...)
+	nt.processTCP("10.0.0.5", "10.0.0.20", 49800, 445, msg, ts)
+	nt.processTCP("10.0.0.5", "10.0.0.20", 49801, 445, msg, ts.Add(time.Minute))
+
+	rows := nt.rows()
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(rows))
+	}
+	r := rows[0]
+	if r.Domain != "CORP" || r.Username != "alice" || r.Workstation != "WS01" {
+		t.Errorf("account = %s\\%s@%s, want CORP\\alice@WS01", r.Domain, r.Username, r.Workstation)
+	}
+	if r.SrcIP != "10.0.0.5" || r.DstIP != "10.0.0.20" || r.Protocol != "smb" {
+		t.Errorf("path = %s->%s/%s, want 10.0.0.5->10.0.0.20/smb", r.SrcIP, r.DstIP, r.Protocol)
+	}
+	if r.Count != 2 || !r.FirstSeen.Equal(ts) || !r.LastSeen.Equal(ts.Add(time.Minute)) {
+		t.Errorf("count/times = %d/%v/%v", r.Count, r.FirstSeen, r.LastSeen)
+	}
+}
+
+func TestNTLMAuthTrackerHTTP(t *testing.T) {
+	nt := newNTLMAuthTracker()
+	token := base64.StdEncoding.EncodeToString(ntlmAuthenticate("CORP", "bob", "WS02"))
+	req := []byte("GET /share HTTP/1.1\r\nHost: proxy\r\nAuthorization: NTLM " + token + "\r\n\r\n")
+	nt.processTCP("10.0.0.6", "10.0.0.30", 50000, 8080, req, time.Now())
+
+	rows := nt.rows()
+	if len(rows) != 1 || rows[0].Username != "bob" || rows[0].Protocol != "http" {
+		t.Fatalf("rows = %+v, want one http row for bob", rows)
+	}
+}
+
+func TestNTLMAuthTrackerIgnoresNonAuthenticate(t *testing.T) {
+	nt := newNTLMAuthTracker()
+	// Type-2 challenge from a server carries no account.
+	nt.processTCP("10.0.0.20", "10.0.0.5", 49800, 445, ntlmChallenge(10, 0, 19041), time.Now())
+	// Anonymous type 3: no username.
+	nt.processTCP("10.0.0.5", "10.0.0.20", 49800, 445, ntlmAuthenticate("", "", ""), time.Now())
+
+	if rows := nt.rows(); len(rows) != 0 {
+		t.Errorf("rows = %+v, want none", rows)
+	}
+}
+
+func TestParseNTLMAuthenticateOEM(t *testing.T) {
+	msg := make([]byte, 64, 80)
+	copy(msg, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(msg[8:12], 3)
+	// No unicode flag: fields are plain OEM strings.
+	put := func(off int, s string) {
+		binary.LittleEndian.PutUint16(msg[off:off+2], uint16(len(s)))
+		binary.LittleEndian.PutUint32(msg[off+4:off+8], uint32(len(msg)))
+		msg = append(msg, s...)
+	}
+	put(28, "CORP")
+	put(36, "carol")
+
+	domain, user, _, ok := parseNTLMAuthenticate(msg)
+	if !ok || domain != "CORP" || user != "carol" {
+		t.Errorf("parsed %q\\%q (ok=%v), want CORP\\carol", domain, user, ok)
+	}
+}
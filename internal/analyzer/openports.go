@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// openPortTracker builds a passive service inventory: ports a host proved
+// it listens on, either by answering a SYN with a SYN-ACK or by sending
+// payload from a well-known service port.
+type openPortTracker struct {
+	// ip|proto|port -> service name
+	open map[string]openPort
+}
+
+type openPort struct {
+	ip       string
+	protocol string
+	port     uint16
+}
+
+func newOpenPortTracker() *openPortTracker {
+	return &openPortTracker{open: make(map[string]openPort)}
+}
+
+func (t *openPortTracker) add(ip, protocol string, port uint16) {
+	key := fmt.Sprintf("%s|%s|%d", ip, protocol, port)
+	if _, ok := t.open[key]; !ok {
+		t.open[key] = openPort{ip: ip, protocol: protocol, port: port}
+	}
+}
+
+// processTCP records the source of a SYN-ACK as a listener, and treats
+// payload sent from a well-known port to an ephemeral one as a banner.
+func (t *openPortTracker) processTCP(srcIP string, srcPort, dstPort uint16, synAck bool, payloadLen int) {
+	switch {
+	case synAck:
+		t.add(srcIP, "tcp", srcPort)
+	case payloadLen > 0 && ServiceForPort("tcp", srcPort) != "" && dstPort >= 1024:
+		t.add(srcIP, "tcp", srcPort)
+	}
+}
+
+// processUDP records payload sent from a well-known UDP port as evidence
+// of a listening service.
+func (t *openPortTracker) processUDP(srcIP string, srcPort, dstPort uint16, payloadLen int) {
+	if payloadLen > 0 && ServiceForPort("udp", srcPort) != "" && dstPort >= 1024 {
+		t.add(srcIP, "udp", srcPort)
+	}
+}
+
+// rows returns the inventory sorted by host, protocol and port.
+func (t *openPortTracker) rows() []models.AssetService {
+	out := make([]models.AssetService, 0, len(t.open))
+	for _, p := range t.open {
+		out = append(out, models.AssetService{
+			IP:       p.ip,
+			Port:     int(p.port),
+			Protocol: p.protocol,
+			Service:  ServiceForPort(p.protocol, p.port),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].IP != out[j].IP {
+			return out[i].IP < out[j].IP
+		}
+		if out[i].Protocol != out[j].Protocol {
+			return out[i].Protocol < out[j].Protocol
+		}
+		return out[i].Port < out[j].Port
+	})
+	return out
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import "testing"
+
+func TestOpenPortTrackerSynAck(t *testing.T) {
+	pt := newOpenPortTracker()
+	// SYN-ACK from 10.0.0.2:22 proves a listener; repeats do not duplicate.
+	pt.processTCP("10.0.0.2", 22, 50123, true, 0)
+	pt.processTCP("10.0.0.2", 22, 50124, true, 0)
+	// A plain client SYN is not evidence.
+	pt.processTCP("10.0.0.1", 50123, 22, false, 0)
+
+	rows := pt.rows()
+	if len(rows) != 1 {
+		t.Fatalf("rows = %+v, want one listener", rows)
+	}
+	if rows[0].IP != "10.0.0.2" || rows[0].Port != 22 || rows[0].Service != "ssh" {
+		t.Errorf("row = %+v", rows[0])
+	}
+}
+
+func TestOpenPortTrackerBanners(t *testing.T) {
+	pt := newOpenPortTracker()
+	// DNS response payload from port 53 counts as a banner.
+	pt.processUDP("10.0.0.53", 53, 51000, 64)
+	// Payload from an ephemeral port does not.
+	pt.processUDP("10.0.0.9", 51000, 53, 64)
+	// TCP banner from a well-known port.
+	pt.processTCP("10.0.0.8", 21, 51001, false, 32)
+
+	rows := pt.rows()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want two listeners", rows)
+	}
+	if rows[0].IP != "10.0.0.53" || rows[0].Protocol != "udp" || rows[0].Port != 53 {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[1].IP != "10.0.0.8" || rows[1].Protocol != "tcp" || rows[1].Port != 21 {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+}
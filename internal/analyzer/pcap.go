@@ -0,0 +1,784 @@
+// Package analyzer reads capture files and turns them into assets, flows,
+// DNS records and findings.
+package analyzer
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/events"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// ResultSink receives the output of an analysis. The database layer is the
+// production sink; tests and the headless binary can supply their own.
+type ResultSink interface {
+	SaveAssets(ctx context.Context, analysisID int64, assets []models.Asset) error
+	SaveConnections(ctx context.Context, analysisID int64, conns []models.Connection) error
+	SaveDNSQueries(ctx context.Context, analysisID int64, queries []models.DNSQuery) error
+	SaveIPMACBindings(ctx context.Context, analysisID int64, bindings []models.IPMACBinding) error
+	SaveUserAgents(ctx context.Context, analysisID int64, agents []models.UserAgent) error
+	SaveAssetServices(ctx context.Context, analysisID int64, services []models.AssetService) error
+	SaveBroadcastSummary(ctx context.Context, analysisID int64, rows []models.BroadcastRow) error
+	SaveDNSServerHealth(ctx context.Context, analysisID int64, rows []models.DNSServerHealth) error
+	SaveMulticastGroups(ctx context.Context, analysisID int64, rows []models.MulticastMembership) error
+	SaveSubnetLatency(ctx context.Context, analysisID int64, rows []models.SubnetLatency) error
+	SaveProtocolHierarchy(ctx context.Context, analysisID int64, rows []models.ProtocolCount) error
+	SaveAccountUsage(ctx context.Context, analysisID int64, rows []models.AccountUsage) error
+	SaveServiceDurations(ctx context.Context, analysisID int64, rows []models.ServiceDuration) error
+	SaveFindings(ctx context.Context, analysisID int64, findings []models.Finding) error
+	SetAnalysisPacketCount(ctx context.Context, analysisID, count int64) error
+	SetAnalysisVerdict(ctx context.Context, analysisID int64, verdict string) error
+	SetAnalysisPartial(ctx context.Context, analysisID int64, partial bool) error
+	SetAnalysisTimeframe(ctx context.Context, analysisID int64, start, end time.Time) error
+}
+
+// Options bound how much of a capture an analysis reads, for quick triage
+// of huge files. The zero value analyzes everything.
+type Options struct {
+	// MaxPackets stops the analysis after reading this many packets.
+	MaxPackets int64
+	// MaxCaptureTime stops the analysis once the capture timestamps span
+	// more than this duration.
+	MaxCaptureTime time.Duration
+	// SampleRate processes only one in every SampleRate packets. Values
+	// below 2 mean no sampling.
+	SampleRate int
+	// TimeOffset is added to every packet timestamp, correcting captures
+	// taken with a skewed clock.
+	TimeOffset time.Duration
+	// Policies are user-defined traffic rules evaluated against the
+	// capture's connections; violations become findings.
+	Policies []PolicyRule
+}
+
+// packetReader is satisfied by both the classic pcap and the pcapng
+// readers from pcapgo.
+type packetReader interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+}
+
+const pcapngMagic = 0x0a0d0d0a
+
+// linkTypeDecoder picks the first-layer decoder for a capture's DLT, so
+// Ethernet-less captures (tcpdump -i any cooked captures, PPP links, raw
+// IP) decode correctly. Unknown link types fall back to Ethernet, which
+// matches the old behavior.
+func linkTypeDecoder(lt layers.LinkType) gopacket.Decoder {
+	switch lt {
+	case layers.LinkTypeEthernet, layers.LinkTypeLinuxSLL, layers.LinkTypePPP,
+		layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6,
+		layers.LinkTypeNull, layers.LinkTypeLoop:
+		return lt
+	default:
+		return layers.LayerTypeEthernet
+	}
+}
+
+// openCapture opens a capture file, sniffing whether it is classic pcap or
+// pcapng from the leading magic number. The returned byteCounter tracks
+// how far into the file the reader has progressed.
+func openCapture(f *os.File) (packetReader, *byteCounter, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return nil, nil, fmt.Errorf("read magic: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	bc := &byteCounter{r: f}
+	if binary.LittleEndian.Uint32(magic[:]) == pcapngMagic {
+		r, err := pcapgo.NewNgReader(bc, pcapgo.DefaultNgReaderOptions)
+		return r, bc, err
+	}
+	r, err := pcapgo.NewReader(bc)
+	return r, bc, err
+}
+
+type flowKey struct {
+	protocol string
+	srcIP    string
+	srcPort  uint16
+	dstIP    string
+	dstPort  uint16
+}
+
+func (k flowKey) reversed() flowKey {
+	return flowKey{k.protocol, k.dstIP, k.dstPort, k.srcIP, k.srcPort}
+}
+
+type flowStat struct {
+	packetsSent, packetsRecv int64
+	bytesSent, bytesRecv     int64
+	firstSeen, lastSeen      time.Time
+	sawClose                 bool
+	// Retransmitted TCP segments are counted here instead of the totals
+	// above; nextSeq* track the per-direction expected sequence numbers.
+	retransPackets, retransBytes int64
+	nextSeqSent, nextSeqRecv     uint32
+	seqSeenSent, seqSeenRecv     bool
+}
+
+type assetStat struct {
+	mac                      string
+	packetsSent, packetsRecv int64
+	bytesSent, bytesRecv     int64
+	firstSeen, lastSeen      time.Time
+}
+
+// Results is everything an analysis extracts from a capture, independent
+// of how (or whether) it is persisted.
+type Results struct {
+	PacketCount  int64                        `json:"packetCount"`
+	Partial      bool                         `json:"partial"`
+	CaptureStart time.Time                    `json:"captureStart"`
+	CaptureEnd   time.Time                    `json:"captureEnd"`
+	Assets       []models.Asset               `json:"assets"`
+	Connections  []models.Connection          `json:"connections"`
+	DNSQueries   []models.DNSQuery            `json:"dnsQueries"`
+	Bindings     []models.IPMACBinding        `json:"bindings"`
+	UserAgents   []models.UserAgent           `json:"userAgents"`
+	Services     []models.AssetService        `json:"services"`
+	Broadcast    []models.BroadcastRow        `json:"broadcast"`
+	DNSServers   []models.DNSServerHealth     `json:"dnsServers"`
+	Multicast    []models.MulticastMembership `json:"multicast"`
+	Latency      []models.SubnetLatency       `json:"latency"`
+	Protocols    []models.ProtocolCount       `json:"protocols"`
+	Accounts     []models.AccountUsage        `json:"accounts"`
+	Durations    []models.ServiceDuration     `json:"durations"`
+	Verdict      string                       `json:"verdict"`
+	Findings     []models.Finding             `json:"findings"`
+}
+
+// AnalyzePCAP processes the capture file for the given analysis and emits
+// all results through the sink.
+func AnalyzePCAP(ctx context.Context, sink ResultSink, analysisID int64, path string) error {
+	return AnalyzePCAPOpts(ctx, sink, analysisID, path, Options{})
+}
+
+// AnalyzePCAPOpts is AnalyzePCAP with triage limits applied.
+func AnalyzePCAPOpts(ctx context.Context, sink ResultSink, analysisID int64, path string, opts Options) error {
+	results, err := AnalyzeFileOpts(analysisID, path, opts)
+	if err != nil {
+		return err
+	}
+	return saveResults(ctx, sink, analysisID, results)
+}
+
+// AnalyzeFile runs the full analysis over a capture file and returns the
+// results in memory, without touching any sink. analysisID is only used
+// to tag progress events and may be zero in headless use.
+func AnalyzeFile(analysisID int64, path string) (*Results, error) {
+	return AnalyzeFileOpts(analysisID, path, Options{})
+}
+
+// AnalyzeFileOpts is AnalyzeFile with triage limits applied. Results are
+// marked partial when a limit cut the capture short or sampling skipped
+// packets.
+func AnalyzeFileOpts(analysisID int64, path string, opts Options) (*Results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open capture: %w", err)
+	}
+	defer f.Close()
+
+	r, counter, err := openCapture(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse capture: %w", err)
+	}
+
+	var totalBytes int64
+	if fi, err := f.Stat(); err == nil {
+		totalBytes = fi.Size()
+	}
+	prog, finished := newProgressTracker(analysisID, totalBytes)
+	defer finished()
+
+	decoder := linkTypeDecoder(r.LinkType())
+
+	plugins := newPluginSet()
+	flows := newFlowTable()
+	defer flows.release()
+	flows.onClose = plugins.onFlowClose
+	assets := make(map[string]*assetStat)
+	var dnsQueries []models.DNSQuery
+	synTargets := make(map[string]map[string]bool) // srcIP -> set of dst:port SYNs
+	fp := NewOSFingerprinter()
+	mc := newMobileCore()
+	bindings := newBindingTracker()
+	tlsi := newTLSInspector()
+	sens := newSensitiveScanner()
+	remote := newRemoteAccessTracker()
+	tunnels := newTunnelDetector()
+	uas := newUATracker()
+	ports := newOpenPortTracker()
+	brute := newBruteForceTracker()
+	apps := newAppProtoTracker()
+	sums := newChecksumTracker()
+	sshTr := newSSHTracker()
+	legacy := newLegacyFileTracker()
+	policies := newPolicyEngine(opts.Policies)
+	bcast := newBroadcastTracker()
+	hs := newHandshakeTracker()
+	dnsh := newDNSHealthTracker()
+	mcast := newMulticastTracker()
+	dark := newDarkSpaceTracker()
+	rtc := newWebRTCTracker()
+	defrag := newIPDefragmenter()
+	dce := newDCERPCTracker()
+	lat := newLatencyTracker()
+	storm := newStormTracker()
+	meta := newFlowMetaTracker()
+	hier := newProtoHierarchy()
+	infra := newInfraTracker()
+	mismatch := newMismatchTracker()
+	ntlm := newNTLMAuthTracker()
+	weak := newWeakCryptoTracker()
+	mining := newMiningTracker()
+
+	d := newDispatcher()
+	d.on(layers.LayerTypeARP, func(ctx *PacketContext) {
+		if arp, ok := ctx.Packet.Layer(layers.LayerTypeARP).(*layers.ARP); ok {
+			bindings.processARP(arp, ctx.Timestamp)
+		}
+	})
+	d.on(layers.LayerTypeDHCPv4, func(ctx *PacketContext) {
+		if dhcp, ok := ctx.Packet.Layer(layers.LayerTypeDHCPv4).(*layers.DHCPv4); ok {
+			bindings.processDHCP(dhcp, ctx.Timestamp)
+			infra.processDHCP(dhcp, ctx.SrcIP)
+		}
+	})
+	d.onIP(func(ctx *PacketContext) {
+		touchAsset(assets, analysisID, ctx.SrcIP, ctx.SrcMAC, ctx.Timestamp, ctx.Size, true)
+		// Broadcast and multicast destinations are not real hosts; they
+		// get their own summary instead of asset rows.
+		if !isBroadcastTarget(ctx.DstIP) {
+			touchAsset(assets, analysisID, ctx.DstIP, ctx.DstMAC, ctx.Timestamp, ctx.Size, false)
+		}
+	})
+	d.onEvery(func(ctx *PacketContext) {
+		bcast.process(ctx)
+		storm.process(ctx)
+		hier.process(ctx)
+	})
+	d.onIP(func(ctx *PacketContext) {
+		sums.process(ctx.Packet, ctx.SrcIP)
+	})
+	d.onIP(func(ctx *PacketContext) {
+		dark.process(ctx)
+		infra.process(ctx)
+	})
+	d.on(layers.LayerTypeTCP, func(ctx *PacketContext) {
+		tcp, ok := ctx.Packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		if !ok || ctx.SrcIP == "" {
+			return
+		}
+		if tcp.SYN && !tcp.ACK {
+			brute.processSYN(ctx.SrcIP, ctx.DstIP, ctx.DstPort)
+			if ip4, ok := ctx.Packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+				fp.ProcessSYN(ctx.SrcIP, ip4.TTL, tcp.Window, tcp.Options)
+			}
+			if synTargets[ctx.SrcIP] == nil {
+				synTargets[ctx.SrcIP] = make(map[string]bool)
+			}
+			synTargets[ctx.SrcIP][fmt.Sprintf("%s:%d", ctx.DstIP, ctx.DstPort)] = true
+		}
+		if tcp.SYN && tcp.ACK {
+			if ip4, ok := ctx.Packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+				fp.ProcessSYNACK(ctx.SrcIP, ip4.TTL, tcp.Window, tcp.Options)
+			}
+		}
+		ports.processTCP(ctx.SrcIP, ctx.SrcPort, ctx.DstPort, tcp.SYN && tcp.ACK, len(ctx.Payload))
+		remote.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		sshTr.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		legacy.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		hs.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Flags, ctx.Timestamp)
+		dce.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		mining.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		lat.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Flags, ctx.Timestamp)
+		if len(ctx.Payload) > 0 {
+			apps.process(ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort, ctx.Payload)
+			meta.processTCP(ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort, ctx.Payload)
+			mismatch.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+			ntlm.processTCP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+			weak.processTCP(ctx.SrcIP, ctx.SrcPort, ctx.Payload)
+			policies.processTCP(ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort, ctx.Payload)
+			tlsi.processSegment(ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort, ctx.Payload)
+			if !looksLikeTLS(ctx.Payload) {
+				sens.scan(ctx.SrcIP, ctx.DstIP, "tcp", ctx.DstPort, ctx.Payload, ctx.Timestamp)
+				uas.processHTTP(ctx.SrcIP, ctx.Payload)
+				brute.processPayload(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload)
+				fp.ProcessHTTPResponse(ctx.SrcIP, ctx.Payload)
+				if ctx.SrcPort == 445 || ctx.SrcPort == 139 || ctx.SrcPort == 3389 {
+					fp.ProcessNTLM(ctx.SrcIP, ctx.Payload)
+				}
+			}
+		}
+	})
+	d.on(layers.LayerTypeUDP, func(ctx *PacketContext) {
+		if ctx.SrcIP == "" || ctx.Protocol != "udp" {
+			return
+		}
+		ports.processUDP(ctx.SrcIP, ctx.SrcPort, ctx.DstPort, len(ctx.Payload))
+		legacy.processUDP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		rtc.processUDP(ctx.SrcIP, ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		weak.processUDP(ctx.DstIP, ctx.SrcPort, ctx.DstPort, ctx.Payload)
+		if len(ctx.Payload) > 0 && !looksLikeTLS(ctx.Payload) {
+			sens.scan(ctx.SrcIP, ctx.DstIP, "udp", ctx.DstPort, ctx.Payload, ctx.Timestamp)
+		}
+	})
+	d.on(layers.LayerTypeICMPv4, func(ctx *PacketContext) {
+		icmp, ok := ctx.Packet.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4)
+		if !ok || ctx.SrcIP == "" {
+			return
+		}
+		t := icmp.TypeCode.Type()
+		if t == layers.ICMPv4TypeEchoRequest || t == layers.ICMPv4TypeEchoReply {
+			tunnels.processICMPEcho(ctx.SrcIP, len(icmp.Payload), ctx.Timestamp)
+		}
+	})
+	d.on(layers.LayerTypeIGMP, func(ctx *PacketContext) {
+		switch msg := ctx.Packet.Layer(layers.LayerTypeIGMP).(type) {
+		case *layers.IGMPv1or2:
+			mcast.processV1or2(ctx.SrcIP, msg, ctx.Timestamp)
+		case *layers.IGMP:
+			mcast.processV3(ctx.SrcIP, msg, ctx.Timestamp)
+		}
+	})
+	d.on(layers.LayerTypeDNS, func(ctx *PacketContext) {
+		dns, ok := ctx.Packet.Layer(layers.LayerTypeDNS).(*layers.DNS)
+		if !ok || ctx.SrcIP == "" {
+			return
+		}
+		if !dns.QR {
+			dnsh.processQuery(ctx.SrcIP, ctx.DstIP, dns.ID, ctx.Timestamp)
+			for _, q := range dns.Questions {
+				tunnels.processDNSQuery(ctx.SrcIP, string(q.Name), ctx.Timestamp)
+				mining.processDNSQuery(ctx.SrcIP, string(q.Name), ctx.Timestamp)
+				meta.set(ctx.Protocol, ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort, "dns.qname", string(q.Name))
+				dnsQueries = append(dnsQueries, models.DNSQuery{
+					SrcIP:     ctx.SrcIP,
+					Query:     string(q.Name),
+					QueryType: q.Type.String(),
+					Timestamp: ctx.Timestamp,
+				})
+			}
+		} else {
+			dnsh.processResponse(ctx.SrcIP, ctx.DstIP, dns.ID, dns.ResponseCode, ctx.Timestamp)
+			infra.processDNS(ctx.SrcIP)
+			recordDNSAnswers(dnsQueries, dns)
+		}
+	})
+	d.onEvery(func(ctx *PacketContext) {
+		plugins.onPacket(&PacketInfo{
+			Packet:    ctx.Packet,
+			Timestamp: ctx.Timestamp,
+			SrcIP:     ctx.SrcIP,
+			DstIP:     ctx.DstIP,
+			Protocol:  ctx.Protocol,
+			SrcPort:   ctx.SrcPort,
+			DstPort:   ctx.DstPort,
+			Payload:   ctx.Payload,
+		})
+	})
+	d.onIP(func(ctx *PacketContext) {
+		flows.record(flowKey{ctx.Protocol, ctx.SrcIP, ctx.SrcPort, ctx.DstIP, ctx.DstPort},
+			ctx.Timestamp, ctx.Size, ctx.Flags)
+	})
+
+	var packetCount int64
+	var firstTS, lastTS, prevTS time.Time
+	var skewJumps int
+	var maxSkew time.Duration
+	truncated := false
+	var limitReason string
+	lims := limitsFromEnv()
+	for {
+		data, ci, err := r.ReadPacketData()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			slog.Warn("packet decode error", "analysis_id", analysisID, "packet", packetCount+1, "err", err)
+			continue
+		}
+		if opts.TimeOffset != 0 {
+			ci.Timestamp = ci.Timestamp.Add(opts.TimeOffset)
+		}
+		packetCount++
+		if opts.MaxPackets > 0 && packetCount > opts.MaxPackets {
+			packetCount--
+			truncated = true
+			break
+		}
+		if firstTS.IsZero() {
+			firstTS = ci.Timestamp
+		}
+		if ci.Timestamp.After(lastTS) {
+			lastTS = ci.Timestamp
+		}
+		// Timestamps running backwards mean merged captures whose source
+		// clocks disagreed.
+		if !prevTS.IsZero() && prevTS.Sub(ci.Timestamp) > clockSkewTolerance {
+			skewJumps++
+			if d := prevTS.Sub(ci.Timestamp); d > maxSkew {
+				maxSkew = d
+			}
+		}
+		prevTS = ci.Timestamp
+		if opts.MaxCaptureTime > 0 && ci.Timestamp.Sub(firstTS) > opts.MaxCaptureTime {
+			packetCount--
+			truncated = true
+			break
+		}
+		if packetCount%progressEvery == 0 {
+			prog.update(counter.n, packetCount)
+		}
+		if lims != nil && packetCount%resourceCheckEvery == 0 {
+			if reason, over := lims.exceeded(); over {
+				slog.Warn("analysis stopped at resource limit",
+					"analysis_id", analysisID, "packets", packetCount, "reason", reason)
+				truncated = true
+				limitReason = reason
+				break
+			}
+		}
+		if opts.SampleRate > 1 && packetCount%int64(opts.SampleRate) != 0 {
+			continue
+		}
+
+		packet := gopacket.NewPacket(data, decoder, gopacket.NoCopy)
+		size := int64(ci.Length)
+
+		// Reassemble fragmented datagrams before anything looks at the
+		// transport layer; non-final fragments are held until the
+		// datagram completes.
+		fullSize, done := defrag.process(packet, ci.Timestamp)
+		if !done {
+			continue
+		}
+		if fullSize > 0 {
+			size = fullSize
+		}
+
+		// Unwrap GTP-U tunnels so subscriber traffic is analyzed as
+		// normal flows; skim GTP-C for signaling metadata.
+		if udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP); ok {
+			switch {
+			case udp.SrcPort == gtpUPort || udp.DstPort == gtpUPort:
+				if inner := decapGTPU(udp.Payload); inner != nil {
+					mc.gtpuPackets++
+					packet = inner
+				}
+			case udp.SrcPort == gtpCPort || udp.DstPort == gtpCPort:
+				mc.processGTPC(udp.Payload)
+			}
+		}
+
+		d.dispatch(&PacketContext{
+			AnalysisID: analysisID,
+			Packet:     packet,
+			Timestamp:  ci.Timestamp,
+			Size:       size,
+		})
+	}
+	flows.finish()
+
+	results := buildResults(analysisID, packetCount, flows, assets, dnsQueries, synTargets, fp, mc, bindings, tlsi, sens, remote, tunnels, uas, ports, brute, apps, sums, sshTr, legacy, policies, hs, meta, infra)
+	results.Broadcast = bcast.rows()
+	results.DNSServers = dnsh.rows()
+	results.Multicast = mcast.rows()
+	results.Latency = lat.rows()
+	results.Protocols = hier.rows()
+	results.Accounts = ntlm.rows()
+	results.Durations = serviceDurations(results.Connections)
+	applyFanMetrics(results.Assets, results.Connections)
+	results.Findings = append(results.Findings, longLivedFindings(results.Connections, firstTS, lastTS)...)
+	results.Findings = append(results.Findings, dark.findings()...)
+	results.Findings = append(results.Findings, mismatch.findings()...)
+	results.Findings = append(results.Findings, rtc.findings()...)
+	results.Findings = append(results.Findings, defrag.findings()...)
+	results.Findings = append(results.Findings, dce.findings()...)
+	results.Findings = append(results.Findings, storm.findings()...)
+	results.Findings = append(results.Findings, weak.findings()...)
+	results.Findings = append(results.Findings, mining.findings()...)
+	results.Partial = truncated || opts.SampleRate > 1
+	results.CaptureStart, results.CaptureEnd = firstTS, lastTS
+	if limitReason != "" {
+		results.Findings = append(results.Findings, models.Finding{
+			Severity: "info",
+			Type:     "resource-limit",
+			Title:    "Analysis truncated by resource limit",
+			Description: fmt.Sprintf(
+				"Processing stopped after %d packets: %s. Results cover only the capture up to that point.",
+				packetCount, limitReason),
+			Timestamp: lastTS,
+		})
+	}
+	if skewJumps > 0 {
+		f := models.Finding{
+			Severity: "low",
+			Type:     "clock-skew",
+			Title:    "Capture timestamps run backwards",
+			Description: fmt.Sprintf(
+				"timestamps jumped backwards %d times (largest jump %s); the capture likely merges files from unsynchronized clocks",
+				skewJumps, maxSkew),
+			Timestamp: time.Now(),
+		}
+		results.Findings = append(results.Findings, f)
+		events.Default.Publish(analysisID, "finding", f)
+	}
+	for _, f := range plugins.results() {
+		results.Findings = append(results.Findings, f)
+		events.Default.Publish(analysisID, "finding", f)
+	}
+	results.Verdict = buildVerdict(results)
+	return results, nil
+}
+
+// clockSkewTolerance is how far backwards a timestamp may step before it
+// counts as clock skew; reordering inside a capture stays under this.
+const clockSkewTolerance = time.Second
+
+// touchAsset updates per-host counters, creating the asset on first sight.
+// Newly discovered hosts are announced on the event hub so live tails see
+// them before the analysis completes.
+func touchAsset(assets map[string]*assetStat, analysisID int64, ip, mac string, ts time.Time, size int64, sending bool) {
+	if ip == "" {
+		return
+	}
+	a, ok := assets[ip]
+	if !ok {
+		a = &assetStat{mac: mac, firstSeen: ts}
+		assets[ip] = a
+		events.Default.Publish(analysisID, "asset", map[string]string{"ip": ip, "mac": mac})
+	}
+	if a.mac == "" {
+		a.mac = mac
+	}
+	if sending {
+		a.packetsSent++
+		a.bytesSent += size
+	} else {
+		a.packetsRecv++
+		a.bytesRecv += size
+	}
+	a.lastSeen = ts
+}
+
+// recordDNSAnswers fills in the answers column of the most recent matching
+// query, if one was captured.
+func recordDNSAnswers(queries []models.DNSQuery, dns *layers.DNS) {
+	for _, ans := range dns.Answers {
+		if ans.IP == nil && ans.CNAME == nil {
+			continue
+		}
+		name := string(ans.Name)
+		for i := len(queries) - 1; i >= 0; i-- {
+			if queries[i].Query != name {
+				continue
+			}
+			var val string
+			if ans.IP != nil {
+				val = ans.IP.String()
+			} else {
+				val = string(ans.CNAME)
+			}
+			if queries[i].Answers == "" {
+				queries[i].Answers = val
+			} else {
+				queries[i].Answers += "," + val
+			}
+			break
+		}
+	}
+}
+
+// portScanThreshold is the number of distinct dst:port pairs a single
+// source must SYN to before we raise a port-scan finding.
+const portScanThreshold = 100
+
+// buildResults converts the in-memory tables into result rows.
+func buildResults(analysisID, packetCount int64,
+	flows *flowTable, assets map[string]*assetStat,
+	dnsQueries []models.DNSQuery, synTargets map[string]map[string]bool,
+	fp *OSFingerprinter, mc *mobileCore, bindings *bindingTracker,
+	tlsi *tlsInspector, sens *sensitiveScanner, remote *remoteAccessTracker,
+	tunnels *tunnelDetector, uas *uaTracker, ports *openPortTracker,
+	brute *bruteForceTracker, apps *appProtoTracker, sums *checksumTracker,
+	sshTr *sshTracker, legacy *legacyFileTracker, policies *policyEngine,
+	hs *handshakeTracker, meta *flowMetaTracker, infra *infraTracker) *Results {
+
+	var conns []models.Connection
+	for _, rec := range flows.all() {
+		key, fs := rec.key, rec.stat
+		appProto, appMsgs := apps.flowInfo(key)
+		conns = append(conns, models.Connection{
+			Protocol:       key.protocol,
+			SrcIP:          key.srcIP,
+			SrcPort:        int(key.srcPort),
+			DstIP:          key.dstIP,
+			DstPort:        int(key.dstPort),
+			Service:        ServiceForPort(key.protocol, key.dstPort),
+			AppProtocol:    appProto,
+			AppMessages:    appMsgs,
+			PacketsSent:    fs.packetsSent,
+			PacketsRecv:    fs.packetsRecv,
+			BytesSent:      fs.bytesSent,
+			BytesRecv:      fs.bytesRecv,
+			RetransPackets: fs.retransPackets,
+			RetransBytes:   fs.retransBytes,
+			FirstSeen:      fs.firstSeen,
+			LastSeen:       fs.lastSeen,
+			Metadata:       meta.forFlow(key),
+		})
+	}
+
+	anomalies := anomalyScores(conns)
+
+	var rows []models.Asset
+	for ip, a := range assets {
+		os, confidence, signals := fp.Guess(ip)
+		var asn int
+		var asName string
+		parsed := net.ParseIP(ip)
+		ipClass := ClassifyIP(parsed)
+		var reputation int
+		if parsed != nil && ipClass == "public" {
+			asn, asName, _ = asnData().Lookup(parsed)
+			reputation, _ = reputationData().Lookup(ip)
+		}
+		rows = append(rows, models.Asset{
+			MAC:           a.mac,
+			IP:            ip,
+			Vendor:        LookupVendor(a.mac),
+			RandomizedMAC: IsRandomizedMAC(a.mac),
+			OS:            os,
+			OSConfidence:  confidence,
+			OSSignals:     signals,
+			IPClass:       ipClass,
+			ASN:           asn,
+			ASName:        asName,
+			AnomalyScore:  anomalies[ip].score,
+			Reputation:    reputation,
+			DeviceType:    infra.deviceType(ip, a.mac),
+			PacketsSent:   a.packetsSent,
+			PacketsRecv:   a.packetsRecv,
+			BytesSent:     a.bytesSent,
+			BytesRecv:     a.bytesRecv,
+			FirstSeen:     a.firstSeen,
+			LastSeen:      a.lastSeen,
+		})
+	}
+
+	findings := mc.findings()
+	findings = append(findings, bindings.findings()...)
+	findings = append(findings, tlsi.findings()...)
+	findings = append(findings, sens.findings()...)
+	findings = append(findings, remote.findings()...)
+	findings = append(findings, anomalyFindings(anomalies)...)
+	findings = append(findings, tunnels.findings()...)
+	findings = append(findings, uas.findings()...)
+	findings = append(findings, brute.findings()...)
+	findings = append(findings, sums.findings()...)
+	findings = append(findings, sshTr.findings()...)
+	findings = append(findings, legacy.findings()...)
+	findings = append(findings, policies.evaluate(conns)...)
+	findings = append(findings, hs.findings()...)
+	for src, targets := range synTargets {
+		if len(targets) >= portScanThreshold {
+			findings = append(findings, models.Finding{
+				Severity:    "medium",
+				Type:        "port-scan",
+				Title:       fmt.Sprintf("Possible port scan from %s", src),
+				Description: fmt.Sprintf("%s sent SYN packets to %d distinct host:port pairs", src, len(targets)),
+				SrcIP:       src,
+				Timestamp:   time.Now(),
+			})
+		}
+	}
+
+	for _, f := range findings {
+		events.Default.Publish(analysisID, "finding", f)
+	}
+
+	return &Results{
+		PacketCount: packetCount,
+		Assets:      rows,
+		Connections: conns,
+		DNSQueries:  dnsQueries,
+		Bindings:    bindings.rows(),
+		UserAgents:  uas.rows(),
+		Services:    ports.rows(),
+		Findings:    findings,
+	}
+}
+
+// saveResults pushes a Results set through the sink.
+func saveResults(ctx context.Context, sink ResultSink, analysisID int64, res *Results) error {
+	if err := sink.SaveConnections(ctx, analysisID, res.Connections); err != nil {
+		return fmt.Errorf("save connections: %w", err)
+	}
+	if err := sink.SaveAssets(ctx, analysisID, res.Assets); err != nil {
+		return fmt.Errorf("save assets: %w", err)
+	}
+	if err := sink.SaveDNSQueries(ctx, analysisID, res.DNSQueries); err != nil {
+		return fmt.Errorf("save dns queries: %w", err)
+	}
+	if err := sink.SaveIPMACBindings(ctx, analysisID, res.Bindings); err != nil {
+		return fmt.Errorf("save ip-mac bindings: %w", err)
+	}
+	if err := sink.SaveUserAgents(ctx, analysisID, res.UserAgents); err != nil {
+		return fmt.Errorf("save user agents: %w", err)
+	}
+	if err := sink.SaveAssetServices(ctx, analysisID, res.Services); err != nil {
+		return fmt.Errorf("save asset services: %w", err)
+	}
+	if err := sink.SaveBroadcastSummary(ctx, analysisID, res.Broadcast); err != nil {
+		return fmt.Errorf("save broadcast summary: %w", err)
+	}
+	if err := sink.SaveDNSServerHealth(ctx, analysisID, res.DNSServers); err != nil {
+		return fmt.Errorf("save dns server health: %w", err)
+	}
+	if err := sink.SaveMulticastGroups(ctx, analysisID, res.Multicast); err != nil {
+		return fmt.Errorf("save multicast groups: %w", err)
+	}
+	if err := sink.SaveSubnetLatency(ctx, analysisID, res.Latency); err != nil {
+		return fmt.Errorf("save subnet latency: %w", err)
+	}
+	if err := sink.SaveProtocolHierarchy(ctx, analysisID, res.Protocols); err != nil {
+		return fmt.Errorf("save protocol hierarchy: %w", err)
+	}
+	if err := sink.SaveAccountUsage(ctx, analysisID, res.Accounts); err != nil {
+		return fmt.Errorf("save account usage: %w", err)
+	}
+	if err := sink.SaveServiceDurations(ctx, analysisID, res.Durations); err != nil {
+		return fmt.Errorf("save service durations: %w", err)
+	}
+	if err := sink.SaveFindings(ctx, analysisID, res.Findings); err != nil {
+		return fmt.Errorf("save findings: %w", err)
+	}
+	if err := sink.SetAnalysisPartial(ctx, analysisID, res.Partial); err != nil {
+		return fmt.Errorf("set partial flag: %w", err)
+	}
+	if !res.CaptureStart.IsZero() {
+		if err := sink.SetAnalysisTimeframe(ctx, analysisID, res.CaptureStart, res.CaptureEnd); err != nil {
+			return fmt.Errorf("set timeframe: %w", err)
+		}
+	}
+	if err := sink.SetAnalysisVerdict(ctx, analysisID, res.Verdict); err != nil {
+		return fmt.Errorf("set verdict: %w", err)
+	}
+	return sink.SetAnalysisPacketCount(ctx, analysisID, res.PacketCount)
+}
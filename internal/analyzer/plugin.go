@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// PacketInfo is the decoded view of one packet handed to plugins. SrcIP
+// and the port fields are empty for packets without an IP layer.
+type PacketInfo struct {
+	Packet    gopacket.Packet
+	Timestamp time.Time
+	SrcIP     string
+	DstIP     string
+	Protocol  string
+	SrcPort   uint16
+	DstPort   uint16
+	Payload   []byte
+}
+
+// Flow summarizes one finished session for plugins.
+type Flow struct {
+	Protocol    string
+	SrcIP       string
+	SrcPort     uint16
+	DstIP       string
+	DstPort     uint16
+	PacketsSent int64
+	PacketsRecv int64
+	BytesSent   int64
+	BytesRecv   int64
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+// Plugin is a custom analyzer module run alongside the built-in
+// trackers. Implementations keep per-capture state; a fresh instance is
+// created for every analysis via the registered factory.
+type Plugin interface {
+	// Name identifies the plugin in logs and findings.
+	Name() string
+	// OnPacket is called once per packet, after decoding.
+	OnPacket(p *PacketInfo)
+	// OnFlowClose is called when a session ends, and once more for every
+	// still-open session when the capture is exhausted.
+	OnFlowClose(f *Flow)
+	// Results returns the plugin's findings after the last packet.
+	Results() []models.Finding
+}
+
+var (
+	pluginMu        sync.Mutex
+	pluginFactories []func() Plugin
+)
+
+// RegisterPlugin adds a plugin factory to the registry, typically from an
+// init function in the plugin's own file. Every analysis instantiates
+// each registered plugin once.
+func RegisterPlugin(factory func() Plugin) {
+	pluginMu.Lock()
+	defer pluginMu.Unlock()
+	pluginFactories = append(pluginFactories, factory)
+}
+
+// newPluginSet instantiates all registered plugins for one analysis.
+func newPluginSet() *pluginSet {
+	pluginMu.Lock()
+	defer pluginMu.Unlock()
+	ps := &pluginSet{}
+	for _, factory := range pluginFactories {
+		ps.plugins = append(ps.plugins, factory())
+	}
+	return ps
+}
+
+// pluginSet fans the analyzer hooks out to every plugin instance.
+type pluginSet struct {
+	plugins []Plugin
+}
+
+func (ps *pluginSet) onPacket(p *PacketInfo) {
+	for _, pl := range ps.plugins {
+		pl.OnPacket(p)
+	}
+}
+
+func (ps *pluginSet) onFlowClose(key flowKey, stat *flowStat) {
+	if len(ps.plugins) == 0 {
+		return
+	}
+	f := &Flow{
+		Protocol:    key.protocol,
+		SrcIP:       key.srcIP,
+		SrcPort:     key.srcPort,
+		DstIP:       key.dstIP,
+		DstPort:     key.dstPort,
+		PacketsSent: stat.packetsSent,
+		PacketsRecv: stat.packetsRecv,
+		BytesSent:   stat.bytesSent,
+		BytesRecv:   stat.bytesRecv,
+		FirstSeen:   stat.firstSeen,
+		LastSeen:    stat.lastSeen,
+	}
+	for _, pl := range ps.plugins {
+		pl.OnFlowClose(f)
+	}
+}
+
+func (ps *pluginSet) results() []models.Finding {
+	var out []models.Finding
+	for _, pl := range ps.plugins {
+		out = append(out, pl.Results()...)
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// fakePlugin records the hook calls it receives.
+type fakePlugin struct {
+	packets []*PacketInfo
+	flows   []*Flow
+}
+
+func (fp *fakePlugin) Name() string           { return "fake" }
+func (fp *fakePlugin) OnPacket(p *PacketInfo) { fp.packets = append(fp.packets, p) }
+func (fp *fakePlugin) OnFlowClose(f *Flow)    { fp.flows = append(fp.flows, f) }
+func (fp *fakePlugin) Results() []models.Finding {
+	return []models.Finding{{Type: "fake", Severity: "low", Title: "fake finding"}}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	pluginMu.Lock()
+	saved := pluginFactories
+	pluginFactories = nil
+	pluginMu.Unlock()
+	defer func() {
+		pluginMu.Lock()
+		pluginFactories = saved
+		pluginMu.Unlock()
+	}()
+
+	var instance *fakePlugin
+	RegisterPlugin(func() Plugin {
+		instance = &fakePlugin{}
+		return instance
+	})
+
+	ps := newPluginSet()
+	if len(ps.plugins) != 1 || instance == nil {
+		t.Fatalf("expected one plugin instance, got %d", len(ps.plugins))
+	}
+
+	ps.onPacket(&PacketInfo{SrcIP: "10.0.0.1", Protocol: "tcp"})
+	if len(instance.packets) != 1 || instance.packets[0].SrcIP != "10.0.0.1" {
+		t.Fatalf("OnPacket not delivered: %+v", instance.packets)
+	}
+
+	findings := ps.results()
+	if len(findings) != 1 || findings[0].Type != "fake" {
+		t.Fatalf("expected the plugin finding, got %+v", findings)
+	}
+}
+
+func TestPluginFlowClose(t *testing.T) {
+	fp := &fakePlugin{}
+	ps := &pluginSet{plugins: []Plugin{fp}}
+
+	ft := newFlowTable()
+	ft.onClose = ps.onFlowClose
+
+	base := time.Now()
+	key := flowKey{"tcp", "10.0.0.1", 40000, "10.0.0.2", 80}
+	ft.record(key, base, 100, &tcpFlags{syn: true})
+	ft.record(key, base.Add(time.Second), 200, &tcpFlags{fin: true, ack: true})
+	// A fresh SYN after the FIN splits the tuple and closes the first
+	// session, which must reach the plugin.
+	ft.record(key, base.Add(2*time.Second), 100, &tcpFlags{syn: true})
+
+	if len(fp.flows) != 1 {
+		t.Fatalf("expected 1 closed flow, got %d", len(fp.flows))
+	}
+	f := fp.flows[0]
+	if f.SrcIP != "10.0.0.1" || f.DstPort != 80 || f.PacketsSent != 2 || f.BytesSent != 300 {
+		t.Fatalf("unexpected flow summary: %+v", f)
+	}
+
+	// finish flushes the session still open at EOF.
+	ft.finish()
+	if len(fp.flows) != 2 {
+		t.Fatalf("expected finish to close the open flow, got %d flows", len(fp.flows))
+	}
+}
@@ -0,0 +1,201 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// policyMaxExamples caps how many matching connections a violation lists.
+const policyMaxExamples = 5
+
+// PolicyRule is one user-defined traffic policy, supplied as JSON with an
+// upload. A connection violates the rule when every set condition matches;
+// unset conditions match anything. Example:
+//
+//	{"name": "no telnet", "service": "telnet"}
+//	{"name": "no workstation RDP", "srcCidr": "10.1.0.0/16",
+//	 "dstCidr": "10.1.0.0/16", "port": 3389}
+type PolicyRule struct {
+	// Name labels the resulting finding.
+	Name string `json:"name"`
+	// Severity of a violation: info, low, medium or high (the default).
+	Severity string `json:"severity"`
+	// Protocol restricts the rule to tcp or udp.
+	Protocol string `json:"protocol"`
+	// Port matches the connection's destination port.
+	Port uint16 `json:"port"`
+	// Service matches the well-known service name or detected application
+	// protocol (e.g. "telnet", "smb", "smb1", "grpc").
+	Service string `json:"service"`
+	// SrcCIDR and DstCIDR restrict the rule to connections whose endpoints
+	// fall inside the given networks.
+	SrcCIDR string `json:"srcCidr"`
+	DstCIDR string `json:"dstCidr"`
+}
+
+// ParsePolicyRules decodes and validates a JSON array of policy rules.
+func ParsePolicyRules(data []byte) ([]PolicyRule, error) {
+	var rules []PolicyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parse policy rules: %w", err)
+	}
+	if err := ValidatePolicyRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ValidatePolicyRules rejects rules that are unnamed, contradictory or
+// would silently match nothing.
+func ValidatePolicyRules(rules []PolicyRule) error {
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("policy rule %d: name is required", i+1)
+		}
+		switch r.Severity {
+		case "", "info", "low", "medium", "high":
+		default:
+			return fmt.Errorf("policy rule %q: unknown severity %q", r.Name, r.Severity)
+		}
+		if r.Protocol != "" && r.Protocol != "tcp" && r.Protocol != "udp" {
+			return fmt.Errorf("policy rule %q: protocol must be tcp or udp", r.Name)
+		}
+		if r.Protocol == "" && r.Port == 0 && r.Service == "" &&
+			r.SrcCIDR == "" && r.DstCIDR == "" {
+			return fmt.Errorf("policy rule %q: at least one condition is required", r.Name)
+		}
+		for _, cidr := range []string{r.SrcCIDR, r.DstCIDR} {
+			if cidr == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("policy rule %q: bad CIDR %q", r.Name, cidr)
+			}
+		}
+	}
+	return nil
+}
+
+// compiledRule is a PolicyRule with its CIDRs parsed once.
+type compiledRule struct {
+	PolicyRule
+	srcNet, dstNet *net.IPNet
+}
+
+// policyEngine evaluates user-defined rules against the capture's
+// connections. It also labels SMBv1 flows from their wire header, since
+// "no SMBv1" is the policy these rules most often exist for and the port
+// alone cannot tell SMB dialects apart.
+type policyEngine struct {
+	rules []compiledRule
+	smb1  map[string]bool // flowPairKey of flows seen speaking SMBv1
+}
+
+func newPolicyEngine(rules []PolicyRule) *policyEngine {
+	pe := &policyEngine{smb1: make(map[string]bool)}
+	for _, r := range rules {
+		cr := compiledRule{PolicyRule: r}
+		if r.Severity == "" {
+			cr.Severity = "high"
+		}
+		if r.SrcCIDR != "" {
+			_, cr.srcNet, _ = net.ParseCIDR(r.SrcCIDR)
+		}
+		if r.DstCIDR != "" {
+			_, cr.dstNet, _ = net.ParseCIDR(r.DstCIDR)
+		}
+		pe.rules = append(pe.rules, cr)
+	}
+	return pe
+}
+
+// smb1Magic is the SMBv1 header: 0xFF followed by "SMB". SMBv2+ uses 0xFE.
+var smb1Magic = []byte{0xff, 'S', 'M', 'B'}
+
+// processTCP sniffs the SMB dialect of a segment. The header sits either
+// at the start of the payload or after the 4-byte NetBIOS session prefix.
+func (pe *policyEngine) processTCP(srcIP string, srcPort uint16, dstIP string, dstPort uint16, payload []byte) {
+	if len(pe.rules) == 0 || len(payload) < 8 {
+		return
+	}
+	if bytes.HasPrefix(payload, smb1Magic) || bytes.Equal(payload[4:8], smb1Magic) {
+		pe.smb1[flowPairKey(srcIP, srcPort, dstIP, dstPort)] = true
+	}
+}
+
+// matches reports whether conn violates the rule.
+func (r *compiledRule) matches(conn *models.Connection, smb1 bool) bool {
+	if r.Protocol != "" && r.Protocol != conn.Protocol {
+		return false
+	}
+	if r.Port != 0 && int(r.Port) != conn.DstPort {
+		return false
+	}
+	if r.Service != "" {
+		svc := strings.EqualFold(r.Service, conn.Service) ||
+			strings.EqualFold(r.Service, conn.AppProtocol) ||
+			(strings.EqualFold(r.Service, "smb1") && smb1)
+		if !svc {
+			return false
+		}
+	}
+	if r.srcNet != nil {
+		ip := net.ParseIP(conn.SrcIP)
+		if ip == nil || !r.srcNet.Contains(ip) {
+			return false
+		}
+	}
+	if r.dstNet != nil {
+		ip := net.ParseIP(conn.DstIP)
+		if ip == nil || !r.dstNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate checks every connection against every rule and returns one
+// policy-violation finding per violated rule.
+func (pe *policyEngine) evaluate(conns []models.Connection) []models.Finding {
+	var out []models.Finding
+	for i := range pe.rules {
+		r := &pe.rules[i]
+		var count int
+		var examples []string
+		var first models.Connection
+		for j := range conns {
+			conn := &conns[j]
+			smb1 := pe.smb1[flowPairKey(conn.SrcIP, uint16(conn.SrcPort), conn.DstIP, uint16(conn.DstPort))]
+			if !r.matches(conn, smb1) {
+				continue
+			}
+			if count == 0 {
+				first = *conn
+			}
+			count++
+			if len(examples) < policyMaxExamples {
+				examples = append(examples, fmt.Sprintf("%s:%d -> %s:%d/%s",
+					conn.SrcIP, conn.SrcPort, conn.DstIP, conn.DstPort, conn.Protocol))
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: r.Severity,
+			Type:     "policy-violation",
+			Title:    fmt.Sprintf("Policy violation: %s", r.Name),
+			Description: fmt.Sprintf("%d connection(s) violate the %q policy: %s",
+				count, r.Name, strings.Join(examples, ", ")),
+			SrcIP:     first.SrcIP,
+			DstIP:     first.DstIP,
+			Timestamp: first.FirstSeen,
+		})
+	}
+	return out
+}
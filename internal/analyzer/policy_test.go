@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func TestParsePolicyRules(t *testing.T) {
+	rules, err := ParsePolicyRules([]byte(
+		`[{"name": "no telnet", "service": "telnet"},
+		  {"name": "no workstation RDP", "srcCidr": "10.1.0.0/16",
+		   "dstCidr": "10.1.0.0/16", "port": 3389, "severity": "medium"}]`))
+	if err != nil {
+		t.Fatalf("ParsePolicyRules: %v", err)
+	}
+	if len(rules) != 2 || rules[1].Port != 3389 {
+		t.Fatalf("rules = %+v", rules)
+	}
+
+	bad := []string{
+		`[{"service": "telnet"}]`,                        // no name
+		`[{"name": "x"}]`,                                // no condition
+		`[{"name": "x", "srcCidr": "10.1.0.0"}]`,         // bad CIDR
+		`[{"name": "x", "protocol": "icmp"}]`,            // bad protocol
+		`[{"name": "x", "port": 1, "severity": "oops"}]`, // bad severity
+	}
+	for _, doc := range bad {
+		if _, err := ParsePolicyRules([]byte(doc)); err == nil {
+			t.Errorf("ParsePolicyRules(%s) accepted", doc)
+		}
+	}
+}
+
+func TestPolicyEvaluation(t *testing.T) {
+	pe := newPolicyEngine([]PolicyRule{
+		{Name: "no telnet", Service: "telnet"},
+		{Name: "no workstation RDP", SrcCIDR: "10.1.0.0/16", DstCIDR: "10.1.0.0/16",
+			Port: 3389, Severity: "medium"},
+	})
+	conns := []models.Connection{
+		{Protocol: "tcp", SrcIP: "10.1.0.5", SrcPort: 40000,
+			DstIP: "10.2.0.9", DstPort: 23, Service: "telnet"},
+		{Protocol: "tcp", SrcIP: "10.1.0.5", SrcPort: 40001,
+			DstIP: "10.1.0.6", DstPort: 3389, Service: "rdp"},
+		// RDP to a server subnet is allowed by the rule's dstCidr.
+		{Protocol: "tcp", SrcIP: "10.1.0.5", SrcPort: 40002,
+			DstIP: "10.2.0.9", DstPort: 3389, Service: "rdp"},
+	}
+
+	findings := pe.evaluate(conns)
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(findings), findings)
+	}
+	if findings[0].Severity != "high" || !strings.Contains(findings[0].Title, "no telnet") {
+		t.Errorf("telnet finding = %+v", findings[0])
+	}
+	if findings[1].Severity != "medium" ||
+		!strings.Contains(findings[1].Description, "10.1.0.6:3389") {
+		t.Errorf("rdp finding = %+v", findings[1])
+	}
+}
+
+func TestPolicySMB1Detection(t *testing.T) {
+	pe := newPolicyEngine([]PolicyRule{{Name: "no SMBv1", Service: "smb1"}})
+
+	// NetBIOS session prefix then the SMBv1 magic.
+	seg := append([]byte{0, 0, 0, 0x20}, 0xff, 'S', 'M', 'B')
+	pe.processTCP("10.0.0.5", 40000, "10.0.0.9", 445, seg)
+
+	conns := []models.Connection{
+		{Protocol: "tcp", SrcIP: "10.0.0.5", SrcPort: 40000,
+			DstIP: "10.0.0.9", DstPort: 445, Service: "smb"},
+		{Protocol: "tcp", SrcIP: "10.0.0.6", SrcPort: 40001,
+			DstIP: "10.0.0.9", DstPort: 445, Service: "smb"},
+	}
+	findings := pe.evaluate(conns)
+	if len(findings) != 1 || !strings.Contains(findings[0].Description, "1 connection(s)") {
+		t.Fatalf("findings = %+v, want one violation for the SMBv1 flow", findings)
+	}
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress is a point-in-time snapshot of a running analysis, for status
+// responses on large captures.
+type Progress struct {
+	BytesProcessed int64   `json:"bytesProcessed"`
+	TotalBytes     int64   `json:"totalBytes"`
+	Packets        int64   `json:"packets"`
+	PacketsPerSec  float64 `json:"packetsPerSec"`
+	BytesPerSec    float64 `json:"bytesPerSec"`
+	ETASeconds     float64 `json:"etaSeconds"`
+}
+
+// progressEvery is how many packets pass between progress updates.
+const progressEvery = 256
+
+// running holds the live progress of in-flight analyses by ID.
+var running sync.Map // int64 -> *progressTracker
+
+// ProgressFor returns the current progress of an analysis, if one is
+// running under that ID.
+func ProgressFor(analysisID int64) (Progress, bool) {
+	v, ok := running.Load(analysisID)
+	if !ok {
+		return Progress{}, false
+	}
+	return v.(*progressTracker).snapshot(), true
+}
+
+// progressTracker accumulates throughput while a capture is processed.
+type progressTracker struct {
+	mu      sync.Mutex
+	total   int64
+	bytes   int64
+	packets int64
+	started time.Time
+}
+
+// newProgressTracker registers a tracker for analysisID. The returned
+// cleanup must run when the analysis ends so stale entries do not linger.
+func newProgressTracker(analysisID, totalBytes int64) (*progressTracker, func()) {
+	pt := &progressTracker{total: totalBytes, started: time.Now()}
+	if analysisID != 0 {
+		running.Store(analysisID, pt)
+	}
+	return pt, func() { running.Delete(analysisID) }
+}
+
+// update records the bytes consumed so far and the packet count.
+func (pt *progressTracker) update(bytes, packets int64) {
+	pt.mu.Lock()
+	pt.bytes, pt.packets = bytes, packets
+	pt.mu.Unlock()
+}
+
+// snapshot derives rates and the ETA from the counters.
+func (pt *progressTracker) snapshot() Progress {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	p := Progress{
+		BytesProcessed: pt.bytes,
+		TotalBytes:     pt.total,
+		Packets:        pt.packets,
+	}
+	elapsed := time.Since(pt.started).Seconds()
+	if elapsed <= 0 {
+		return p
+	}
+	p.PacketsPerSec = float64(pt.packets) / elapsed
+	p.BytesPerSec = float64(pt.bytes) / elapsed
+	if p.BytesPerSec > 0 && pt.total > pt.bytes {
+		p.ETASeconds = float64(pt.total-pt.bytes) / p.BytesPerSec
+	}
+	return p
+}
+
+// byteCounter counts the bytes the capture reader has consumed, which
+// tracks the file offset closely enough for progress reporting.
+type byteCounter struct {
+	r io.Reader
+	n int64
+}
+
+func (bc *byteCounter) Read(p []byte) (int, error) {
+	n, err := bc.r.Read(p)
+	bc.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"net"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// ReenrichAssets recomputes every asset field that derives from a lookup
+// table rather than from packet contents — MAC vendor, randomized-MAC
+// detection, ASN data and anomaly scores — so stored analyses pick up
+// signature updates without re-reading the capture. It returns how many
+// assets changed.
+func ReenrichAssets(assets []models.Asset, conns []models.Connection) int {
+	anomalies := anomalyScores(conns)
+	var changed int
+	for i := range assets {
+		a := &assets[i]
+		updated := *a
+		updated.Vendor = LookupVendor(a.MAC)
+		updated.RandomizedMAC = IsRandomizedMAC(a.MAC)
+		updated.ASN, updated.ASName = 0, ""
+		parsed := net.ParseIP(a.IP)
+		updated.IPClass = ClassifyIP(parsed)
+		if parsed != nil && updated.IPClass == "public" {
+			updated.ASN, updated.ASName, _ = asnData().Lookup(parsed)
+		}
+		updated.AnomalyScore = anomalies[a.IP].score
+		if updated.Vendor != a.Vendor || updated.RandomizedMAC != a.RandomizedMAC ||
+			updated.ASN != a.ASN || updated.ASName != a.ASName ||
+			updated.AnomalyScore != a.AnomalyScore || updated.IPClass != a.IPClass {
+			*a = updated
+			changed++
+		}
+	}
+	return changed
+}
+
+// ReenrichConnections remaps every connection's destination port to the
+// current service definitions, returning how many changed.
+func ReenrichConnections(conns []models.Connection) int {
+	var changed int
+	for i := range conns {
+		conn := &conns[i]
+		if svc := ServiceForPort(conn.Protocol, uint16(conn.DstPort)); svc != conn.Service {
+			conn.Service = svc
+			changed++
+		}
+	}
+	return changed
+}
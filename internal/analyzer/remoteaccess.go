@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Remote-access service ports.
+const (
+	rdpPort        = 3389
+	teamviewerPort = 5938
+	anydeskPort    = 7070
+)
+
+// rdpProtocolCredSSP is the CredSSP bit in an RDP negotiation response;
+// CredSSP means Network Level Authentication is in use.
+const rdpProtocolCredSSP = 0x02
+
+// remoteSession is one observed remote-access session.
+type remoteSession struct {
+	protocol  string // rdp, vnc, teamviewer, anydesk
+	srcIP     string
+	dstIP     string
+	dstPort   uint16
+	first     time.Time
+	last      time.Time
+	nlaKnown  bool
+	nlaActive bool
+}
+
+// remoteAccessTracker spots RDP, VNC, TeamViewer and AnyDesk sessions,
+// including whether RDP negotiated NLA, so remote-control activity in a
+// capture is explicit instead of buried in the flow list.
+type remoteAccessTracker struct {
+	sessions map[string]*remoteSession
+}
+
+func newRemoteAccessTracker() *remoteAccessTracker {
+	return &remoteAccessTracker{sessions: make(map[string]*remoteSession)}
+}
+
+// processTCP inspects one TCP packet in either direction.
+func (rt *remoteAccessTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	proto, client, server, serverPort := classifyRemoteAccess(srcIP, dstIP, srcPort, dstPort, payload)
+	if proto == "" {
+		return
+	}
+	key := proto + "|" + client + "|" + server
+	s, ok := rt.sessions[key]
+	if !ok {
+		s = &remoteSession{
+			protocol: proto,
+			srcIP:    client,
+			dstIP:    server,
+			dstPort:  serverPort,
+			first:    ts,
+		}
+		rt.sessions[key] = s
+	}
+	s.last = ts
+
+	// NLA state comes from the server's RDP negotiation response.
+	if proto == "rdp" && srcIP == server && !s.nlaKnown {
+		if selected, ok := parseRDPNegotiationResponse(payload); ok {
+			s.nlaKnown = true
+			s.nlaActive = selected&rdpProtocolCredSSP != 0
+		}
+	}
+}
+
+// classifyRemoteAccess maps a packet to a remote-access protocol and
+// decides which endpoint is the client. An empty protocol means the
+// packet is not remote-access traffic.
+func classifyRemoteAccess(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte) (proto, client, server string, serverPort uint16) {
+	switch {
+	case dstPort == rdpPort:
+		return "rdp", srcIP, dstIP, dstPort
+	case srcPort == rdpPort:
+		return "rdp", dstIP, srcIP, srcPort
+	case dstPort >= 5900 && dstPort <= 5909:
+		return "vnc", srcIP, dstIP, dstPort
+	case srcPort >= 5900 && srcPort <= 5909:
+		return "vnc", dstIP, srcIP, srcPort
+	case dstPort == teamviewerPort:
+		return "teamviewer", srcIP, dstIP, dstPort
+	case srcPort == teamviewerPort:
+		return "teamviewer", dstIP, srcIP, srcPort
+	case dstPort == anydeskPort:
+		return "anydesk", srcIP, dstIP, dstPort
+	case srcPort == anydeskPort:
+		return "anydesk", dstIP, srcIP, srcPort
+	case bytes.HasPrefix(payload, []byte("RFB ")):
+		// VNC on a non-standard port; the banner comes from the server.
+		return "vnc", dstIP, srcIP, srcPort
+	}
+	return "", "", "", 0
+}
+
+// parseRDPNegotiationResponse extracts the selected protocol from a
+// TPKT-framed X.224 Connection Confirm carrying an RDP_NEG_RSP.
+func parseRDPNegotiationResponse(payload []byte) (uint32, bool) {
+	// TPKT(4) + X.224 CC(7) + RDP_NEG_RSP{type, flags, length(2), selectedProtocol(4)}.
+	if len(payload) < 19 || payload[0] != 0x03 || payload[5]&0xf0 != 0xd0 {
+		return 0, false
+	}
+	if payload[11] != 0x02 { // TYPE_RDP_NEG_RSP
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(payload[15:19]), true
+}
+
+// findings reports each remote-access session, escalating sessions that
+// originate from public IPs unless REMOTE_ACCESS_FLAG_PUBLIC=0.
+func (rt *remoteAccessTracker) findings() []models.Finding {
+	flagPublic := os.Getenv("REMOTE_ACCESS_FLAG_PUBLIC") != "0"
+
+	keys := make([]string, 0, len(rt.sessions))
+	for k := range rt.sessions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []models.Finding
+	for _, k := range keys {
+		s := rt.sessions[k]
+		duration := s.last.Sub(s.first).Round(time.Second)
+		desc := fmt.Sprintf("%s session from %s to %s:%d lasting %s.",
+			strings.ToUpper(s.protocol), s.srcIP, s.dstIP, s.dstPort, duration)
+		if s.protocol == "rdp" {
+			switch {
+			case !s.nlaKnown:
+				desc += " NLA status unknown."
+			case s.nlaActive:
+				desc += " Network Level Authentication is enabled."
+			default:
+				desc += " Network Level Authentication is NOT enabled."
+			}
+		}
+
+		severity := "info"
+		title := fmt.Sprintf("%s session from %s to %s", strings.ToUpper(s.protocol), s.srcIP, s.dstIP)
+		if src := net.ParseIP(s.srcIP); flagPublic && src != nil && isExternalIP(src) {
+			severity = "high"
+			title = fmt.Sprintf("%s session from public IP %s to %s", strings.ToUpper(s.protocol), s.srcIP, s.dstIP)
+			desc += " The client address is publicly routable, which most remote-access policies prohibit."
+		}
+
+		out = append(out, models.Finding{
+			Severity:    severity,
+			Type:        "remote-desktop",
+			Title:       title,
+			Description: desc,
+			SrcIP:       s.srcIP,
+			DstIP:       s.dstIP,
+			Timestamp:   s.first,
+		})
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoteAccessTrackerRDPWithNLA(t *testing.T) {
+	rt := newRemoteAccessTracker()
+	start := time.Now()
+
+	// Client connection request, then server negotiation response
+	// selecting CredSSP (NLA).
+	rt.processTCP("10.0.0.5", "10.0.0.9", 50000, rdpPort, nil, start)
+	rsp := []byte{
+		0x03, 0x00, 0x00, 0x13, // TPKT
+		0x0e, 0xd0, 0x00, 0x00, 0x12, 0x34, 0x00, // X.224 CC
+		0x02, 0x00, 0x08, 0x00, // RDP_NEG_RSP header
+		0x02, 0x00, 0x00, 0x00, // selectedProtocol = CredSSP
+	}
+	rt.processTCP("10.0.0.9", "10.0.0.5", rdpPort, 50000, rsp, start.Add(90*time.Second))
+
+	findings := rt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != "remote-desktop" || f.Severity != "info" {
+		t.Errorf("finding = %s/%s, want remote-desktop/info", f.Type, f.Severity)
+	}
+	if !strings.Contains(f.Description, "Network Level Authentication is enabled") {
+		t.Errorf("NLA state missing from %q", f.Description)
+	}
+	if !strings.Contains(f.Description, "1m30s") {
+		t.Errorf("duration missing from %q", f.Description)
+	}
+}
+
+func TestRemoteAccessTrackerFlagsPublicSource(t *testing.T) {
+	rt := newRemoteAccessTracker()
+	rt.processTCP("203.0.113.7", "192.168.1.20", 40000, 5900, nil, time.Now())
+
+	findings := rt.findings()
+	if len(findings) != 1 || findings[0].Severity != "high" {
+		t.Fatalf("public-source VNC should be high severity: %+v", findings)
+	}
+}
+
+func TestClassifyRemoteAccessByBanner(t *testing.T) {
+	proto, client, server, _ := classifyRemoteAccess("10.0.0.9", "10.0.0.5", 6000, 40000, []byte("RFB 003.008\n"))
+	if proto != "vnc" || server != "10.0.0.9" || client != "10.0.0.5" {
+		t.Errorf("banner classification = %s client=%s server=%s", proto, client, server)
+	}
+}
@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Reputation lookups are optional: they only run when REPUTATION_API_KEY
+// is set, against an AbuseIPDB-compatible endpoint (REPUTATION_API_URL).
+// Results are cached on disk (REPUTATION_CACHE, REPUTATION_TTL_HOURS) and
+// requests are rate limited (REPUTATION_RATE_MS between calls). The rate
+// limiter skips rather than sleeps: IPs beyond the budget stay unscored
+// this run and are filled in from the growing cache on later runs, so an
+// analysis never stalls on the feed.
+const (
+	defaultReputationURL    = "https://api.abuseipdb.com/api/v2/check"
+	defaultReputationTTL    = 168 * time.Hour
+	defaultReputationGap    = 1500 * time.Millisecond
+	reputationHTTPTimeout   = 5 * time.Second
+	reputationCacheFilename = "pcap-analyzer-reputation.json"
+)
+
+// repEntry is one cached verdict.
+type repEntry struct {
+	Score   int       `json:"score"`
+	Checked time.Time `json:"checked"`
+}
+
+// reputationClient scores public IPs against a threat-intelligence feed,
+// backed by an on-disk cache.
+type reputationClient struct {
+	apiKey    string
+	url       string
+	cachePath string
+	ttl       time.Duration
+	gap       time.Duration
+	client    *http.Client
+
+	mu          sync.Mutex
+	entries     map[string]repEntry
+	nextAllowed time.Time
+}
+
+var (
+	repOnce   sync.Once
+	repShared *reputationClient
+)
+
+// reputationData returns the process-wide reputation client, configured
+// from the environment on first use.
+func reputationData() *reputationClient {
+	repOnce.Do(func() {
+		cachePath := os.Getenv("REPUTATION_CACHE")
+		if cachePath == "" {
+			cachePath = filepath.Join(os.TempDir(), reputationCacheFilename)
+		}
+		ttl := defaultReputationTTL
+		if v := os.Getenv("REPUTATION_TTL_HOURS"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				ttl = time.Duration(n) * time.Hour
+			}
+		}
+		gap := defaultReputationGap
+		if v := os.Getenv("REPUTATION_RATE_MS"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				gap = time.Duration(n) * time.Millisecond
+			}
+		}
+		url := os.Getenv("REPUTATION_API_URL")
+		if url == "" {
+			url = defaultReputationURL
+		}
+		repShared = newReputationClient(os.Getenv("REPUTATION_API_KEY"), url, cachePath, ttl, gap)
+	})
+	return repShared
+}
+
+func newReputationClient(apiKey, endpoint, cachePath string, ttl, gap time.Duration) *reputationClient {
+	rc := &reputationClient{
+		apiKey:    apiKey,
+		url:       endpoint,
+		cachePath: cachePath,
+		ttl:       ttl,
+		gap:       gap,
+		client:    &http.Client{Timeout: reputationHTTPTimeout},
+		entries:   make(map[string]repEntry),
+	}
+	rc.loadCache()
+	return rc
+}
+
+// enabled reports whether lookups are configured at all.
+func (rc *reputationClient) enabled() bool {
+	return rc.apiKey != ""
+}
+
+// Lookup returns the abuse confidence score (0-100) for ip. ok is false
+// when lookups are disabled, the cache misses and the rate budget is
+// spent, or the feed errors.
+func (rc *reputationClient) Lookup(ip string) (int, bool) {
+	if !rc.enabled() {
+		return 0, false
+	}
+
+	rc.mu.Lock()
+	if e, hit := rc.entries[ip]; hit && time.Since(e.Checked) < rc.ttl {
+		rc.mu.Unlock()
+		return e.Score, true
+	}
+	now := time.Now()
+	if now.Before(rc.nextAllowed) {
+		rc.mu.Unlock()
+		return 0, false
+	}
+	rc.nextAllowed = now.Add(rc.gap)
+	rc.mu.Unlock()
+
+	score, err := rc.fetch(ip)
+	if err != nil {
+		slog.Warn("reputation lookup", "ip", ip, "err", err)
+		return 0, false
+	}
+
+	rc.mu.Lock()
+	rc.entries[ip] = repEntry{Score: score, Checked: time.Now()}
+	rc.saveCacheLocked()
+	rc.mu.Unlock()
+	return score, true
+}
+
+// fetch asks the feed about one IP.
+func (rc *reputationClient) fetch(ip string) (int, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		rc.url+"?ipAddress="+url.QueryEscape(ip), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Key", rc.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, &url.Error{Op: "Get", URL: rc.url, Err: errStatus(resp.StatusCode)}
+	}
+
+	var body struct {
+		Data struct {
+			AbuseConfidenceScore int `json:"abuseConfidenceScore"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, err
+	}
+	return body.Data.AbuseConfidenceScore, nil
+}
+
+// errStatus turns an HTTP status into an error value.
+type errStatus int
+
+func (e errStatus) Error() string { return "unexpected status " + strconv.Itoa(int(e)) }
+
+// loadCache reads the on-disk cache; a missing or corrupt file simply
+// starts empty.
+func (rc *reputationClient) loadCache() {
+	raw, err := os.ReadFile(rc.cachePath)
+	if err != nil {
+		return
+	}
+	var entries map[string]repEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return
+	}
+	rc.entries = entries
+}
+
+// saveCacheLocked persists the cache. Callers hold rc.mu.
+func (rc *reputationClient) saveCacheLocked() {
+	raw, err := json.Marshal(rc.entries)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(rc.cachePath, raw, 0o600); err != nil {
+		slog.Warn("write reputation cache", "path", rc.cachePath, "err", err)
+	}
+}
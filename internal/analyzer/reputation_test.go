@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func reputationServer(score int, calls *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprintf(w, `{"data":{"abuseConfidenceScore":%d}}`, score)
+	}))
+}
+
+func TestReputationDisabledWithoutKey(t *testing.T) {
+	rc := newReputationClient("", defaultReputationURL,
+		filepath.Join(t.TempDir(), "rep.json"), time.Hour, 0)
+	if _, ok := rc.Lookup("203.0.113.9"); ok {
+		t.Error("Lookup succeeded with no API key configured")
+	}
+}
+
+func TestReputationCachesLookups(t *testing.T) {
+	var calls atomic.Int64
+	srv := reputationServer(72, &calls)
+	defer srv.Close()
+
+	rc := newReputationClient("key", srv.URL,
+		filepath.Join(t.TempDir(), "rep.json"), time.Hour, 0)
+	for i := 0; i < 3; i++ {
+		score, ok := rc.Lookup("203.0.113.9")
+		if !ok || score != 72 {
+			t.Fatalf("Lookup = %d, %v, want 72, true", score, ok)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("feed requests = %d, want 1 (cache should absorb repeats)", calls.Load())
+	}
+}
+
+func TestReputationCachePersistsAcrossClients(t *testing.T) {
+	var calls atomic.Int64
+	srv := reputationServer(40, &calls)
+	defer srv.Close()
+	cache := filepath.Join(t.TempDir(), "rep.json")
+
+	first := newReputationClient("key", srv.URL, cache, time.Hour, 0)
+	if _, ok := first.Lookup("198.51.100.4"); !ok {
+		t.Fatal("initial lookup failed")
+	}
+
+	second := newReputationClient("key", srv.URL, cache, time.Hour, 0)
+	score, ok := second.Lookup("198.51.100.4")
+	if !ok || score != 40 {
+		t.Fatalf("Lookup from fresh client = %d, %v, want 40, true", score, ok)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("feed requests = %d, want 1 (second client should read the disk cache)", calls.Load())
+	}
+}
+
+func TestReputationExpiredEntryRefetched(t *testing.T) {
+	var calls atomic.Int64
+	srv := reputationServer(15, &calls)
+	defer srv.Close()
+
+	rc := newReputationClient("key", srv.URL,
+		filepath.Join(t.TempDir(), "rep.json"), time.Hour, 0)
+	rc.Lookup("192.0.2.7")
+	rc.mu.Lock()
+	e := rc.entries["192.0.2.7"]
+	e.Checked = time.Now().Add(-2 * time.Hour)
+	rc.entries["192.0.2.7"] = e
+	rc.mu.Unlock()
+
+	if _, ok := rc.Lookup("192.0.2.7"); !ok {
+		t.Fatal("lookup after expiry failed")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("feed requests = %d, want 2 (expired entry should refetch)", calls.Load())
+	}
+}
+
+func TestReputationRateLimitSkips(t *testing.T) {
+	var calls atomic.Int64
+	srv := reputationServer(5, &calls)
+	defer srv.Close()
+
+	rc := newReputationClient("key", srv.URL,
+		filepath.Join(t.TempDir(), "rep.json"), time.Hour, time.Minute)
+	if _, ok := rc.Lookup("203.0.113.1"); !ok {
+		t.Fatal("first lookup failed")
+	}
+	// The second distinct IP lands inside the gap: it must skip, not sleep.
+	if _, ok := rc.Lookup("203.0.113.2"); ok {
+		t.Error("second lookup inside the rate gap succeeded, want skip")
+	}
+	// The already-cached IP is still served.
+	if score, ok := rc.Lookup("203.0.113.1"); !ok || score != 5 {
+		t.Errorf("cached lookup = %d, %v, want 5, true", score, ok)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("feed requests = %d, want 1", calls.Load())
+	}
+}
+
+func TestReputationFeedErrorUnscored(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	rc := newReputationClient("key", srv.URL,
+		filepath.Join(t.TempDir(), "rep.json"), time.Hour, 0)
+	if _, ok := rc.Lookup("203.0.113.9"); ok {
+		t.Error("Lookup succeeded against an erroring feed")
+	}
+}
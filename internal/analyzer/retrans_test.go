@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func retransKey() flowKey {
+	return flowKey{"tcp", "10.0.0.1", 40000, "10.0.1.1", 443}
+}
+
+func dataFlags(seq uint32, payloadLen int) *tcpFlags {
+	return &tcpFlags{ack: true, seq: seq, payloadLen: payloadLen}
+}
+
+func TestFlowTableDeduplicatesRetransmissions(t *testing.T) {
+	ft := newFlowTable()
+	key := retransKey()
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	ft.record(key, ts, 154, dataFlags(1000, 100))
+	ft.record(key, ts.Add(time.Second), 154, dataFlags(1000, 100)) // retransmit
+	ft.record(key, ts.Add(2*time.Second), 154, dataFlags(1100, 100))
+
+	stat := ft.active[key]
+	if stat.bytesSent != 308 || stat.packetsSent != 2 {
+		t.Errorf("sent = %d bytes/%d packets, want 308/2", stat.bytesSent, stat.packetsSent)
+	}
+	if stat.retransBytes != 154 || stat.retransPackets != 1 {
+		t.Errorf("retrans = %d bytes/%d packets, want 154/1", stat.retransBytes, stat.retransPackets)
+	}
+	if !stat.lastSeen.Equal(ts.Add(2 * time.Second)) {
+		t.Errorf("lastSeen = %v, want the latest packet time", stat.lastSeen)
+	}
+}
+
+func TestFlowTableRetransDirectionsIndependent(t *testing.T) {
+	ft := newFlowTable()
+	key := retransKey()
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// Both directions happen to use the same sequence range; neither is a
+	// retransmission of the other.
+	ft.record(key, ts, 154, dataFlags(1000, 100))
+	ft.record(key.reversed(), ts.Add(time.Second), 154, dataFlags(1000, 100))
+
+	stat := ft.active[key]
+	if stat.retransPackets != 0 {
+		t.Errorf("retrans packets = %d, want 0 across directions", stat.retransPackets)
+	}
+	if stat.bytesSent != 154 || stat.bytesRecv != 154 {
+		t.Errorf("bytes sent/recv = %d/%d, want 154/154", stat.bytesSent, stat.bytesRecv)
+	}
+}
+
+func TestFlowTableRetransPartialOverlapIsNewData(t *testing.T) {
+	ft := newFlowTable()
+	key := retransKey()
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	ft.record(key, ts, 154, dataFlags(1000, 100))
+	// Overlaps the first segment but extends past it: carries new bytes.
+	ft.record(key, ts.Add(time.Second), 254, dataFlags(1050, 200))
+
+	stat := ft.active[key]
+	if stat.retransPackets != 0 {
+		t.Errorf("retrans packets = %d, want 0 for an extending overlap", stat.retransPackets)
+	}
+	if stat.packetsSent != 2 {
+		t.Errorf("packets sent = %d, want 2", stat.packetsSent)
+	}
+}
+
+func TestFlowTableRetransSequenceWraparound(t *testing.T) {
+	ft := newFlowTable()
+	key := retransKey()
+	ts := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// A segment straddling the 2^32 boundary, then new data past the wrap.
+	ft.record(key, ts, 154, dataFlags(0xFFFFFFB0, 100))
+	ft.record(key, ts.Add(time.Second), 154, dataFlags(20, 100))
+
+	stat := ft.active[key]
+	if stat.retransPackets != 0 {
+		t.Errorf("retrans packets = %d, want 0 across the wrap", stat.retransPackets)
+	}
+	// And a retransmission of the wrapped segment is still recognized.
+	ft.record(key, ts.Add(2*time.Second), 154, dataFlags(20, 100))
+	if stat.retransPackets != 1 {
+		t.Errorf("retrans packets = %d, want 1 after re-sending", stat.retransPackets)
+	}
+}
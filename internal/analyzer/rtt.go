@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// latencyTracker measures TCP handshake round-trips — SYN to SYN-ACK as
+// seen at the capture point — and buckets the samples per subnet pair,
+// so slow links show up as a latency matrix instead of anecdotes.
+type latencyTracker struct {
+	pending map[string]time.Time // flow -> first SYN
+	pairs   map[string][]float64 // "clientSubnet|serverSubnet" -> RTT samples in ms
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		pending: make(map[string]time.Time),
+		pairs:   make(map[string][]float64),
+	}
+}
+
+// processTCP watches the handshake. The first SYN of a flow starts the
+// clock; the SYN-ACK stops it, so a retransmitted SYN counts the delay
+// the client actually experienced.
+func (lt *latencyTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, flags *tcpFlags, ts time.Time) {
+	if flags == nil || !flags.syn {
+		return
+	}
+	flow := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	if !flags.ack {
+		if _, ok := lt.pending[flow]; !ok {
+			lt.pending[flow] = ts
+		}
+		return
+	}
+	// SYN-ACK: the sender is the server, the destination the client.
+	t0, ok := lt.pending[flow]
+	if !ok {
+		return
+	}
+	delete(lt.pending, flow)
+	rtt := ts.Sub(t0)
+	if rtt < 0 {
+		return
+	}
+	client, server := subnetOf(dstIP), subnetOf(srcIP)
+	if client == "" || server == "" {
+		return
+	}
+	key := client + "|" + server
+	lt.pairs[key] = append(lt.pairs[key], float64(rtt)/float64(time.Millisecond))
+}
+
+// rows renders the latency matrix, one directed subnet pair per row.
+func (lt *latencyTracker) rows() []models.SubnetLatency {
+	var out []models.SubnetLatency
+	for key, samples := range lt.pairs {
+		sort.Float64s(samples)
+		src, dst, _ := strings.Cut(key, "|")
+		out = append(out, models.SubnetLatency{
+			SrcSubnet: src,
+			DstSubnet: dst,
+			Samples:   int64(len(samples)),
+			MedianMs:  percentile(samples, 0.5),
+			P95Ms:     percentile(samples, 0.95),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SrcSubnet != out[j].SrcSubnet {
+			return out[i].SrcSubnet < out[j].SrcSubnet
+		}
+		return out[i].DstSubnet < out[j].DstSubnet
+	})
+	return out
+}
+
+// subnetOf buckets an address into its /24 (IPv4) or /64 (IPv6) subnet.
+func subnetOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return subnet24(ip)
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String() + "/64"
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTrackerMeasuresHandshakes(t *testing.T) {
+	lt := newLatencyTracker()
+	base := time.Now()
+
+	// Two handshakes from 10.0.1.x to 10.0.2.x, 10ms and 30ms apart.
+	lt.processTCP("10.0.1.5", "10.0.2.9", 40000, 443, &tcpFlags{syn: true}, base)
+	lt.processTCP("10.0.2.9", "10.0.1.5", 443, 40000, &tcpFlags{syn: true, ack: true}, base.Add(10*time.Millisecond))
+	lt.processTCP("10.0.1.6", "10.0.2.9", 40001, 443, &tcpFlags{syn: true}, base)
+	lt.processTCP("10.0.2.9", "10.0.1.6", 443, 40001, &tcpFlags{syn: true, ack: true}, base.Add(30*time.Millisecond))
+
+	rows := lt.rows()
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1: %+v", len(rows), rows)
+	}
+	r := rows[0]
+	if r.SrcSubnet != "10.0.1.0/24" || r.DstSubnet != "10.0.2.0/24" {
+		t.Errorf("pair = %s -> %s, want 10.0.1.0/24 -> 10.0.2.0/24", r.SrcSubnet, r.DstSubnet)
+	}
+	if r.Samples != 2 {
+		t.Errorf("samples = %d, want 2", r.Samples)
+	}
+	if r.MedianMs < 9 || r.MedianMs > 31 {
+		t.Errorf("median = %.1fms, want within the sample range", r.MedianMs)
+	}
+}
+
+func TestLatencyTrackerIgnoresUnsolicitedSynAck(t *testing.T) {
+	lt := newLatencyTracker()
+	lt.processTCP("10.0.2.9", "10.0.1.5", 443, 40000, &tcpFlags{syn: true, ack: true}, time.Now())
+	if rows := lt.rows(); len(rows) != 0 {
+		t.Fatalf("rows = %+v, want none without a captured SYN", rows)
+	}
+}
+
+func TestSubnetOf(t *testing.T) {
+	cases := []struct{ ip, want string }{
+		{"10.1.2.3", "10.1.2.0/24"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"not-an-ip", ""},
+	}
+	for _, c := range cases {
+		if got := subnetOf(c.ip); got != c.want {
+			t.Errorf("subnetOf(%q) = %q, want %q", c.ip, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// sensitivePattern is one cleartext data pattern worth flagging.
+type sensitivePattern struct {
+	name     string
+	severity string
+	re       *regexp.Regexp
+}
+
+// defaultSensitivePatterns cover the classics; extra patterns can be
+// supplied via SENSITIVE_PATTERNS as "name<TAB>severity<TAB>regex" lines.
+var defaultSensitivePatterns = []sensitivePattern{
+	{"credit-card", "high", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+	{"ssn", "high", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"aws-access-key", "high", regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)},
+	{"private-key", "critical", regexp.MustCompile(`-----BEGIN (?:RSA |EC |OPENSSH )?PRIVATE KEY-----`)},
+	{"basic-auth", "medium", regexp.MustCompile(`Authorization: Basic [A-Za-z0-9+/=]{8,}`)},
+}
+
+// sensitiveMatch aggregates sightings of one pattern between two hosts.
+type sensitiveMatch struct {
+	pattern  sensitivePattern
+	srcIP    string
+	dstIP    string
+	sample   string // already masked
+	count    int64
+	firstTS  time.Time
+	protocol string
+	dstPort  uint16
+}
+
+// sensitiveScanner looks for sensitive data crossing the wire in
+// cleartext. TLS payloads are skipped by the caller, so a hit means the
+// data really was readable on the network.
+type sensitiveScanner struct {
+	patterns []sensitivePattern
+	matches  map[string]*sensitiveMatch
+}
+
+func newSensitiveScanner() *sensitiveScanner {
+	return &sensitiveScanner{
+		patterns: loadSensitivePatterns(),
+		matches:  make(map[string]*sensitiveMatch),
+	}
+}
+
+// loadSensitivePatterns merges SENSITIVE_PATTERNS entries over the
+// defaults; bad lines are logged and skipped.
+func loadSensitivePatterns() []sensitivePattern {
+	patterns := defaultSensitivePatterns
+	path := os.Getenv("SENSITIVE_PATTERNS")
+	if path == "" {
+		return patterns
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		slog.Warn("load sensitive patterns", "path", path, "err", err)
+		return patterns
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			slog.Warn("sensitive pattern line needs name<TAB>severity<TAB>regex", "line", line)
+			continue
+		}
+		re, err := regexp.Compile(parts[2])
+		if err != nil {
+			slog.Warn("sensitive pattern regex", "name", parts[0], "err", err)
+			continue
+		}
+		patterns = append(patterns, sensitivePattern{name: parts[0], severity: parts[1], re: re})
+	}
+	return patterns
+}
+
+// scan checks one cleartext payload for every configured pattern.
+func (ss *sensitiveScanner) scan(srcIP, dstIP, protocol string, dstPort uint16, payload []byte, ts time.Time) {
+	for _, p := range ss.patterns {
+		hit := p.re.Find(payload)
+		if hit == nil {
+			continue
+		}
+		if p.name == "credit-card" && !luhnValid(string(hit)) {
+			continue
+		}
+		key := p.name + "|" + srcIP + "|" + dstIP
+		m, ok := ss.matches[key]
+		if !ok {
+			m = &sensitiveMatch{
+				pattern:  p,
+				srcIP:    srcIP,
+				dstIP:    dstIP,
+				sample:   maskSensitive(string(hit)),
+				firstTS:  ts,
+				protocol: protocol,
+				dstPort:  dstPort,
+			}
+			ss.matches[key] = m
+		}
+		m.count++
+	}
+}
+
+// luhnValid runs the Luhn checksum over the digits of s, weeding out
+// random numbers that merely look like card numbers.
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum, double := 0, false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// maskSensitive hides all but the last four characters of a match so the
+// stored finding never contains the sensitive value itself.
+func maskSensitive(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+}
+
+// findings reports one finding per pattern and host pair.
+func (ss *sensitiveScanner) findings() []models.Finding {
+	keys := make([]string, 0, len(ss.matches))
+	for k := range ss.matches {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []models.Finding
+	for _, k := range keys {
+		m := ss.matches[k]
+		out = append(out, models.Finding{
+			Severity: m.pattern.severity,
+			Type:     "cleartext-sensitive-data",
+			Title:    fmt.Sprintf("Cleartext %s data from %s to %s", m.pattern.name, m.srcIP, m.dstIP),
+			Description: fmt.Sprintf(
+				"A %s pattern matched %d time(s) in unencrypted %s traffic to port %d (sample: %s).",
+				m.pattern.name, m.count, m.protocol, m.dstPort, m.sample),
+			SrcIP:     m.srcIP,
+			DstIP:     m.dstIP,
+			Timestamp: m.firstTS,
+		})
+	}
+	return out
+}
+
+// looksLikeTLS reports whether a payload starts with a TLS record, so
+// encrypted traffic is not scanned for cleartext patterns.
+func looksLikeTLS(payload []byte) bool {
+	return len(payload) >= 3 && payload[0] >= 20 && payload[0] <= 23 && payload[1] == 3
+}
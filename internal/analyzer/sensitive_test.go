@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLuhnValid(t *testing.T) {
+	if !luhnValid("4111 1111 1111 1111") {
+		t.Error("4111... is a valid test card number")
+	}
+	if luhnValid("4111 1111 1111 1112") {
+		t.Error("checksum failure should be rejected")
+	}
+	if luhnValid("123") {
+		t.Error("too few digits should be rejected")
+	}
+}
+
+func TestMaskSensitive(t *testing.T) {
+	if got := maskSensitive("4111111111111111"); !strings.HasSuffix(got, "1111") || strings.Count(got, "*") != 12 {
+		t.Errorf("maskSensitive = %q, want 12 stars and last four digits", got)
+	}
+	if got := maskSensitive("abc"); got != "***" {
+		t.Errorf("short values should be fully masked, got %q", got)
+	}
+}
+
+func TestSensitiveScannerMasksFindings(t *testing.T) {
+	ss := newSensitiveScanner()
+	payload := []byte("POST /pay HTTP/1.1\r\n\r\ncard=4111 1111 1111 1111&cvv=123")
+	ss.scan("10.0.0.5", "203.0.113.9", "tcp", 80, payload, time.Now())
+	ss.scan("10.0.0.5", "203.0.113.9", "tcp", 80, payload, time.Now())
+
+	findings := ss.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	f := findings[0]
+	if f.Type != "cleartext-sensitive-data" || f.Severity != "high" {
+		t.Errorf("finding = %s/%s", f.Type, f.Severity)
+	}
+	if strings.Contains(f.Description, "4111 1111 1111 1111") {
+		t.Error("finding leaked the unmasked card number")
+	}
+	if !strings.Contains(f.Description, "2 time(s)") {
+		t.Errorf("expected aggregated count in %q", f.Description)
+	}
+}
+
+func TestLooksLikeTLS(t *testing.T) {
+	if !looksLikeTLS([]byte{22, 3, 1, 0, 5}) {
+		t.Error("handshake record should look like TLS")
+	}
+	if looksLikeTLS([]byte("GET / HTTP/1.1")) {
+		t.Error("HTTP should not look like TLS")
+	}
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+// wellKnownTCP maps destination ports to service names for TCP flows.
+var wellKnownTCP = map[uint16]string{
+	20:   "ftp-data",
+	21:   "ftp",
+	22:   "ssh",
+	23:   "telnet",
+	25:   "smtp",
+	53:   "dns",
+	80:   "http",
+	110:  "pop3",
+	135:  "msrpc",
+	139:  "netbios",
+	143:  "imap",
+	389:  "ldap",
+	443:  "https",
+	445:  "smb",
+	465:  "smtps",
+	587:  "submission",
+	636:  "ldaps",
+	993:  "imaps",
+	995:  "pop3s",
+	1433: "mssql",
+	3306: "mysql",
+	3389: "rdp",
+	3868: "diameter",
+	5432: "postgres",
+	5900: "vnc",
+	6379: "redis",
+	8080: "http-alt",
+	8443: "https-alt",
+}
+
+// wellKnownUDP maps destination ports to service names for UDP flows.
+var wellKnownUDP = map[uint16]string{
+	53:   "dns",
+	67:   "dhcp",
+	68:   "dhcp",
+	69:   "tftp",
+	123:  "ntp",
+	137:  "netbios-ns",
+	138:  "netbios-dgm",
+	161:  "snmp",
+	500:  "ike",
+	514:  "syslog",
+	1900: "ssdp",
+	2123: "gtp-c",
+	2152: "gtp-u",
+	4500: "ipsec-nat",
+	5353: "mdns",
+}
+
+// ServiceForPort returns a friendly service name for a flow's destination
+// port, or "" when the port is not well known.
+func ServiceForPort(protocol string, dstPort uint16) string {
+	switch protocol {
+	case "tcp":
+		return wellKnownTCP[dstPort]
+	case "udp":
+		return wellKnownUDP[dstPort]
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// memSink captures everything pushed through it, standing in for the
+// database layer in tests.
+type memSink struct {
+	assets      []models.Asset
+	conns       []models.Connection
+	queries     []models.DNSQuery
+	bindings    []models.IPMACBinding
+	agents      []models.UserAgent
+	services    []models.AssetService
+	broadcast   []models.BroadcastRow
+	dnsServers  []models.DNSServerHealth
+	multicast   []models.MulticastMembership
+	latency     []models.SubnetLatency
+	protocols   []models.ProtocolCount
+	accounts    []models.AccountUsage
+	durations   []models.ServiceDuration
+	findings    []models.Finding
+	packetCount int64
+	verdict     string
+	partial     bool
+	start, end  time.Time
+	failSaves   bool
+}
+
+func (m *memSink) SaveAssets(_ context.Context, _ int64, assets []models.Asset) error {
+	m.assets = assets
+	return nil
+}
+
+func (m *memSink) SaveConnections(_ context.Context, _ int64, conns []models.Connection) error {
+	if m.failSaves {
+		return errors.New("boom")
+	}
+	m.conns = conns
+	return nil
+}
+
+func (m *memSink) SaveDNSQueries(_ context.Context, _ int64, queries []models.DNSQuery) error {
+	m.queries = queries
+	return nil
+}
+
+func (m *memSink) SaveIPMACBindings(_ context.Context, _ int64, bindings []models.IPMACBinding) error {
+	m.bindings = bindings
+	return nil
+}
+
+func (m *memSink) SaveUserAgents(_ context.Context, _ int64, agents []models.UserAgent) error {
+	m.agents = agents
+	return nil
+}
+
+func (m *memSink) SaveFindings(_ context.Context, _ int64, findings []models.Finding) error {
+	m.findings = findings
+	return nil
+}
+
+func (m *memSink) SaveAssetServices(_ context.Context, _ int64, services []models.AssetService) error {
+	m.services = services
+	return nil
+}
+
+func (m *memSink) SaveBroadcastSummary(_ context.Context, _ int64, rows []models.BroadcastRow) error {
+	m.broadcast = rows
+	return nil
+}
+
+func (m *memSink) SaveDNSServerHealth(_ context.Context, _ int64, rows []models.DNSServerHealth) error {
+	m.dnsServers = rows
+	return nil
+}
+
+func (m *memSink) SaveMulticastGroups(_ context.Context, _ int64, rows []models.MulticastMembership) error {
+	m.multicast = rows
+	return nil
+}
+
+func (m *memSink) SaveSubnetLatency(_ context.Context, _ int64, rows []models.SubnetLatency) error {
+	m.latency = rows
+	return nil
+}
+
+func (m *memSink) SaveProtocolHierarchy(_ context.Context, _ int64, rows []models.ProtocolCount) error {
+	m.protocols = rows
+	return nil
+}
+
+func (m *memSink) SaveAccountUsage(_ context.Context, _ int64, rows []models.AccountUsage) error {
+	m.accounts = rows
+	return nil
+}
+
+func (m *memSink) SaveServiceDurations(_ context.Context, _ int64, rows []models.ServiceDuration) error {
+	m.durations = rows
+	return nil
+}
+
+func (m *memSink) SetAnalysisVerdict(_ context.Context, _ int64, verdict string) error {
+	m.verdict = verdict
+	return nil
+}
+
+func (m *memSink) SetAnalysisPartial(_ context.Context, _ int64, partial bool) error {
+	m.partial = partial
+	return nil
+}
+
+func (m *memSink) SetAnalysisTimeframe(_ context.Context, _ int64, start, end time.Time) error {
+	m.start, m.end = start, end
+	return nil
+}
+
+func (m *memSink) SetAnalysisPacketCount(_ context.Context, _, count int64) error {
+	m.packetCount = count
+	return nil
+}
+
+func TestSaveResultsForwardsToSink(t *testing.T) {
+	res := &Results{
+		PacketCount: 42,
+		Assets:      []models.Asset{{IP: "10.0.0.1"}},
+		Connections: []models.Connection{{Protocol: "tcp", SrcIP: "10.0.0.1", DstIP: "10.0.0.2"}},
+		DNSQueries:  []models.DNSQuery{{Query: "example.com", Timestamp: time.Now()}},
+		Findings:    []models.Finding{{Severity: "info", Type: "test"}},
+	}
+	sink := &memSink{}
+	if err := saveResults(context.Background(), sink, 7, res); err != nil {
+		t.Fatalf("saveResults: %v", err)
+	}
+	if len(sink.assets) != 1 || len(sink.conns) != 1 || len(sink.queries) != 1 || len(sink.findings) != 1 {
+		t.Errorf("sink received assets=%d conns=%d queries=%d findings=%d, want 1 each",
+			len(sink.assets), len(sink.conns), len(sink.queries), len(sink.findings))
+	}
+	if sink.packetCount != 42 {
+		t.Errorf("packet count = %d, want 42", sink.packetCount)
+	}
+}
+
+func TestSaveResultsPropagatesErrors(t *testing.T) {
+	sink := &memSink{failSaves: true}
+	if err := saveResults(context.Background(), sink, 7, &Results{}); err == nil {
+		t.Fatal("expected error from failing sink")
+	}
+}
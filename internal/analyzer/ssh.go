@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+const (
+	sshPort = 22
+
+	// sshBulkBytes is the transfer volume in one direction above which a
+	// session is reported as a bulk transfer (scp/sftp) rather than an
+	// interactive shell.
+	sshBulkBytes = 1 << 20
+
+	// sshTypingMaxPayload is the largest encrypted packet that still
+	// looks like a keystroke, and sshTypingMinGap the smallest gap
+	// between two packets typed by a human.
+	sshTypingMaxPayload = 64
+	sshTypingMinGap     = 50 * time.Millisecond
+
+	// sshTypingMinEvents is how many keystroke-spaced packets a session
+	// needs before it is called interactive.
+	sshTypingMinEvents = 20
+
+	msgKexInit = 20
+)
+
+// sshSession is one observed SSH session and its negotiation metadata.
+type sshSession struct {
+	client     string
+	server     string
+	serverPort uint16
+	first      time.Time
+	last       time.Time
+
+	clientVersion string
+	serverVersion string
+	clientKex     []string
+	serverKex     []string
+	clientCiphers []string
+	serverCiphers []string
+
+	bytesC2S, bytesS2C int64
+	lastC2S            time.Time
+	typingEvents       int64
+}
+
+// sshTracker records SSH sessions: versions and negotiated algorithms
+// from the cleartext handshake, volume per direction, and whether the
+// packet timing looks like interactive typing or a bulk transfer.
+type sshTracker struct {
+	sessions map[string]*sshSession
+}
+
+func newSSHTracker() *sshTracker {
+	return &sshTracker{sessions: make(map[string]*sshSession)}
+}
+
+// processTCP inspects one TCP payload in either direction.
+func (st *sshTracker) processTCP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	key := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	s, known := st.sessions[key]
+	if !known {
+		// Sessions start on port 22 or with an SSH banner anywhere.
+		if srcPort != sshPort && dstPort != sshPort && !bytes.HasPrefix(payload, []byte("SSH-")) {
+			return
+		}
+		client, server, serverPort := srcIP, dstIP, dstPort
+		if srcPort == sshPort {
+			client, server, serverPort = dstIP, srcIP, srcPort
+		}
+		s = &sshSession{client: client, server: server, serverPort: serverPort, first: ts}
+		st.sessions[key] = s
+	}
+	s.last = ts
+
+	fromClient := srcIP == s.client
+	if fromClient {
+		s.bytesC2S += int64(len(payload))
+		if len(payload) > 0 && len(payload) <= sshTypingMaxPayload {
+			if !s.lastC2S.IsZero() && ts.Sub(s.lastC2S) >= sshTypingMinGap {
+				s.typingEvents++
+			}
+			s.lastC2S = ts
+		}
+	} else {
+		s.bytesS2C += int64(len(payload))
+	}
+
+	if bytes.HasPrefix(payload, []byte("SSH-")) {
+		version := string(bytes.TrimRight(payload[:bannerLen(payload)], "\r\n"))
+		if fromClient && s.clientVersion == "" {
+			s.clientVersion = version
+		} else if !fromClient && s.serverVersion == "" {
+			s.serverVersion = version
+		}
+	}
+
+	if kex, ciphers, ok := parseKexInit(payload); ok {
+		if fromClient && s.clientKex == nil {
+			s.clientKex, s.clientCiphers = kex, ciphers
+		} else if !fromClient && s.serverKex == nil {
+			s.serverKex, s.serverCiphers = kex, ciphers
+		}
+	}
+}
+
+// bannerLen bounds an SSH identification line at its newline or 255
+// bytes, whichever comes first.
+func bannerLen(payload []byte) int {
+	n := bytes.IndexByte(payload, '\n')
+	if n < 0 || n > 255 {
+		n = len(payload)
+		if n > 255 {
+			n = 255
+		}
+	}
+	return n
+}
+
+// parseKexInit extracts the kex and client-to-server cipher name-lists
+// from an SSH_MSG_KEXINIT binary packet. The KEXINIT may follow the
+// banner in the same segment, so the banner is skipped first.
+func parseKexInit(payload []byte) (kex, ciphers []string, ok bool) {
+	if bytes.HasPrefix(payload, []byte("SSH-")) {
+		n := bannerLen(payload)
+		if n >= len(payload) {
+			return nil, nil, false
+		}
+		payload = payload[n+1:]
+	}
+	// uint32 length, byte padding, byte type, 16-byte cookie.
+	if len(payload) < 22 || payload[5] != msgKexInit {
+		return nil, nil, false
+	}
+	rest := payload[22:]
+	lists := make([][]string, 0, 3)
+	// kex_algorithms, server_host_key_algorithms,
+	// encryption_algorithms_client_to_server.
+	for i := 0; i < 3; i++ {
+		if len(rest) < 4 {
+			return nil, nil, false
+		}
+		n := binary.BigEndian.Uint32(rest)
+		rest = rest[4:]
+		if uint32(len(rest)) < n || n > 4096 {
+			return nil, nil, false
+		}
+		lists = append(lists, strings.Split(string(rest[:n]), ","))
+		rest = rest[n:]
+	}
+	return lists[0], lists[2], true
+}
+
+// negotiated returns the first client algorithm the server also offers,
+// per RFC 4253 section 7.1.
+func negotiated(client, server []string) string {
+	for _, c := range client {
+		for _, s := range server {
+			if c == s {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// findings reports each SSH session with its negotiated algorithms and
+// behavioral classification.
+func (st *sshTracker) findings() []models.Finding {
+	keys := make([]string, 0, len(st.sessions))
+	for k := range st.sessions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []models.Finding
+	for _, k := range keys {
+		s := st.sessions[k]
+		duration := s.last.Sub(s.first).Round(time.Second)
+		desc := fmt.Sprintf("SSH session from %s to %s:%d lasting %s (%d bytes sent, %d received).",
+			s.client, s.server, s.serverPort, duration, s.bytesC2S, s.bytesS2C)
+		if s.serverVersion != "" {
+			desc += fmt.Sprintf(" Server %s.", s.serverVersion)
+		}
+		if kex := negotiated(s.clientKex, s.serverKex); kex != "" {
+			desc += fmt.Sprintf(" Negotiated kex %s, cipher %s.",
+				kex, negotiated(s.clientCiphers, s.serverCiphers))
+		}
+
+		severity := "info"
+		bulk := s.bytesC2S > sshBulkBytes || s.bytesS2C > sshBulkBytes
+		interactive := s.typingEvents >= sshTypingMinEvents
+		switch {
+		case bulk && interactive:
+			desc += " Traffic pattern: interactive shell with a bulk transfer."
+			severity = "low"
+		case bulk:
+			desc += " Traffic pattern: bulk transfer (scp/sftp sized)."
+			severity = "low"
+		case interactive:
+			desc += " Traffic pattern: interactive typing."
+		}
+
+		out = append(out, models.Finding{
+			Severity:    severity,
+			Type:        "ssh-session",
+			Title:       fmt.Sprintf("SSH session from %s to %s", s.client, s.server),
+			Description: desc,
+			SrcIP:       s.client,
+			DstIP:       s.server,
+			Timestamp:   s.first,
+		})
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// kexInitPacket builds a minimal SSH_MSG_KEXINIT with the given kex and
+// cipher name-lists.
+func kexInitPacket(kex, hostkeys, ciphers string) []byte {
+	body := []byte{msgKexInit}
+	body = append(body, make([]byte, 16)...) // cookie
+	for _, list := range []string{kex, hostkeys, ciphers} {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(list)))
+		body = append(body, n[:]...)
+		body = append(body, list...)
+	}
+	pkt := make([]byte, 5)
+	binary.BigEndian.PutUint32(pkt, uint32(len(body)+1))
+	pkt[4] = 0 // padding length
+	return append(pkt, body...)
+}
+
+func TestSSHNegotiation(t *testing.T) {
+	st := newSSHTracker()
+	base := time.Now()
+
+	st.processTCP("10.0.0.1", "10.0.0.2", 50000, 22, []byte("SSH-2.0-OpenSSH_9.6\r\n"), base)
+	st.processTCP("10.0.0.2", "10.0.0.1", 22, 50000, []byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu\r\n"), base)
+	st.processTCP("10.0.0.1", "10.0.0.2", 50000, 22,
+		kexInitPacket("curve25519-sha256,diffie-hellman-group14-sha256", "ssh-ed25519", "chacha20-poly1305@openssh.com,aes128-ctr"), base)
+	st.processTCP("10.0.0.2", "10.0.0.1", 22, 50000,
+		kexInitPacket("diffie-hellman-group14-sha256,curve25519-sha256", "ssh-ed25519", "aes128-ctr,aes256-ctr"), base)
+
+	fs := st.findings()
+	if len(fs) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(fs))
+	}
+	f := fs[0]
+	if f.Type != "ssh-session" || f.SrcIP != "10.0.0.1" || f.DstIP != "10.0.0.2" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+	if !strings.Contains(f.Description, "Server SSH-2.0-OpenSSH_8.9p1 Ubuntu") {
+		t.Errorf("server version missing: %s", f.Description)
+	}
+	if !strings.Contains(f.Description, "kex curve25519-sha256") ||
+		!strings.Contains(f.Description, "cipher aes128-ctr") {
+		t.Errorf("negotiated algorithms missing: %s", f.Description)
+	}
+}
+
+func TestSSHBulkVsInteractive(t *testing.T) {
+	st := newSSHTracker()
+	base := time.Now()
+
+	// Interactive: keystroke-sized client packets spaced like typing.
+	ts := base
+	for i := 0; i < sshTypingMinEvents+1; i++ {
+		st.processTCP("10.0.0.1", "10.0.0.2", 50000, 22, make([]byte, 36), ts)
+		ts = ts.Add(200 * time.Millisecond)
+	}
+	// Bulk: a different session pushing more than sshBulkBytes.
+	for i := 0; i < 100; i++ {
+		st.processTCP("10.0.0.3", "10.0.0.2", 50001, 22, make([]byte, 16384), base)
+	}
+
+	fs := st.findings()
+	if len(fs) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(fs))
+	}
+	byClient := map[string]string{}
+	for _, f := range fs {
+		byClient[f.SrcIP] = f.Description
+	}
+	if !strings.Contains(byClient["10.0.0.1"], "interactive typing") {
+		t.Errorf("interactive session not classified: %s", byClient["10.0.0.1"])
+	}
+	if !strings.Contains(byClient["10.0.0.3"], "bulk transfer") {
+		t.Errorf("bulk session not classified: %s", byClient["10.0.0.3"])
+	}
+}
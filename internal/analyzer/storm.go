@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// stormRateThreshold is how many broadcast packets per second one source
+// must sustain before a second counts as part of a storm. Normal ARP,
+// NetBIOS and DHCP chatter sits orders of magnitude below this.
+const stormRateThreshold = 100
+
+// stormMaxGap merges qualifying seconds separated by up to this many
+// quiet seconds into one storm window.
+const stormMaxGap = 2
+
+// stormTracker watches per-second broadcast rates per source to catch
+// ARP storms, NetBIOS floods and DHCP-discover loops, recording who
+// flooded, when and how hard.
+type stormTracker struct {
+	sources map[string]*stormStat
+}
+
+type stormStat struct {
+	seconds map[int64]int64  // unix second -> broadcast packets
+	byProto map[string]int64 // protocol -> total packets
+}
+
+func newStormTracker() *stormTracker {
+	return &stormTracker{sources: make(map[string]*stormStat)}
+}
+
+// process counts one broadcast packet against its source's current
+// second. Classification mirrors the broadcast summary tracker.
+func (st *stormTracker) process(ctx *PacketContext) {
+	var src, proto string
+	switch {
+	case ctx.Packet != nil && ctx.Packet.Layer(layers.LayerTypeARP) != nil:
+		if ctx.DstMAC != "ff:ff:ff:ff:ff:ff" {
+			return
+		}
+		src, proto = ctx.SrcMAC, "arp"
+	case isBroadcastTarget(ctx.DstIP):
+		src = ctx.SrcIP
+		switch ctx.DstPort {
+		case 137, 138:
+			proto = "netbios"
+		case 67, 68:
+			proto = "dhcp"
+		default:
+			proto = "broadcast"
+		}
+	default:
+		return
+	}
+	if src == "" {
+		return
+	}
+	s, ok := st.sources[src]
+	if !ok {
+		s = &stormStat{seconds: make(map[int64]int64), byProto: make(map[string]int64)}
+		st.sources[src] = s
+	}
+	s.seconds[ctx.Timestamp.Unix()]++
+	s.byProto[proto]++
+}
+
+// findings reports one storm per source: the widest window of sustained
+// over-threshold seconds, with its peak rate and dominant protocol.
+func (st *stormTracker) findings() []models.Finding {
+	srcs := make([]string, 0, len(st.sources))
+	for src := range st.sources {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+
+	var out []models.Finding
+	for _, src := range srcs {
+		s := st.sources[src]
+		start, end, peak, packets := s.widestStorm()
+		if packets == 0 {
+			continue
+		}
+		proto, protoCount := "", int64(0)
+		for p, n := range s.byProto {
+			if n > protoCount || (n == protoCount && p < proto) {
+				proto, protoCount = p, n
+			}
+		}
+		from := time.Unix(start, 0).UTC()
+		to := time.Unix(end+1, 0).UTC()
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "broadcast-storm",
+			Title:    fmt.Sprintf("Broadcast storm from %s", src),
+			Description: fmt.Sprintf(
+				"%s flooded the segment with %d broadcast packets (mostly %s) between %s and %s, peaking at %d packets/s.",
+				src, packets, proto,
+				from.Format("15:04:05"), to.Format("15:04:05"), peak),
+			SrcIP:     ipOnly(src),
+			Timestamp: from,
+		})
+	}
+	return out
+}
+
+// widestStorm finds the longest run of over-threshold seconds, tolerating
+// short gaps, and returns its bounds, peak rate and packet total.
+func (s *stormStat) widestStorm() (start, end, peak, packets int64) {
+	var hot []int64
+	for sec, n := range s.seconds {
+		if n >= stormRateThreshold {
+			hot = append(hot, sec)
+		}
+	}
+	if len(hot) == 0 {
+		return 0, 0, 0, 0
+	}
+	sort.Slice(hot, func(i, j int) bool { return hot[i] < hot[j] })
+
+	bestLen := int64(-1)
+	runStart := hot[0]
+	prev := hot[0]
+	flush := func(last int64) {
+		if length := last - runStart; length > bestLen {
+			bestLen, start, end = length, runStart, last
+		}
+	}
+	for _, sec := range hot[1:] {
+		if sec-prev > stormMaxGap {
+			flush(prev)
+			runStart = sec
+		}
+		prev = sec
+	}
+	flush(prev)
+
+	for sec := start; sec <= end; sec++ {
+		n := s.seconds[sec]
+		packets += n
+		if n > peak {
+			peak = n
+		}
+	}
+	return start, end, peak, packets
+}
+
+// ipOnly returns src when it is an IP address and "" for MAC sources, so
+// ARP storm findings do not put a MAC in an IP field.
+func ipOnly(src string) string {
+	if subnetOf(src) == "" {
+		return ""
+	}
+	return src
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// stormPacket fakes the decoded context of one broadcast UDP packet.
+func stormPacket(srcIP string, dstPort uint16, ts time.Time) *PacketContext {
+	return &PacketContext{
+		SrcIP:     srcIP,
+		DstIP:     "255.255.255.255",
+		DstPort:   dstPort,
+		Timestamp: ts,
+		Size:      100,
+	}
+}
+
+func TestStormTrackerFlagsDHCPFlood(t *testing.T) {
+	st := newStormTracker()
+	base := time.Unix(1700000000, 0)
+
+	// Three seconds at 200 DHCP discovers per second.
+	for sec := 0; sec < 3; sec++ {
+		for i := 0; i < 200; i++ {
+			st.process(stormPacket("10.0.0.5", 67, base.Add(time.Duration(sec)*time.Second)))
+		}
+	}
+
+	findings := st.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %d, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != "broadcast-storm" || f.SrcIP != "10.0.0.5" {
+		t.Fatalf("finding = %+v, want broadcast-storm from 10.0.0.5", f)
+	}
+	for _, want := range []string{"600 broadcast packets", "dhcp", "200 packets/s"} {
+		if !strings.Contains(f.Description, want) {
+			t.Errorf("description %q missing %q", f.Description, want)
+		}
+	}
+}
+
+func TestStormTrackerIgnoresNormalChatter(t *testing.T) {
+	st := newStormTracker()
+	base := time.Unix(1700000000, 0)
+	for sec := 0; sec < 60; sec++ {
+		for i := 0; i < 5; i++ {
+			st.process(stormPacket("10.0.0.5", 137, base.Add(time.Duration(sec)*time.Second)))
+		}
+	}
+	if findings := st.findings(); len(findings) != 0 {
+		t.Fatalf("findings = %+v, want none for 5 packets/s", findings)
+	}
+}
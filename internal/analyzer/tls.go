@@ -0,0 +1,355 @@
+package analyzer
+
+import (
+	"crypto/md5"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// TLS record and handshake constants.
+const (
+	tlsRecordHandshake = 22
+
+	tlsHandshakeClientHello = 1
+	tlsHandshakeServerHello = 2
+	tlsHandshakeCertificate = 11
+
+	tlsExtServerName = 0
+)
+
+// middleboxIssuers are substrings of certificate issuers that identify
+// TLS-intercepting middleboxes and debugging proxies.
+var middleboxIssuers = []string{
+	"mitmproxy",
+	"Burp",
+	"Charles Proxy",
+	"Fortinet",
+	"FortiGate",
+	"Zscaler",
+	"Blue Coat",
+	"BlueCoat",
+	"Palo Alto",
+	"Websense",
+	"Sophos",
+	"SSL Decryption",
+	"DO_NOT_TRUST",
+}
+
+// expectedIssuers maps well-known service domains to issuer substrings
+// their certificates are normally signed by. A certificate for one of
+// these domains from an unrelated issuer is a strong interception signal.
+var expectedIssuers = map[string][]string{
+	"google.com":     {"Google Trust Services", "GlobalSign"},
+	"gstatic.com":    {"Google Trust Services"},
+	"youtube.com":    {"Google Trust Services"},
+	"microsoft.com":  {"Microsoft", "DigiCert"},
+	"windows.com":    {"Microsoft", "DigiCert"},
+	"office.com":     {"Microsoft", "DigiCert"},
+	"apple.com":      {"Apple", "DigiCert"},
+	"icloud.com":     {"Apple", "DigiCert"},
+	"amazonaws.com":  {"Amazon"},
+	"facebook.com":   {"DigiCert"},
+	"cloudflare.com": {"Cloudflare", "DigiCert", "Google Trust Services"},
+}
+
+// tlsServerObs accumulates what one SNI name presented over the capture.
+type tlsServerObs struct {
+	serverIP string
+	ja3s     map[string]bool
+	issuers  map[string]bool
+}
+
+// tlsInspector watches TLS handshakes for signs of interception: proxy
+// CAs, issuer anomalies for known services and servers whose JA3S
+// fingerprint is not stable. Only handshake messages that fit entirely in
+// one TCP segment are parsed; that covers the vast majority of captures
+// without needing stream reassembly.
+type tlsInspector struct {
+	sniByFlow map[flowKey]string
+	servers   map[string]*tlsServerObs
+}
+
+func newTLSInspector() *tlsInspector {
+	return &tlsInspector{
+		sniByFlow: make(map[flowKey]string),
+		servers:   make(map[string]*tlsServerObs),
+	}
+}
+
+// processSegment scans a TCP payload for TLS handshake records.
+func (ti *tlsInspector) processSegment(srcIP string, srcPort uint16, dstIP string, dstPort uint16, payload []byte) {
+	for len(payload) >= 5 {
+		recType := payload[0]
+		if payload[1] != 3 || payload[2] > 4 {
+			return
+		}
+		recLen := int(binary.BigEndian.Uint16(payload[3:5]))
+		if recLen == 0 || 5+recLen > len(payload) {
+			return
+		}
+		if recType == tlsRecordHandshake {
+			ti.processHandshake(srcIP, srcPort, dstIP, dstPort, payload[5:5+recLen])
+		}
+		payload = payload[5+recLen:]
+	}
+}
+
+// processHandshake walks the handshake messages inside one record.
+func (ti *tlsInspector) processHandshake(srcIP string, srcPort uint16, dstIP string, dstPort uint16, data []byte) {
+	for len(data) >= 4 {
+		hsType := data[0]
+		hsLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+		if 4+hsLen > len(data) {
+			return
+		}
+		body := data[4 : 4+hsLen]
+		key := flowKey{"tcp", srcIP, srcPort, dstIP, dstPort}
+		switch hsType {
+		case tlsHandshakeClientHello:
+			if sni := parseClientHelloSNI(body); sni != "" {
+				ti.sniByFlow[key] = sni
+			}
+		case tlsHandshakeServerHello:
+			if ja3s := ja3sFingerprint(body); ja3s != "" {
+				ti.observe(key.reversed(), srcIP).ja3s[ja3s] = true
+			}
+		case tlsHandshakeCertificate:
+			if issuer := parseLeafIssuer(body); issuer != "" {
+				ti.observe(key.reversed(), srcIP).issuers[issuer] = true
+			}
+		}
+		data = data[4+hsLen:]
+	}
+}
+
+// observe returns the observation bucket for the server side of clientKey,
+// keyed by SNI when the ClientHello was seen and by server IP otherwise.
+func (ti *tlsInspector) observe(clientKey flowKey, serverIP string) *tlsServerObs {
+	name := ti.sniByFlow[clientKey]
+	if name == "" {
+		name = serverIP
+	}
+	obs, ok := ti.servers[name]
+	if !ok {
+		obs = &tlsServerObs{
+			serverIP: serverIP,
+			ja3s:     make(map[string]bool),
+			issuers:  make(map[string]bool),
+		}
+		ti.servers[name] = obs
+	}
+	return obs
+}
+
+// parseClientHelloSNI extracts the server_name extension from a
+// ClientHello body, or "" when absent or unparseable.
+func parseClientHelloSNI(body []byte) string {
+	exts := helloExtensions(body, true)
+	sni, ok := exts[tlsExtServerName]
+	if !ok || len(sni) < 5 {
+		return ""
+	}
+	// server_name_list length(2), type(1), name length(2), name.
+	nameLen := int(binary.BigEndian.Uint16(sni[3:5]))
+	if sni[2] != 0 || 5+nameLen > len(sni) {
+		return ""
+	}
+	return strings.ToLower(string(sni[5 : 5+nameLen]))
+}
+
+// ja3sFingerprint computes the JA3S hash (version,cipher,extensions) of a
+// ServerHello body.
+func ja3sFingerprint(body []byte) string {
+	if len(body) < 38 {
+		return ""
+	}
+	version := binary.BigEndian.Uint16(body[:2])
+	// Skip random(32) and session ID.
+	off := 34
+	sessLen := int(body[off])
+	off += 1 + sessLen
+	if off+3 > len(body) {
+		return ""
+	}
+	cipher := binary.BigEndian.Uint16(body[off : off+2])
+	off += 3 // cipher suite + compression method
+
+	var extIDs []string
+	if off+2 <= len(body) {
+		extLen := int(binary.BigEndian.Uint16(body[off : off+2]))
+		off += 2
+		end := off + extLen
+		for off+4 <= end && end <= len(body) {
+			id := binary.BigEndian.Uint16(body[off : off+2])
+			l := int(binary.BigEndian.Uint16(body[off+2 : off+4]))
+			extIDs = append(extIDs, strconv.Itoa(int(id)))
+			off += 4 + l
+		}
+	}
+
+	raw := fmt.Sprintf("%d,%d,%s", version, cipher, strings.Join(extIDs, "-"))
+	sum := md5.Sum([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// helloExtensions returns the extension map of a ClientHello or
+// ServerHello body. client selects the ClientHello layout, which carries
+// cipher suite and compression lists before the extensions.
+func helloExtensions(body []byte, client bool) map[uint16][]byte {
+	if len(body) < 35 {
+		return nil
+	}
+	off := 34 // version + random
+	sessLen := int(body[off])
+	off += 1 + sessLen
+	if client {
+		if off+2 > len(body) {
+			return nil
+		}
+		csLen := int(binary.BigEndian.Uint16(body[off : off+2]))
+		off += 2 + csLen
+		if off+1 > len(body) {
+			return nil
+		}
+		cmLen := int(body[off])
+		off += 1 + cmLen
+	} else {
+		off += 3
+	}
+	if off+2 > len(body) {
+		return nil
+	}
+	extLen := int(binary.BigEndian.Uint16(body[off : off+2]))
+	off += 2
+	end := off + extLen
+	if end > len(body) {
+		end = len(body)
+	}
+	exts := make(map[uint16][]byte)
+	for off+4 <= end {
+		id := binary.BigEndian.Uint16(body[off : off+2])
+		l := int(binary.BigEndian.Uint16(body[off+2 : off+4]))
+		if off+4+l > end {
+			break
+		}
+		exts[id] = body[off+4 : off+4+l]
+		off += 4 + l
+	}
+	return exts
+}
+
+// parseLeafIssuer parses the first (leaf) certificate of a Certificate
+// message and returns a printable issuer, or "".
+func parseLeafIssuer(body []byte) string {
+	if len(body) < 6 {
+		return ""
+	}
+	// certificate_list length(3), then per-entry length(3) + DER.
+	certLen := int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+	if 6+certLen > len(body) {
+		return ""
+	}
+	cert, err := x509.ParseCertificate(body[6 : 6+certLen])
+	if err != nil {
+		return ""
+	}
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+	if len(cert.Issuer.Organization) > 0 {
+		return cert.Issuer.Organization[0]
+	}
+	return ""
+}
+
+// isMiddleboxIssuer reports whether issuer matches a known TLS-intercepting
+// product.
+func isMiddleboxIssuer(issuer string) bool {
+	low := strings.ToLower(issuer)
+	for _, kw := range middleboxIssuers {
+		if strings.Contains(low, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// unexpectedIssuer reports whether sni belongs to a known service whose
+// certificates should come from a specific set of CAs, and issuer is not
+// among them.
+func unexpectedIssuer(sni, issuer string) bool {
+	for domain, allowed := range expectedIssuers {
+		if sni != domain && !strings.HasSuffix(sni, "."+domain) {
+			continue
+		}
+		for _, a := range allowed {
+			if strings.Contains(issuer, a) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// findings reports the interception signals gathered over the capture.
+func (ti *tlsInspector) findings() []models.Finding {
+	names := make([]string, 0, len(ti.servers))
+	for name := range ti.servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []models.Finding
+	for _, name := range names {
+		obs := ti.servers[name]
+		for issuer := range obs.issuers {
+			if isMiddleboxIssuer(issuer) {
+				out = append(out, models.Finding{
+					Severity: "high",
+					Type:     "tls-interception",
+					Title:    fmt.Sprintf("TLS traffic to %s intercepted by a middlebox", name),
+					Description: fmt.Sprintf(
+						"The certificate presented for %s was issued by %q, which matches a known TLS-intercepting product.",
+						name, issuer),
+					DstIP:     obs.serverIP,
+					Timestamp: time.Now(),
+				})
+			} else if unexpectedIssuer(name, issuer) {
+				out = append(out, models.Finding{
+					Severity: "medium",
+					Type:     "tls-issuer-anomaly",
+					Title:    fmt.Sprintf("Unexpected certificate issuer for %s", name),
+					Description: fmt.Sprintf(
+						"%s normally presents certificates from a small set of CAs, but %q was observed. "+
+							"This can indicate a TLS-intercepting proxy re-signing traffic.",
+						name, issuer),
+					DstIP:     obs.serverIP,
+					Timestamp: time.Now(),
+				})
+			}
+		}
+		if len(obs.ja3s) > 1 {
+			out = append(out, models.Finding{
+				Severity: "medium",
+				Type:     "tls-fingerprint-variance",
+				Title:    fmt.Sprintf("Inconsistent TLS server fingerprint for %s", name),
+				Description: fmt.Sprintf(
+					"%d different JA3S fingerprints were observed for %s during the capture. "+
+						"A single server normally produces a stable fingerprint; variance can indicate interception.",
+					len(obs.ja3s), name),
+				DstIP:     obs.serverIP,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	return out
+}
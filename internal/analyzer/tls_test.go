@@ -0,0 +1,61 @@
+package analyzer
+
+import "testing"
+
+func TestIsMiddleboxIssuer(t *testing.T) {
+	cases := []struct {
+		issuer string
+		want   bool
+	}{
+		{"mitmproxy", true},
+		{"FortiGate CA", true},
+		{"Zscaler Intermediate Root CA", true},
+		{"DigiCert TLS RSA SHA256 2020 CA1", false},
+		{"Let's Encrypt R3", false},
+	}
+	for _, c := range cases {
+		if got := isMiddleboxIssuer(c.issuer); got != c.want {
+			t.Errorf("isMiddleboxIssuer(%q) = %v, want %v", c.issuer, got, c.want)
+		}
+	}
+}
+
+func TestUnexpectedIssuer(t *testing.T) {
+	cases := []struct {
+		sni, issuer string
+		want        bool
+	}{
+		{"www.google.com", "Google Trust Services LLC", false},
+		{"www.google.com", "Corp Proxy CA", true},
+		{"mail.google.com", "GlobalSign", false},
+		{"internal.example.net", "Corp Proxy CA", false}, // not a known service
+	}
+	for _, c := range cases {
+		if got := unexpectedIssuer(c.sni, c.issuer); got != c.want {
+			t.Errorf("unexpectedIssuer(%q, %q) = %v, want %v", c.sni, c.issuer, got, c.want)
+		}
+	}
+}
+
+func TestTLSInspectorFindings(t *testing.T) {
+	ti := newTLSInspector()
+	obs := ti.observe(flowKey{"tcp", "10.0.0.5", 55001, "142.250.1.1", 443}, "142.250.1.1")
+	obs.issuers["mitmproxy"] = true
+	obs.ja3s["aaaa"] = true
+	obs.ja3s["bbbb"] = true
+
+	findings := ti.findings()
+	var types []string
+	for _, f := range findings {
+		types = append(types, f.Type)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings (%v), want 2", len(findings), types)
+	}
+	if findings[0].Type != "tls-interception" || findings[0].Severity != "high" {
+		t.Errorf("first finding = %s/%s, want tls-interception/high", findings[0].Type, findings[0].Severity)
+	}
+	if findings[1].Type != "tls-fingerprint-variance" {
+		t.Errorf("second finding = %s, want tls-fingerprint-variance", findings[1].Type)
+	}
+}
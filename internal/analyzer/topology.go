@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Subnet is one inferred IPv4 subnet in a capture.
+type Subnet struct {
+	CIDR  string `json:"cidr"`
+	Hosts int    `json:"hosts"`
+	Local bool   `json:"local"` // ARP seen, so inside the broadcast domain
+}
+
+// Gateway is a MAC that fronts traffic for many networks, almost
+// certainly a router.
+type Gateway struct {
+	MAC        string `json:"mac"`
+	IP         string `json:"ip,omitempty"` // from ARP, when the router answered for itself
+	Vendor     string `json:"vendor,omitempty"`
+	FrontedIPs int    `json:"frontedIps"`
+}
+
+// Topology summarizes the network layout visible in a capture.
+type Topology struct {
+	Subnets      []Subnet  `json:"subnets"`
+	Gateways     []Gateway `json:"gateways"`
+	CapturePoint string    `json:"capturePoint,omitempty"` // most active local subnet
+}
+
+// gatewaySubnetThreshold is how many distinct /24s one MAC must front
+// before it is treated as a gateway.
+const gatewaySubnetThreshold = 3
+
+// BuildTopology infers subnets, gateways and the probable capture point
+// from the assets and ARP/DHCP bindings of an analysis. Subnets are
+// approximated as /24s, which matches the vast majority of office and lab
+// networks; ARP visibility separates local segments from routed ones.
+func BuildTopology(assets []models.Asset, bindings []models.IPMACBinding) *Topology {
+	hostsBySubnet := make(map[string]int)
+	arpSubnets := make(map[string]bool)
+	subnetsByMAC := make(map[string]map[string]bool)
+	ipsByMAC := make(map[string]int)
+	arpIPByMAC := make(map[string]string)
+
+	for _, a := range assets {
+		subnet := subnet24(a.IP)
+		if subnet == "" {
+			continue
+		}
+		hostsBySubnet[subnet]++
+		if a.MAC != "" {
+			if subnetsByMAC[a.MAC] == nil {
+				subnetsByMAC[a.MAC] = make(map[string]bool)
+			}
+			subnetsByMAC[a.MAC][subnet] = true
+			ipsByMAC[a.MAC]++
+		}
+	}
+
+	for _, b := range bindings {
+		if b.Source != "arp" {
+			continue
+		}
+		if subnet := subnet24(b.IP); subnet != "" {
+			arpSubnets[subnet] = true
+		}
+		arpIPByMAC[b.MAC] = b.IP
+	}
+
+	topo := &Topology{}
+	for cidr, hosts := range hostsBySubnet {
+		topo.Subnets = append(topo.Subnets, Subnet{
+			CIDR:  cidr,
+			Hosts: hosts,
+			Local: arpSubnets[cidr],
+		})
+	}
+	sort.Slice(topo.Subnets, func(i, j int) bool {
+		if topo.Subnets[i].Hosts != topo.Subnets[j].Hosts {
+			return topo.Subnets[i].Hosts > topo.Subnets[j].Hosts
+		}
+		return topo.Subnets[i].CIDR < topo.Subnets[j].CIDR
+	})
+
+	for mac, subnets := range subnetsByMAC {
+		if len(subnets) < gatewaySubnetThreshold {
+			continue
+		}
+		topo.Gateways = append(topo.Gateways, Gateway{
+			MAC:        mac,
+			IP:         arpIPByMAC[mac],
+			Vendor:     LookupVendor(mac),
+			FrontedIPs: ipsByMAC[mac],
+		})
+	}
+	sort.Slice(topo.Gateways, func(i, j int) bool {
+		return topo.Gateways[i].FrontedIPs > topo.Gateways[j].FrontedIPs
+	})
+
+	// The capture point is the busiest subnet we can ARP into: that is
+	// where the capturing interface lived.
+	for _, s := range topo.Subnets {
+		if s.Local {
+			topo.CapturePoint = s.CIDR
+			break
+		}
+	}
+	return topo
+}
+
+// subnet24 maps an IPv4 address to its /24, or "" for IPv6 and garbage.
+func subnet24(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() == nil {
+		return ""
+	}
+	v4 := parsed.To4()
+	return fmt.Sprintf("%d.%d.%d.0/24", v4[0], v4[1], v4[2])
+}
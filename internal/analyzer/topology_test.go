@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func TestBuildTopology(t *testing.T) {
+	gw := "aa:bb:cc:00:00:01"
+	assets := []models.Asset{
+		{IP: "192.168.1.10", MAC: "aa:bb:cc:00:00:10"},
+		{IP: "192.168.1.11", MAC: "aa:bb:cc:00:00:11"},
+		{IP: "192.168.1.1", MAC: gw},
+		// Remote hosts all front through the gateway MAC.
+		{IP: "8.8.8.8", MAC: gw},
+		{IP: "1.1.1.1", MAC: gw},
+		{IP: "151.101.1.1", MAC: gw},
+	}
+	bindings := []models.IPMACBinding{
+		{IP: "192.168.1.1", MAC: gw, Source: "arp"},
+		{IP: "192.168.1.10", MAC: "aa:bb:cc:00:00:10", Source: "arp"},
+	}
+
+	topo := BuildTopology(assets, bindings)
+
+	if topo.CapturePoint != "192.168.1.0/24" {
+		t.Errorf("capture point = %q, want 192.168.1.0/24", topo.CapturePoint)
+	}
+	if len(topo.Gateways) != 1 || topo.Gateways[0].MAC != gw {
+		t.Fatalf("gateways = %+v, want one entry for %s", topo.Gateways, gw)
+	}
+	if topo.Gateways[0].IP != "192.168.1.1" {
+		t.Errorf("gateway IP = %q, want 192.168.1.1", topo.Gateways[0].IP)
+	}
+	if len(topo.Subnets) == 0 || !topo.Subnets[0].Local {
+		t.Errorf("busiest subnet should be local: %+v", topo.Subnets)
+	}
+}
+
+func TestSubnet24(t *testing.T) {
+	if got := subnet24("10.1.2.3"); got != "10.1.2.0/24" {
+		t.Errorf("subnet24(10.1.2.3) = %q", got)
+	}
+	if got := subnet24("2001:db8::1"); got != "" {
+		t.Errorf("IPv6 should map to empty, got %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// Covert-channel thresholds. Normal ping payloads are ~56 bytes; normal
+// hostnames are short and low-entropy.
+const (
+	icmpTunnelMinEchoes   = 50
+	icmpTunnelAvgPayload  = 128
+	icmpTunnelMaxPayload  = 1000
+	dnsTunnelMinQueries   = 20
+	dnsTunnelLabelLen     = 30
+	dnsTunnelNameLen      = 60
+	dnsTunnelEntropyLimit = 3.5
+)
+
+// icmpProfile tracks echo traffic from one source host.
+type icmpProfile struct {
+	echoes     int64
+	totalBytes int64
+	maxPayload int
+	firstSeen  time.Time
+}
+
+// dnsProfile tracks query shape from one source host.
+type dnsProfile struct {
+	queries    int64
+	suspicious int64
+	example    string
+	firstSeen  time.Time
+}
+
+// tunnelDetector watches for covert channels over ICMP echo payloads and
+// DNS query names.
+type tunnelDetector struct {
+	icmp map[string]*icmpProfile
+	dns  map[string]*dnsProfile
+}
+
+func newTunnelDetector() *tunnelDetector {
+	return &tunnelDetector{
+		icmp: make(map[string]*icmpProfile),
+		dns:  make(map[string]*dnsProfile),
+	}
+}
+
+// processICMPEcho records one echo request/reply payload from srcIP.
+func (td *tunnelDetector) processICMPEcho(srcIP string, payloadLen int, ts time.Time) {
+	p, ok := td.icmp[srcIP]
+	if !ok {
+		p = &icmpProfile{firstSeen: ts}
+		td.icmp[srcIP] = p
+	}
+	p.echoes++
+	p.totalBytes += int64(payloadLen)
+	if payloadLen > p.maxPayload {
+		p.maxPayload = payloadLen
+	}
+}
+
+// processDNSQuery records one query name from srcIP.
+func (td *tunnelDetector) processDNSQuery(srcIP, name string, ts time.Time) {
+	p, ok := td.dns[srcIP]
+	if !ok {
+		p = &dnsProfile{firstSeen: ts}
+		td.dns[srcIP] = p
+	}
+	p.queries++
+	if suspiciousDNSName(name) {
+		p.suspicious++
+		if p.example == "" {
+			p.example = name
+		}
+	}
+}
+
+// suspiciousDNSName reports whether a query name looks like an encoded
+// data carrier: very long, or with a long high-entropy leading label.
+func suspiciousDNSName(name string) bool {
+	if len(name) > dnsTunnelNameLen {
+		return true
+	}
+	label := name
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		label = name[:i]
+	}
+	return len(label) > dnsTunnelLabelLen && shannonEntropy(label) > dnsTunnelEntropyLimit
+}
+
+// shannonEntropy is bits per character of s.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	for _, r := range s {
+		freq[r]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range freq {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// findings raises tunneling findings with the supporting metrics.
+func (td *tunnelDetector) findings() []models.Finding {
+	var out []models.Finding
+
+	ips := make([]string, 0, len(td.icmp))
+	for ip := range td.icmp {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		p := td.icmp[ip]
+		avg := float64(p.totalBytes) / float64(p.echoes)
+		if !(p.echoes >= icmpTunnelMinEchoes && avg > icmpTunnelAvgPayload) &&
+			p.maxPayload < icmpTunnelMaxPayload {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "high",
+			Type:     "icmp-tunneling",
+			Title:    fmt.Sprintf("Possible ICMP tunnel from %s", ip),
+			Description: fmt.Sprintf(
+				"%s sent %d ICMP echo packets averaging %.0f payload bytes (max %d). "+
+					"Sustained large echo payloads are characteristic of ICMP tunneling.",
+				ip, p.echoes, avg, p.maxPayload),
+			SrcIP:     ip,
+			Timestamp: p.firstSeen,
+		})
+	}
+
+	ips = ips[:0]
+	for ip := range td.dns {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		p := td.dns[ip]
+		if p.suspicious < dnsTunnelMinQueries {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "high",
+			Type:     "dns-tunneling",
+			Title:    fmt.Sprintf("Possible DNS tunnel from %s", ip),
+			Description: fmt.Sprintf(
+				"%s issued %d DNS queries of which %d had very long or high-entropy names (e.g. %s). "+
+					"Encoded subdomains at this rate indicate DNS tunneling.",
+				ip, p.queries, p.suspicious, p.example),
+			SrcIP:     ip,
+			Timestamp: p.firstSeen,
+		})
+	}
+	return out
+}
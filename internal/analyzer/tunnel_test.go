@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICMPTunnelDetection(t *testing.T) {
+	td := newTunnelDetector()
+	now := time.Now()
+	for i := 0; i < 60; i++ {
+		td.processICMPEcho("10.0.0.9", 900, now)
+	}
+	// A normal pinger.
+	for i := 0; i < 10; i++ {
+		td.processICMPEcho("10.0.0.2", 56, now)
+	}
+
+	findings := td.findings()
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1", len(findings))
+	}
+	if findings[0].Type != "icmp-tunneling" || findings[0].SrcIP != "10.0.0.9" {
+		t.Errorf("finding = %s for %s", findings[0].Type, findings[0].SrcIP)
+	}
+}
+
+func TestDNSTunnelDetection(t *testing.T) {
+	td := newTunnelDetector()
+	now := time.Now()
+	for i := 0; i < 25; i++ {
+		name := fmt.Sprintf("%s%d.exfil.example.com",
+			"aGVsbG8gd29ybGQgdGhpcyBpcyBlbmNvZGVkIGRhdGE", i)
+		td.processDNSQuery("10.0.0.9", name, now)
+	}
+	for i := 0; i < 25; i++ {
+		td.processDNSQuery("10.0.0.2", "www.example.com", now)
+	}
+
+	findings := td.findings()
+	if len(findings) != 1 || findings[0].Type != "dns-tunneling" {
+		t.Fatalf("findings = %+v, want one dns-tunneling", findings)
+	}
+	if !strings.Contains(findings[0].Description, "25 DNS queries") {
+		t.Errorf("metrics missing from %q", findings[0].Description)
+	}
+}
+
+func TestSuspiciousDNSName(t *testing.T) {
+	if suspiciousDNSName("www.example.com") {
+		t.Error("ordinary name flagged")
+	}
+	if !suspiciousDNSName(strings.Repeat("a1b2c3d4", 10) + ".t.example.com") {
+		t.Error("very long name not flagged")
+	}
+}
@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// uaDiversityThreshold is how many distinct User-Agents one host must
+// present before it looks like a proxy or NAT aggregation point.
+const uaDiversityThreshold = 5
+
+// httpMethods are the request-line prefixes that mark an HTTP request.
+var httpMethods = [][]byte{
+	[]byte("GET "), []byte("POST "), []byte("PUT "), []byte("HEAD "),
+	[]byte("DELETE "), []byte("OPTIONS "), []byte("PATCH "),
+}
+
+// toolUserAgents maps substrings of known attack/audit tool User-Agents
+// to the severity their presence deserves.
+var toolUserAgents = map[string]string{
+	"sqlmap":    "high",
+	"nikto":     "high",
+	"nmap":      "medium",
+	"masscan":   "medium",
+	"dirbuster": "medium",
+	"gobuster":  "medium",
+	"hydra":     "high",
+	"wpscan":    "medium",
+}
+
+// uaTracker inventories HTTP User-Agent strings per source host.
+type uaTracker struct {
+	counts map[string]int64 // srcIP|ua -> sightings
+}
+
+func newUATracker() *uaTracker {
+	return &uaTracker{counts: make(map[string]int64)}
+}
+
+// processHTTP extracts the User-Agent header from a cleartext HTTP
+// request payload, if it is one.
+func (ut *uaTracker) processHTTP(srcIP string, payload []byte) {
+	if !isHTTPRequest(payload) {
+		return
+	}
+	ua := httpHeader(payload, "User-Agent")
+	if ua == "" {
+		return
+	}
+	ut.counts[srcIP+"|"+ua]++
+}
+
+// isHTTPRequest reports whether the payload starts with an HTTP request line.
+func isHTTPRequest(payload []byte) bool {
+	for _, m := range httpMethods {
+		if bytes.HasPrefix(payload, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpHeader returns the value of the named header in a raw HTTP message,
+// or "" when absent.
+func httpHeader(payload []byte, name string) string {
+	for _, line := range bytes.Split(payload, []byte("\r\n")) {
+		if len(line) == 0 {
+			break // end of headers
+		}
+		i := bytes.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		if strings.EqualFold(string(line[:i]), name) {
+			return string(bytes.TrimSpace(line[i+1:]))
+		}
+	}
+	return ""
+}
+
+// rows returns the inventory sorted for stable storage.
+func (ut *uaTracker) rows() []models.UserAgent {
+	out := make([]models.UserAgent, 0, len(ut.counts))
+	for key, count := range ut.counts {
+		srcIP, ua, _ := strings.Cut(key, "|")
+		out = append(out, models.UserAgent{SrcIP: srcIP, UserAgent: ua, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SrcIP != out[j].SrcIP {
+			return out[i].SrcIP < out[j].SrcIP
+		}
+		return out[i].UserAgent < out[j].UserAgent
+	})
+	return out
+}
+
+// findings flags hosts with contradictory UA sets and known tool UAs.
+func (ut *uaTracker) findings() []models.Finding {
+	rows := ut.rows()
+	perHost := make(map[string]int)
+	for _, r := range rows {
+		perHost[r.SrcIP]++
+	}
+
+	var out []models.Finding
+	seenTool := make(map[string]bool) // srcIP|tool, one finding per pair
+	for _, r := range rows {
+		low := strings.ToLower(r.UserAgent)
+		for tool, severity := range toolUserAgents {
+			if !strings.Contains(low, tool) || seenTool[r.SrcIP+"|"+tool] {
+				continue
+			}
+			seenTool[r.SrcIP+"|"+tool] = true
+			out = append(out, models.Finding{
+				Severity: severity,
+				Type:     "suspicious-user-agent",
+				Title:    fmt.Sprintf("Attack tool User-Agent (%s) from %s", tool, r.SrcIP),
+				Description: fmt.Sprintf(
+					"%s issued HTTP requests identifying as %q, the signature of the %s tool.",
+					r.SrcIP, r.UserAgent, tool),
+				SrcIP:     r.SrcIP,
+				Timestamp: time.Now(),
+			})
+		}
+	}
+
+	hosts := make([]string, 0, len(perHost))
+	for ip := range perHost {
+		hosts = append(hosts, ip)
+	}
+	sort.Strings(hosts)
+	for _, ip := range hosts {
+		if perHost[ip] < uaDiversityThreshold {
+			continue
+		}
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "ua-diversity",
+			Title:    fmt.Sprintf("%s presented %d different User-Agents", ip, perHost[ip]),
+			Description: fmt.Sprintf(
+				"%s sent HTTP requests with %d distinct User-Agent strings. "+
+					"A single machine rarely does this; the address is likely a proxy or NAT gateway.",
+				ip, perHost[ip]),
+			SrcIP:     ip,
+			Timestamp: time.Now(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUATrackerInventory(t *testing.T) {
+	ut := newUATracker()
+	req := []byte("GET / HTTP/1.1\r\nHost: example.com\r\nUser-Agent: Mozilla/5.0\r\n\r\n")
+	ut.processHTTP("10.0.0.5", req)
+	ut.processHTTP("10.0.0.5", req)
+	ut.processHTTP("10.0.0.5", []byte("RANDOM BYTES")) // not HTTP
+
+	rows := ut.rows()
+	if len(rows) != 1 || rows[0].Count != 2 || rows[0].UserAgent != "Mozilla/5.0" {
+		t.Fatalf("rows = %+v", rows)
+	}
+}
+
+func TestUATrackerFindings(t *testing.T) {
+	ut := newUATracker()
+	ut.processHTTP("10.0.0.9", []byte("GET /admin HTTP/1.1\r\nUser-Agent: sqlmap/1.7\r\n\r\n"))
+	for i := 0; i < uaDiversityThreshold; i++ {
+		ut.processHTTP("10.0.0.7", []byte(fmt.Sprintf("GET / HTTP/1.1\r\nUser-Agent: Agent-%d\r\n\r\n", i)))
+	}
+
+	findings := ut.findings()
+	var gotTool, gotDiversity bool
+	for _, f := range findings {
+		switch f.Type {
+		case "suspicious-user-agent":
+			gotTool = f.SrcIP == "10.0.0.9" && f.Severity == "high"
+		case "ua-diversity":
+			gotDiversity = f.SrcIP == "10.0.0.7"
+		}
+	}
+	if !gotTool || !gotDiversity {
+		t.Errorf("findings = %+v, want sqlmap and diversity flags", findings)
+	}
+}
@@ -0,0 +1,127 @@
+package analyzer
+
+import "strings"
+
+// ouiVendors maps the first three octets of a MAC address (upper-case,
+// colon-separated) to the registered manufacturer. This is a curated subset
+// of the IEEE OUI registry covering vendors commonly seen on enterprise
+// networks; unknown prefixes simply resolve to "".
+var ouiVendors = map[string]string{
+	"00:00:0C": "Cisco",
+	"00:01:42": "Cisco",
+	"00:03:93": "Apple",
+	"00:05:69": "VMware",
+	"00:0C:29": "VMware",
+	"00:50:56": "VMware",
+	"00:0D:3A": "Microsoft",
+	"00:12:17": "Cisco-Linksys",
+	"00:13:10": "Cisco-Linksys",
+	"00:15:5D": "Microsoft Hyper-V",
+	"00:16:3E": "Xen",
+	"00:17:88": "Philips Lighting",
+	"00:18:0A": "Cisco Meraki",
+	"00:1A:11": "Google",
+	"00:1B:63": "Apple",
+	"00:1D:D8": "Microsoft",
+	"00:21:6A": "Intel",
+	"00:23:24": "G-PRO Computer",
+	"00:25:90": "Super Micro",
+	"00:50:BA": "D-Link",
+	"00:E0:4C": "Realtek",
+	"08:00:27": "PCS Systemtechnik (VirtualBox)",
+	"18:66:DA": "Dell",
+	"28:6A:BA": "Apple",
+	"2C:F0:5D": "Micro-Star",
+	"30:9C:23": "Micro-Star",
+	"34:17:EB": "Dell",
+	"3C:07:54": "Apple",
+	"3C:5A:B4": "Google",
+	"40:B0:34": "Hewlett Packard",
+	"44:38:39": "Cumulus Networks",
+	"4C:5E:0C": "Routerboard (MikroTik)",
+	"50:9A:4C": "Dell",
+	"52:54:00": "QEMU/KVM",
+	"54:52:00": "Realtek",
+	"58:EF:68": "Belkin",
+	"5C:26:0A": "Dell",
+	"60:38:E0": "Belkin",
+	"64:16:66": "Nest Labs",
+	"68:54:FD": "Amazon Technologies",
+	"6C:3B:6B": "Routerboard (MikroTik)",
+	"70:B3:D5": "IEEE Registration Authority",
+	"74:DA:38": "Edimax",
+	"78:4F:43": "Apple",
+	"7C:D1:C3": "Apple",
+	"80:EE:73": "Shuttle",
+	"84:B5:9C": "Juniper Networks",
+	"88:66:5A": "Apple",
+	"8C:85:90": "Apple",
+	"90:72:40": "Apple",
+	"94:10:3E": "Belkin",
+	"98:01:A7": "Apple",
+	"9C:B6:D0": "Rivet Networks (Killer)",
+	"A0:36:9F": "Intel",
+	"A4:4C:C8": "Dell",
+	"A4:83:E7": "Apple",
+	"A8:20:66": "Apple",
+	"AC:DE:48": "Private",
+	"B0:4E:26": "TP-Link",
+	"B4:2E:99": "GIGA-BYTE",
+	"B8:27:EB": "Raspberry Pi Foundation",
+	"BC:5F:F4": "ASRock",
+	"C0:25:E9": "TP-Link",
+	"C4:6E:1F": "TP-Link",
+	"C8:D7:19": "Cisco-Linksys",
+	"CC:46:D6": "Cisco",
+	"D4:81:D7": "Dell",
+	"D8:50:E6": "ASUSTek",
+	"DC:A6:32": "Raspberry Pi Trading",
+	"E0:D5:5E": "GIGA-BYTE",
+	"E4:54:E8": "Dell",
+	"EC:08:6B": "TP-Link",
+	"F0:18:98": "Apple",
+	"F4:8E:38": "Dell",
+	"F8:75:A4": "LCFC (Lenovo)",
+	"FC:FB:FB": "Cisco",
+}
+
+// LookupVendor returns the manufacturer registered for the MAC's OUI
+// prefix. Randomized (locally administered) MACs and unknown prefixes
+// return "".
+func LookupVendor(mac string) string {
+	if len(mac) < 8 {
+		return ""
+	}
+	if IsRandomizedMAC(mac) {
+		return ""
+	}
+	return ouiVendors[strings.ToUpper(mac[:8])]
+}
+
+// IsRandomizedMAC reports whether the MAC has the locally-administered bit
+// set (bit 1 of the first octet), which modern mobile OSes use for MAC
+// randomization. Broadcast and zero MACs are not considered randomized.
+func IsRandomizedMAC(mac string) bool {
+	if len(mac) < 2 || mac == "ff:ff:ff:ff:ff:ff" || mac == "00:00:00:00:00:00" {
+		return false
+	}
+	firstOctet := hexVal(mac[0])<<4 | hexVal(mac[1])
+	if firstOctet < 0 {
+		return false
+	}
+	// U/L bit set and not a group (multicast) address.
+	return firstOctet&0x02 != 0 && firstOctet&0x01 == 0
+}
+
+// hexVal decodes a single hex digit, returning -1 for invalid input.
+func hexVal(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
@@ -0,0 +1,37 @@
+package analyzer
+
+import "testing"
+
+func TestIsRandomizedMAC(t *testing.T) {
+	cases := []struct {
+		mac  string
+		want bool
+	}{
+		{"b8:27:eb:01:02:03", false}, // Raspberry Pi OUI, U/L clear
+		{"da:a1:19:01:02:03", true},  // locally administered
+		{"02:00:00:aa:bb:cc", true},  // locally administered
+		{"01:00:5e:00:00:fb", false}, // multicast, not randomized
+		{"ff:ff:ff:ff:ff:ff", false}, // broadcast
+		{"00:00:00:00:00:00", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := IsRandomizedMAC(tc.mac); got != tc.want {
+			t.Errorf("IsRandomizedMAC(%q) = %v, want %v", tc.mac, got, tc.want)
+		}
+	}
+}
+
+func TestLookupVendor(t *testing.T) {
+	if got := LookupVendor("b8:27:eb:01:02:03"); got != "Raspberry Pi Foundation" {
+		t.Errorf("LookupVendor raspberry pi = %q", got)
+	}
+	// Randomized MACs must not resolve to a vendor even if the prefix
+	// happens to collide with a registered OUI.
+	if got := LookupVendor("da:a1:19:01:02:03"); got != "" {
+		t.Errorf("LookupVendor randomized = %q, want empty", got)
+	}
+	if got := LookupVendor("aa"); got != "" {
+		t.Errorf("LookupVendor short input = %q, want empty", got)
+	}
+}
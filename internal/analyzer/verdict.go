@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// verdictMaxTypes caps how many finding types and protocols the verdict
+// names, keeping it one line.
+const verdictMaxTypes = 3
+
+// buildVerdict condenses finished results into a short machine-written
+// summary for analysis listings: scale, the riskiest findings, the
+// protocols that dominated and the largest transfer.
+func buildVerdict(res *Results) string {
+	var parts []string
+
+	parts = append(parts, fmt.Sprintf("%d hosts, %d flows, %d packets",
+		len(res.Assets), len(res.Connections), res.PacketCount))
+
+	if top := topFindings(res.Findings); top != "" {
+		parts = append(parts, top)
+	} else {
+		parts = append(parts, "no findings")
+	}
+
+	if protos := topProtocols(res.Connections); protos != "" {
+		parts = append(parts, "mostly "+protos)
+	}
+
+	if largest := largestTransfer(res.Connections); largest != "" {
+		parts = append(parts, largest)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// topFindings names the highest severities present and their most common
+// types.
+func topFindings(findings []models.Finding) string {
+	bySeverity := make(map[string]int)
+	types := make(map[string]int)
+	for _, f := range findings {
+		if f.Severity == "info" {
+			continue
+		}
+		bySeverity[f.Severity]++
+		types[f.Type]++
+	}
+	total := 0
+	for _, n := range bySeverity {
+		total += n
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// Highest severity tier present, for the headline count.
+	severity := "low"
+	for _, s := range []string{"high", "medium"} {
+		if bySeverity[s] > 0 {
+			severity = s
+			break
+		}
+	}
+
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if types[names[i]] != types[names[j]] {
+			return types[names[i]] > types[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > verdictMaxTypes {
+		names = names[:verdictMaxTypes]
+	}
+	return fmt.Sprintf("%d findings (worst %s: %s)", total, severity, strings.Join(names, ", "))
+}
+
+// topProtocols lists the services moving the most bytes.
+func topProtocols(conns []models.Connection) string {
+	bytes := make(map[string]int64)
+	for _, conn := range conns {
+		service := conn.Service
+		if service == "" {
+			service = conn.AppProtocol
+		}
+		if service == "" {
+			service = conn.Protocol
+		}
+		bytes[service] += conn.BytesSent + conn.BytesRecv
+	}
+	names := make([]string, 0, len(bytes))
+	for s := range bytes {
+		names = append(names, s)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bytes[names[i]] != bytes[names[j]] {
+			return bytes[names[i]] > bytes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > verdictMaxTypes {
+		names = names[:verdictMaxTypes]
+	}
+	return strings.Join(names, ", ")
+}
+
+// largestTransfer describes the single heaviest flow.
+func largestTransfer(conns []models.Connection) string {
+	var best *models.Connection
+	var bestBytes int64
+	for i := range conns {
+		total := conns[i].BytesSent + conns[i].BytesRecv
+		if total > bestBytes {
+			best, bestBytes = &conns[i], total
+		}
+	}
+	if best == nil || bestBytes == 0 {
+		return ""
+	}
+	return fmt.Sprintf("largest transfer %s %s -> %s",
+		humanBytes(bestBytes), best.SrcIP, best.DstIP)
+}
+
+// humanBytes renders a byte count with a binary unit suffix.
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
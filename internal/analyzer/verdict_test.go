@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+func TestBuildVerdictSummarizesResults(t *testing.T) {
+	res := &Results{
+		PacketCount: 1000,
+		Assets:      []models.Asset{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+		Connections: []models.Connection{
+			{SrcIP: "10.0.0.1", DstIP: "10.0.0.2", Service: "https", BytesSent: 5 << 20},
+			{SrcIP: "10.0.0.2", DstIP: "10.0.0.1", Service: "dns", BytesSent: 1024},
+		},
+		Findings: []models.Finding{
+			{Severity: "high", Type: "brute-force"},
+			{Severity: "low", Type: "dark-space"},
+			{Severity: "info", Type: "resource-limit"},
+		},
+	}
+	v := buildVerdict(res)
+
+	for _, want := range []string{
+		"2 hosts", "2 flows", "1000 packets",
+		"2 findings", "worst high", "brute-force",
+		"https", "largest transfer 5.0 MiB 10.0.0.1 -> 10.0.0.2",
+	} {
+		if !strings.Contains(v, want) {
+			t.Errorf("verdict %q missing %q", v, want)
+		}
+	}
+}
+
+func TestBuildVerdictQuietCapture(t *testing.T) {
+	v := buildVerdict(&Results{PacketCount: 10})
+	if !strings.Contains(v, "no findings") {
+		t.Errorf("verdict %q should report no findings", v)
+	}
+}
@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// weakTLSVersions are handshake versions deprecated for compliance
+// purposes (PCI DSS, RFC 8996).
+var weakTLSVersions = map[uint16]string{
+	0x0300: "SSLv3",
+	0x0301: "TLS 1.0",
+	0x0302: "TLS 1.1",
+}
+
+// weakCipherSuites are TLS cipher suites built on broken or deprecated
+// primitives: NULL, EXPORT, DES, RC4 and 3DES.
+var weakCipherSuites = map[uint16]string{
+	0x0000: "TLS_NULL_WITH_NULL_NULL",
+	0x0001: "TLS_RSA_WITH_NULL_MD5",
+	0x0002: "TLS_RSA_WITH_NULL_SHA",
+	0x0003: "TLS_RSA_EXPORT_WITH_RC4_40_MD5",
+	0x0004: "TLS_RSA_WITH_RC4_128_MD5",
+	0x0005: "TLS_RSA_WITH_RC4_128_SHA",
+	0x0006: "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5",
+	0x0008: "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA",
+	0x0009: "TLS_RSA_WITH_DES_CBC_SHA",
+	0x000A: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	0x0014: "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA",
+	0x0015: "TLS_DHE_RSA_WITH_DES_CBC_SHA",
+	0x0016: "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	0x003B: "TLS_RSA_WITH_NULL_SHA256",
+	0xC007: "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+	0xC008: "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA",
+	0xC011: "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+	0xC012: "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+}
+
+// weakSSHAlgorithms are SSH kex and cipher names that no longer meet
+// baseline strength: 1024-bit DH groups, SHA-1 group exchange, RC4
+// (arcfour), single DES, 3DES and Blowfish.
+var weakSSHAlgorithms = map[string]string{
+	"diffie-hellman-group1-sha1":         "1024-bit DH group (group1-sha1)",
+	"diffie-hellman-group-exchange-sha1": "SHA-1 DH group exchange",
+	"arcfour":                            "RC4 cipher",
+	"arcfour128":                         "RC4 cipher (arcfour128)",
+	"arcfour256":                         "RC4 cipher (arcfour256)",
+	"des-cbc":                            "single DES cipher",
+	"3des-cbc":                           "3DES cipher",
+	"blowfish-cbc":                       "Blowfish cipher",
+	"rijndael-cbc@lysator.liu.se":        "non-standard legacy AES cipher",
+	"hmac-md5":                           "MD5 MAC",
+}
+
+// weakCryptoTracker collects deprecated protocol and cipher usage per
+// server from the cleartext parts of TLS, SSH and IKE negotiation, for
+// compliance-oriented findings. It judges what servers select or offer;
+// client offers alone are not reported since a modern server refuses
+// them.
+type weakCryptoTracker struct {
+	servers map[string]map[string]bool
+}
+
+func newWeakCryptoTracker() *weakCryptoTracker {
+	return &weakCryptoTracker{servers: make(map[string]map[string]bool)}
+}
+
+func (wc *weakCryptoTracker) note(server, issue string) {
+	issues, ok := wc.servers[server]
+	if !ok {
+		issues = make(map[string]bool)
+		wc.servers[server] = issues
+	}
+	issues[issue] = true
+}
+
+// processTCP inspects one server-to-client TCP payload segment for TLS
+// ServerHello choices and SSH server offers.
+func (wc *weakCryptoTracker) processTCP(srcIP string, srcPort uint16, payload []byte) {
+	if looksLikeTLS(payload) {
+		wc.scanTLS(fmt.Sprintf("%s:%d", srcIP, srcPort), payload)
+		return
+	}
+	if srcPort != sshPort {
+		return
+	}
+	server := fmt.Sprintf("%s:%d", srcIP, srcPort)
+	if bytes.HasPrefix(payload, []byte("SSH-1.")) &&
+		!bytes.HasPrefix(payload, []byte("SSH-1.99")) {
+		wc.note(server, "SSH protocol version 1")
+	}
+	if kex, ciphers, ok := parseKexInit(payload); ok {
+		for _, name := range append(kex, ciphers...) {
+			if label, weak := weakSSHAlgorithms[name]; weak {
+				wc.note(server, "SSH offers "+label)
+			}
+		}
+	}
+}
+
+// scanTLS walks the TLS records of one segment and judges any ServerHello
+// found: the version and cipher suite there are what the server selected.
+func (wc *weakCryptoTracker) scanTLS(server string, payload []byte) {
+	for len(payload) >= 5 {
+		recLen := int(binary.BigEndian.Uint16(payload[3:5]))
+		if recLen == 0 || 5+recLen > len(payload) {
+			return
+		}
+		if payload[0] == tlsRecordHandshake {
+			data := payload[5 : 5+recLen]
+			for len(data) >= 4 {
+				hsLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+				if 4+hsLen > len(data) {
+					break
+				}
+				if data[0] == tlsHandshakeServerHello {
+					wc.judgeServerHello(server, data[4:4+hsLen])
+				}
+				data = data[4+hsLen:]
+			}
+		}
+		payload = payload[5+recLen:]
+	}
+}
+
+// judgeServerHello records deprecated versions and cipher suites selected
+// in a ServerHello body.
+func (wc *weakCryptoTracker) judgeServerHello(server string, body []byte) {
+	if len(body) < 38 {
+		return
+	}
+	version := binary.BigEndian.Uint16(body[:2])
+	if name, weak := weakTLSVersions[version]; weak {
+		wc.note(server, "negotiated "+name)
+	}
+	off := 34 + 1 + int(body[34]) // random + session ID
+	if off+2 > len(body) {
+		return
+	}
+	cipher := binary.BigEndian.Uint16(body[off : off+2])
+	if name, weak := weakCipherSuites[cipher]; weak {
+		wc.note(server, "selected cipher suite "+name)
+	}
+}
+
+// processUDP flags IKEv1 responders. IKEv1 is deprecated (RFC 9395); any
+// ISAKMP version 1.x exchange on the IKE ports is worth a compliance
+// note regardless of the transforms inside.
+func (wc *weakCryptoTracker) processUDP(dstIP string, srcPort, dstPort uint16, payload []byte) {
+	if dstPort != 500 && srcPort != 500 {
+		return
+	}
+	// ISAKMP header: two 8-byte cookies, next payload, version, exchange
+	// type, flags, message id, length.
+	if len(payload) < 28 {
+		return
+	}
+	if major := payload[17] >> 4; major == 1 {
+		wc.note(fmt.Sprintf("%s:%d", dstIP, 500), "IKEv1 key exchange")
+	}
+}
+
+// findings emits one compliance finding per server, aggregating every
+// deprecated protocol and cipher it used.
+func (wc *weakCryptoTracker) findings() []models.Finding {
+	servers := make([]string, 0, len(wc.servers))
+	for server := range wc.servers {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+
+	out := make([]models.Finding, 0, len(servers))
+	for _, server := range servers {
+		issues := make([]string, 0, len(wc.servers[server]))
+		for issue := range wc.servers[server] {
+			issues = append(issues, issue)
+		}
+		sort.Strings(issues)
+
+		ip := server
+		if i := strings.LastIndex(ip, ":"); i >= 0 {
+			ip = ip[:i]
+		}
+		out = append(out, models.Finding{
+			Severity: "medium",
+			Type:     "weak-crypto",
+			Title:    fmt.Sprintf("Deprecated cryptography in use on %s", server),
+			Description: fmt.Sprintf(
+				"%s negotiated or offered deprecated cryptography during the capture: %s. "+
+					"These protocols and ciphers fail current compliance baselines and should be disabled.",
+				server, strings.Join(issues, "; ")),
+			DstIP:     ip,
+			Timestamp: time.Now(),
+		})
+	}
+	return out
+}
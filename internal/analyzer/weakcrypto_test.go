@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// serverHelloRecord builds a TLS handshake record holding one ServerHello
+// with the given version and cipher suite.
+func serverHelloRecord(version, cipher uint16) []byte {
+	body := make([]byte, 38)
+	binary.BigEndian.PutUint16(body[:2], version)
+	// 32 bytes random, zero-length session ID at offset 34.
+	binary.BigEndian.PutUint16(body[35:37], cipher)
+
+	hs := []byte{tlsHandshakeServerHello, 0, 0, byte(len(body))}
+	hs = append(hs, body...)
+	rec := []byte{tlsRecordHandshake, 3, 1, 0, byte(len(hs))}
+	return append(rec, hs...)
+}
+
+func TestWeakCryptoTLSVersionAndCipher(t *testing.T) {
+	wc := newWeakCryptoTracker()
+	wc.processTCP("10.0.0.9", 443, serverHelloRecord(0x0301, 0x0005))
+
+	out := wc.findings()
+	if len(out) != 1 {
+		t.Fatalf("findings = %d, want 1", len(out))
+	}
+	f := out[0]
+	if f.Type != "weak-crypto" || f.DstIP != "10.0.0.9" {
+		t.Errorf("finding = %s/%s, want weak-crypto for 10.0.0.9", f.Type, f.DstIP)
+	}
+	if !strings.Contains(f.Description, "TLS 1.0") ||
+		!strings.Contains(f.Description, "TLS_RSA_WITH_RC4_128_SHA") {
+		t.Errorf("description misses version or cipher: %s", f.Description)
+	}
+}
+
+func TestWeakCryptoModernTLSIgnored(t *testing.T) {
+	wc := newWeakCryptoTracker()
+	// TLS 1.2 with TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	wc.processTCP("10.0.0.9", 443, serverHelloRecord(0x0303, 0xC02F))
+
+	if out := wc.findings(); len(out) != 0 {
+		t.Errorf("findings = %+v, want none", out)
+	}
+}
+
+func TestWeakCryptoSSHOffers(t *testing.T) {
+	wc := newWeakCryptoTracker()
+	wc.processTCP("10.0.0.2", 22, []byte("SSH-1.5-legacyd\r\n"))
+	wc.processTCP("10.0.0.2", 22,
+		kexInitPacket("diffie-hellman-group1-sha1,curve25519-sha256", "ssh-rsa", "3des-cbc,aes128-ctr"))
+	// Client offers of the same algorithms are the server's problem only
+	// if it accepts them; the client side is not reported.
+	wc.processTCP("10.0.0.1", 50000, kexInitPacket("diffie-hellman-group1-sha1", "ssh-rsa", "3des-cbc"))
+
+	out := wc.findings()
+	if len(out) != 1 {
+		t.Fatalf("findings = %d, want 1", len(out))
+	}
+	desc := out[0].Description
+	for _, want := range []string{"SSH protocol version 1", "group1-sha1", "3DES"} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("description misses %q: %s", want, desc)
+		}
+	}
+	if strings.Contains(desc, "curve25519") || strings.Contains(desc, "aes128") {
+		t.Errorf("description flags modern algorithms: %s", desc)
+	}
+}
+
+func TestWeakCryptoIKEv1(t *testing.T) {
+	wc := newWeakCryptoTracker()
+	hdr := make([]byte, 28)
+	hdr[17] = 0x10 // ISAKMP version 1.0
+	wc.processUDP("10.0.0.30", 500, 500, hdr)
+
+	// IKEv2 on the same port is fine.
+	hdr2 := make([]byte, 28)
+	hdr2[17] = 0x20
+	wc.processUDP("10.0.0.31", 500, 500, hdr2)
+
+	out := wc.findings()
+	if len(out) != 1 || out[0].DstIP != "10.0.0.30" {
+		t.Fatalf("findings = %+v, want one IKEv1 finding for 10.0.0.30", out)
+	}
+	if !strings.Contains(out[0].Description, "IKEv1") {
+		t.Errorf("description misses IKEv1: %s", out[0].Description)
+	}
+}
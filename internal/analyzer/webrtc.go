@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// STUN message types seen on the wire (RFC 5389 / 8656). The class bits
+// are baked in, so requests and successes are distinct values.
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingSuccess  = 0x0101
+	turnAllocateRequest = 0x0003
+	turnAllocateSuccess = 0x0103
+)
+
+// stunMagicCookie is the fixed value in bytes 4..8 of every RFC 5389
+// STUN message.
+const stunMagicCookie = 0x2112a442
+
+// webrtcMinMediaPackets is how many RTP-shaped packets a flow needs
+// before it counts as carrying media rather than a stray probe.
+const webrtcMinMediaPackets = 50
+
+// webrtcSession is one candidate pair: STUN negotiation plus whatever
+// media followed on the same 5-tuple.
+type webrtcSession struct {
+	peerA, peerB          string
+	ipA, ipB              string
+	stunBindings          int64
+	turnAllocated         bool
+	dtls                  bool
+	mediaPackets          int64
+	mediaBytes            int64
+	firstSeen             time.Time
+	mediaStart, mediaStop time.Time
+}
+
+// webrtcTracker recognizes STUN binding requests, TURN allocations and
+// DTLS-SRTP media on UDP flows, pairing them into WebRTC sessions so
+// call traffic is identified instead of landing in the unknown-UDP pile.
+type webrtcTracker struct {
+	sessions map[string]*webrtcSession
+}
+
+func newWebRTCTracker() *webrtcTracker {
+	return &webrtcTracker{sessions: make(map[string]*webrtcSession)}
+}
+
+// isSTUN reports whether the datagram is a STUN message, trusting the
+// leading zero bits, the magic cookie and a consistent length field.
+func isSTUN(p []byte) bool {
+	if len(p) < 20 || p[0]&0xc0 != 0 {
+		return false
+	}
+	if binary.BigEndian.Uint32(p[4:8]) != stunMagicCookie {
+		return false
+	}
+	return int(binary.BigEndian.Uint16(p[2:4]))+20 <= len(p)
+}
+
+// isDTLS matches the DTLS record header: a TLS content type followed by
+// the 0xfe version family.
+func isDTLS(p []byte) bool {
+	return len(p) >= 13 && p[0] >= 20 && p[0] <= 23 && p[1] == 0xfe
+}
+
+// isRTP matches the RTP version-2 header shape. Callers must rule out
+// STUN and DTLS first; those share ports inside a WebRTC flow.
+func isRTP(p []byte) bool {
+	return len(p) >= 12 && p[0]>>6 == 2
+}
+
+func (wt *webrtcTracker) session(srcIP string, srcPort uint16, dstIP string, dstPort uint16, ts time.Time) *webrtcSession {
+	key := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+	s, ok := wt.sessions[key]
+	if !ok {
+		s = &webrtcSession{
+			peerA:     fmt.Sprintf("%s:%d", srcIP, srcPort),
+			peerB:     fmt.Sprintf("%s:%d", dstIP, dstPort),
+			ipA:       srcIP,
+			ipB:       dstIP,
+			firstSeen: ts,
+		}
+		wt.sessions[key] = s
+	}
+	return s
+}
+
+// processUDP classifies one datagram. Flows that never show STUN, DTLS
+// or RTP traits are never tracked.
+func (wt *webrtcTracker) processUDP(srcIP, dstIP string, srcPort, dstPort uint16, payload []byte, ts time.Time) {
+	switch {
+	case isSTUN(payload):
+		s := wt.session(srcIP, srcPort, dstIP, dstPort, ts)
+		switch binary.BigEndian.Uint16(payload[0:2]) {
+		case stunBindingRequest, stunBindingSuccess:
+			s.stunBindings++
+		case turnAllocateRequest, turnAllocateSuccess:
+			s.turnAllocated = true
+		}
+	case isDTLS(payload):
+		wt.session(srcIP, srcPort, dstIP, dstPort, ts).dtls = true
+	case isRTP(payload):
+		// Only count media on flows STUN or DTLS already claimed;
+		// matching bare RTP shapes everywhere would misfire constantly.
+		key := flowPairKey(srcIP, srcPort, dstIP, dstPort)
+		s, ok := wt.sessions[key]
+		if !ok || (s.stunBindings == 0 && !s.dtls) {
+			return
+		}
+		if s.mediaPackets == 0 {
+			s.mediaStart = ts
+		}
+		s.mediaPackets++
+		s.mediaBytes += int64(len(payload))
+		s.mediaStop = ts
+	}
+}
+
+// findings reports each recognized session with its peers, transport and
+// estimated media duration.
+func (wt *webrtcTracker) findings() []models.Finding {
+	keys := make([]string, 0, len(wt.sessions))
+	for k := range wt.sessions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out []models.Finding
+	for _, k := range keys {
+		s := wt.sessions[k]
+		if !s.dtls && s.mediaPackets < webrtcMinMediaPackets {
+			continue
+		}
+		var traits []string
+		if s.stunBindings > 0 {
+			traits = append(traits, fmt.Sprintf("%d STUN bindings", s.stunBindings))
+		}
+		if s.turnAllocated {
+			traits = append(traits, "TURN relay")
+		}
+		if s.dtls {
+			traits = append(traits, "DTLS-SRTP")
+		}
+		media := "no media observed"
+		if s.mediaPackets > 0 {
+			media = fmt.Sprintf("~%s of media (%d packets, %s)",
+				s.mediaStop.Sub(s.mediaStart).Round(time.Second),
+				s.mediaPackets, humanBytes(s.mediaBytes))
+		}
+		out = append(out, models.Finding{
+			Severity: "info",
+			Type:     "webrtc-session",
+			Title:    fmt.Sprintf("WebRTC session between %s and %s", s.peerA, s.peerB),
+			Description: fmt.Sprintf("%s; %s.",
+				strings.Join(traits, ", "), media),
+			SrcIP:     s.ipA,
+			DstIP:     s.ipB,
+			Timestamp: s.firstSeen,
+		})
+	}
+	return out
+}
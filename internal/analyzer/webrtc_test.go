@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// stunPacket builds a minimal STUN message of the given type.
+func stunPacket(msgType uint16) []byte {
+	return []byte{
+		byte(msgType >> 8), byte(msgType), 0x00, 0x00,
+		0x21, 0x12, 0xa4, 0x42,
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12,
+	}
+}
+
+func dtlsPacket() []byte {
+	return []byte{22, 0xfe, 0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+}
+
+func rtpPacket() []byte {
+	return []byte{0x80, 96, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1}
+}
+
+func TestWebRTCTrackerPairsSession(t *testing.T) {
+	wt := newWebRTCTracker()
+	base := time.Now()
+
+	wt.processUDP("10.0.0.5", "203.0.113.9", 50000, 3478, stunPacket(stunBindingRequest), base)
+	wt.processUDP("203.0.113.9", "10.0.0.5", 3478, 50000, stunPacket(stunBindingSuccess), base.Add(time.Millisecond))
+	wt.processUDP("10.0.0.5", "203.0.113.9", 50000, 3478, dtlsPacket(), base.Add(time.Second))
+	for i := 0; i < 100; i++ {
+		wt.processUDP("10.0.0.5", "203.0.113.9", 50000, 3478, rtpPacket(),
+			base.Add(2*time.Second+time.Duration(i)*300*time.Millisecond))
+	}
+
+	findings := wt.findings()
+	if len(findings) != 1 {
+		t.Fatalf("findings = %d, want 1: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Type != "webrtc-session" || f.SrcIP != "10.0.0.5" || f.DstIP != "203.0.113.9" {
+		t.Fatalf("finding = %+v, want webrtc-session 10.0.0.5 -> 203.0.113.9", f)
+	}
+	for _, want := range []string{"STUN bindings", "DTLS-SRTP", "media"} {
+		if !strings.Contains(f.Description, want) {
+			t.Errorf("description %q missing %q", f.Description, want)
+		}
+	}
+}
+
+func TestWebRTCTrackerIgnoresBareRTPShapes(t *testing.T) {
+	wt := newWebRTCTracker()
+	base := time.Now()
+
+	// RTP-shaped datagrams with no preceding STUN or DTLS: random UDP
+	// traffic often starts with a 0x8x byte and must not become a call.
+	for i := 0; i < 200; i++ {
+		wt.processUDP("10.0.0.5", "10.0.0.9", 40000, 9999, rtpPacket(), base)
+	}
+	if findings := wt.findings(); len(findings) != 0 {
+		t.Fatalf("findings = %d, want 0: %+v", len(findings), findings)
+	}
+}
+
+func TestWebRTCTrackerReportsTURNRelay(t *testing.T) {
+	wt := newWebRTCTracker()
+	base := time.Now()
+
+	wt.processUDP("10.0.0.5", "203.0.113.9", 50000, 3478, stunPacket(turnAllocateRequest), base)
+	wt.processUDP("10.0.0.5", "203.0.113.9", 50000, 3478, dtlsPacket(), base)
+
+	findings := wt.findings()
+	if len(findings) != 1 || !strings.Contains(findings[0].Description, "TURN relay") {
+		t.Fatalf("findings = %+v, want one session mentioning TURN relay", findings)
+	}
+}
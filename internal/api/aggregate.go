@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flowLink is one directional edge of the sankey/chord aggregation.
+type flowLink struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Service string `json:"service"`
+	Bytes   int64  `json:"bytes"`
+	Packets int64  `json:"packets"`
+	Flows   int64  `json:"flows"`
+}
+
+// handleFlowAggregate serves the precomputed-style service-level flow
+// aggregation for sankey and chord charts: srcGroup -> dstGroup -> service
+// with byte weights. ?granularity=host keeps individual addresses;
+// the default groups by subnet, with ?prefix= adjusting the IPv4 prefix
+// length (default 24; IPv6 always groups at /64). ?limit= caps the number
+// of links, heaviest first (default 50).
+func (s *Server) handleFlowAggregate(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", "subnet")
+	if granularity != "subnet" && granularity != "host" {
+		c.JSON(http.StatusBadRequest, apiError("granularity_invalid"))
+		return
+	}
+	prefix := 24
+	if p := c.Query("prefix"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 32 {
+			c.JSON(http.StatusBadRequest, apiError("prefix_out_of_range"))
+			return
+		}
+		prefix = n
+	}
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, apiError("limit_invalid"))
+			return
+		}
+		limit = n
+	}
+
+	conns, err := s.db.GetConnections(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	type linkKey struct{ src, dst, service string }
+	agg := make(map[linkKey]*flowLink)
+	for _, conn := range conns {
+		service := conn.Service
+		if service == "" {
+			service = conn.AppProtocol
+		}
+		if service == "" {
+			service = fmt.Sprintf("%d/%s", conn.DstPort, conn.Protocol)
+		}
+		key := linkKey{
+			src:     flowGroup(conn.SrcIP, granularity, prefix),
+			dst:     flowGroup(conn.DstIP, granularity, prefix),
+			service: service,
+		}
+		l, ok := agg[key]
+		if !ok {
+			l = &flowLink{Source: key.src, Target: key.dst, Service: key.service}
+			agg[key] = l
+		}
+		l.Bytes += conn.BytesSent + conn.BytesRecv
+		l.Packets += conn.PacketsSent + conn.PacketsRecv
+		l.Flows++
+	}
+
+	links := make([]flowLink, 0, len(agg))
+	for _, l := range agg {
+		links = append(links, *l)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Bytes != links[j].Bytes {
+			return links[i].Bytes > links[j].Bytes
+		}
+		if links[i].Source != links[j].Source {
+			return links[i].Source < links[j].Source
+		}
+		return links[i].Target < links[j].Target
+	})
+	if len(links) > limit {
+		links = links[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"granularity": granularity,
+		"links":       links,
+	})
+}
+
+// flowGroup maps an address to its aggregation bucket. Unparseable
+// addresses pass through untouched.
+func flowGroup(ip, granularity string, prefix int) string {
+	if granularity == "host" {
+		return ip
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		masked := v4.Mask(net.CIDRMask(prefix, 32))
+		return fmt.Sprintf("%s/%d", masked, prefix)
+	}
+	// IPv6 groups at the conventional /64 site boundary.
+	masked := parsed.Mask(net.CIDRMask(64, 128))
+	return fmt.Sprintf("%s/64", masked)
+}
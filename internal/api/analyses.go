@@ -0,0 +1,810 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// maxUploadSize caps uploaded capture files at 2 GiB.
+const maxUploadSize = 2 << 30
+
+func (s *Server) handleUpload(c *gin.Context) {
+	user := currentUser(c)
+
+	// An optional client-chosen token makes the upload observable via
+	// /api/upload/progress while the body is still arriving.
+	if token := c.Query("upload_id"); token != "" {
+		done := trackUpload(c, user.ID, token)
+		defer done()
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("file_field_missing"))
+		return
+	}
+	if file.Size > maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, apiError("file_too_large"))
+		return
+	}
+	name := storage.SanitizeFilename(file.Filename)
+	if !strings.HasSuffix(name, ".pcap") && !strings.HasSuffix(name, ".pcapng") &&
+		!strings.HasSuffix(name, ".cap") {
+		c.JSON(http.StatusBadRequest, apiError("file_type_unsupported"))
+		return
+	}
+
+	key := storage.ObjectKey(user.ID, name)
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("upload_read_failed"))
+		return
+	}
+	defer src.Close()
+	if err := s.store.Save(key, src, file.Size); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("upload_store_failed"))
+		return
+	}
+
+	opts, err := triageOptions(c)
+	if err != nil {
+		s.store.Delete(key)
+		c.JSON(http.StatusBadRequest, apiErrorParams("options_invalid", gin.H{"detail": err.Error()}))
+		return
+	}
+	id, err := s.db.CreateAnalysis(c.Request.Context(), user.ID, name, key, file.Size)
+	if err != nil {
+		s.store.Delete(key)
+		c.JSON(http.StatusInternalServerError, apiError("analysis_create_failed"))
+		return
+	}
+	s.pool.Enqueue(user.ID, id, key, opts)
+
+	analysis, err := s.db.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusCreated, analysis)
+}
+
+// triageOptions reads the optional quick-triage form fields of an upload:
+// max_packets, max_seconds, sample_rate and policies. Absent or invalid
+// numeric values mean a full analysis; a malformed policies document is an
+// error, since silently dropping a policy would hide the violations the
+// user asked about.
+func triageOptions(c *gin.Context) (analyzer.Options, error) {
+	var opts analyzer.Options
+	if n, err := strconv.ParseInt(c.PostForm("max_packets"), 10, 64); err == nil && n > 0 {
+		opts.MaxPackets = n
+	}
+	if n, err := strconv.Atoi(c.PostForm("max_seconds")); err == nil && n > 0 {
+		opts.MaxCaptureTime = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(c.PostForm("sample_rate")); err == nil && n > 1 {
+		opts.SampleRate = n
+	}
+	if n, err := strconv.Atoi(c.PostForm("time_offset_seconds")); err == nil && n != 0 {
+		opts.TimeOffset = time.Duration(n) * time.Second
+	}
+	if raw := c.PostForm("policies"); raw != "" {
+		rules, err := analyzer.ParsePolicyRules([]byte(raw))
+		if err != nil {
+			return opts, err
+		}
+		opts.Policies = rules
+	}
+	return opts, nil
+}
+
+func (s *Server) handleListAnalyses(c *gin.Context) {
+	// A project-scoped API key lists its project and nothing else.
+	if key := apiKeyFromContext(c); key != nil && key.ProjectID != 0 {
+		analyses, err := s.db.ListProjectAnalyses(c.Request.Context(), key.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if analyses == nil {
+			analyses = []database.Analysis{}
+		}
+		c.JSON(http.StatusOK, analyses)
+		return
+	}
+	// ?project= narrows the list to one project, including projects
+	// shared by other users.
+	if p := c.Query("project"); p != "" {
+		projectID, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiError("project_id_invalid"))
+			return
+		}
+		ok, err := s.db.ProjectAccessible(c.Request.Context(), projectID, currentUser(c).ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusNotFound, apiError("project_not_found"))
+			return
+		}
+		analyses, err := s.db.ListProjectAnalyses(c.Request.Context(), projectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if analyses == nil {
+			analyses = []database.Analysis{}
+		}
+		c.JSON(http.StatusOK, analyses)
+		return
+	}
+	analyses, err := s.db.ListAnalyses(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if analyses == nil {
+		analyses = []database.Analysis{}
+	}
+	c.JSON(http.StatusOK, analyses)
+}
+
+func (s *Server) handleGetAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	// While the analyzer is running, include throughput and an ETA so
+	// clients of multi-GB captures know whether to wait.
+	resp := struct {
+		*database.Analysis
+		Progress *analyzer.Progress `json:"progress,omitempty"`
+	}{Analysis: analysis}
+	if analysis.Status == "processing" {
+		if p, ok := analyzer.ProgressFor(analysis.ID); ok {
+			resp.Progress = &p
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) handleDeleteAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	// Project sharing is read-only: only the owner may delete.
+	if analysis.UserID != currentUser(c).ID {
+		c.JSON(http.StatusForbidden, apiError("delete_owner_only"))
+		return
+	}
+	// Soft delete: the capture and rows stay until the janitor's
+	// retention window passes, so the analysis can be restored.
+	if err := s.db.DeleteAnalysis(c.Request.Context(), analysis.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func (s *Server) handleListTrash(c *gin.Context) {
+	analyses, err := s.db.ListTrashedAnalyses(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if analyses == nil {
+		analyses = []database.Analysis{}
+	}
+	c.JSON(http.StatusOK, analyses)
+}
+
+func (s *Server) handleRestoreAnalysis(c *gin.Context) {
+	// ownedAnalysis hides trashed analyses, so restore does its own
+	// lookup and ownership check.
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("analysis_id_invalid"))
+		return
+	}
+	analysis, err := s.db.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if analysis == nil || analysis.UserID != currentUser(c).ID {
+		c.JSON(http.StatusNotFound, apiError("analysis_not_found"))
+		return
+	}
+	if analysis.DeletedAt == nil {
+		c.JSON(http.StatusConflict, apiError("analysis_not_trashed"))
+		return
+	}
+	if err := s.db.RestoreAnalysis(c.Request.Context(), analysis.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	analysis.DeletedAt = nil
+	c.JSON(http.StatusOK, analysis)
+}
+
+// handleRetryAnalysis requeues a failed analysis from its stored capture.
+// Transient failures retry automatically; this is the manual path for
+// everything the worker gave up on.
+func (s *Server) handleRetryAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if analysis.UserID != currentUser(c).ID {
+		c.JSON(http.StatusForbidden, apiError("retry_owner_only"))
+		return
+	}
+	if analysis.Status != "failed" {
+		c.JSON(http.StatusConflict, apiError("analysis_not_failed"))
+		return
+	}
+	// Guarded transition so two concurrent retries enqueue the analysis
+	// only once.
+	ok, err := s.db.TransitionAnalysisStatus(c.Request.Context(), analysis.ID, "failed", "pending", "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusConflict, apiError("analysis_not_failed"))
+		return
+	}
+	s.pool.Enqueue(analysis.UserID, analysis.ID, analysis.FilePath, analyzer.Options{})
+	c.JSON(http.StatusOK, gin.H{"status": "pending"})
+}
+
+// handleReprocessAnalysis re-runs the enrichment stages (vendor, ASN,
+// anomaly and service lookups) against the stored rows of a completed
+// analysis, so signature updates take effect without re-reading the
+// capture.
+func (s *Server) handleReprocessAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if analysis.Status != "completed" {
+		c.JSON(http.StatusConflict, apiError("analysis_not_completed"))
+		return
+	}
+
+	assets, err := s.db.GetAssets(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	conns, err := s.db.GetConnections(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	assetsChanged := analyzer.ReenrichAssets(assets, conns)
+	connsChanged := analyzer.ReenrichConnections(conns)
+	if assetsChanged > 0 {
+		if err := s.db.UpdateAssetEnrichment(c.Request.Context(), assets); err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+	}
+	if connsChanged > 0 {
+		if err := s.db.UpdateConnectionServices(c.Request.Context(), conns); err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"assetsUpdated":      assetsChanged,
+		"connectionsUpdated": connsChanged,
+	})
+}
+
+func (s *Server) handleGetAssets(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	// Without query parameters the endpoint keeps its original shape (a
+	// bare array); search, sort, limit or offset switch to a paginated
+	// envelope so clients also learn the total match count.
+	q := database.AssetQuery{
+		Search:   c.Query("search"),
+		SortBy:   c.Query("sort"),
+		SortDesc: c.Query("order") == "desc",
+	}
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		q.Limit = n
+	}
+	if n, err := strconv.Atoi(c.Query("offset")); err == nil && n > 0 {
+		q.Offset = n
+	}
+	paged := q.Search != "" || q.SortBy != "" || q.SortDesc || q.Limit > 0 || q.Offset > 0
+
+	assets, total, err := s.db.QueryAssets(c.Request.Context(), analysis.ID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if assets == nil {
+		assets = []database.Asset{}
+	}
+	if !paged {
+		c.JSON(http.StatusOK, assets)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"assets": assets,
+		"total":  total,
+		"limit":  q.Limit,
+		"offset": q.Offset,
+	})
+}
+
+// handleGetBroadcastSummary returns the per-source broadcast/multicast
+// totals (ARP, mDNS, SSDP, NetBIOS, LLMNR, DHCP, IGMP), chattiest first.
+func (s *Server) handleGetBroadcastSummary(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetBroadcastSummary(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rows == nil {
+		rows = []database.BroadcastRow{}
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// handleGetDNSHealth returns the per-server DNS health report: query
+// volume, latency percentiles, SERVFAIL/NXDOMAIN counts and client reach.
+func (s *Server) handleGetDNSHealth(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetDNSServerHealth(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rows == nil {
+		rows = []database.DNSServerHealth{}
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// handleGetMulticast returns the IGMP multicast group memberships seen in
+// the capture, grouped by multicast address.
+func (s *Server) handleGetMulticast(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetMulticastGroups(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rows == nil {
+		rows = []database.MulticastMembership{}
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// handleGetLatency returns the TCP handshake latency matrix of the
+// capture: the distinct subnets on each axis plus one row per directed
+// pair with sample count and median/p95 round-trip in milliseconds.
+func (s *Server) handleGetLatency(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetSubnetLatency(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rows == nil {
+		rows = []database.SubnetLatency{}
+	}
+	seen := make(map[string]bool)
+	subnets := []string{}
+	for _, r := range rows {
+		for _, sn := range []string{r.SrcSubnet, r.DstSubnet} {
+			if !seen[sn] {
+				seen[sn] = true
+				subnets = append(subnets, sn)
+			}
+		}
+	}
+	sort.Strings(subnets)
+	c.JSON(http.StatusOK, gin.H{"subnets": subnets, "rows": rows})
+}
+
+// protoNode is one level of the protocol hierarchy tree.
+type protoNode struct {
+	Protocol string       `json:"protocol"`
+	Packets  int64        `json:"packets"`
+	Bytes    int64        `json:"bytes"`
+	Children []*protoNode `json:"children,omitempty"`
+}
+
+func (s *Server) handleGetProtocols(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetProtocolHierarchy(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"protocols": protoTree(rows)})
+}
+
+// protoTree nests the flattened hierarchy rows. Rows arrive ordered by
+// path, so a parent always precedes its children.
+func protoTree(rows []database.ProtocolCount) []*protoNode {
+	roots := []*protoNode{}
+	byPath := make(map[string]*protoNode, len(rows))
+	for _, r := range rows {
+		parts := strings.Split(r.Path, "/")
+		node := &protoNode{Protocol: parts[len(parts)-1], Packets: r.Packets, Bytes: r.Bytes}
+		byPath[r.Path] = node
+		if parent := byPath[strings.Join(parts[:len(parts)-1], "/")]; parent != nil {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+func (s *Server) handleGetDurations(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetServiceDurations(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rows == nil {
+		rows = []database.ServiceDuration{}
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// accountEntry groups the usage rows of one account for the response.
+type accountEntry struct {
+	Domain   string                  `json:"domain,omitempty"`
+	Username string                  `json:"username"`
+	Usage    []database.AccountUsage `json:"usage"`
+}
+
+func (s *Server) handleGetAccounts(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	rows, err := s.db.GetAccountUsage(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	// Rows arrive ordered by account, so grouping is a single pass.
+	accounts := []accountEntry{}
+	for _, r := range rows {
+		n := len(accounts)
+		if n == 0 || accounts[n-1].Domain != r.Domain || accounts[n-1].Username != r.Username {
+			accounts = append(accounts, accountEntry{Domain: r.Domain, Username: r.Username})
+			n++
+		}
+		accounts[n-1].Usage = append(accounts[n-1].Usage, r)
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+func (s *Server) handleGetConnections(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	conns, err := s.db.GetConnections(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if conns == nil {
+		conns = []database.Connection{}
+	}
+	c.JSON(http.StatusOK, conns)
+}
+
+// attackCoverage is one ATT&CK technique with the findings backing it.
+type attackCoverage struct {
+	Technique    analyzer.AttackTechnique `json:"technique"`
+	FindingTypes []string                 `json:"findingTypes"`
+	Findings     int                      `json:"findings"`
+}
+
+func (s *Server) handleGetAttackCoverage(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	findings, err := s.db.GetFindings(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	byID := make(map[string]*attackCoverage)
+	for _, f := range findings {
+		for _, tech := range analyzer.TechniquesFor(f.Type) {
+			cov, ok := byID[tech.ID]
+			if !ok {
+				cov = &attackCoverage{Technique: tech}
+				byID[tech.ID] = cov
+			}
+			cov.Findings++
+			if !contains(cov.FindingTypes, f.Type) {
+				cov.FindingTypes = append(cov.FindingTypes, f.Type)
+			}
+		}
+	}
+
+	out := make([]attackCoverage, 0, len(byID))
+	for _, cov := range byID {
+		out = append(out, *cov)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Technique.ID < out[j].Technique.ID
+	})
+	c.JSON(http.StatusOK, out)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleGetTopology(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	assets, err := s.db.GetAssets(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	bindings, err := s.db.GetIPMACBindings(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, analyzer.BuildTopology(assets, bindings))
+}
+
+func (s *Server) handleGetConversations(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	convs, err := s.db.GetConversations(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if convs == nil {
+		convs = []database.Conversation{}
+	}
+	c.JSON(http.StatusOK, convs)
+}
+
+func (s *Server) handleGetBandwidth(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	conns, err := s.db.GetConnections(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	report := analyzer.BandwidthReport(conns)
+	if report == nil {
+		report = []analyzer.ServiceBandwidth{}
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (s *Server) handleGetDNS(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	queries, err := s.db.GetDNSQueries(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if queries == nil {
+		queries = []database.DNSQuery{}
+	}
+	c.JSON(http.StatusOK, queries)
+}
+
+func (s *Server) handleGetFindings(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	findings, err := s.db.GetFindings(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if findings == nil {
+		findings = []database.Finding{}
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+func (s *Server) handleGetBindings(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	bindings, err := s.db.GetIPMACBindings(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if bindings == nil {
+		bindings = []database.IPMACBinding{}
+	}
+	c.JSON(http.StatusOK, bindings)
+}
+
+func (s *Server) handleGetAssetServices(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	services, err := s.db.GetAssetServices(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if services == nil {
+		services = []database.AssetService{}
+	}
+	c.JSON(http.StatusOK, services)
+}
+
+func (s *Server) handleGetUserAgents(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	agents, err := s.db.GetUserAgents(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if agents == nil {
+		agents = []database.UserAgent{}
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+// vendorSummary is one row of the per-vendor asset breakdown.
+type vendorSummary struct {
+	Vendor     string `json:"vendor"`
+	Count      int    `json:"count"`
+	Randomized int    `json:"randomized"`
+}
+
+func (s *Server) handleGetVendorSummary(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	assets, err := s.db.GetAssets(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	byVendor := make(map[string]*vendorSummary)
+	for _, a := range assets {
+		vendor := a.Vendor
+		switch {
+		case a.RandomizedMAC:
+			vendor = "Randomized"
+		case vendor == "":
+			vendor = "Unknown"
+		}
+		row, ok := byVendor[vendor]
+		if !ok {
+			row = &vendorSummary{Vendor: vendor}
+			byVendor[vendor] = row
+		}
+		row.Count++
+		if a.RandomizedMAC {
+			row.Randomized++
+		}
+	}
+
+	out := make([]vendorSummary, 0, len(byVendor))
+	for _, row := range byVendor {
+		out = append(out, *row)
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// ownedAnalysis loads the :id analysis and verifies the caller may access
+// it: they own it, or it is filed in a project shared with them. On any
+// failure it writes the error response and returns nil.
+func (s *Server) ownedAnalysis(c *gin.Context) *database.Analysis {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("analysis_id_invalid"))
+		return nil
+	}
+	analysis, err := s.db.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return nil
+	}
+	if analysis == nil || analysis.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, apiError("analysis_not_found"))
+		return nil
+	}
+	if analysis.UserID != currentUser(c).ID {
+		shared := false
+		if analysis.ProjectID != 0 {
+			shared, err = s.db.ProjectAccessible(c.Request.Context(), analysis.ProjectID, currentUser(c).ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, apiError("database_error"))
+				return nil
+			}
+		}
+		if !shared {
+			c.JSON(http.StatusNotFound, apiError("analysis_not_found"))
+			return nil
+		}
+	}
+	// Project-scoped API keys only ever see their own project.
+	if key := apiKeyFromContext(c); key != nil && key.ProjectID != 0 && analysis.ProjectID != key.ProjectID {
+		c.JSON(http.StatusNotFound, apiError("analysis_not_found"))
+		return nil
+	}
+	return analysis
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/events"
+)
+
+// annotationEvent is the payload broadcast to annotation subscribers.
+type annotationEvent struct {
+	Action     string               `json:"action"` // "created" or "deleted"
+	Annotation *database.Annotation `json:"annotation,omitempty"`
+	ID         int64                `json:"id,omitempty"`
+}
+
+func (s *Server) handleListAnnotations(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	annotations, err := s.db.ListAnnotations(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if annotations == nil {
+		annotations = []database.Annotation{}
+	}
+	c.JSON(http.StatusOK, annotations)
+}
+
+func (s *Server) handleCreateAnnotation(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	var req struct {
+		Kind   string `json:"kind" binding:"required"`
+		Target string `json:"target"`
+		Text   string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("annotation_fields_required"))
+		return
+	}
+	if req.Kind != "tag" && req.Kind != "note" {
+		c.JSON(http.StatusBadRequest, apiError("annotation_kind_invalid"))
+		return
+	}
+
+	user := currentUser(c)
+	a := &database.Annotation{
+		AnalysisID: analysis.ID,
+		UserID:     user.ID,
+		Author:     user.Username,
+		Kind:       req.Kind,
+		Target:     req.Target,
+		Text:       req.Text,
+	}
+	if err := s.db.CreateAnnotation(c.Request.Context(), a); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	events.Default.Publish(analysis.ID, "annotation", annotationEvent{Action: "created", Annotation: a})
+	c.JSON(http.StatusCreated, a)
+}
+
+func (s *Server) handleDeleteAnnotation(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	annID, err := strconv.ParseInt(c.Param("annotationID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("annotation_id_invalid"))
+		return
+	}
+	a, err := s.db.GetAnnotation(c.Request.Context(), annID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if a == nil || a.AnalysisID != analysis.ID {
+		c.JSON(http.StatusNotFound, apiError("annotation_not_found"))
+		return
+	}
+	if err := s.db.DeleteAnnotation(c.Request.Context(), annID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	events.Default.Publish(analysis.ID, "annotation", annotationEvent{Action: "deleted", ID: annID})
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// handleAnnotationStream pushes annotation changes to every viewer of an
+// analysis over a WebSocket, so teams see each other's tags and notes
+// without refreshing. Unlike the live tail it stays open after the
+// analysis completes.
+func (s *Server) handleAnnotationStream(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade already wrote the error response
+	}
+	defer conn.Close()
+
+	ch := events.Default.Subscribe(analysis.ID)
+	defer events.Default.Unsubscribe(analysis.ID, ch)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if ev.Type != "annotation" {
+				continue
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-done:
+			return
+		case <-time.After(30 * time.Second):
+			if err := conn.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// apiKeyPrefix distinguishes API keys from session tokens in the
+// Authorization header.
+const apiKeyPrefix = "pk_"
+
+// apiKeyScopes are the recognized key scopes: full access, read-only, or
+// just enough to push captures in.
+var apiKeyScopes = map[string]bool{
+	"full":   true,
+	"read":   true,
+	"upload": true,
+}
+
+type createAPIKeyRequest struct {
+	Name      string `json:"name"`
+	Scope     string `json:"scope"`
+	ProjectID int64  `json:"projectId"`
+}
+
+// handleCreateAPIKey mints a scoped automation credential. The token is
+// returned exactly once; only its hash is stored.
+func (s *Server) handleCreateAPIKey(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+	user := currentUser(c)
+
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, apiError("name_required"))
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "full"
+	}
+	if !apiKeyScopes[req.Scope] {
+		c.JSON(http.StatusBadRequest, apiError("scope_invalid"))
+		return
+	}
+	if req.ProjectID != 0 {
+		project, err := s.db.GetProject(c.Request.Context(), req.ProjectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if project == nil || project.UserID != user.ID {
+			c.JSON(http.StatusNotFound, apiError("project_not_found"))
+			return
+		}
+	}
+
+	token, err := newAPIKeyToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("token_generate_failed"))
+		return
+	}
+	id, err := s.db.CreateAPIKey(c.Request.Context(), user.ID,
+		strings.TrimSpace(req.Name), hashAPIKey(token), req.Scope, req.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":        id,
+		"name":      strings.TrimSpace(req.Name),
+		"scope":     req.Scope,
+		"projectId": req.ProjectID,
+		"token":     token,
+	})
+}
+
+func (s *Server) handleListAPIKeys(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+	keys, err := s.db.ListAPIKeys(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if keys == nil {
+		keys = []database.APIKey{}
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (s *Server) handleDeleteAPIKey(c *gin.Context) {
+	if !requireSessionAuth(c) {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("keyID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("key_id_invalid"))
+		return
+	}
+	if err := s.db.DeleteAPIKey(c.Request.Context(), currentUser(c).ID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// apiKeyAuth authenticates a request carrying an API key, enforcing the
+// key's scope before any handler runs.
+func (s *Server) apiKeyAuth(c *gin.Context, token string) {
+	key, err := s.db.GetAPIKeyByHash(c.Request.Context(), hashAPIKey(token))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if key == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, apiError("api_key_invalid"))
+		return
+	}
+	if !apiKeyAllows(key, c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, apiError("api_key_scope_denied"))
+		return
+	}
+	user, err := s.db.GetUserByID(c.Request.Context(), key.UserID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if user == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, apiError("api_key_invalid"))
+		return
+	}
+	// Best effort; losing a last-used update never fails a request.
+	s.db.TouchAPIKey(c.Request.Context(), key.ID)
+	c.Set("user", user)
+	c.Set("apiKey", key)
+	c.Next()
+}
+
+// apiKeyAllows applies the key's scope to one request. Read-only keys
+// may only GET; upload keys may only push captures and watch their
+// progress; full keys behave like a session.
+func apiKeyAllows(key *database.APIKey, c *gin.Context) bool {
+	switch key.Scope {
+	case "full":
+		return true
+	case "read":
+		return c.Request.Method == http.MethodGet
+	case "upload":
+		switch c.FullPath() {
+		case "/api/upload", "/api/upload/url", "/api/import":
+			return c.Request.Method == http.MethodPost
+		case "/api/upload/progress", "/api/analysis/:id/fetch-progress":
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// apiKeyFromContext returns the key a request authenticated with, or nil
+// for session and proxy authentication.
+func apiKeyFromContext(c *gin.Context) *database.APIKey {
+	if v, ok := c.Get("apiKey"); ok {
+		return v.(*database.APIKey)
+	}
+	return nil
+}
+
+// requireSessionAuth rejects requests authenticated by an API key, so a
+// leaked key cannot mint or remove credentials.
+func requireSessionAuth(c *gin.Context) bool {
+	if apiKeyFromContext(c) != nil {
+		c.JSON(http.StatusForbidden, apiError("api_key_self_manage"))
+		return false
+	}
+	return true
+}
+
+func newAPIKeyToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// hashAPIKey is the stored form of a token; a database leak does not
+// leak usable credentials.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
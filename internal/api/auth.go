@@ -0,0 +1,295 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// sessionDuration is how long a login remains valid.
+const sessionDuration = 24 * time.Hour
+
+type credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// Code is the TOTP code, required only for accounts with two-factor
+	// authentication enabled.
+	Code string `json:"code"`
+}
+
+// validatePassword enforces the configured complexity policy.
+func (s *Server) validatePassword(pw string) error {
+	if len(pw) < s.minPasswordLen {
+		return fmt.Errorf("password must be at least %d characters", s.minPasswordLen)
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return fmt.Errorf("password must contain both letters and digits")
+	}
+	return nil
+}
+
+func (s *Server) handleRegister(c *gin.Context) {
+	var req credentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("credentials_required"))
+		return
+	}
+	if err := s.validatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrorParams("password_policy", gin.H{"detail": err.Error()}))
+		return
+	}
+	existing, err := s.db.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if existing != nil {
+		c.JSON(http.StatusConflict, apiError("username_taken"))
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("password_hash_failed"))
+		return
+	}
+	user, err := s.db.CreateUser(c.Request.Context(), req.Username, string(hash))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("user_create_failed"))
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+func (s *Server) handleLogin(c *gin.Context) {
+	var req credentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("credentials_required"))
+		return
+	}
+	user, err := s.db.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if user == nil ||
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, apiError("credentials_invalid"))
+		return
+	}
+	if user.TOTPEnabled {
+		if req.Code == "" {
+			c.JSON(http.StatusUnauthorized, apiError("totp_required"))
+			return
+		}
+		if !verifyTOTP(user.TOTPSecret, req.Code) {
+			c.JSON(http.StatusUnauthorized, apiError("totp_invalid"))
+			return
+		}
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("token_generate_failed"))
+		return
+	}
+	if err := s.sessions.Create(c.Request.Context(), token, user.ID, time.Now().Add(sessionDuration)); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("session_create_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+}
+
+func (s *Server) handleLogout(c *gin.Context) {
+	if token := bearerToken(c); token != "" {
+		s.sessions.Delete(c.Request.Context(), token)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
+}
+
+func (s *Server) handleMe(c *gin.Context) {
+	c.JSON(http.StatusOK, currentUser(c))
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+func (s *Server) handleChangePassword(c *gin.Context) {
+	user := currentUser(c)
+
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("passwords_required"))
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)) != nil {
+		c.JSON(http.StatusUnauthorized, apiError("credentials_invalid"))
+		return
+	}
+	if err := s.validatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrorParams("password_policy", gin.H{"detail": err.Error()}))
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), s.bcryptCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("password_hash_failed"))
+		return
+	}
+	if err := s.db.SetUserPassword(c.Request.Context(), user.ID, string(hash)); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "password changed"})
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// handleTOTPEnroll generates a fresh secret for the account. Two-factor is
+// only enforced after the user confirms a code via /2fa/verify.
+func (s *Server) handleTOTPEnroll(c *gin.Context) {
+	user := currentUser(c)
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, apiError("totp_already_enabled"))
+		return
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("totp_secret_generate_failed"))
+		return
+	}
+	if err := s.db.SetUserTOTP(c.Request.Context(), user.ID, secret, false); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret": secret,
+		"url":    otpauthURL(user.Username, secret),
+	})
+}
+
+func (s *Server) handleTOTPVerify(c *gin.Context) {
+	user := currentUser(c)
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("code_required"))
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, apiError("totp_enrollment_missing"))
+		return
+	}
+	if !verifyTOTP(user.TOTPSecret, req.Code) {
+		c.JSON(http.StatusUnauthorized, apiError("totp_invalid"))
+		return
+	}
+	if err := s.db.SetUserTOTP(c.Request.Context(), user.ID, user.TOTPSecret, true); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "two-factor authentication enabled"})
+}
+
+func (s *Server) handleTOTPDisable(c *gin.Context) {
+	user := currentUser(c)
+
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("code_required"))
+		return
+	}
+	if !user.TOTPEnabled || !verifyTOTP(user.TOTPSecret, req.Code) {
+		c.JSON(http.StatusUnauthorized, apiError("totp_invalid"))
+		return
+	}
+	if err := s.db.SetUserTOTP(c.Request.Context(), user.ID, "", false); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "two-factor authentication disabled"})
+}
+
+// authMiddleware resolves the bearer token to a user and aborts with 401
+// when it is missing or invalid.
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Upstream identities (mTLS client cert, signed proxy header)
+		// take precedence over session tokens.
+		if user, err := s.proxyAuthUser(c); err == errInvalidProxySig {
+			return
+		} else if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		} else if user != nil {
+			c.Set("user", user)
+			c.Next()
+			return
+		}
+
+		token := bearerToken(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apiError("auth_required"))
+			return
+		}
+		if strings.HasPrefix(token, apiKeyPrefix) {
+			s.apiKeyAuth(c, token)
+			return
+		}
+		user, err := s.sessions.User(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, apiError("session_invalid"))
+			return
+		}
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// currentUser returns the authenticated user stored by authMiddleware.
+func currentUser(c *gin.Context) *database.User {
+	u, _ := c.Get("user")
+	return u.(*database.User)
+}
+
+// bearerToken extracts the session token from the Authorization header,
+// falling back to the token query parameter for WebSocket clients that
+// cannot set headers.
+func bearerToken(c *gin.Context) string {
+	h := c.GetHeader("Authorization")
+	if strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+	return c.Query("token")
+}
+
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// handleSetBaseline marks an analysis as the gold baseline for its
+// project. Later analyses in the same project report their deviation
+// from it.
+func (s *Server) handleSetBaseline(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if analysis.ProjectID == 0 {
+		c.JSON(http.StatusBadRequest,
+			apiError("baseline_requires_project"))
+		return
+	}
+	if analysis.Status != "completed" {
+		c.JSON(http.StatusConflict, apiError("analysis_not_completed"))
+		return
+	}
+	if err := s.db.SetBaselineAnalysis(c.Request.Context(), analysis.ProjectID, analysis.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	analysis.Baseline = true
+	c.JSON(http.StatusOK, analysis)
+}
+
+// handleClearBaseline unmarks an analysis as its project's baseline.
+func (s *Server) handleClearBaseline(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if err := s.db.ClearBaselineAnalysis(c.Request.Context(), analysis.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	analysis.Baseline = false
+	c.JSON(http.StatusOK, analysis)
+}
+
+// deviation is the difference between an analysis and its project's
+// baseline capture.
+type deviation struct {
+	BaselineID       int64                   `json:"baselineId"`
+	BaselineFilename string                  `json:"baselineFilename"`
+	NewHosts         []database.Asset        `json:"newHosts"`
+	RemovedHosts     []database.Asset        `json:"removedHosts"`
+	NewServices      []database.AssetService `json:"newServices"`
+}
+
+// handleGetDeviation compares an analysis against its project's baseline.
+func (s *Server) handleGetDeviation(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	dev, err := s.baselineDeviation(c.Request.Context(), analysis)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if dev == nil {
+		c.JSON(http.StatusNotFound, apiError("baseline_not_set"))
+		return
+	}
+	c.JSON(http.StatusOK, dev)
+}
+
+// baselineDeviation computes the delta against the project baseline. It
+// returns nil when no baseline applies: the analysis is unfiled, no
+// baseline is set, or the analysis is the baseline itself.
+func (s *Server) baselineDeviation(ctx context.Context, analysis *database.Analysis) (*deviation, error) {
+	if analysis.ProjectID == 0 {
+		return nil, nil
+	}
+	base, err := s.db.GetBaselineAnalysis(ctx, analysis.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil || base.ID == analysis.ID {
+		return nil, nil
+	}
+
+	baseAssets, err := s.db.GetAssets(ctx, base.ID)
+	if err != nil {
+		return nil, err
+	}
+	curAssets, err := s.db.GetAssets(ctx, analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+	baseServices, err := s.db.GetAssetServices(ctx, base.ID)
+	if err != nil {
+		return nil, err
+	}
+	curServices, err := s.db.GetAssetServices(ctx, analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	dev := &deviation{
+		BaselineID:       base.ID,
+		BaselineFilename: base.Filename,
+		NewHosts:         []database.Asset{},
+		RemovedHosts:     []database.Asset{},
+		NewServices:      []database.AssetService{},
+	}
+
+	baseIPs := make(map[string]bool, len(baseAssets))
+	for _, a := range baseAssets {
+		baseIPs[a.IP] = true
+	}
+	curIPs := make(map[string]bool, len(curAssets))
+	for _, a := range curAssets {
+		curIPs[a.IP] = true
+		if !baseIPs[a.IP] {
+			dev.NewHosts = append(dev.NewHosts, a)
+		}
+	}
+	for _, a := range baseAssets {
+		if !curIPs[a.IP] {
+			dev.RemovedHosts = append(dev.RemovedHosts, a)
+		}
+	}
+
+	baseSvc := make(map[string]bool, len(baseServices))
+	for _, svc := range baseServices {
+		baseSvc[serviceKey(svc)] = true
+	}
+	for _, svc := range curServices {
+		if !baseSvc[serviceKey(svc)] {
+			dev.NewServices = append(dev.NewServices, svc)
+		}
+	}
+	return dev, nil
+}
+
+func serviceKey(svc database.AssetService) string {
+	return svc.IP + "/" + svc.Protocol + "/" + strconv.Itoa(svc.Port)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Every API error carries a stable machine-readable code alongside the
+// default English message, so clients can localize and branch on specific
+// conditions without string matching. Codes are part of the API contract:
+// rename a message freely, never a code. GET /codes serves the full
+// catalog.
+
+// errorMessages maps each error code to its default English message.
+var errorMessages = map[string]string{
+	"analysis_create_failed":        "create analysis",
+	"analysis_id_invalid":           "invalid analysis id",
+	"analysis_not_completed":        "analysis has not completed yet",
+	"analysis_not_failed":           "analysis is not failed",
+	"analysis_not_found":            "analysis not found",
+	"analysis_not_trashed":          "analysis is not in the trash",
+	"annotation_fields_required":    "kind and text are required",
+	"annotation_id_invalid":         "invalid annotation id",
+	"annotation_kind_invalid":       "kind must be tag or note",
+	"annotation_not_found":          "annotation not found",
+	"api_key_invalid":               "invalid API key",
+	"api_key_scope_denied":          "API key scope does not allow this request",
+	"api_key_self_manage":           "API keys cannot manage API keys",
+	"archive_encode_failed":         "encode archive",
+	"archive_import_failed":         "import rows",
+	"archive_malformed":             "malformed archive",
+	"archive_manifest_malformed":    "malformed analysis.json",
+	"archive_manifest_missing":      "archive is missing analysis.json",
+	"archive_not_gzip":              "not a gzip archive",
+	"archive_version_unsupported":   "unsupported archive version",
+	"auth_required":                 "authentication required",
+	"baseline_not_set":              "no baseline is set for this project",
+	"baseline_requires_project":     "analysis must be filed under a project to serve as its baseline",
+	"capture_store_failed":          "store capture",
+	"code_required":                 "code is required",
+	"credentials_invalid":           "invalid credentials",
+	"credentials_required":          "username and password are required",
+	"database_error":                "database error",
+	"delete_owner_only":             "only the owner can delete an analysis",
+	"export_format_invalid":         "format must be csv or stix",
+	"file_field_missing":            "missing file field",
+	"file_too_large":                "file too large",
+	"file_type_unsupported":         "only .pcap, .pcapng and .cap files are supported",
+	"finding_id_invalid":            "invalid finding id",
+	"finding_not_found":             "finding not found",
+	"granularity_invalid":           "granularity must be subnet or host",
+	"identity_claim_missing":        "no usable identity claim",
+	"identity_signature_invalid":    "invalid identity header signature",
+	"idp_unavailable":               "identity provider unavailable",
+	"key_id_invalid":                "invalid key id",
+	"limit_invalid":                 "invalid limit",
+	"name_required":                 "name is required",
+	"options_invalid":               "invalid analysis options",
+	"password_hash_failed":          "hash password",
+	"password_policy":               "password does not meet the password policy",
+	"passwords_required":            "oldPassword and newPassword are required",
+	"policy_rules_invalid":          "invalid policy rules",
+	"prefix_out_of_range":           "prefix must be between 1 and 32",
+	"project_id_invalid":            "invalid project id",
+	"project_name_required":         "project name is required",
+	"project_not_found":             "project not found",
+	"project_share_owner":           "cannot share a project with its owner",
+	"report_format_invalid":         "format must be html or pdf",
+	"report_pdf_failed":             "convert report to PDF",
+	"report_pdf_unconfigured":       "PDF output requires WKHTMLTOPDF_PATH to be configured",
+	"report_render_failed":          "render report",
+	"request_body_invalid":          "invalid request body",
+	"retry_owner_only":              "only the owner can retry an analysis",
+	"scope_invalid":                 "scope must be full, read or upload",
+	"search_criteria_required":      "at least one of ip, mac, port or domain is required",
+	"session_create_failed":         "create session",
+	"session_invalid":               "invalid or expired session",
+	"sso_not_configured":            "single sign-on is not configured",
+	"sso_state_generate_failed":     "generate state",
+	"sso_state_invalid":             "invalid or expired state",
+	"suppression_criteria_required": "a type or srcIp criterion is required",
+	"suppression_id_invalid":        "invalid suppression id",
+	"temp_file_failed":              "temp file",
+	"token_generate_failed":         "generate token",
+	"totp_already_enabled":          "two-factor authentication is already enabled",
+	"totp_enrollment_missing":       "no pending enrollment; call /2fa/enroll first",
+	"totp_invalid":                  "invalid two-factor code",
+	"totp_required":                 "two-factor code required",
+	"totp_secret_generate_failed":   "generate secret",
+	"upload_id_required":            "upload_id is required",
+	"upload_not_found":              "no upload in flight with that id",
+	"upload_read_failed":            "read upload",
+	"upload_store_failed":           "store upload",
+	"url_file_type_unsupported":     "URL must point to a .pcap, .pcapng or .cap file",
+	"url_required":                  "url is required",
+	"url_scheme_unsupported":        "only http and https URLs are supported",
+	"user_create_failed":            "create user",
+	"user_not_found":                "user not found",
+	"username_required":             "username is required",
+	"username_taken":                "username already taken",
+}
+
+// findingMessages maps each finding type the analyzer raises to a short
+// default English description. The per-finding title and description stay
+// free text; clients localizing findings key off Type.
+var findingMessages = map[string]string{
+	"broadcast-storm":          "broadcast storm from a single source",
+	"brute-force":              "password brute force or spray attempt",
+	"checksum-errors":          "host emits packets with invalid checksums",
+	"cleartext-on-tls-port":    "plaintext protocol on a TLS port",
+	"cleartext-sensitive-data": "sensitive data observed in cleartext",
+	"clock-skew":               "capture timestamps run backwards",
+	"connection-refusals":      "host refused many connections",
+	"cryptomining":             "host appears to be mining cryptocurrency",
+	"dark-space":               "traffic to an address that never responded",
+	"dcerpc-bind":              "remote management RPC use",
+	"dns-tunneling":            "possible DNS tunnel",
+	"gtp-signaling":            "mobile-core GTP-C signaling observed",
+	"gtp-tunnel":               "GTP-U tunneled traffic decapsulated",
+	"handshake-failures":       "failed connection attempts",
+	"icmp-tunneling":           "possible ICMP tunnel",
+	"ip-fragmentation":         "fragmented IP traffic reassembled",
+	"ip-mac-conflict":          "IP address claimed by multiple MACs",
+	"lateral-movement":         "remote execution against another host",
+	"long-lived-session":       "unusually long-lived session",
+	"malformed-packets":        "host emits malformed packets",
+	"nfs-activity":             "NFS traffic observed",
+	"policy-violation":         "user-defined policy rule matched",
+	"port-scan":                "possible port scan",
+	"remote-desktop":           "remote desktop session observed",
+	"resource-limit":           "analysis truncated by resource limit",
+	"ssh-session":              "SSH session observed",
+	"suspicious-user-agent":    "attack tool User-Agent observed",
+	"tftp-transfer":            "TFTP file transfer observed",
+	"tls-fingerprint-variance": "inconsistent TLS server fingerprint",
+	"tls-interception":         "TLS traffic intercepted by a middlebox",
+	"tls-issuer-anomaly":       "unexpected certificate issuer",
+	"tls-on-cleartext-port":    "TLS on a conventionally cleartext port",
+	"traffic-anomaly":          "anomalous traffic profile",
+	"ua-diversity":             "host presented many different User-Agents",
+	"weak-crypto":              "deprecated cryptography in use",
+	"webrtc-session":           "WebRTC session observed",
+}
+
+// apiError builds the standard error payload for a code.
+func apiError(code string) gin.H {
+	return gin.H{"error": errorMessages[code], "code": code}
+}
+
+// apiErrorParams is apiError with condition-specific parameters the
+// client can fold into its localized template.
+func apiErrorParams(code string, params gin.H) gin.H {
+	out := apiError(code)
+	out["params"] = params
+	return out
+}
+
+// handleGetCodes serves the full code catalog, unauthenticated so clients
+// can localize login and registration errors too.
+func (s *Server) handleGetCodes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"errors":   errorMessages,
+		"findings": findingMessages,
+	})
+}
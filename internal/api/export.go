@@ -0,0 +1,263 @@
+package api
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// exportVersion marks the archive layout, for forward compatibility.
+const exportVersion = 1
+
+// exportArchive is the JSON document inside an exported analysis.
+type exportArchive struct {
+	Version     int                     `json:"version"`
+	ExportedAt  time.Time               `json:"exportedAt"`
+	Analysis    database.Analysis       `json:"analysis"`
+	Assets      []database.Asset        `json:"assets"`
+	Connections []database.Connection   `json:"connections"`
+	DNSQueries  []database.DNSQuery     `json:"dnsQueries"`
+	Bindings    []database.IPMACBinding `json:"bindings"`
+	UserAgents  []database.UserAgent    `json:"userAgents"`
+	Findings    []database.Finding      `json:"findings"`
+}
+
+// handleExportAnalysis streams an analysis as a .tar.gz holding
+// analysis.json and, with ?pcap=1, the original capture, so work can move
+// between instances.
+func (s *Server) handleExportAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	arch := exportArchive{
+		Version:    exportVersion,
+		ExportedAt: time.Now().UTC(),
+		Analysis:   *analysis,
+	}
+	var err error
+	if arch.Assets, err = s.db.GetAssets(c.Request.Context(), analysis.ID); err == nil {
+		if arch.Connections, err = s.db.GetConnections(c.Request.Context(), analysis.ID); err == nil {
+			if arch.DNSQueries, err = s.db.GetDNSQueries(c.Request.Context(), analysis.ID); err == nil {
+				if arch.Bindings, err = s.db.GetIPMACBindings(c.Request.Context(), analysis.ID); err == nil {
+					if arch.UserAgents, err = s.db.GetUserAgents(c.Request.Context(), analysis.ID); err == nil {
+						arch.Findings, err = s.db.GetFindings(c.Request.Context(), analysis.ID)
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	doc, err := json.MarshalIndent(arch, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("archive_encode_failed"))
+		return
+	}
+
+	name := fmt.Sprintf("analysis-%d.tar.gz", analysis.ID)
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	gz := gzip.NewWriter(c.Writer)
+	tw := tar.NewWriter(gz)
+	defer func() {
+		tw.Close()
+		gz.Close()
+	}()
+
+	if err := writeTarFile(tw, "analysis.json", doc); err != nil {
+		return
+	}
+
+	if c.Query("pcap") == "1" {
+		rc, err := s.store.Open(analysis.FilePath)
+		if err != nil {
+			return // archive is still valid without the capture
+		}
+		defer rc.Close()
+		hdr := &tar.Header{
+			Name:    "capture" + filepath.Ext(analysis.Filename),
+			Mode:    0o644,
+			Size:    analysis.FileSize,
+			ModTime: analysis.UploadedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return
+		}
+		io.CopyN(tw, rc, analysis.FileSize)
+	}
+}
+
+// writeTarFile adds one in-memory file to a tar stream.
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// handleImportAnalysis accepts an exported archive and recreates the
+// analysis, its result rows and (when included) the capture under the
+// importing user's account.
+func (s *Server) handleImportAnalysis(c *gin.Context) {
+	user := currentUser(c)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("file_field_missing"))
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("upload_read_failed"))
+		return
+	}
+	defer src.Close()
+
+	gz, err := gzip.NewReader(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("archive_not_gzip"))
+		return
+	}
+	defer gz.Close()
+
+	var arch *exportArchive
+	var pcapTmp string
+	var pcapSize int64
+	var pcapExt string
+	defer func() {
+		if pcapTmp != "" {
+			os.Remove(pcapTmp)
+		}
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiError("archive_malformed"))
+			return
+		}
+		switch {
+		case hdr.Name == "analysis.json":
+			arch = &exportArchive{}
+			if err := json.NewDecoder(io.LimitReader(tr, maxUploadSize)).Decode(arch); err != nil {
+				c.JSON(http.StatusBadRequest, apiError("archive_manifest_malformed"))
+				return
+			}
+		case filepath.Base(hdr.Name) == hdr.Name && isCaptureName(hdr.Name):
+			tmp, err := os.CreateTemp("", "pcap-import-*")
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, apiError("temp_file_failed"))
+				return
+			}
+			n, err := io.Copy(tmp, io.LimitReader(tr, maxUploadSize))
+			tmp.Close()
+			if err != nil {
+				os.Remove(tmp.Name())
+				c.JSON(http.StatusBadRequest, apiError("archive_malformed"))
+				return
+			}
+			pcapTmp, pcapSize, pcapExt = tmp.Name(), n, filepath.Ext(hdr.Name)
+		}
+	}
+
+	if arch == nil {
+		c.JSON(http.StatusBadRequest, apiError("archive_manifest_missing"))
+		return
+	}
+	if arch.Version != exportVersion {
+		c.JSON(http.StatusBadRequest, apiError("archive_version_unsupported"))
+		return
+	}
+
+	key := ""
+	if pcapTmp != "" {
+		key = storage.ObjectKey(user.ID, "imported"+pcapExt)
+		f, err := os.Open(pcapTmp)
+		if err == nil {
+			err = s.store.Save(key, f, pcapSize)
+			f.Close()
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("capture_store_failed"))
+			return
+		}
+	}
+
+	id, err := s.db.CreateAnalysis(c.Request.Context(), user.ID, arch.Analysis.Filename, key, arch.Analysis.FileSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("analysis_create_failed"))
+		return
+	}
+	if err := s.importRows(c.Request.Context(), id, arch); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("archive_import_failed"))
+		return
+	}
+	s.db.SetAnalysisPacketCount(c.Request.Context(), id, arch.Analysis.PacketCount)
+	s.db.SetAnalysisStatus(c.Request.Context(), id, arch.Analysis.Status, arch.Analysis.Error)
+
+	analysis, err := s.db.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusCreated, analysis)
+}
+
+// importRows persists the result rows of an imported archive.
+func (s *Server) importRows(ctx context.Context, analysisID int64, arch *exportArchive) error {
+	if err := s.db.SaveAssets(ctx, analysisID, arch.Assets); err != nil {
+		return err
+	}
+	if err := s.db.SaveConnections(ctx, analysisID, arch.Connections); err != nil {
+		return err
+	}
+	if err := s.db.SaveDNSQueries(ctx, analysisID, arch.DNSQueries); err != nil {
+		return err
+	}
+	if err := s.db.SaveIPMACBindings(ctx, analysisID, arch.Bindings); err != nil {
+		return err
+	}
+	if err := s.db.SaveUserAgents(ctx, analysisID, arch.UserAgents); err != nil {
+		return err
+	}
+	return s.db.SaveFindings(ctx, analysisID, arch.Findings)
+}
+
+// isCaptureName reports whether a tar entry looks like the exported
+// capture file.
+func isCaptureName(name string) bool {
+	switch filepath.Ext(name) {
+	case ".pcap", ".pcapng", ".cap":
+		return true
+	}
+	return false
+}
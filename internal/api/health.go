@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleHealthz is the liveness probe: the process is up and serving.
+func (s *Server) handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe. It checks every dependency the
+// service needs to do useful work and reports each one, returning 503
+// when any check fails so load balancers stop routing here.
+func (s *Server) handleReadyz(c *gin.Context) {
+	checks := gin.H{}
+	healthy := true
+
+	if err := s.db.Ping(); err != nil {
+		checks["database"] = err.Error()
+		healthy = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	// A round-trip through the capture store proves it is writable.
+	probe := ".readyz-probe"
+	if err := s.store.Save(probe, strings.NewReader("ok"), 2); err != nil {
+		checks["storage"] = err.Error()
+		healthy = false
+	} else {
+		s.store.Delete(probe)
+		checks["storage"] = "ok"
+	}
+
+	stats := s.pool.Stats()
+	if stats.Workers < 1 {
+		checks["workers"] = "no workers running"
+		healthy = false
+	} else {
+		checks["workers"] = "ok"
+	}
+	checks["queueDepth"] = stats.QueueDepth
+
+	status := http.StatusOK
+	result := "ready"
+	if !healthy {
+		status = http.StatusServiceUnavailable
+		result = "not ready"
+	}
+	c.JSON(status, gin.H{"status": result, "checks": checks})
+}
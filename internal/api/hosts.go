@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// handleListHosts returns the caller's cross-capture host inventory. Each
+// entry is one physical machine correlated across analyses by MAC,
+// hostname or fingerprint, with a stable ID that assets reference via
+// their hostId field.
+func (s *Server) handleListHosts(c *gin.Context) {
+	hosts, err := s.db.ListHosts(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if hosts == nil {
+		hosts = []database.Host{}
+	}
+	c.JSON(http.StatusOK, hosts)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+)
+
+// ioc is one indicator extracted from an analysis: a public address that
+// was contacted or a domain that was looked up. The set grows as more
+// extractors persist shareable artifacts.
+type ioc struct {
+	Type      string // ipv4-addr, ipv6-addr or domain-name
+	Value     string
+	FirstSeen time.Time
+	Context   string
+}
+
+// handleExportIOCs returns the consolidated indicator list of an
+// analysis, as CSV by default or as a STIX 2.1 bundle with ?format=stix,
+// for handoff to blocklists and other security tools.
+func (s *Server) handleExportIOCs(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	conns, err := s.db.GetConnections(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	queries, err := s.db.GetDNSQueries(c.Request.Context(), analysis.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	seen := make(map[string]*ioc)
+	add := func(typ, value string, ts time.Time, context string) {
+		key := typ + "|" + value
+		if cur, ok := seen[key]; ok {
+			if ts.Before(cur.FirstSeen) {
+				cur.FirstSeen = ts
+			}
+			return
+		}
+		seen[key] = &ioc{Type: typ, Value: value, FirstSeen: ts, Context: context}
+	}
+
+	for _, conn := range conns {
+		for _, ip := range []string{conn.SrcIP, conn.DstIP} {
+			parsed := net.ParseIP(ip)
+			if parsed == nil || analyzer.ClassifyIP(parsed) != "public" {
+				continue
+			}
+			context := "contacted"
+			if conn.Service != "" {
+				context = "contacted over " + conn.Service
+			}
+			add(ipIndicatorType(parsed), ip, conn.FirstSeen, context)
+		}
+	}
+	for _, q := range queries {
+		domain := strings.ToLower(strings.TrimSuffix(q.Query, "."))
+		if domain == "" || strings.HasSuffix(domain, ".arpa") ||
+			strings.HasSuffix(domain, ".local") {
+			continue
+		}
+		add("domain-name", domain, q.Timestamp, "resolved via DNS ("+q.QueryType+")")
+	}
+
+	iocs := make([]ioc, 0, len(seen))
+	for _, i := range seen {
+		iocs = append(iocs, *i)
+	}
+	sort.Slice(iocs, func(a, b int) bool {
+		if iocs[a].Type != iocs[b].Type {
+			return iocs[a].Type < iocs[b].Type
+		}
+		return iocs[a].Value < iocs[b].Value
+	})
+
+	switch c.DefaultQuery("format", "csv") {
+	case "csv":
+		writeIOCsCSV(c, analysis.ID, iocs)
+	case "stix":
+		writeIOCsSTIX(c, analysis.ID, iocs)
+	default:
+		c.JSON(http.StatusBadRequest, apiError("export_format_invalid"))
+	}
+}
+
+func writeIOCsCSV(c *gin.Context, analysisID int64, iocs []ioc) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("analysis-%d-iocs.csv", analysisID)))
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"type", "value", "first_seen", "context"})
+	for _, i := range iocs {
+		w.Write([]string{i.Type, i.Value, i.FirstSeen.UTC().Format(time.RFC3339), i.Context})
+	}
+	w.Flush()
+}
+
+// writeIOCsSTIX emits a STIX 2.1 bundle of indicator objects with stix
+// comparison patterns, the lingua franca of threat-intel platforms.
+func writeIOCsSTIX(c *gin.Context, analysisID int64, iocs []ioc) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	objects := make([]gin.H, 0, len(iocs))
+	for _, i := range iocs {
+		objects = append(objects, gin.H{
+			"type":         "indicator",
+			"spec_version": "2.1",
+			"id":           "indicator--" + stixUUID(),
+			"created":      now,
+			"modified":     now,
+			"name":         i.Context + ": " + i.Value,
+			"pattern":      fmt.Sprintf("[%s:value = '%s']", i.Type, strings.ReplaceAll(i.Value, "'", "")),
+			"pattern_type": "stix",
+			"valid_from":   i.FirstSeen.UTC().Format(time.RFC3339),
+		})
+	}
+
+	c.Header("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("analysis-%d-iocs.stix.json", analysisID)))
+	c.JSON(http.StatusOK, gin.H{
+		"type":    "bundle",
+		"id":      "bundle--" + stixUUID(),
+		"objects": objects,
+	})
+}
+
+func ipIndicatorType(ip net.IP) string {
+	if ip.To4() != nil {
+		return "ipv4-addr"
+	}
+	return "ipv6-addr"
+}
+
+// stixUUID returns a random RFC 4122 version-4 UUID, as STIX identifiers
+// require.
+func stixUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "00000000-0000-4000-8000-000000000000"
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/events"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Origin is checked by the CORS layer; the WebSocket carries only
+	// data the authenticated owner may read anyway.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleLiveTail streams findings, newly discovered assets and status
+// transitions over a WebSocket while the analysis runs, so triage can
+// start before the capture finishes processing.
+func (s *Server) handleLiveTail(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade already wrote the error response
+	}
+	defer conn.Close()
+
+	ch := events.Default.Subscribe(analysis.ID)
+	defer events.Default.Unsubscribe(analysis.ID, ch)
+
+	// Drain client frames so pings and close messages are processed.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// If the analysis is already finished, tell the client immediately.
+	if analysis.Status == "completed" || analysis.Status == "failed" {
+		conn.WriteJSON(events.Event{
+			AnalysisID: analysis.ID,
+			Type:       "status",
+			Payload:    analysis.Status,
+			Time:       time.Now(),
+		})
+		return
+	}
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+			if ev.Type == "status" && (ev.Payload == "completed" || ev.Payload == "failed") {
+				return
+			}
+		case <-done:
+			return
+		case <-time.After(30 * time.Second):
+			if err := conn.WriteControl(websocket.PingMessage, nil,
+				time.Now().Add(5*time.Second)); err != nil {
+				slog.Warn("live tail ping failed", "analysis_id", analysis.ID, "err", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDC single sign-on via the authorization-code flow. The provider is
+// configured with OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and
+// OIDC_REDIRECT_URL; endpoints are discovered from the issuer's
+// well-known document. Identity comes from the provider's userinfo
+// endpoint, fetched directly over TLS, so no local token signature
+// verification is needed.
+
+// oidcStateTTL bounds how long a login may sit between the redirect to
+// the provider and the callback.
+const oidcStateTTL = 10 * time.Minute
+
+var oidcClient = &http.Client{Timeout: 10 * time.Second}
+
+type oidcConfig struct {
+	issuer        string
+	clientID      string
+	clientSecret  string
+	redirectURL   string
+	usernameClaim string
+}
+
+// oidcFromEnv reads the provider configuration, returning nil when SSO is
+// not configured.
+func oidcFromEnv() *oidcConfig {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+	return &oidcConfig{
+		issuer:        strings.TrimSuffix(issuer, "/"),
+		clientID:      os.Getenv("OIDC_CLIENT_ID"),
+		clientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		redirectURL:   os.Getenv("OIDC_REDIRECT_URL"),
+		usernameClaim: envStr("OIDC_USERNAME_CLAIM", "preferred_username"),
+	}
+}
+
+// oidcProvider holds the endpoints discovered from the issuer.
+type oidcProvider struct {
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserinfoURL string `json:"userinfo_endpoint"`
+}
+
+// oidcProvider discovers (and caches) the provider endpoints.
+func (s *Server) oidcProvider() (*oidcProvider, error) {
+	s.oidcMu.Lock()
+	defer s.oidcMu.Unlock()
+	if s.oidcProv != nil {
+		return s.oidcProv, nil
+	}
+	resp, err := oidcClient.Get(s.oidc.issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery: status %d", resp.StatusCode)
+	}
+	var prov oidcProvider
+	if err := json.NewDecoder(resp.Body).Decode(&prov); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if prov.AuthURL == "" || prov.TokenURL == "" || prov.UserinfoURL == "" {
+		return nil, fmt.Errorf("oidc discovery: incomplete document")
+	}
+	s.oidcProv = &prov
+	return s.oidcProv, nil
+}
+
+// handleOIDCLogin starts the authorization-code flow by redirecting the
+// browser to the provider.
+func (s *Server) handleOIDCLogin(c *gin.Context) {
+	if s.oidc == nil {
+		c.JSON(http.StatusNotFound, apiError("sso_not_configured"))
+		return
+	}
+	prov, err := s.oidcProvider()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, apiError("idp_unavailable"))
+		return
+	}
+	state, err := newSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("sso_state_generate_failed"))
+		return
+	}
+	s.oidcMu.Lock()
+	for k, t := range s.oidcStates {
+		if time.Since(t) > oidcStateTTL {
+			delete(s.oidcStates, k)
+		}
+	}
+	s.oidcStates[state] = time.Now()
+	s.oidcMu.Unlock()
+
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {s.oidc.clientID},
+		"redirect_uri":  {s.oidc.redirectURL},
+		"scope":         {"openid profile email"},
+		"state":         {state},
+	}
+	c.Redirect(http.StatusFound, prov.AuthURL+"?"+q.Encode())
+}
+
+// handleOIDCCallback finishes the flow: it validates state, exchanges the
+// code for tokens, resolves the identity via userinfo and opens a local
+// session, provisioning the user on first login.
+func (s *Server) handleOIDCCallback(c *gin.Context) {
+	if s.oidc == nil {
+		c.JSON(http.StatusNotFound, apiError("sso_not_configured"))
+		return
+	}
+	state, code := c.Query("state"), c.Query("code")
+	s.oidcMu.Lock()
+	started, ok := s.oidcStates[state]
+	delete(s.oidcStates, state)
+	s.oidcMu.Unlock()
+	if !ok || time.Since(started) > oidcStateTTL {
+		c.JSON(http.StatusBadRequest, apiError("sso_state_invalid"))
+		return
+	}
+	if code == "" {
+		c.JSON(http.StatusBadRequest, apiError("code_required"))
+		return
+	}
+
+	claims, err := s.oidcExchange(code)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, apiError("idp_unavailable"))
+		return
+	}
+	username, _ := claims[s.oidc.usernameClaim].(string)
+	if username == "" {
+		username, _ = claims["email"].(string)
+	}
+	if username == "" {
+		username, _ = claims["sub"].(string)
+	}
+	if username == "" {
+		c.JSON(http.StatusBadGateway, apiError("identity_claim_missing"))
+		return
+	}
+
+	user, err := s.provisionExternalUser(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	token, err := newSessionToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("token_generate_failed"))
+		return
+	}
+	if err := s.sessions.Create(c.Request.Context(), token, user.ID, time.Now().Add(sessionDuration)); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("session_create_failed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+}
+
+// oidcExchange trades the authorization code for tokens and returns the
+// userinfo claims.
+func (s *Server) oidcExchange(code string) (map[string]any, error) {
+	prov, err := s.oidcProvider()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.oidc.redirectURL},
+		"client_id":     {s.oidc.clientID},
+		"client_secret": {s.oidc.clientSecret},
+	}
+	resp, err := oidcClient.PostForm(prov.TokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange: status %d", resp.StatusCode)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("token exchange: no access token")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, prov.UserinfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	uresp, err := oidcClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("userinfo: %w", err)
+	}
+	defer uresp.Body.Close()
+	if uresp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo: status %d", uresp.StatusCode)
+	}
+	var claims map[string]any
+	if err := json.NewDecoder(uresp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("userinfo: %w", err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// handleCreateProject creates a new project folder for the caller.
+func (s *Server) handleCreateProject(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, apiError("project_name_required"))
+		return
+	}
+	id, err := s.db.CreateProject(c.Request.Context(), currentUser(c).ID, strings.TrimSpace(req.Name))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	project, err := s.db.GetProject(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusCreated, project)
+}
+
+// handleListProjects returns the caller's projects plus any shared with
+// them.
+func (s *Server) handleListProjects(c *gin.Context) {
+	projects, err := s.db.ListProjects(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if projects == nil {
+		projects = []database.Project{}
+	}
+	c.JSON(http.StatusOK, projects)
+}
+
+// handleRenameProject renames a project. Owner only.
+func (s *Server) handleRenameProject(c *gin.Context) {
+	project := s.ownedProject(c)
+	if project == nil {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, apiError("project_name_required"))
+		return
+	}
+	if err := s.db.RenameProject(c.Request.Context(), project.ID, strings.TrimSpace(req.Name)); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	project.Name = strings.TrimSpace(req.Name)
+	c.JSON(http.StatusOK, project)
+}
+
+// handleDeleteProject deletes a project; its analyses become unfiled
+// rather than being deleted with it. Owner only.
+func (s *Server) handleDeleteProject(c *gin.Context) {
+	project := s.ownedProject(c)
+	if project == nil {
+		return
+	}
+	if err := s.db.DeleteProject(c.Request.Context(), project.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// handleShareProject grants another user read access to a project. Owner
+// only.
+func (s *Server) handleShareProject(c *gin.Context) {
+	project := s.ownedProject(c)
+	if project == nil {
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, apiError("username_required"))
+		return
+	}
+	user, err := s.db.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, apiError("user_not_found"))
+		return
+	}
+	if user.ID == project.UserID {
+		c.JSON(http.StatusBadRequest, apiError("project_share_owner"))
+		return
+	}
+	if err := s.db.ShareProject(c.Request.Context(), project.ID, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "shared"})
+}
+
+// handleUnshareProject revokes a user's access to a project. Owner only.
+func (s *Server) handleUnshareProject(c *gin.Context) {
+	project := s.ownedProject(c)
+	if project == nil {
+		return
+	}
+	username := c.Query("username")
+	if username == "" {
+		c.JSON(http.StatusBadRequest, apiError("username_required"))
+		return
+	}
+	user, err := s.db.GetUserByUsername(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, apiError("user_not_found"))
+		return
+	}
+	if err := s.db.UnshareProject(c.Request.Context(), project.ID, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "unshared"})
+}
+
+// handleMoveAnalysis files an analysis under a project (or unfiles it
+// with projectId 0). The analysis must be owned; the target project must
+// be accessible.
+func (s *Server) handleMoveAnalysis(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	var req struct {
+		ProjectID int64 `json:"projectId"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ProjectID < 0 {
+		c.JSON(http.StatusBadRequest, apiError("project_id_invalid"))
+		return
+	}
+	if req.ProjectID != 0 {
+		ok, err := s.db.ProjectAccessible(c.Request.Context(), req.ProjectID, currentUser(c).ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusNotFound, apiError("project_not_found"))
+			return
+		}
+	}
+	if err := s.db.SetAnalysisProject(c.Request.Context(), analysis.ID, req.ProjectID); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	analysis.ProjectID = req.ProjectID
+	c.JSON(http.StatusOK, analysis)
+}
+
+// ownedProject loads the :projectID project and verifies the caller owns
+// it. On any failure it writes the error response and returns nil.
+func (s *Server) ownedProject(c *gin.Context) *database.Project {
+	id, err := strconv.ParseInt(c.Param("projectID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("project_id_invalid"))
+		return nil
+	}
+	project, err := s.db.GetProject(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return nil
+	}
+	if project == nil || project.UserID != currentUser(c).ID {
+		c.JSON(http.StatusNotFound, apiError("project_not_found"))
+		return nil
+	}
+	return project
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// Identity headers set by a trusting reverse proxy. The signature is the
+// hex HMAC-SHA256 of the username under PROXY_AUTH_SECRET, so a client
+// reaching the backend directly cannot forge the user header.
+const (
+	proxyUserHeader = "X-Forwarded-User"
+	proxySigHeader  = "X-Forwarded-User-Sig"
+)
+
+// disabledPasswordHash marks externally authenticated accounts. It is not
+// a valid bcrypt hash, so password login for these users always fails.
+const disabledPasswordHash = "*"
+
+// proxyAuthUser resolves the request to a user via an upstream identity:
+// a verified client certificate (mTLS terminated by this server) or the
+// signed X-Forwarded-User header from a reverse proxy. It returns nil when
+// neither applies; the caller then falls back to session auth. Unknown
+// usernames are provisioned on first sight with password login disabled.
+func (s *Server) proxyAuthUser(c *gin.Context) (*database.User, error) {
+	if tls := c.Request.TLS; tls != nil && len(tls.PeerCertificates) > 0 {
+		// The TLS layer already verified the chain against the client CA.
+		if cn := tls.PeerCertificates[0].Subject.CommonName; cn != "" {
+			return s.provisionExternalUser(c.Request.Context(), cn)
+		}
+	}
+
+	if s.proxyAuthSecret == "" {
+		return nil, nil
+	}
+	username := c.GetHeader(proxyUserHeader)
+	if username == "" {
+		return nil, nil
+	}
+	mac := hmac.New(sha256.New, []byte(s.proxyAuthSecret))
+	mac.Write([]byte(username))
+	want := hex.EncodeToString(mac.Sum(nil))
+	got := c.GetHeader(proxySigHeader)
+	if !hmac.Equal([]byte(want), []byte(got)) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, apiError("identity_signature_invalid"))
+		return nil, errInvalidProxySig
+	}
+	return s.provisionExternalUser(c.Request.Context(), username)
+}
+
+// errInvalidProxySig signals that the middleware already wrote a response.
+var errInvalidProxySig = &proxySigError{}
+
+type proxySigError struct{}
+
+func (*proxySigError) Error() string { return "invalid proxy auth signature" }
+
+// provisionExternalUser looks the username up, creating the account on
+// first sight.
+func (s *Server) provisionExternalUser(ctx context.Context, username string) (*database.User, error) {
+	user, err := s.db.GetUserByUsername(ctx, username)
+	if err != nil || user != nil {
+		return user, err
+	}
+	user, err = s.db.CreateUser(ctx, username, disabledPasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("auto-provisioned external user", "username", username)
+	return user, nil
+}
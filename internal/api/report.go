@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// reportData is everything the report template needs.
+type reportData struct {
+	Analysis    *database.Analysis
+	GeneratedAt time.Time
+	AssetCount  int
+	TopAssets   []database.Asset
+	Findings    []database.Finding
+	Protocols   []protocolShare
+	// Deviation is nil unless the project has a baseline to compare with.
+	Deviation *deviation
+}
+
+// protocolShare is one slice of the protocol distribution chart.
+type protocolShare struct {
+	Service string
+	Bytes   int64
+	Percent float64
+}
+
+// handleGetReport renders a standalone, shareable report of the analysis.
+// format=html (default) returns self-contained HTML; format=pdf converts
+// it through wkhtmltopdf when WKHTMLTOPDF_PATH is configured.
+func (s *Server) handleGetReport(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if analysis.Status != "completed" {
+		c.JSON(http.StatusConflict, apiError("analysis_not_completed"))
+		return
+	}
+
+	data, err := s.buildReportData(c.Request.Context(), analysis)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("report_render_failed"))
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Header("Content-Disposition",
+			fmt.Sprintf("inline; filename=%q", analysis.Filename+"-report.html"))
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	case "pdf":
+		converter := os.Getenv("WKHTMLTOPDF_PATH")
+		if converter == "" {
+			c.JSON(http.StatusNotImplemented,
+				apiError("report_pdf_unconfigured"))
+			return
+		}
+		pdf, err := htmlToPDF(converter, buf.Bytes())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("report_pdf_failed"))
+			return
+		}
+		c.Header("Content-Disposition",
+			fmt.Sprintf("attachment; filename=%q", analysis.Filename+"-report.pdf"))
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	default:
+		c.JSON(http.StatusBadRequest, apiError("report_format_invalid"))
+	}
+}
+
+func (s *Server) buildReportData(ctx context.Context, analysis *database.Analysis) (*reportData, error) {
+	assets, err := s.db.GetAssets(ctx, analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+	findings, err := s.db.GetFindings(ctx, analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Suppressed findings are managed false positives; keep them out of
+	// the report.
+	kept := findings[:0]
+	for _, f := range findings {
+		if !f.Suppressed {
+			kept = append(kept, f)
+		}
+	}
+	findings = kept
+	conns, err := s.db.GetConnections(ctx, analysis.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].BytesSent+assets[i].BytesRecv >
+			assets[j].BytesSent+assets[j].BytesRecv
+	})
+	top := assets
+	if len(top) > 10 {
+		top = top[:10]
+	}
+
+	byService := make(map[string]int64)
+	var total int64
+	for _, conn := range conns {
+		service := conn.Service
+		if service == "" {
+			service = "other"
+		}
+		byService[service] += conn.BytesSent + conn.BytesRecv
+		total += conn.BytesSent + conn.BytesRecv
+	}
+	var protocols []protocolShare
+	for service, bytes := range byService {
+		share := protocolShare{Service: service, Bytes: bytes}
+		if total > 0 {
+			share.Percent = float64(bytes) * 100 / float64(total)
+		}
+		protocols = append(protocols, share)
+	}
+	sort.Slice(protocols, func(i, j int) bool { return protocols[i].Bytes > protocols[j].Bytes })
+
+	severityRank := map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3, "info": 4}
+	sort.Slice(findings, func(i, j int) bool {
+		return severityRank[findings[i].Severity] < severityRank[findings[j].Severity]
+	})
+
+	dev, err := s.baselineDeviation(ctx, analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reportData{
+		Analysis:    analysis,
+		GeneratedAt: time.Now(),
+		AssetCount:  len(assets),
+		TopAssets:   top,
+		Findings:    findings,
+		Protocols:   protocols,
+		Deviation:   dev,
+	}, nil
+}
+
+// htmlToPDF shells out to wkhtmltopdf, feeding HTML on stdin.
+func htmlToPDF(converter string, html []byte) ([]byte, error) {
+	cmd := exec.Command(converter, "-q", "-", "-")
+	cmd.Stdin = bytes.NewReader(html)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Capture report — {{.Analysis.Filename}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 60em; color: #1a202c; }
+h1 { border-bottom: 2px solid #2b6cb0; padding-bottom: .3em; }
+h2 { color: #2b6cb0; margin-top: 1.6em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .4em .6em; border-bottom: 1px solid #e2e8f0; }
+th { background: #f7fafc; }
+.sev-critical, .sev-high { color: #c53030; font-weight: bold; }
+.sev-medium { color: #c05621; }
+.sev-low, .sev-info { color: #4a5568; }
+.meta { color: #718096; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>Capture analysis report</h1>
+<p class="meta">Capture: <strong>{{.Analysis.Filename}}</strong> ·
+{{.Analysis.PacketCount}} packets · {{.AssetCount}} hosts ·
+generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+
+<h2>Findings ({{len .Findings}})</h2>
+{{if .Findings}}
+<table>
+<tr><th>Severity</th><th>Type</th><th>Title</th><th>Description</th></tr>
+{{range .Findings}}
+<tr><td class="sev-{{.Severity}}">{{.Severity}}</td><td>{{.Type}}</td><td>{{.Title}}</td><td>{{.Description}}</td></tr>
+{{end}}
+</table>
+{{else}}<p>No findings were raised.</p>{{end}}
+
+{{with .Deviation}}
+<h2>Deviation from baseline</h2>
+<p class="meta">Compared against baseline capture <strong>{{.BaselineFilename}}</strong>.</p>
+{{if .NewHosts}}
+<h3>New hosts ({{len .NewHosts}})</h3>
+<table>
+<tr><th>IP</th><th>MAC</th><th>Vendor</th><th>OS</th></tr>
+{{range .NewHosts}}
+<tr><td>{{.IP}}</td><td>{{.MAC}}</td><td>{{.Vendor}}</td><td>{{.OS}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if .RemovedHosts}}
+<h3>Removed hosts ({{len .RemovedHosts}})</h3>
+<table>
+<tr><th>IP</th><th>MAC</th><th>Vendor</th><th>OS</th></tr>
+{{range .RemovedHosts}}
+<tr><td>{{.IP}}</td><td>{{.MAC}}</td><td>{{.Vendor}}</td><td>{{.OS}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if .NewServices}}
+<h3>New services ({{len .NewServices}})</h3>
+<table>
+<tr><th>IP</th><th>Port</th><th>Protocol</th><th>Service</th></tr>
+{{range .NewServices}}
+<tr><td>{{.IP}}</td><td>{{.Port}}</td><td>{{.Protocol}}</td><td>{{.Service}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{if and (not .NewHosts) (not .RemovedHosts) (not .NewServices)}}
+<p>No deviation from the baseline was observed.</p>
+{{end}}
+{{end}}
+
+<h2>Protocol distribution</h2>
+<table>
+<tr><th>Service</th><th>Bytes</th><th>Share</th></tr>
+{{range .Protocols}}
+<tr><td>{{.Service}}</td><td>{{.Bytes}}</td><td>{{printf "%.1f" .Percent}}%</td></tr>
+{{end}}
+</table>
+
+<h2>Top hosts by traffic</h2>
+<table>
+<tr><th>IP</th><th>MAC</th><th>Vendor</th><th>OS</th><th>Bytes sent</th><th>Bytes received</th></tr>
+{{range .TopAssets}}
+<tr><td>{{.IP}}</td><td>{{.MAC}}</td><td>{{.Vendor}}</td><td>{{.OS}}</td><td>{{.BytesSent}}</td><td>{{.BytesRecv}}</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware tags every request with an ID (honoring one supplied
+// by an upstream proxy), echoes it in the response and emits one
+// structured access-log line per request.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set("requestID", id)
+		c.Header(requestIDHeader, id)
+
+		start := time.Now()
+		c.Next()
+
+		slog.Info("request",
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"client", c.ClientIP(),
+		)
+	}
+}
+
+// newRequestID returns a short random hex identifier.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// handleSearch answers "have we seen this indicator before?" by searching
+// connections, assets and DNS records across every analysis the caller
+// owns. Indicators combine: /api/search?ip=10.0.0.5&port=445 finds only
+// captures where that host used that port.
+func (s *Server) handleSearch(c *gin.Context) {
+	q := database.IndicatorQuery{
+		IP:     c.Query("ip"),
+		MAC:    c.Query("mac"),
+		Domain: c.Query("domain"),
+	}
+	if n, err := strconv.Atoi(c.Query("port")); err == nil && n > 0 && n < 65536 {
+		q.Port = n
+	}
+	if q.IP == "" && q.MAC == "" && q.Domain == "" && q.Port == 0 {
+		c.JSON(http.StatusBadRequest, apiError("search_criteria_required"))
+		return
+	}
+
+	hits, err := s.db.SearchIndicators(c.Request.Context(), currentUser(c).ID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if hits == nil {
+		hits = []database.IndicatorHit{}
+	}
+	c.JSON(http.StatusOK, gin.H{"hits": hits})
+}
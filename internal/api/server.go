@@ -0,0 +1,219 @@
+// Package api exposes the REST interface of the analyzer.
+package api
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/worker"
+)
+
+// Server holds the dependencies shared by all handlers.
+type Server struct {
+	db    *database.DB
+	pool  *worker.WorkerPool
+	store storage.Storage
+
+	// sessions caches token lookups in front of the database.
+	sessions sessionStore
+
+	minPasswordLen int
+	bcryptCost     int
+
+	// proxyAuthSecret enables reverse-proxy identity headers when set.
+	proxyAuthSecret string
+
+	// oidc is nil unless single sign-on is configured via OIDC_*.
+	oidc       *oidcConfig
+	oidcMu     sync.Mutex
+	oidcProv   *oidcProvider
+	oidcStates map[string]time.Time
+}
+
+// NewServer wires up a Server. Password policy and hashing cost come from
+// PASSWORD_MIN_LENGTH and BCRYPT_COST; setting PROXY_AUTH_SECRET turns on
+// trust of signed identity headers from a reverse proxy.
+func NewServer(db *database.DB, pool *worker.WorkerPool, store storage.Storage) *Server {
+	return &Server{
+		db:              db,
+		pool:            pool,
+		store:           store,
+		sessions:        newSessionCache(db),
+		minPasswordLen:  envInt("PASSWORD_MIN_LENGTH", 8),
+		bcryptCost:      envInt("BCRYPT_COST", bcrypt.DefaultCost),
+		proxyAuthSecret: os.Getenv("PROXY_AUTH_SECRET"),
+		oidc:            oidcFromEnv(),
+		oidcStates:      make(map[string]time.Time),
+	}
+}
+
+// envInt reads an integer environment variable, falling back on absence or
+// garbage.
+func envInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// Router builds the gin engine with all routes registered.
+func (s *Server) Router() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(requestIDMiddleware())
+	r.Use(corsMiddleware())
+	r.Use(requestTimeoutMiddleware(
+		time.Duration(envInt("REQUEST_TIMEOUT_SECONDS", 120)) * time.Second))
+
+	// /health is the legacy liveness path; /healthz and /readyz follow
+	// the Kubernetes probe convention.
+	r.GET("/health", s.handleHealthz)
+	r.GET("/healthz", s.handleHealthz)
+	r.GET("/readyz", s.handleReadyz)
+
+	api := r.Group("/api")
+	api.GET("/codes", s.handleGetCodes)
+	api.POST("/register", s.handleRegister)
+	api.POST("/login", s.handleLogin)
+	api.GET("/oidc/login", s.handleOIDCLogin)
+	api.GET("/oidc/callback", s.handleOIDCCallback)
+
+	auth := api.Group("")
+	auth.Use(s.authMiddleware())
+	auth.POST("/logout", s.handleLogout)
+	auth.GET("/me", s.handleMe)
+	auth.POST("/password", s.handleChangePassword)
+	auth.POST("/2fa/enroll", s.handleTOTPEnroll)
+	auth.POST("/2fa/verify", s.handleTOTPVerify)
+	auth.POST("/2fa/disable", s.handleTOTPDisable)
+	auth.POST("/apikeys", s.handleCreateAPIKey)
+	auth.GET("/apikeys", s.handleListAPIKeys)
+	auth.DELETE("/apikeys/:keyID", s.handleDeleteAPIKey)
+	auth.POST("/upload", s.handleUpload)
+	auth.GET("/upload/progress", s.handleUploadProgress)
+	auth.POST("/upload/url", s.handleUploadURL)
+	auth.GET("/analysis/:id/fetch-progress", s.handleFetchProgress)
+	auth.GET("/analyses", s.handleListAnalyses)
+	auth.POST("/projects", s.handleCreateProject)
+	auth.GET("/projects", s.handleListProjects)
+	auth.PUT("/projects/:projectID", s.handleRenameProject)
+	auth.DELETE("/projects/:projectID", s.handleDeleteProject)
+	auth.POST("/projects/:projectID/share", s.handleShareProject)
+	auth.DELETE("/projects/:projectID/share", s.handleUnshareProject)
+	auth.PUT("/analysis/:id/project", s.handleMoveAnalysis)
+	auth.GET("/search", s.handleSearch)
+	auth.GET("/hosts", s.handleListHosts)
+	auth.GET("/analysis/:id", s.handleGetAnalysis)
+	auth.DELETE("/analysis/:id", s.handleDeleteAnalysis)
+	auth.GET("/trash", s.handleListTrash)
+	auth.POST("/analysis/:id/restore", s.handleRestoreAnalysis)
+	auth.POST("/analysis/:id/reprocess", s.handleReprocessAnalysis)
+	auth.POST("/analysis/:id/retry", s.handleRetryAnalysis)
+	auth.GET("/analysis/:id/assets", s.handleGetAssets)
+	auth.GET("/analysis/:id/connections", s.handleGetConnections)
+	auth.GET("/analysis/:id/conversations", s.handleGetConversations)
+	auth.GET("/analysis/:id/flows/aggregate", s.handleFlowAggregate)
+	auth.GET("/analysis/:id/bandwidth", s.handleGetBandwidth)
+	auth.GET("/analysis/:id/broadcast", s.handleGetBroadcastSummary)
+	auth.GET("/analysis/:id/multicast", s.handleGetMulticast)
+	auth.GET("/analysis/:id/latency", s.handleGetLatency)
+	auth.GET("/analysis/:id/protocols", s.handleGetProtocols)
+	auth.GET("/analysis/:id/accounts", s.handleGetAccounts)
+	auth.GET("/analysis/:id/durations", s.handleGetDurations)
+	auth.POST("/analysis/:id/baseline", s.handleSetBaseline)
+	auth.DELETE("/analysis/:id/baseline", s.handleClearBaseline)
+	auth.GET("/analysis/:id/deviation", s.handleGetDeviation)
+	auth.GET("/analysis/:id/topology", s.handleGetTopology)
+	auth.GET("/analysis/:id/attack", s.handleGetAttackCoverage)
+	auth.GET("/analysis/:id/dns", s.handleGetDNS)
+	auth.GET("/analysis/:id/dns-health", s.handleGetDNSHealth)
+	auth.GET("/analysis/:id/findings", s.handleGetFindings)
+	auth.POST("/analysis/:id/findings/:findingID/suppress", s.handleSuppressFinding)
+	auth.DELETE("/analysis/:id/findings/:findingID/suppress", s.handleUnsuppressFinding)
+	auth.POST("/suppressions", s.handleCreateSuppression)
+	auth.GET("/suppressions", s.handleListSuppressions)
+	auth.DELETE("/suppressions/:suppressionID", s.handleDeleteSuppression)
+	auth.GET("/analysis/:id/bindings", s.handleGetBindings)
+	auth.GET("/analysis/:id/vendors", s.handleGetVendorSummary)
+	auth.GET("/analysis/:id/user-agents", s.handleGetUserAgents)
+	auth.GET("/analysis/:id/services", s.handleGetAssetServices)
+	auth.GET("/analysis/:id/report", s.handleGetReport)
+	auth.GET("/analysis/:id/live", s.handleLiveTail)
+	auth.GET("/analysis/:id/events", s.handleAnalysisEvents)
+	auth.GET("/analysis/:id/annotations", s.handleListAnnotations)
+	auth.POST("/analysis/:id/annotations", s.handleCreateAnnotation)
+	auth.DELETE("/analysis/:id/annotations/:annotationID", s.handleDeleteAnnotation)
+	auth.GET("/analysis/:id/annotations/ws", s.handleAnnotationStream)
+	auth.GET("/analysis/:id/export", s.handleExportAnalysis)
+	auth.GET("/analysis/:id/iocs", s.handleExportIOCs)
+	auth.POST("/import", s.handleImportAnalysis)
+
+	return r
+}
+
+// corsMiddleware reflects origins listed in CORS_ORIGINS (comma
+// separated, "*" for any). The default covers the development frontend.
+func corsMiddleware() gin.HandlerFunc {
+	allowed := strings.Split(envStr("CORS_ORIGINS", "http://localhost:3000"), ",")
+	for i := range allowed {
+		allowed[i] = strings.TrimSpace(allowed[i])
+	}
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		for _, a := range allowed {
+			if a == "*" || a == origin {
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Access-Control-Allow-Credentials", "true")
+				c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type")
+				c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				break
+			}
+		}
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestTimeoutMiddleware bounds each request's context so an abandoned
+// or slow request cannot keep an expensive query running on SQLite's
+// single connection; handlers thread the context into every database
+// call. Streaming endpoints (websockets, SSE) and capture uploads run as
+// long as they need.
+func requestTimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exempt := c.IsWebsocket() ||
+			strings.Contains(c.GetHeader("Accept"), "text/event-stream") ||
+			c.Request.URL.Path == "/api/upload" ||
+			c.Request.URL.Path == "/api/import"
+		if exempt {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// envStr reads a string environment variable with a fallback.
+func envStr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
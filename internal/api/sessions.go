@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+// sessionCacheTTL bounds how long a cached session is trusted before it is
+// re-resolved against the database. It caps the staleness of remote
+// revocations and profile changes (password, TOTP) at one minute.
+const sessionCacheTTL = time.Minute
+
+// sessionStore resolves and manages login sessions. The default
+// implementation caches on top of SQLite; a Redis-backed store can slot in
+// for multi-instance deployments.
+type sessionStore interface {
+	Create(ctx context.Context, token string, userID int64, expiresAt time.Time) error
+	// User resolves a token to its user, or nil for unknown and expired
+	// tokens.
+	User(ctx context.Context, token string) (*database.User, error)
+	Delete(ctx context.Context, token string) error
+}
+
+// cachedSessions keeps recently resolved sessions in memory so the hot
+// path of every authenticated request does not consume a database
+// connection.
+type cachedSessions struct {
+	db *database.DB
+
+	mu    sync.Mutex
+	cache map[string]cachedSession
+}
+
+type cachedSession struct {
+	user    *database.User
+	checked time.Time
+}
+
+func newSessionCache(db *database.DB) *cachedSessions {
+	return &cachedSessions{db: db, cache: make(map[string]cachedSession)}
+}
+
+// Create stores the session and primes the cache, so the first request
+// after login is already a hit.
+func (cs *cachedSessions) Create(ctx context.Context, token string, userID int64, expiresAt time.Time) error {
+	if err := cs.db.CreateSession(ctx, token, userID, expiresAt); err != nil {
+		return err
+	}
+	user, err := cs.db.GetUserByID(ctx, userID)
+	if err == nil && user != nil {
+		cs.mu.Lock()
+		cs.cache[token] = cachedSession{user: user, checked: time.Now()}
+		cs.mu.Unlock()
+	}
+	return nil
+}
+
+// User implements sessionStore, refreshing entries older than
+// sessionCacheTTL from the database.
+func (cs *cachedSessions) User(ctx context.Context, token string) (*database.User, error) {
+	cs.mu.Lock()
+	entry, ok := cs.cache[token]
+	cs.mu.Unlock()
+	if ok && time.Since(entry.checked) < sessionCacheTTL {
+		return entry.user, nil
+	}
+
+	user, err := cs.db.GetSessionUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	cs.mu.Lock()
+	if user == nil {
+		delete(cs.cache, token)
+	} else {
+		cs.cache[token] = cachedSession{user: user, checked: time.Now()}
+	}
+	cs.mu.Unlock()
+	return user, nil
+}
+
+// Delete implements sessionStore. The cache entry goes first so a logout
+// takes effect immediately even if the database delete fails.
+func (cs *cachedSessions) Delete(ctx context.Context, token string) error {
+	cs.mu.Lock()
+	delete(cs.cache, token)
+	cs.mu.Unlock()
+	return cs.db.DeleteSession(ctx, token)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/events"
+)
+
+// handleAnalysisEvents streams status transitions as Server-Sent Events
+// so frontends that cannot (or would rather not) open a WebSocket can
+// still follow pending → processing → completed/failed without polling.
+func (s *Server) handleAnalysisEvents(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return
+	}
+
+	// Always report the current status first; if the analysis is already
+	// finished there is nothing further to wait for.
+	writeSSE(c.Writer, "status", analysis.Status)
+	flusher.Flush()
+	if analysis.Status == "completed" || analysis.Status == "failed" {
+		return
+	}
+
+	ch := events.Default.Subscribe(analysis.ID)
+	defer events.Default.Unsubscribe(analysis.ID, ch)
+
+	for {
+		select {
+		case ev, open := <-ch:
+			if !open {
+				return
+			}
+			if ev.Type != "status" {
+				continue
+			}
+			writeSSE(c.Writer, "status", ev.Payload)
+			flusher.Flush()
+			if ev.Payload == "completed" || ev.Payload == "failed" {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		case <-time.After(30 * time.Second):
+			// SSE comment line as keep-alive, ignored by EventSource.
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE emits one event in text/event-stream framing.
+func writeSSE(w http.ResponseWriter, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+)
+
+type createSuppressionRequest struct {
+	Type   string `json:"type"`
+	SrcIP  string `json:"srcIp"`
+	Reason string `json:"reason"`
+}
+
+// handleCreateSuppression records a standing false-positive rule. Rules
+// apply to findings of every analysis saved after this point.
+func (s *Server) handleCreateSuppression(c *gin.Context) {
+	var req createSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("request_body_invalid"))
+		return
+	}
+	req.Type = strings.TrimSpace(req.Type)
+	req.SrcIP = strings.TrimSpace(req.SrcIP)
+	if req.Type == "" && req.SrcIP == "" {
+		c.JSON(http.StatusBadRequest, apiError("suppression_criteria_required"))
+		return
+	}
+	id, err := s.db.CreateSuppression(c.Request.Context(), currentUser(c).ID,
+		req.Type, req.SrcIP, strings.TrimSpace(req.Reason))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"id":     id,
+		"type":   req.Type,
+		"srcIp":  req.SrcIP,
+		"reason": strings.TrimSpace(req.Reason),
+	})
+}
+
+func (s *Server) handleListSuppressions(c *gin.Context) {
+	rules, err := s.db.ListSuppressions(c.Request.Context(), currentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if rules == nil {
+		rules = []database.Suppression{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func (s *Server) handleDeleteSuppression(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("suppressionID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("suppression_id_invalid"))
+		return
+	}
+	if err := s.db.DeleteSuppression(c.Request.Context(), currentUser(c).ID, id); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+type suppressFindingRequest struct {
+	Reason string `json:"reason"`
+	// Rule additionally creates a suppression rule from the finding's
+	// type and source IP, so the same false positive never resurfaces.
+	Rule bool `json:"rule"`
+}
+
+// handleSuppressFinding marks one finding as a false positive.
+func (s *Server) handleSuppressFinding(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	findingID, err := strconv.ParseInt(c.Param("findingID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("finding_id_invalid"))
+		return
+	}
+	var req suppressFindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("request_body_invalid"))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "marked as false positive"
+	}
+	finding, err := s.db.GetFinding(c.Request.Context(), analysis.ID, findingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if finding == nil {
+		c.JSON(http.StatusNotFound, apiError("finding_not_found"))
+		return
+	}
+	if _, err := s.db.SetFindingSuppressed(c.Request.Context(), analysis.ID, findingID, true, reason); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	resp := gin.H{"status": "suppressed"}
+	if req.Rule {
+		ruleID, err := s.db.CreateSuppression(c.Request.Context(), currentUser(c).ID,
+			finding.Type, finding.SrcIP, reason)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiError("database_error"))
+			return
+		}
+		resp["ruleId"] = ruleID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleUnsuppressFinding clears a false-positive mark.
+func (s *Server) handleUnsuppressFinding(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	findingID, err := strconv.ParseInt(c.Param("findingID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiError("finding_id_invalid"))
+		return
+	}
+	ok, err := s.db.SetFindingSuppressed(c.Request.Context(), analysis.ID, findingID, false, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, apiError("finding_not_found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "unsuppressed"})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// totpPeriod is the RFC 6238 time step.
+const totpPeriod = 30 * time.Second
+
+// generateTOTPSecret returns a new base32 shared secret.
+func generateTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf), nil
+}
+
+// totpCode computes the 6-digit code for the secret at time t.
+func totpCode(secret string, t time.Time) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).
+		DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix())/uint64(totpPeriod.Seconds()))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
+
+// verifyTOTP checks code against the secret, allowing one time step of
+// clock drift in either direction.
+func verifyTOTP(secret, code string) bool {
+	now := time.Now()
+	for _, t := range []time.Time{now.Add(-totpPeriod), now, now.Add(totpPeriod)} {
+		want, err := totpCode(secret, t)
+		if err != nil {
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// otpauthURL builds the provisioning URI encoded into enrollment QR codes.
+func otpauthURL(username, secret string) string {
+	return fmt.Sprintf("otpauth://totp/pcap-analyzer:%s?secret=%s&issuer=pcap-analyzer",
+		username, secret)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadProgress tracks how much of an in-flight upload body the server
+// has received.
+type uploadProgress struct {
+	Received int64 `json:"received"`
+	Total    int64 `json:"total"` // -1 when the client sent no Content-Length
+}
+
+// uploads maps user-scoped upload tokens to the progress of their
+// in-flight request bodies. Entries live only while the upload runs.
+var uploads sync.Map
+
+// uploadKey scopes an upload token to its user so progress cannot be read
+// across accounts.
+func uploadKey(userID int64, token string) string {
+	return fmt.Sprintf("%d:%s", userID, token)
+}
+
+// trackUpload wraps the request body so received bytes are counted under
+// the caller's upload token. The returned func removes the entry and must
+// run when the upload handler finishes.
+func trackUpload(c *gin.Context, userID int64, token string) func() {
+	prog := &uploadProgress{Total: c.Request.ContentLength}
+	key := uploadKey(userID, token)
+	uploads.Store(key, prog)
+	c.Request.Body = &countingReadCloser{rc: c.Request.Body, prog: prog}
+	return func() { uploads.Delete(key) }
+}
+
+// countingReadCloser counts bytes as the multipart parser consumes them.
+type countingReadCloser struct {
+	rc   io.ReadCloser
+	prog *uploadProgress
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	atomic.AddInt64(&r.prog.Received, int64(n))
+	return n, err
+}
+
+func (r *countingReadCloser) Close() error {
+	return r.rc.Close()
+}
+
+// handleUploadProgress reports how much of an upload identified by the
+// client-chosen upload_id token has arrived, so clients can render a
+// progress bar for multi-GB files. Finished uploads return 404; the
+// analysis record is the signal that the upload completed.
+func (s *Server) handleUploadProgress(c *gin.Context) {
+	token := c.Query("upload_id")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, apiError("upload_id_required"))
+		return
+	}
+	p, ok := uploads.Load(uploadKey(currentUser(c).ID, token))
+	if !ok {
+		c.JSON(http.StatusNotFound, apiError("upload_not_found"))
+		return
+	}
+	prog := p.(*uploadProgress)
+	c.JSON(http.StatusOK, uploadProgress{
+		Received: atomic.LoadInt64(&prog.Received),
+		Total:    prog.Total,
+	})
+}
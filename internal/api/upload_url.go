@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// fetchProgress tracks an in-flight remote download for one analysis.
+type fetchProgress struct {
+	Received int64 `json:"received"`
+	Total    int64 `json:"total"` // -1 when the server sent no Content-Length
+}
+
+// fetches maps analysis IDs to the progress of their remote download.
+// Entries are removed once the download finishes or fails.
+var fetches sync.Map
+
+type urlUploadRequest struct {
+	URL               string                `json:"url" binding:"required"`
+	MaxPackets        int64                 `json:"maxPackets"`
+	MaxSeconds        int                   `json:"maxSeconds"`
+	SampleRate        int                   `json:"sampleRate"`
+	TimeOffsetSeconds int                   `json:"timeOffsetSeconds"`
+	Policies          []analyzer.PolicyRule `json:"policies"`
+}
+
+// options converts the optional triage fields into analyzer limits.
+func (r urlUploadRequest) options() analyzer.Options {
+	var opts analyzer.Options
+	if r.MaxPackets > 0 {
+		opts.MaxPackets = r.MaxPackets
+	}
+	if r.MaxSeconds > 0 {
+		opts.MaxCaptureTime = time.Duration(r.MaxSeconds) * time.Second
+	}
+	if r.SampleRate > 1 {
+		opts.SampleRate = r.SampleRate
+	}
+	if r.TimeOffsetSeconds != 0 {
+		opts.TimeOffset = time.Duration(r.TimeOffsetSeconds) * time.Second
+	}
+	opts.Policies = r.Policies
+	return opts
+}
+
+// handleUploadURL downloads a capture from a remote HTTP(S) or S3 URL and
+// queues it for analysis, so captures stored on appliances or object
+// storage need not pass through the client.
+func (s *Server) handleUploadURL(c *gin.Context) {
+	user := currentUser(c)
+
+	var req urlUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiError("url_required"))
+		return
+	}
+	if err := analyzer.ValidatePolicyRules(req.Policies); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrorParams("policy_rules_invalid", gin.H{"detail": err.Error()}))
+		return
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		c.JSON(http.StatusBadRequest, apiError("url_scheme_unsupported"))
+		return
+	}
+
+	name := path.Base(parsed.Path)
+	if !strings.HasSuffix(name, ".pcap") && !strings.HasSuffix(name, ".pcapng") &&
+		!strings.HasSuffix(name, ".cap") {
+		c.JSON(http.StatusBadRequest, apiError("url_file_type_unsupported"))
+		return
+	}
+
+	name = storage.SanitizeFilename(name)
+	key := storage.ObjectKey(user.ID, name)
+	id, err := s.db.CreateAnalysis(c.Request.Context(), user.ID, name, key, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("analysis_create_failed"))
+		return
+	}
+	if err := s.db.SetAnalysisStatus(c.Request.Context(), id, "downloading", ""); err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+
+	go s.fetchRemote(user.ID, id, req.URL, key, req.options())
+
+	analysis, err := s.db.GetAnalysis(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiError("database_error"))
+		return
+	}
+	c.JSON(http.StatusAccepted, analysis)
+}
+
+// handleFetchProgress reports how far along a remote download is.
+func (s *Server) handleFetchProgress(c *gin.Context) {
+	analysis := s.ownedAnalysis(c)
+	if analysis == nil {
+		return
+	}
+	if p, ok := fetches.Load(analysis.ID); ok {
+		prog := p.(*fetchProgress)
+		c.JSON(http.StatusOK, fetchProgress{
+			Received: atomic.LoadInt64(&prog.Received),
+			Total:    prog.Total,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": analysis.Status})
+}
+
+// fetchRemote downloads rawURL into storage under key, enforcing
+// maxUploadSize, and queues the analysis on success. The download lands in
+// a temp file first so partial transfers never reach storage.
+func (s *Server) fetchRemote(userID, id int64, rawURL, key string, opts analyzer.Options) {
+	fail := func(msg string) {
+		fetches.Delete(id)
+		s.db.SetAnalysisStatus(context.Background(), id, "failed", msg)
+	}
+
+	resp, err := fetchClient().Get(rawURL)
+	if err != nil {
+		fail(fmt.Sprintf("fetch URL: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fail(fmt.Sprintf("fetch URL: server returned %s", resp.Status))
+		return
+	}
+	if resp.ContentLength > maxUploadSize {
+		fail("remote file exceeds the upload size limit")
+		return
+	}
+
+	prog := &fetchProgress{Total: resp.ContentLength}
+	fetches.Store(id, prog)
+
+	tmp, err := os.CreateTemp("", "pcap-fetch-*")
+	if err != nil {
+		fail("store download")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	n, err := io.Copy(tmp, io.TeeReader(io.LimitReader(resp.Body, maxUploadSize+1), progressWriter{prog}))
+	if err != nil {
+		fail(fmt.Sprintf("download: %v", err))
+		return
+	}
+	if n > maxUploadSize {
+		fail("remote file exceeds the upload size limit")
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		fail("store download")
+		return
+	}
+	if err := s.store.Save(key, tmp, n); err != nil {
+		fail(fmt.Sprintf("store download: %v", err))
+		return
+	}
+
+	fetches.Delete(id)
+	s.db.SetAnalysisFileSize(context.Background(), id, n)
+	s.db.SetAnalysisStatus(context.Background(), id, "pending", "")
+	s.pool.Enqueue(userID, id, key, opts)
+}
+
+// fetchClient returns the HTTP client used for remote capture downloads.
+// Its dialer vets every connection — including each redirect hop, since
+// hops dial through the same transport — against the actually-dialed
+// address, so a user-supplied URL cannot make the server probe loopback,
+// private or link-local services (cloud metadata endpoints included).
+// FETCH_ALLOW_PRIVATE=1 disables the filter for deployments whose
+// captures legitimately live on appliance LANs.
+func fetchClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if err := checkFetchIP(ip.IP); err != nil {
+					return nil, err
+				}
+			}
+			// Dial the vetted addresses, not the hostname, so a second
+			// resolution cannot answer differently.
+			var lastErr error
+			for _, ip := range ips {
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			return nil, lastErr
+		},
+	}
+	return &http.Client{Timeout: 30 * time.Minute, Transport: transport}
+}
+
+// checkFetchIP rejects download targets inside the server's own network:
+// loopback, RFC 1918 and unique-local private ranges, and link-local
+// addresses, which is where cloud metadata services live.
+func checkFetchIP(ip net.IP) error {
+	if os.Getenv("FETCH_ALLOW_PRIVATE") == "1" {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to fetch from internal address %s", ip)
+	}
+	return nil
+}
+
+// progressWriter counts bytes as they stream through io.TeeReader.
+type progressWriter struct {
+	prog *fetchProgress
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	atomic.AddInt64(&w.prog.Received, int64(len(p)))
+	return len(p), nil
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateAnalysis inserts a new pending analysis and returns its id.
+func (db *DB) CreateAnalysis(ctx context.Context, userID int64, filename, filePath string, fileSize int64) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO analyses (user_id, filename, file_path, file_size) VALUES (?, ?, ?, ?)`,
+		userID, filename, filePath, fileSize)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAnalysis returns the analysis with the given id, or nil if not found.
+func (db *DB) GetAnalysis(ctx context.Context, id int64) (*Analysis, error) {
+	return db.scanAnalysis(db.conn.QueryRowContext(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE id = ?`, id))
+}
+
+// ListAnalyses returns all live analyses owned by the user, newest first.
+// Trashed analyses are excluded; see ListTrashedAnalyses.
+func (db *DB) ListAnalyses(ctx context.Context, userID int64) ([]Analysis, error) {
+	return db.listAnalyses(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE user_id = ? AND deleted_at IS NULL
+		 ORDER BY uploaded_at DESC`, userID)
+}
+
+// ListTrashedAnalyses returns the user's soft-deleted analyses, most
+// recently deleted first.
+func (db *DB) ListTrashedAnalyses(ctx context.Context, userID int64) ([]Analysis, error) {
+	return db.listAnalyses(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE user_id = ? AND deleted_at IS NOT NULL
+		 ORDER BY deleted_at DESC`, userID)
+}
+
+// ListExpiredTrash returns analyses that were trashed before the cutoff
+// and are due for a final purge.
+func (db *DB) ListExpiredTrash(ctx context.Context, cutoff time.Time) ([]Analysis, error) {
+	return db.listAnalyses(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+}
+
+func (db *DB) listAnalyses(ctx context.Context, query string, args ...any) ([]Analysis, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Analysis
+	for rows.Next() {
+		a, err := db.scanAnalysisRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// SetAnalysisStatus updates the status of an analysis, recording start and
+// completion times as it transitions.
+func (db *DB) SetAnalysisStatus(ctx context.Context, id int64, status, errMsg string) error {
+	now := time.Now()
+	switch status {
+	case "processing":
+		_, err := db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ?, started_at = ? WHERE id = ?`,
+			status, now, id)
+		return err
+	case "completed", "failed":
+		_, err := db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ?, error = ?, completed_at = ? WHERE id = ?`,
+			status, errMsg, now, id)
+		return err
+	default:
+		_, err := db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ? WHERE id = ?`, status, id)
+		return err
+	}
+}
+
+// TransitionAnalysisStatus moves an analysis from one status to another
+// in a single guarded UPDATE, recording start and completion times the
+// same way SetAnalysisStatus does. It reports whether the row was still
+// in the expected status: false means another worker got there first —
+// a duplicate queue entry claiming the same analysis, or a stale retry
+// trying to overwrite a finished one — and nothing was changed.
+func (db *DB) TransitionAnalysisStatus(ctx context.Context, id int64, from, to, errMsg string) (bool, error) {
+	now := time.Now()
+	var res sql.Result
+	var err error
+	switch to {
+	case "processing":
+		res, err = db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ?, started_at = ? WHERE id = ? AND status = ?`,
+			to, now, id, from)
+	case "completed", "failed":
+		res, err = db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ?, error = ?, completed_at = ? WHERE id = ? AND status = ?`,
+			to, errMsg, now, id, from)
+	default:
+		res, err = db.conn.ExecContext(ctx,
+			`UPDATE analyses SET status = ? WHERE id = ? AND status = ?`, to, id, from)
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetAnalysisFileSize records the on-disk size of the capture, used when
+// the size is only known after a remote download completes.
+func (db *DB) SetAnalysisFileSize(ctx context.Context, id, size int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET file_size = ? WHERE id = ?`, size, id)
+	return err
+}
+
+// SetAnalysisPacketCount records the total number of packets processed.
+func (db *DB) SetAnalysisPacketCount(ctx context.Context, id, count int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET packet_count = ? WHERE id = ?`, count, id)
+	return err
+}
+
+// SetAnalysisTimeframe records the absolute start and end timestamps of
+// the capture.
+func (db *DB) SetAnalysisTimeframe(ctx context.Context, id int64, start, end time.Time) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET capture_start = ?, capture_end = ? WHERE id = ?`,
+		start, end, id)
+	return err
+}
+
+// SetAnalysisVerdict stores the machine-written one-line summary shown in
+// analysis listings.
+func (db *DB) SetAnalysisVerdict(ctx context.Context, id int64, verdict string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET verdict = ? WHERE id = ?`, verdict, id)
+	return err
+}
+
+// SetAnalysisPartial marks whether triage limits cut the analysis short.
+func (db *DB) SetAnalysisPartial(ctx context.Context, id int64, partial bool) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET partial = ? WHERE id = ?`, partial, id)
+	return err
+}
+
+// DeleteAnalysis moves an analysis to the trash. Its rows and capture
+// stay on disk until the janitor purges them after the retention window.
+func (db *DB) DeleteAnalysis(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET deleted_at = ? WHERE id = ?`, time.Now(), id)
+	return err
+}
+
+// RestoreAnalysis brings a trashed analysis back.
+func (db *DB) RestoreAnalysis(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET deleted_at = NULL WHERE id = ?`, id)
+	return err
+}
+
+// PurgeAnalysis permanently removes an analysis and, via foreign keys,
+// all of its results.
+func (db *DB) PurgeAnalysis(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM analyses WHERE id = ?`, id)
+	return err
+}
+
+func (db *DB) scanAnalysis(row *sql.Row) (*Analysis, error) {
+	var a Analysis
+	var startedAt, completedAt, deletedAt, capStart, capEnd sql.NullTime
+	err := row.Scan(&a.ID, &a.UserID, &a.ProjectID, &a.Filename, &a.FilePath, &a.FileSize,
+		&a.Status, &a.Error, &a.PacketCount, &a.Partial, &a.Verdict, &a.Baseline, &a.UploadedAt,
+		&startedAt, &completedAt, &deletedAt, &capStart, &capEnd)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fillAnalysisTimes(&a, startedAt, completedAt, deletedAt, capStart, capEnd)
+	return &a, nil
+}
+
+func (db *DB) scanAnalysisRows(rows *sql.Rows) (*Analysis, error) {
+	var a Analysis
+	var startedAt, completedAt, deletedAt, capStart, capEnd sql.NullTime
+	err := rows.Scan(&a.ID, &a.UserID, &a.ProjectID, &a.Filename, &a.FilePath, &a.FileSize,
+		&a.Status, &a.Error, &a.PacketCount, &a.Partial, &a.Verdict, &a.Baseline, &a.UploadedAt,
+		&startedAt, &completedAt, &deletedAt, &capStart, &capEnd)
+	if err != nil {
+		return nil, err
+	}
+	fillAnalysisTimes(&a, startedAt, completedAt, deletedAt, capStart, capEnd)
+	return &a, nil
+}
+
+func fillAnalysisTimes(a *Analysis, startedAt, completedAt, deletedAt, capStart, capEnd sql.NullTime) {
+	if startedAt.Valid {
+		a.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		a.CompletedAt = &completedAt.Time
+	}
+	if deletedAt.Valid {
+		a.DeletedAt = &deletedAt.Time
+	}
+	if capStart.Valid {
+		a.CaptureStart = &capStart.Time
+	}
+	if capEnd.Valid {
+		a.CaptureEnd = &capEnd.Time
+	}
+}
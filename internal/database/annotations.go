@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Annotation is a tag or note a reviewer attached to an analysis, either
+// to the analysis as a whole or to a specific target such as an asset IP.
+type Annotation struct {
+	ID         int64     `json:"id"`
+	AnalysisID int64     `json:"analysisId"`
+	UserID     int64     `json:"userId"`
+	Author     string    `json:"author"`
+	Kind       string    `json:"kind"` // "tag" or "note"
+	Target     string    `json:"target,omitempty"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CreateAnnotation inserts an annotation and fills in its ID and
+// creation time.
+func (db *DB) CreateAnnotation(ctx context.Context, a *Annotation) error {
+	a.CreatedAt = time.Now()
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO annotations (analysis_id, user_id, kind, target, text, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		a.AnalysisID, a.UserID, a.Kind, a.Target, a.Text, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	a.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetAnnotation returns one annotation, or nil when it does not exist.
+func (db *DB) GetAnnotation(ctx context.Context, id int64) (*Annotation, error) {
+	var a Annotation
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT a.id, a.analysis_id, a.user_id, u.username, a.kind, a.target, a.text, a.created_at
+		 FROM annotations a JOIN users u ON u.id = a.user_id
+		 WHERE a.id = ?`, id).
+		Scan(&a.ID, &a.AnalysisID, &a.UserID, &a.Author, &a.Kind, &a.Target, &a.Text, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListAnnotations returns the annotations of an analysis, oldest first.
+func (db *DB) ListAnnotations(ctx context.Context, analysisID int64) ([]Annotation, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT a.id, a.analysis_id, a.user_id, u.username, a.kind, a.target, a.text, a.created_at
+		 FROM annotations a JOIN users u ON u.id = a.user_id
+		 WHERE a.analysis_id = ? ORDER BY a.created_at, a.id`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Annotation
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.ID, &a.AnalysisID, &a.UserID, &a.Author, &a.Kind, &a.Target, &a.Text, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAnnotation removes an annotation.
+func (db *DB) DeleteAnnotation(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM annotations WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// APIKey is an automation credential. Only a hash of the token is
+// stored. Scope limits what the key may do (full, read or upload) and
+// ProjectID, when nonzero, confines it to one project's analyses.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"userId"`
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	ProjectID int64      `json:"projectId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastUsed  *time.Time `json:"lastUsed,omitempty"`
+}
+
+// CreateAPIKey stores a new key and returns its id.
+func (db *DB) CreateAPIKey(ctx context.Context, userID int64, name, tokenHash, scope string, projectID int64) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO api_keys (user_id, name, token_hash, scope, project_id)
+		 VALUES (?, ?, ?, ?, ?)`,
+		userID, name, tokenHash, scope, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetAPIKeyByHash resolves a presented token's hash to its key, or nil
+// when no such key exists.
+func (db *DB) GetAPIKeyByHash(ctx context.Context, tokenHash string) (*APIKey, error) {
+	var k APIKey
+	var lastUsed sql.NullTime
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT id, user_id, name, scope, project_id, created_at, last_used
+		 FROM api_keys WHERE token_hash = ?`, tokenHash).
+		Scan(&k.ID, &k.UserID, &k.Name, &k.Scope, &k.ProjectID, &k.CreatedAt, &lastUsed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		k.LastUsed = &lastUsed.Time
+	}
+	return &k, nil
+}
+
+// ListAPIKeys returns the user's keys, newest first.
+func (db *DB) ListAPIKeys(ctx context.Context, userID int64) ([]APIKey, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, user_id, name, scope, project_id, created_at, last_used
+		 FROM api_keys WHERE user_id = ?
+		 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []APIKey
+	for rows.Next() {
+		var k APIKey
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Scope, &k.ProjectID,
+			&k.CreatedAt, &lastUsed); err != nil {
+			return nil, err
+		}
+		if lastUsed.Valid {
+			k.LastUsed = &lastUsed.Time
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAPIKey removes one of the user's keys.
+func (db *DB) DeleteAPIKey(ctx context.Context, userID, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+// TouchAPIKey records that a key was just used.
+func (db *DB) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE api_keys SET last_used = ? WHERE id = ?`, time.Now(), id)
+	return err
+}
@@ -0,0 +1,41 @@
+package database
+
+import "context"
+
+// SetBaselineAnalysis marks one analysis as the gold baseline for its
+// project, clearing any previous baseline in the same project.
+func (db *DB) SetBaselineAnalysis(ctx context.Context, projectID, analysisID int64) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE analyses SET baseline = 0 WHERE project_id = ?`, projectID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE analyses SET baseline = 1 WHERE id = ?`, analysisID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearBaselineAnalysis unmarks an analysis as baseline.
+func (db *DB) ClearBaselineAnalysis(ctx context.Context, analysisID int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET baseline = 0 WHERE id = ?`, analysisID)
+	return err
+}
+
+// GetBaselineAnalysis returns the project's baseline analysis, or nil if
+// none is set.
+func (db *DB) GetBaselineAnalysis(ctx context.Context, projectID int64) (*Analysis, error) {
+	return db.scanAnalysis(db.conn.QueryRowContext(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE project_id = ? AND baseline = 1 AND deleted_at IS NULL`,
+		projectID))
+}
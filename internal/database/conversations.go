@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Conversation is one aggregated src→dst host pair, in the style of
+// Wireshark's conversations view.
+type Conversation struct {
+	SrcIP    string   `json:"srcIp"`
+	DstIP    string   `json:"dstIp"`
+	Flows    int64    `json:"flows"`
+	Packets  int64    `json:"packets"`
+	Bytes    int64    `json:"bytes"`
+	Services []string `json:"services,omitempty"`
+}
+
+// GetConversations aggregates the connections of an analysis into host
+// pairs, ordered by traffic volume.
+func (db *DB) GetConversations(ctx context.Context, analysisID int64) ([]Conversation, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`
+		SELECT src_ip, dst_ip,
+		       COUNT(*),
+		       SUM(packets_sent + packets_recv),
+		       SUM(bytes_sent + bytes_recv),
+		       GROUP_CONCAT(DISTINCT NULLIF(service, ''))
+		FROM connections
+		WHERE analysis_id = ?
+		GROUP BY src_ip, dst_ip
+		ORDER BY SUM(bytes_sent + bytes_recv) DESC`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Conversation
+	for rows.Next() {
+		var c Conversation
+		var services sql.NullString
+		if err := rows.Scan(&c.SrcIP, &c.DstIP, &c.Flows, &c.Packets, &c.Bytes, &services); err != nil {
+			return nil, err
+		}
+		if services.String != "" {
+			c.Services = strings.Split(services.String, ",")
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
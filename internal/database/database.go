@@ -0,0 +1,422 @@
+// Package database wraps the SQLite store used by the API server and the
+// analysis workers. All persistence goes through the DB type so the schema
+// stays in one place.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DB wraps the underlying sql.DB handle.
+type DB struct {
+	conn *sql.DB
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	expires_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	scope TEXT NOT NULL DEFAULT 'full',
+	project_id INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_used DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	name TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS project_shares (
+	project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	PRIMARY KEY (project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS analyses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	project_id INTEGER NOT NULL DEFAULT 0,
+	filename TEXT NOT NULL,
+	file_path TEXT NOT NULL,
+	file_size INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'pending',
+	error TEXT NOT NULL DEFAULT '',
+	packet_count INTEGER NOT NULL DEFAULT 0,
+	partial INTEGER NOT NULL DEFAULT 0,
+	verdict TEXT NOT NULL DEFAULT '',
+	baseline INTEGER NOT NULL DEFAULT 0,
+	uploaded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	started_at DATETIME,
+	completed_at DATETIME,
+	deleted_at DATETIME,
+	capture_start DATETIME,
+	capture_end DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS assets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	mac TEXT NOT NULL DEFAULT '',
+	ip TEXT NOT NULL DEFAULT '',
+	hostname TEXT NOT NULL DEFAULT '',
+	vendor TEXT NOT NULL DEFAULT '',
+	randomized_mac INTEGER NOT NULL DEFAULT 0,
+	os TEXT NOT NULL DEFAULT '',
+	os_confidence INTEGER NOT NULL DEFAULT 0,
+	asn INTEGER NOT NULL DEFAULT 0,
+	as_name TEXT NOT NULL DEFAULT '',
+	anomaly_score INTEGER NOT NULL DEFAULT 0,
+	reputation INTEGER NOT NULL DEFAULT 0,
+	fan_out INTEGER NOT NULL DEFAULT 0,
+	fan_in INTEGER NOT NULL DEFAULT 0,
+	ports_dialed INTEGER NOT NULL DEFAULT 0,
+	ports_served INTEGER NOT NULL DEFAULT 0,
+	device_type TEXT NOT NULL DEFAULT '',
+	ip_class TEXT NOT NULL DEFAULT '',
+	packets_sent INTEGER NOT NULL DEFAULT 0,
+	packets_recv INTEGER NOT NULL DEFAULT 0,
+	bytes_sent INTEGER NOT NULL DEFAULT 0,
+	bytes_recv INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS asset_signals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	asset_id INTEGER NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+	signal TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS hosts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	mac TEXT NOT NULL DEFAULT '',
+	hostname TEXT NOT NULL DEFAULT '',
+	vendor TEXT NOT NULL DEFAULT '',
+	os TEXT NOT NULL DEFAULT '',
+	last_ip TEXT NOT NULL DEFAULT '',
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS annotations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	kind TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	text TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS asset_services (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	ip TEXT NOT NULL,
+	port INTEGER NOT NULL,
+	protocol TEXT NOT NULL,
+	service TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS user_agents (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	src_ip TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS connections (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	protocol TEXT NOT NULL,
+	src_ip TEXT NOT NULL,
+	src_port INTEGER NOT NULL DEFAULT 0,
+	dst_ip TEXT NOT NULL,
+	dst_port INTEGER NOT NULL DEFAULT 0,
+	service TEXT NOT NULL DEFAULT '',
+	app_protocol TEXT NOT NULL DEFAULT '',
+	app_messages INTEGER NOT NULL DEFAULT 0,
+	packets_sent INTEGER NOT NULL DEFAULT 0,
+	packets_recv INTEGER NOT NULL DEFAULT 0,
+	bytes_sent INTEGER NOT NULL DEFAULT 0,
+	bytes_recv INTEGER NOT NULL DEFAULT 0,
+	retrans_packets INTEGER NOT NULL DEFAULT 0,
+	retrans_bytes INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS flow_metadata (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	connection_id INTEGER NOT NULL REFERENCES connections(id) ON DELETE CASCADE,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS broadcast_summary (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	src_ip TEXT NOT NULL,
+	protocol TEXT NOT NULL,
+	packets INTEGER NOT NULL DEFAULT 0,
+	bytes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS dns_server_health (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	server_ip TEXT NOT NULL,
+	queries INTEGER NOT NULL DEFAULT 0,
+	responses INTEGER NOT NULL DEFAULT 0,
+	servfail INTEGER NOT NULL DEFAULT 0,
+	nxdomain INTEGER NOT NULL DEFAULT 0,
+	clients INTEGER NOT NULL DEFAULT 0,
+	median_ms REAL NOT NULL DEFAULT 0,
+	p95_ms REAL NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS multicast_groups (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	host_ip TEXT NOT NULL,
+	group_addr TEXT NOT NULL,
+	joins INTEGER NOT NULL DEFAULT 0,
+	leaves INTEGER NOT NULL DEFAULT 0,
+	state TEXT NOT NULL DEFAULT '',
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS subnet_latency (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	src_subnet TEXT NOT NULL,
+	dst_subnet TEXT NOT NULL,
+	samples INTEGER NOT NULL DEFAULT 0,
+	median_ms REAL NOT NULL DEFAULT 0,
+	p95_ms REAL NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS protocol_hierarchy (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	path TEXT NOT NULL,
+	packets INTEGER NOT NULL DEFAULT 0,
+	bytes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS service_durations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	service TEXT NOT NULL,
+	sessions INTEGER NOT NULL DEFAULT 0,
+	median_s REAL NOT NULL DEFAULT 0,
+	p95_s REAL NOT NULL DEFAULT 0,
+	max_s REAL NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS account_usage (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	domain TEXT NOT NULL DEFAULT '',
+	username TEXT NOT NULL,
+	workstation TEXT NOT NULL DEFAULT '',
+	src_ip TEXT NOT NULL,
+	dst_ip TEXT NOT NULL,
+	protocol TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS dns_queries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	src_ip TEXT NOT NULL,
+	query TEXT NOT NULL,
+	query_type TEXT NOT NULL DEFAULT '',
+	answers TEXT NOT NULL DEFAULT '',
+	timestamp DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS findings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	severity TEXT NOT NULL,
+	type TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	src_ip TEXT NOT NULL DEFAULT '',
+	dst_ip TEXT NOT NULL DEFAULT '',
+	timestamp DATETIME,
+	suppressed INTEGER NOT NULL DEFAULT 0,
+	suppress_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS suppressions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL DEFAULT '',
+	src_ip TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS ip_mac_bindings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	analysis_id INTEGER NOT NULL REFERENCES analyses(id) ON DELETE CASCADE,
+	ip TEXT NOT NULL,
+	mac TEXT NOT NULL,
+	source TEXT NOT NULL,
+	packet_count INTEGER NOT NULL DEFAULT 0,
+	first_seen DATETIME,
+	last_seen DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_bindings_analysis ON ip_mac_bindings(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_assets_analysis ON assets(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_assets_analysis_ip ON assets(analysis_id, ip);
+CREATE INDEX IF NOT EXISTS idx_assets_analysis_conf ON assets(analysis_id, os_confidence);
+CREATE INDEX IF NOT EXISTS idx_hosts_user ON hosts(user_id);
+CREATE INDEX IF NOT EXISTS idx_projects_user ON projects(user_id);
+CREATE INDEX IF NOT EXISTS idx_analyses_project ON analyses(project_id);
+CREATE INDEX IF NOT EXISTS idx_broadcast_analysis ON broadcast_summary(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_dns_health_analysis ON dns_server_health(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_multicast_analysis ON multicast_groups(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_subnet_latency_analysis ON subnet_latency(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_protocol_hierarchy_analysis ON protocol_hierarchy(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_account_usage_analysis ON account_usage(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_service_durations_analysis ON service_durations(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_asset_signals_asset ON asset_signals(asset_id);
+CREATE INDEX IF NOT EXISTS idx_user_agents_analysis ON user_agents(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_asset_services_analysis ON asset_services(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_annotations_analysis ON annotations(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_connections_analysis ON connections(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_flow_metadata_connection ON flow_metadata(connection_id);
+CREATE INDEX IF NOT EXISTS idx_dns_queries_analysis ON dns_queries(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_findings_analysis ON findings(analysis_id);
+CREATE INDEX IF NOT EXISTS idx_suppressions_user ON suppressions(user_id);
+`
+
+// Open opens (and creates, if needed) the SQLite database at path and
+// applies the schema.
+func Open(path string) (*DB, error) {
+	// WAL mode lets the API serve reads while a worker is mid-save, so the
+	// connection pool no longer has to be capped at one. There is still only
+	// one writer at a time: _busy_timeout makes a second writer queue for up
+	// to five seconds instead of failing with SQLITE_BUSY, and _txlock=
+	// immediate takes the write lock at BEGIN so our transactions (which all
+	// write) cannot deadlock upgrading from a read lock. synchronous=NORMAL
+	// is the recommended pairing with WAL.
+	conn, err := sql.Open("sqlite3",
+		path+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000"+
+			"&_synchronous=NORMAL&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	conn.SetMaxOpenConns(dbMaxConns())
+	conn.SetMaxIdleConns(dbMaxConns())
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("apply schema: %w", err)
+	}
+	db := &DB{conn: conn}
+	if err := db.migrate(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+	return db, nil
+}
+
+// migrations are idempotent column additions applied to databases created
+// by older versions. SQLite has no ADD COLUMN IF NOT EXISTS, so duplicate
+// column errors are expected and ignored.
+var migrations = []string{
+	`ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN asn INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN as_name TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE assets ADD COLUMN anomaly_score INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE analyses ADD COLUMN deleted_at DATETIME`,
+	`ALTER TABLE analyses ADD COLUMN partial INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE connections ADD COLUMN app_protocol TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE connections ADD COLUMN app_messages INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE analyses ADD COLUMN capture_start DATETIME`,
+	`ALTER TABLE analyses ADD COLUMN capture_end DATETIME`,
+	`ALTER TABLE assets ADD COLUMN host_id INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN ip_class TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE analyses ADD COLUMN project_id INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE analyses ADD COLUMN verdict TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE analyses ADD COLUMN baseline INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN reputation INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN fan_out INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN fan_in INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN ports_dialed INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE assets ADD COLUMN ports_served INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE connections ADD COLUMN retrans_packets INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE connections ADD COLUMN retrans_bytes INTEGER NOT NULL DEFAULT 0`,
+}
+
+// dbMaxConns is the connection pool size, overridable via DB_MAX_CONNS.
+// The default scales with the machine but always leaves room for the API
+// to read while every worker is writing.
+func dbMaxConns() int {
+	if v := os.Getenv("DB_MAX_CONNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	n := runtime.NumCPU()
+	if n < 4 {
+		n = 4
+	}
+	return n
+}
+
+func (db *DB) migrate() error {
+	for _, stmt := range migrations {
+		if _, err := db.conn.Exec(stmt); err != nil {
+			if strings.Contains(err.Error(), "duplicate column name") {
+				continue
+			}
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+// Ping verifies the database connection is alive.
+func (db *DB) Ping() error {
+	return db.conn.Ping()
+}
+
+// Close closes the underlying connection.
+func (db *DB) Close() error {
+	return db.conn.Close()
+}
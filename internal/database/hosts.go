@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Host is one physical machine tracked across a user's captures, so the
+// same laptop keeps its identity through DHCP lease changes.
+type Host struct {
+	ID        int64     `json:"id"`
+	MAC       string    `json:"mac,omitempty"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Vendor    string    `json:"vendor,omitempty"`
+	OS        string    `json:"os,omitempty"`
+	LastIP    string    `json:"lastIp,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Captures counts how many analyses the host was seen in.
+	Captures int `json:"captures"`
+}
+
+// CorrelateHosts links this analysis's assets to the user's stable host
+// inventory. A host matches, in order of confidence, on its MAC (unless
+// randomized), its hostname, or the same fingerprint (OS and vendor) at
+// its last known IP; assets matching nothing become new hosts.
+func (db *DB) CorrelateHosts(ctx context.Context, userID, analysisID int64) error {
+	assets, err := db.GetAssets(ctx, analysisID)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return nil
+	}
+
+	known, err := db.userHosts(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range assets {
+		a := &assets[i]
+		host := matchHost(known, a)
+		if host == nil {
+			res, err := tx.ExecContext(ctx,
+				`INSERT INTO hosts (user_id, mac, hostname, vendor, os, last_ip,
+				                    first_seen, last_seen)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				userID, a.MAC, a.Hostname, a.Vendor, a.OS, a.IP,
+				a.FirstSeen, a.LastSeen)
+			if err != nil {
+				return err
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			host = &Host{ID: id, MAC: a.MAC, Hostname: a.Hostname,
+				Vendor: a.Vendor, OS: a.OS, LastIP: a.IP}
+			known = append(known, *host)
+		} else {
+			// Refresh the identity with anything this capture adds.
+			if host.Hostname == "" {
+				host.Hostname = a.Hostname
+			}
+			if host.OS == "" {
+				host.OS = a.OS
+			}
+			host.LastIP = a.IP
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE hosts SET hostname = ?, os = ?, last_ip = ?, last_seen = ?
+				 WHERE id = ?`,
+				host.Hostname, host.OS, a.IP, a.LastSeen, host.ID); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE assets SET host_id = ? WHERE id = ?`, host.ID, a.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// matchHost finds the known host an asset belongs to, or nil.
+func matchHost(known []Host, a *Asset) *Host {
+	if a.MAC != "" && !a.RandomizedMAC {
+		for i := range known {
+			if known[i].MAC == a.MAC {
+				return &known[i]
+			}
+		}
+	}
+	if a.Hostname != "" {
+		for i := range known {
+			if known[i].Hostname == a.Hostname {
+				return &known[i]
+			}
+		}
+	}
+	if a.OS != "" && a.Vendor != "" {
+		for i := range known {
+			if known[i].OS == a.OS && known[i].Vendor == a.Vendor &&
+				known[i].LastIP == a.IP {
+				return &known[i]
+			}
+		}
+	}
+	return nil
+}
+
+// userHosts loads the user's host inventory without capture counts.
+func (db *DB) userHosts(ctx context.Context, userID int64) ([]Host, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, mac, hostname, vendor, os, last_ip FROM hosts
+		 WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Host
+	for rows.Next() {
+		var h Host
+		if err := rows.Scan(&h.ID, &h.MAC, &h.Hostname, &h.Vendor, &h.OS,
+			&h.LastIP); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// ListHosts returns the user's host inventory with how many captures each
+// host appeared in, most recently seen first.
+func (db *DB) ListHosts(ctx context.Context, userID int64) ([]Host, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT h.id, h.mac, h.hostname, h.vendor, h.os, h.last_ip,
+		        h.first_seen, h.last_seen, COUNT(DISTINCT a.analysis_id)
+		 FROM hosts h
+		 LEFT JOIN assets a ON a.host_id = h.id
+		 WHERE h.user_id = ?
+		 GROUP BY h.id
+		 ORDER BY h.last_seen DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Host
+	for rows.Next() {
+		var h Host
+		var first, last sql.NullTime
+		if err := rows.Scan(&h.ID, &h.MAC, &h.Hostname, &h.Vendor, &h.OS,
+			&h.LastIP, &first, &last, &h.Captures); err != nil {
+			return nil, err
+		}
+		h.FirstSeen, h.LastSeen = first.Time, last.Time
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/models"
+)
+
+// The analyzer result types live in the models package so the analyzer can
+// run without this package; the aliases keep database.Asset etc. working
+// for the rest of the codebase.
+type (
+	Asset               = models.Asset
+	Connection          = models.Connection
+	DNSQuery            = models.DNSQuery
+	IPMACBinding        = models.IPMACBinding
+	UserAgent           = models.UserAgent
+	AssetService        = models.AssetService
+	Finding             = models.Finding
+	BroadcastRow        = models.BroadcastRow
+	DNSServerHealth     = models.DNSServerHealth
+	MulticastMembership = models.MulticastMembership
+	SubnetLatency       = models.SubnetLatency
+	ProtocolCount       = models.ProtocolCount
+	AccountUsage        = models.AccountUsage
+	ServiceDuration     = models.ServiceDuration
+	Suppression         = models.Suppression
+)
+
+// User is a registered account that owns analyses.
+type User struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	TOTPSecret   string    `json:"-"`
+	TOTPEnabled  bool      `json:"totpEnabled"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Analysis represents one uploaded capture and its processing state.
+// Status is one of: pending, downloading, processing, completed, failed.
+type Analysis struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"userId"`
+	ProjectID   int64  `json:"projectId,omitempty"`
+	Filename    string `json:"filename"`
+	FilePath    string `json:"-"`
+	FileSize    int64  `json:"fileSize"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	PacketCount int64  `json:"packetCount"`
+	Partial     bool   `json:"partial"`
+	Verdict     string `json:"verdict,omitempty"`
+	// Baseline marks this analysis as the gold reference for its project;
+	// other analyses in the project report their deviation from it.
+	Baseline     bool       `json:"baseline,omitempty"`
+	UploadedAt   time.Time  `json:"uploadedAt"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+	CaptureStart *time.Time `json:"captureStart,omitempty"`
+	CaptureEnd   *time.Time `json:"captureEnd,omitempty"`
+}
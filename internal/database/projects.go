@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Project is a folder grouping a user's analyses, e.g. one engagement or
+// one site. Projects can be shared read-only with other users.
+type Project struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"userId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Shared is true in listings when the caller is not the owner.
+	Shared bool `json:"shared,omitempty"`
+	// AnalysisCount is the number of live analyses filed in the project.
+	AnalysisCount int64 `json:"analysisCount"`
+}
+
+// CreateProject inserts a new project and returns its id.
+func (db *DB) CreateProject(ctx context.Context, userID int64, name string) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO projects (user_id, name) VALUES (?, ?)`, userID, name)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetProject returns the project with the given id, or nil if not found.
+func (db *DB) GetProject(ctx context.Context, id int64) (*Project, error) {
+	rows, err := db.listProjects(ctx,
+		`SELECT p.id, p.user_id, p.name, p.created_at,
+		        (SELECT COUNT(*) FROM analyses a
+		         WHERE a.project_id = p.id AND a.deleted_at IS NULL)
+		 FROM projects p WHERE p.id = ?`, id)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// ListProjects returns the user's own projects plus any shared with them,
+// newest first.
+func (db *DB) ListProjects(ctx context.Context, userID int64) ([]Project, error) {
+	out, err := db.listProjects(ctx,
+		`SELECT p.id, p.user_id, p.name, p.created_at,
+		        (SELECT COUNT(*) FROM analyses a
+		         WHERE a.project_id = p.id AND a.deleted_at IS NULL)
+		 FROM projects p
+		 WHERE p.user_id = ?
+		    OR p.id IN (SELECT project_id FROM project_shares WHERE user_id = ?)
+		 ORDER BY p.created_at DESC, p.id DESC`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Shared = out[i].UserID != userID
+	}
+	return out, nil
+}
+
+func (db *DB) listProjects(ctx context.Context, query string, args ...any) ([]Project, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Project
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt,
+			&p.AnalysisCount); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// RenameProject updates a project's name.
+func (db *DB) RenameProject(ctx context.Context, id int64, name string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE projects SET name = ? WHERE id = ?`, name, id)
+	return err
+}
+
+// DeleteProject removes a project. Its analyses survive, falling back to
+// the unfiled state; shares go with the project.
+func (db *DB) DeleteProject(ctx context.Context, id int64) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE analyses SET project_id = 0 WHERE project_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM project_shares WHERE project_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM projects WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetAnalysisProject files an analysis under a project; 0 unfiles it.
+func (db *DB) SetAnalysisProject(ctx context.Context, analysisID, projectID int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE analyses SET project_id = ? WHERE id = ?`, projectID, analysisID)
+	return err
+}
+
+// ListProjectAnalyses returns the live analyses filed under a project,
+// newest first.
+func (db *DB) ListProjectAnalyses(ctx context.Context, projectID int64) ([]Analysis, error) {
+	return db.listAnalyses(ctx,
+		`SELECT id, user_id, project_id, filename, file_path, file_size, status, error,
+		        packet_count, partial, verdict, baseline, uploaded_at, started_at, completed_at, deleted_at,
+	        capture_start, capture_end
+		 FROM analyses WHERE project_id = ? AND deleted_at IS NULL
+		 ORDER BY uploaded_at DESC`, projectID)
+}
+
+// ShareProject grants a user read access to a project. Re-sharing with
+// the same user is a no-op.
+func (db *DB) ShareProject(ctx context.Context, projectID, userID int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT OR IGNORE INTO project_shares (project_id, user_id) VALUES (?, ?)`,
+		projectID, userID)
+	return err
+}
+
+// UnshareProject revokes a user's access to a project.
+func (db *DB) UnshareProject(ctx context.Context, projectID, userID int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM project_shares WHERE project_id = ? AND user_id = ?`,
+		projectID, userID)
+	return err
+}
+
+// ProjectAccessible reports whether the user owns the project or has it
+// shared with them.
+func (db *DB) ProjectAccessible(ctx context.Context, projectID, userID int64) (bool, error) {
+	var n int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM projects p
+		 LEFT JOIN project_shares s ON s.project_id = p.id AND s.user_id = ?
+		 WHERE p.id = ? AND (p.user_id = ? OR s.user_id IS NOT NULL)`,
+		userID, projectID, userID).Scan(&n)
+	return n > 0, err
+}
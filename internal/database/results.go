@@ -0,0 +1,991 @@
+package database
+
+import "context"
+
+// SaveAssets bulk-inserts the assets discovered by an analysis.
+func (db *DB) SaveAssets(ctx context.Context, analysisID int64, assets []Asset) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO assets (analysis_id, mac, ip, hostname, vendor, randomized_mac,
+		                     os, os_confidence, asn, as_name, anomaly_score, reputation,
+		                     fan_out, fan_in, ports_dialed, ports_served,
+		                     device_type, ip_class, packets_sent, packets_recv,
+		                     bytes_sent, bytes_recv, first_seen, last_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	sigStmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO asset_signals (asset_id, signal) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer sigStmt.Close()
+
+	for _, a := range assets {
+		res, err := stmt.ExecContext(ctx, analysisID, a.MAC, a.IP, a.Hostname, a.Vendor,
+			a.RandomizedMAC, a.OS, a.OSConfidence, a.ASN, a.ASName,
+			a.AnomalyScore, a.Reputation, a.FanOut, a.FanIn, a.PortsDialed, a.PortsServed,
+			a.DeviceType, a.IPClass, a.PacketsSent, a.PacketsRecv,
+			a.BytesSent, a.BytesRecv, a.FirstSeen, a.LastSeen)
+		if err != nil {
+			return err
+		}
+		assetID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for _, sig := range a.OSSignals {
+			if _, err := sigStmt.ExecContext(ctx, assetID, sig); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveConnections bulk-inserts the flows discovered by an analysis.
+func (db *DB) SaveConnections(ctx context.Context, analysisID int64, conns []Connection) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO connections (analysis_id, protocol, src_ip, src_port, dst_ip,
+		                          dst_port, service, app_protocol, app_messages,
+		                          packets_sent, packets_recv, bytes_sent, bytes_recv,
+		                          retrans_packets, retrans_bytes, first_seen, last_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	meta, err := tx.PrepareContext(ctx,
+		`INSERT INTO flow_metadata (connection_id, key, value) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer meta.Close()
+
+	for _, c := range conns {
+		res, err := stmt.ExecContext(ctx, analysisID, c.Protocol, c.SrcIP, c.SrcPort,
+			c.DstIP, c.DstPort, c.Service, c.AppProtocol, c.AppMessages,
+			c.PacketsSent, c.PacketsRecv, c.BytesSent, c.BytesRecv,
+			c.RetransPackets, c.RetransBytes, c.FirstSeen, c.LastSeen)
+		if err != nil {
+			return err
+		}
+		if len(c.Metadata) == 0 {
+			continue
+		}
+		connID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for k, v := range c.Metadata {
+			if _, err := meta.ExecContext(ctx, connID, k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveDNSQueries bulk-inserts observed DNS queries.
+func (db *DB) SaveDNSQueries(ctx context.Context, analysisID int64, queries []DNSQuery) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO dns_queries (analysis_id, src_ip, query, query_type, answers, timestamp)
+		 VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, q := range queries {
+		if _, err := stmt.ExecContext(ctx, analysisID, q.SrcIP, q.Query, q.QueryType,
+			q.Answers, q.Timestamp); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveFindings bulk-inserts detections raised by an analysis.
+func (db *DB) SaveFindings(ctx context.Context, analysisID int64, findings []Finding) error {
+	// The owner's suppression rules apply as findings land, so known
+	// false positives arrive pre-marked in every new analysis.
+	rules, err := db.suppressionsForAnalysis(ctx, analysisID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO findings (analysis_id, severity, type, title, description,
+		                       src_ip, dst_ip, timestamp, suppressed, suppress_reason)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range findings {
+		for _, rule := range rules {
+			if rule.Matches(f) {
+				f.Suppressed = true
+				f.SuppressReason = rule.Reason
+				break
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, analysisID, f.Severity, f.Type, f.Title,
+			f.Description, f.SrcIP, f.DstIP, f.Timestamp,
+			f.Suppressed, f.SuppressReason); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveIPMACBindings bulk-inserts the IP-to-MAC binding history.
+func (db *DB) SaveIPMACBindings(ctx context.Context, analysisID int64, bindings []IPMACBinding) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO ip_mac_bindings (analysis_id, ip, mac, source, packet_count,
+		                              first_seen, last_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, b := range bindings {
+		if _, err := stmt.ExecContext(ctx, analysisID, b.IP, b.MAC, b.Source, b.PacketCount,
+			b.FirstSeen, b.LastSeen); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveUserAgents bulk-inserts the per-host User-Agent inventory.
+func (db *DB) SaveUserAgents(ctx context.Context, analysisID int64, agents []UserAgent) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO user_agents (analysis_id, src_ip, user_agent, count)
+		 VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, ua := range agents {
+		if _, err := stmt.ExecContext(ctx, analysisID, ua.SrcIP, ua.UserAgent, ua.Count); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUserAgents returns the User-Agent inventory for an analysis.
+func (db *DB) GetUserAgents(ctx context.Context, analysisID int64) ([]UserAgent, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, src_ip, user_agent, count
+		 FROM user_agents WHERE analysis_id = ? ORDER BY src_ip, user_agent`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UserAgent
+	for rows.Next() {
+		var ua UserAgent
+		if err := rows.Scan(&ua.ID, &ua.AnalysisID, &ua.SrcIP, &ua.UserAgent, &ua.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, ua)
+	}
+	return out, rows.Err()
+}
+
+// SaveAssetServices bulk-inserts the passive open-port inventory.
+func (db *DB) SaveAssetServices(ctx context.Context, analysisID int64, services []AssetService) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO asset_services (analysis_id, ip, port, protocol, service)
+		 VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, svc := range services {
+		if _, err := stmt.ExecContext(ctx, analysisID, svc.IP, svc.Port, svc.Protocol, svc.Service); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAssetServices returns the open-port inventory for an analysis.
+func (db *DB) GetAssetServices(ctx context.Context, analysisID int64) ([]AssetService, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, ip, port, protocol, service
+		 FROM asset_services WHERE analysis_id = ? ORDER BY ip, protocol, port`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AssetService
+	for rows.Next() {
+		var svc AssetService
+		if err := rows.Scan(&svc.ID, &svc.AnalysisID, &svc.IP, &svc.Port, &svc.Protocol, &svc.Service); err != nil {
+			return nil, err
+		}
+		out = append(out, svc)
+	}
+	return out, rows.Err()
+}
+
+// GetIPMACBindings returns the binding history for an analysis.
+func (db *DB) GetIPMACBindings(ctx context.Context, analysisID int64) ([]IPMACBinding, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, ip, mac, source, packet_count, first_seen, last_seen
+		 FROM ip_mac_bindings WHERE analysis_id = ? ORDER BY ip, first_seen`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []IPMACBinding
+	for rows.Next() {
+		var b IPMACBinding
+		if err := rows.Scan(&b.ID, &b.AnalysisID, &b.IP, &b.MAC, &b.Source,
+			&b.PacketCount, &b.FirstSeen, &b.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// GetAssets returns the assets for an analysis.
+func (db *DB) GetAssets(ctx context.Context, analysisID int64) ([]Asset, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, mac, ip, hostname, vendor, randomized_mac, os,
+		        os_confidence, asn, as_name, anomaly_score, reputation, fan_out, fan_in,
+	        ports_dialed, ports_served, device_type, ip_class,
+		        host_id, packets_sent, packets_recv, bytes_sent, bytes_recv,
+		        first_seen, last_seen
+		 FROM assets WHERE analysis_id = ? ORDER BY ip`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Asset
+	for rows.Next() {
+		var a Asset
+		if err := rows.Scan(&a.ID, &a.AnalysisID, &a.MAC, &a.IP, &a.Hostname,
+			&a.Vendor, &a.RandomizedMAC, &a.OS, &a.OSConfidence, &a.ASN,
+			&a.ASName, &a.AnomalyScore, &a.Reputation, &a.FanOut, &a.FanIn,
+			&a.PortsDialed, &a.PortsServed, &a.DeviceType, &a.IPClass, &a.HostID,
+			&a.PacketsSent, &a.PacketsRecv, &a.BytesSent, &a.BytesRecv,
+			&a.FirstSeen, &a.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.attachAssetSignals(ctx, analysisID, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SaveBroadcastSummary bulk-inserts the broadcast/multicast totals of an
+// analysis.
+func (db *DB) SaveBroadcastSummary(ctx context.Context, analysisID int64, rows []BroadcastRow) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO broadcast_summary (analysis_id, src_ip, protocol, packets, bytes)
+		 VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.SrcIP, r.Protocol,
+			r.Packets, r.Bytes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBroadcastSummary returns the broadcast/multicast totals for an
+// analysis, chattiest source first.
+func (db *DB) GetBroadcastSummary(ctx context.Context, analysisID int64) ([]BroadcastRow, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, src_ip, protocol, packets, bytes
+		 FROM broadcast_summary WHERE analysis_id = ?
+		 ORDER BY packets DESC, src_ip, protocol`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BroadcastRow
+	for rows.Next() {
+		var r BroadcastRow
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.SrcIP, &r.Protocol,
+			&r.Packets, &r.Bytes); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveDNSServerHealth bulk-inserts the per-server DNS health report of an
+// analysis.
+func (db *DB) SaveDNSServerHealth(ctx context.Context, analysisID int64, rows []DNSServerHealth) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO dns_server_health (analysis_id, server_ip, queries, responses,
+		 servfail, nxdomain, clients, median_ms, p95_ms)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.ServerIP, r.Queries,
+			r.Responses, r.Servfail, r.Nxdomain, r.Clients, r.MedianMs, r.P95Ms); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDNSServerHealth returns the DNS server health report for an analysis,
+// busiest server first.
+func (db *DB) GetDNSServerHealth(ctx context.Context, analysisID int64) ([]DNSServerHealth, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, server_ip, queries, responses, servfail,
+		 nxdomain, clients, median_ms, p95_ms
+		 FROM dns_server_health WHERE analysis_id = ?
+		 ORDER BY queries DESC, server_ip`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DNSServerHealth
+	for rows.Next() {
+		var r DNSServerHealth
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.ServerIP, &r.Queries,
+			&r.Responses, &r.Servfail, &r.Nxdomain, &r.Clients,
+			&r.MedianMs, &r.P95Ms); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveMulticastGroups bulk-inserts the IGMP group memberships of an
+// analysis.
+func (db *DB) SaveMulticastGroups(ctx context.Context, analysisID int64, rows []MulticastMembership) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO multicast_groups (analysis_id, host_ip, group_addr, joins,
+		 leaves, state, first_seen, last_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.HostIP, r.Group,
+			r.Joins, r.Leaves, r.State, r.FirstSeen, r.LastSeen); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetMulticastGroups returns the IGMP group memberships for an analysis,
+// grouped by multicast address.
+func (db *DB) GetMulticastGroups(ctx context.Context, analysisID int64) ([]MulticastMembership, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, host_ip, group_addr, joins, leaves, state,
+		 first_seen, last_seen
+		 FROM multicast_groups WHERE analysis_id = ?
+		 ORDER BY group_addr, host_ip`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MulticastMembership
+	for rows.Next() {
+		var r MulticastMembership
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.HostIP, &r.Group,
+			&r.Joins, &r.Leaves, &r.State, &r.FirstSeen, &r.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveSubnetLatency bulk-inserts the handshake latency matrix of an
+// analysis.
+func (db *DB) SaveSubnetLatency(ctx context.Context, analysisID int64, rows []SubnetLatency) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO subnet_latency (analysis_id, src_subnet, dst_subnet,
+		 samples, median_ms, p95_ms)
+		 VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.SrcSubnet, r.DstSubnet,
+			r.Samples, r.MedianMs, r.P95Ms); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSubnetLatency returns the handshake latency matrix for an analysis,
+// one directed subnet pair per row.
+func (db *DB) GetSubnetLatency(ctx context.Context, analysisID int64) ([]SubnetLatency, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, src_subnet, dst_subnet, samples, median_ms, p95_ms
+		 FROM subnet_latency WHERE analysis_id = ?
+		 ORDER BY src_subnet, dst_subnet`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SubnetLatency
+	for rows.Next() {
+		var r SubnetLatency
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.SrcSubnet, &r.DstSubnet,
+			&r.Samples, &r.MedianMs, &r.P95Ms); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveProtocolHierarchy bulk-inserts the protocol hierarchy nodes.
+func (db *DB) SaveProtocolHierarchy(ctx context.Context, analysisID int64, rows []ProtocolCount) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO protocol_hierarchy (analysis_id, path, packets, bytes)
+		 VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.Path, r.Packets, r.Bytes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetProtocolHierarchy returns the flattened protocol hierarchy for an
+// analysis, ordered so parents precede their children.
+func (db *DB) GetProtocolHierarchy(ctx context.Context, analysisID int64) ([]ProtocolCount, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, path, packets, bytes
+		 FROM protocol_hierarchy WHERE analysis_id = ?
+		 ORDER BY path`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ProtocolCount
+	for rows.Next() {
+		var r ProtocolCount
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.Path, &r.Packets, &r.Bytes); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveServiceDurations bulk-inserts the per-service duration rows.
+func (db *DB) SaveServiceDurations(ctx context.Context, analysisID int64, rows []ServiceDuration) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO service_durations (analysis_id, service, sessions, median_s, p95_s, max_s)
+		 VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, analysisID, r.Service, r.Sessions,
+			r.MedianS, r.P95S, r.MaxS); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetServiceDurations returns the per-service duration percentiles for an
+// analysis, busiest service first.
+func (db *DB) GetServiceDurations(ctx context.Context, analysisID int64) ([]ServiceDuration, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, service, sessions, median_s, p95_s, max_s
+		 FROM service_durations WHERE analysis_id = ?
+		 ORDER BY sessions DESC, service`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ServiceDuration
+	for rows.Next() {
+		var r ServiceDuration
+		if err := rows.Scan(&r.ID, &r.AnalysisID, &r.Service, &r.Sessions,
+			&r.MedianS, &r.P95S, &r.MaxS); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SaveAccountUsage bulk-inserts the NTLM account-to-host usage rows.
+func (db *DB) SaveAccountUsage(ctx context.Context, analysisID int64, usage []AccountUsage) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO account_usage
+		 (analysis_id, domain, username, workstation, src_ip, dst_ip, protocol, count, first_seen, last_seen)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range usage {
+		if _, err := stmt.ExecContext(ctx, analysisID, u.Domain, u.Username, u.Workstation,
+			u.SrcIP, u.DstIP, u.Protocol, u.Count, u.FirstSeen, u.LastSeen); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAccountUsage returns the account-to-host usage map for an analysis,
+// grouped by account.
+func (db *DB) GetAccountUsage(ctx context.Context, analysisID int64) ([]AccountUsage, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, domain, username, workstation, src_ip, dst_ip, protocol,
+		        count, first_seen, last_seen
+		 FROM account_usage WHERE analysis_id = ?
+		 ORDER BY domain, username, src_ip`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AccountUsage
+	for rows.Next() {
+		var u AccountUsage
+		if err := rows.Scan(&u.ID, &u.AnalysisID, &u.Domain, &u.Username, &u.Workstation,
+			&u.SrcIP, &u.DstIP, &u.Protocol, &u.Count, &u.FirstSeen, &u.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// UpdateAssetEnrichment rewrites the lookup-derived columns of existing
+// asset rows, for reprocessing after signature updates.
+func (db *DB) UpdateAssetEnrichment(ctx context.Context, assets []Asset) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`UPDATE assets SET vendor = ?, randomized_mac = ?, asn = ?, as_name = ?,
+		        anomaly_score = ?, ip_class = ?
+		 WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, a := range assets {
+		if _, err := stmt.ExecContext(ctx, a.Vendor, a.RandomizedMAC, a.ASN,
+			a.ASName, a.AnomalyScore, a.IPClass, a.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateConnectionServices rewrites the service column of existing
+// connection rows, for reprocessing after service definition updates.
+func (db *DB) UpdateConnectionServices(ctx context.Context, conns []Connection) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`UPDATE connections SET service = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, conn := range conns {
+		if _, err := stmt.ExecContext(ctx, conn.Service, conn.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AssetQuery filters, sorts and pages an asset listing. The zero value
+// returns everything in IP order.
+type AssetQuery struct {
+	// Search matches a substring of the IP, MAC, hostname, OS or vendor.
+	Search string
+	// SortBy is one of the keys in assetSortColumns; empty means "ip".
+	SortBy string
+	// SortDesc reverses the sort order.
+	SortDesc bool
+	// Limit and Offset page the result. A zero Limit returns all rows.
+	Limit, Offset int
+}
+
+// assetSortColumns whitelists sortable columns, keeping user input out of
+// the ORDER BY clause.
+var assetSortColumns = map[string]string{
+	"ip":         "ip",
+	"mac":        "mac",
+	"os":         "os",
+	"vendor":     "vendor",
+	"confidence": "os_confidence",
+	"anomaly":    "anomaly_score",
+	"reputation": "reputation",
+	"fan_out":    "fan_out",
+	"fan_in":     "fan_in",
+	"bytes":      "bytes_sent + bytes_recv",
+	"last_seen":  "last_seen",
+}
+
+// QueryAssets returns one page of an analysis's assets plus the total
+// number of rows matching the search, for pagination UIs.
+func (db *DB) QueryAssets(ctx context.Context, analysisID int64, q AssetQuery) ([]Asset, int, error) {
+	where := `analysis_id = ?`
+	args := []interface{}{analysisID}
+	if q.Search != "" {
+		where += ` AND (ip LIKE ? OR mac LIKE ? OR hostname LIKE ? OR os LIKE ? OR vendor LIKE ?)`
+		pat := "%" + q.Search + "%"
+		args = append(args, pat, pat, pat, pat, pat)
+	}
+
+	var total int
+	if err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM assets WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	col, ok := assetSortColumns[q.SortBy]
+	if !ok {
+		col = "ip"
+	}
+	order := col
+	if q.SortDesc {
+		order += " DESC"
+	}
+	query := `SELECT id, analysis_id, mac, ip, hostname, vendor, randomized_mac, os,
+	        os_confidence, asn, as_name, anomaly_score, reputation, fan_out, fan_in,
+	        ports_dialed, ports_served, device_type, ip_class,
+	        host_id, packets_sent, packets_recv, bytes_sent, bytes_recv,
+	        first_seen, last_seen
+	 FROM assets WHERE ` + where + ` ORDER BY ` + order
+	if q.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var out []Asset
+	for rows.Next() {
+		var a Asset
+		if err := rows.Scan(&a.ID, &a.AnalysisID, &a.MAC, &a.IP, &a.Hostname,
+			&a.Vendor, &a.RandomizedMAC, &a.OS, &a.OSConfidence, &a.ASN,
+			&a.ASName, &a.AnomalyScore, &a.Reputation, &a.FanOut, &a.FanIn,
+			&a.PortsDialed, &a.PortsServed, &a.DeviceType, &a.IPClass, &a.HostID,
+			&a.PacketsSent, &a.PacketsRecv, &a.BytesSent, &a.BytesRecv,
+			&a.FirstSeen, &a.LastSeen); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := db.attachAssetSignals(ctx, analysisID, out); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// attachAssetSignals fills in the OS fingerprint evidence for each asset,
+// so the API can show why a host was classified the way it was.
+func (db *DB) attachAssetSignals(ctx context.Context, analysisID int64, assets []Asset) error {
+	if len(assets) == 0 {
+		return nil
+	}
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT s.asset_id, s.signal
+		 FROM asset_signals s
+		 JOIN assets a ON a.id = s.asset_id
+		 WHERE a.analysis_id = ?
+		 ORDER BY s.id`, analysisID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byAsset := make(map[int64][]string)
+	for rows.Next() {
+		var assetID int64
+		var signal string
+		if err := rows.Scan(&assetID, &signal); err != nil {
+			return err
+		}
+		byAsset[assetID] = append(byAsset[assetID], signal)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range assets {
+		assets[i].OSSignals = byAsset[assets[i].ID]
+	}
+	return nil
+}
+
+// GetConnections returns the flows for an analysis, largest first.
+func (db *DB) GetConnections(ctx context.Context, analysisID int64) ([]Connection, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, protocol, src_ip, src_port, dst_ip, dst_port,
+		        service, app_protocol, app_messages,
+		        packets_sent, packets_recv, bytes_sent, bytes_recv,
+		        retrans_packets, retrans_bytes, first_seen, last_seen
+		 FROM connections WHERE analysis_id = ?
+		 ORDER BY bytes_sent + bytes_recv DESC`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Connection
+	for rows.Next() {
+		var c Connection
+		if err := rows.Scan(&c.ID, &c.AnalysisID, &c.Protocol, &c.SrcIP,
+			&c.SrcPort, &c.DstIP, &c.DstPort, &c.Service, &c.AppProtocol,
+			&c.AppMessages, &c.PacketsSent,
+			&c.PacketsRecv, &c.BytesSent, &c.BytesRecv,
+			&c.RetransPackets, &c.RetransBytes,
+			&c.FirstSeen, &c.LastSeen); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := db.attachFlowMetadata(ctx, analysisID, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// attachFlowMetadata fills in the Metadata bags for a set of connection
+// rows from one analysis.
+func (db *DB) attachFlowMetadata(ctx context.Context, analysisID int64, conns []Connection) error {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT fm.connection_id, fm.key, fm.value
+		 FROM flow_metadata fm
+		 JOIN connections c ON c.id = fm.connection_id
+		 WHERE c.analysis_id = ?`, analysisID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]*Connection, len(conns))
+	for i := range conns {
+		byID[conns[i].ID] = &conns[i]
+	}
+	for rows.Next() {
+		var connID int64
+		var key, value string
+		if err := rows.Scan(&connID, &key, &value); err != nil {
+			return err
+		}
+		c, ok := byID[connID]
+		if !ok {
+			continue
+		}
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]string)
+		}
+		c.Metadata[key] = value
+	}
+	return rows.Err()
+}
+
+// GetDNSQueries returns the DNS queries recorded for an analysis.
+func (db *DB) GetDNSQueries(ctx context.Context, analysisID int64) ([]DNSQuery, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, src_ip, query, query_type, answers, timestamp
+		 FROM dns_queries WHERE analysis_id = ? ORDER BY timestamp`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DNSQuery
+	for rows.Next() {
+		var q DNSQuery
+		if err := rows.Scan(&q.ID, &q.AnalysisID, &q.SrcIP, &q.Query,
+			&q.QueryType, &q.Answers, &q.Timestamp); err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	return out, rows.Err()
+}
+
+// GetFindings returns the findings recorded for an analysis.
+func (db *DB) GetFindings(ctx context.Context, analysisID int64) ([]Finding, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, analysis_id, severity, type, title, description, src_ip,
+		        dst_ip, timestamp, suppressed, suppress_reason
+		 FROM findings WHERE analysis_id = ? ORDER BY timestamp`, analysisID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Finding
+	for rows.Next() {
+		var f Finding
+		if err := rows.Scan(&f.ID, &f.AnalysisID, &f.Severity, &f.Type,
+			&f.Title, &f.Description, &f.SrcIP, &f.DstIP, &f.Timestamp,
+			&f.Suppressed, &f.SuppressReason); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
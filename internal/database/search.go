@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// IndicatorQuery describes what to look for across a user's analyses. At
+// least one field must be set; set fields are ANDed within each table.
+type IndicatorQuery struct {
+	IP     string
+	MAC    string
+	Port   int
+	Domain string
+}
+
+// IndicatorHit says how often an indicator appears in one capture.
+type IndicatorHit struct {
+	AnalysisID  int64     `json:"analysisId"`
+	Filename    string    `json:"filename"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+	Connections int       `json:"connections"`
+	Assets      int       `json:"assets"`
+	DNSQueries  int       `json:"dnsQueries"`
+}
+
+// SearchIndicators looks for the indicator in the connections, assets and
+// DNS rows of every non-trashed analysis the user owns, returning one hit
+// per capture it appears in, newest upload first.
+func (db *DB) SearchIndicators(ctx context.Context, userID int64, q IndicatorQuery) ([]IndicatorHit, error) {
+	hits := make(map[int64]*IndicatorHit)
+
+	count := func(query string, args []interface{}, assign func(*IndicatorHit, int)) error {
+		rows, err := db.conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var h IndicatorHit
+			var n int
+			if err := rows.Scan(&h.AnalysisID, &h.Filename, &h.UploadedAt, &n); err != nil {
+				return err
+			}
+			if existing, ok := hits[h.AnalysisID]; ok {
+				assign(existing, n)
+			} else {
+				assign(&h, n)
+				hits[h.AnalysisID] = &h
+			}
+		}
+		return rows.Err()
+	}
+
+	if q.IP != "" || q.Port > 0 {
+		where := ""
+		args := []interface{}{userID}
+		if q.IP != "" {
+			where += ` AND (t.src_ip = ? OR t.dst_ip = ?)`
+			args = append(args, q.IP, q.IP)
+		}
+		if q.Port > 0 {
+			where += ` AND (t.src_port = ? OR t.dst_port = ?)`
+			args = append(args, q.Port, q.Port)
+		}
+		err := count(`SELECT a.id, a.filename, a.uploaded_at, COUNT(*)
+			 FROM connections t JOIN analyses a ON a.id = t.analysis_id
+			 WHERE a.user_id = ? AND a.deleted_at IS NULL`+where+`
+			 GROUP BY a.id`, args,
+			func(h *IndicatorHit, n int) { h.Connections = n })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if q.IP != "" || q.MAC != "" {
+		where := ""
+		args := []interface{}{userID}
+		if q.IP != "" {
+			where += ` AND t.ip = ?`
+			args = append(args, q.IP)
+		}
+		if q.MAC != "" {
+			where += ` AND t.mac = ?`
+			args = append(args, q.MAC)
+		}
+		err := count(`SELECT a.id, a.filename, a.uploaded_at, COUNT(*)
+			 FROM assets t JOIN analyses a ON a.id = t.analysis_id
+			 WHERE a.user_id = ? AND a.deleted_at IS NULL`+where+`
+			 GROUP BY a.id`, args,
+			func(h *IndicatorHit, n int) { h.Assets = n })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if q.IP != "" || q.Domain != "" {
+		where := ""
+		args := []interface{}{userID}
+		if q.IP != "" {
+			// Match hosts asking about the IP and answers resolving to it.
+			where += ` AND (t.src_ip = ? OR t.answers LIKE ?)`
+			args = append(args, q.IP, "%"+q.IP+"%")
+		}
+		if q.Domain != "" {
+			where += ` AND t.query LIKE ?`
+			args = append(args, "%"+q.Domain+"%")
+		}
+		err := count(`SELECT a.id, a.filename, a.uploaded_at, COUNT(*)
+			 FROM dns_queries t JOIN analyses a ON a.id = t.analysis_id
+			 WHERE a.user_id = ? AND a.deleted_at IS NULL`+where+`
+			 GROUP BY a.id`, args,
+			func(h *IndicatorHit, n int) { h.DNSQueries = n })
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]IndicatorHit, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].UploadedAt.Equal(out[j].UploadedAt) {
+			return out[i].UploadedAt.After(out[j].UploadedAt)
+		}
+		return out[i].AnalysisID > out[j].AnalysisID
+	})
+	return out, nil
+}
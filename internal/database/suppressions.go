@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CreateSuppression records a standing false-positive rule for a user.
+func (db *DB) CreateSuppression(ctx context.Context, userID int64, ftype, srcIP, reason string) (int64, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO suppressions (user_id, type, src_ip, reason) VALUES (?, ?, ?, ?)`,
+		userID, ftype, srcIP, reason)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ListSuppressions returns a user's suppression rules, newest first.
+func (db *DB) ListSuppressions(ctx context.Context, userID int64) ([]Suppression, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT id, user_id, type, src_ip, reason, created_at
+		 FROM suppressions WHERE user_id = ?
+		 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Suppression
+	for rows.Next() {
+		var s Suppression
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Type, &s.SrcIP, &s.Reason, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteSuppression removes one of the user's rules. Findings already
+// suppressed by it keep their mark; only future analyses are affected.
+func (db *DB) DeleteSuppression(ctx context.Context, userID, id int64) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM suppressions WHERE id = ? AND user_id = ?`, id, userID)
+	return err
+}
+
+// SetFindingSuppressed marks or unmarks one finding as a false positive.
+// The bool return reports whether the finding exists under the analysis.
+func (db *DB) SetFindingSuppressed(ctx context.Context, analysisID, findingID int64, suppressed bool, reason string) (bool, error) {
+	if !suppressed {
+		reason = ""
+	}
+	res, err := db.conn.ExecContext(ctx,
+		`UPDATE findings SET suppressed = ?, suppress_reason = ?
+		 WHERE id = ? AND analysis_id = ?`,
+		suppressed, reason, findingID, analysisID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetFinding returns one finding of an analysis, or nil when absent.
+func (db *DB) GetFinding(ctx context.Context, analysisID, findingID int64) (*Finding, error) {
+	var f Finding
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT id, analysis_id, severity, type, title, description, src_ip,
+		        dst_ip, timestamp, suppressed, suppress_reason
+		 FROM findings WHERE id = ? AND analysis_id = ?`,
+		findingID, analysisID).Scan(&f.ID, &f.AnalysisID, &f.Severity, &f.Type,
+		&f.Title, &f.Description, &f.SrcIP, &f.DstIP, &f.Timestamp,
+		&f.Suppressed, &f.SuppressReason)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// suppressionsForAnalysis loads the rules of the analysis owner, so a
+// save can apply them to incoming findings.
+func (db *DB) suppressionsForAnalysis(ctx context.Context, analysisID int64) ([]Suppression, error) {
+	var userID int64
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT user_id FROM analyses WHERE id = ?`, analysisID).Scan(&userID)
+	if err != nil {
+		return nil, err
+	}
+	return db.ListSuppressions(ctx, userID)
+}
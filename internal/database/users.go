@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateUser inserts a new user and returns it.
+func (db *DB) CreateUser(ctx context.Context, username, passwordHash string) (*User, error) {
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO users (username, password_hash) VALUES (?, ?)`,
+		username, passwordHash)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return db.GetUserByID(ctx, id)
+}
+
+// GetUserByID returns the user with the given id, or nil if not found.
+func (db *DB) GetUserByID(ctx context.Context, id int64) (*User, error) {
+	return db.scanUser(db.conn.QueryRowContext(ctx,
+		`SELECT id, username, password_hash, totp_secret, totp_enabled, created_at
+		 FROM users WHERE id = ?`, id))
+}
+
+// GetUserByUsername returns the user with the given username, or nil if
+// not found.
+func (db *DB) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return db.scanUser(db.conn.QueryRowContext(ctx,
+		`SELECT id, username, password_hash, totp_secret, totp_enabled, created_at
+		 FROM users WHERE username = ?`, username))
+}
+
+// SetUserPassword replaces the stored password hash.
+func (db *DB) SetUserPassword(ctx context.Context, id int64, passwordHash string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	return err
+}
+
+// SetUserTOTP stores the TOTP secret and whether two-factor is active.
+func (db *DB) SetUserTOTP(ctx context.Context, id int64, secret string, enabled bool) error {
+	_, err := db.conn.ExecContext(ctx,
+		`UPDATE users SET totp_secret = ?, totp_enabled = ? WHERE id = ?`,
+		secret, enabled, id)
+	return err
+}
+
+func (db *DB) scanUser(row *sql.Row) (*User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.TOTPSecret,
+		&u.TOTPEnabled, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateSession stores a session token for the user.
+func (db *DB) CreateSession(ctx context.Context, token string, userID int64, expiresAt time.Time) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT INTO sessions (token, user_id, expires_at) VALUES (?, ?, ?)`,
+		token, userID, expiresAt)
+	return err
+}
+
+// GetSessionUser resolves a session token to its user. Expired or unknown
+// tokens return nil.
+func (db *DB) GetSessionUser(ctx context.Context, token string) (*User, error) {
+	var userID int64
+	var expiresAt time.Time
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT user_id, expires_at FROM sessions WHERE token = ?`, token).
+		Scan(&userID, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		db.DeleteSession(ctx, token)
+		return nil, nil
+	}
+	return db.GetUserByID(ctx, userID)
+}
+
+// DeleteSession removes a session token.
+func (db *DB) DeleteSession(ctx context.Context, token string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`DELETE FROM sessions WHERE token = ?`, token)
+	return err
+}
@@ -0,0 +1,76 @@
+// Package events fans out per-analysis progress events to interested
+// subscribers (WebSocket live tails, status streams).
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event is one progress notification for an analysis.
+type Event struct {
+	AnalysisID int64       `json:"analysisId"`
+	Type       string      `json:"type"` // status, asset, finding
+	Payload    interface{} `json:"payload"`
+	Time       time.Time   `json:"time"`
+}
+
+// Hub routes events to subscribers keyed by analysis ID. Slow subscribers
+// drop events rather than block the analyzer.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[int64]map[chan Event]bool
+}
+
+// Default is the process-wide hub the analyzer and workers publish to.
+var Default = NewHub()
+
+// NewHub returns an empty hub.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int64]map[chan Event]bool)}
+}
+
+// Subscribe registers interest in one analysis. The returned channel is
+// buffered; call Unsubscribe when done.
+func (h *Hub) Subscribe(analysisID int64) chan Event {
+	ch := make(chan Event, 64)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[analysisID] == nil {
+		h.subs[analysisID] = make(map[chan Event]bool)
+	}
+	h.subs[analysisID][ch] = true
+	return ch
+}
+
+// Unsubscribe removes a subscription and closes its channel.
+func (h *Hub) Unsubscribe(analysisID int64, ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs := h.subs[analysisID]; subs != nil && subs[ch] {
+		delete(subs, ch)
+		close(ch)
+		if len(subs) == 0 {
+			delete(h.subs, analysisID)
+		}
+	}
+}
+
+// Publish delivers an event to every subscriber of the analysis. Events
+// to subscribers with full buffers are dropped.
+func (h *Hub) Publish(analysisID int64, eventType string, payload interface{}) {
+	ev := Event{
+		AnalysisID: analysisID,
+		Type:       eventType,
+		Payload:    payload,
+		Time:       time.Now(),
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[analysisID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
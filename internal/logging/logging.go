@@ -0,0 +1,36 @@
+// Package logging configures the process-wide structured logger.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup installs a slog default logger according to the LOG_LEVEL
+// (debug, info, warn, error) and LOG_FORMAT (text, json) environment
+// variables and returns it. Text output is the default for interactive
+// use; JSON is intended for production log shippers.
+func Setup() *slog.Logger {
+	level := slog.LevelInfo
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.ToLower(os.Getenv("LOG_FORMAT")) == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger
+}
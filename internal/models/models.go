@@ -0,0 +1,256 @@
+// Package models holds the result types shared between the analyzer and
+// its sinks. Keeping them free of persistence concerns lets the analyzer
+// run as a library without SQLite.
+package models
+
+import "time"
+
+// Asset is a host observed in a capture, keyed by MAC where the link
+// layer is visible and by IP otherwise.
+type Asset struct {
+	ID            int64    `json:"id"`
+	AnalysisID    int64    `json:"analysisId"`
+	MAC           string   `json:"mac"`
+	IP            string   `json:"ip"`
+	Hostname      string   `json:"hostname,omitempty"`
+	Vendor        string   `json:"vendor,omitempty"`
+	RandomizedMAC bool     `json:"randomizedMac"`
+	OS            string   `json:"os,omitempty"`
+	OSConfidence  int      `json:"osConfidence"`
+	OSSignals     []string `json:"osSignals,omitempty"`
+	// IPClass labels the address against the special-purpose registry:
+	// private, cgnat, multicast, reserved, public and friends.
+	IPClass      string `json:"ipClass,omitempty"`
+	ASN          int    `json:"asn,omitempty"`
+	ASName       string `json:"asName,omitempty"`
+	AnomalyScore int    `json:"anomalyScore"`
+	// Reputation is the feed-reported abuse confidence (0-100) for public
+	// addresses; 0 when lookups are disabled or the address is unknown.
+	Reputation int `json:"reputation,omitempty"`
+	// FanOut and FanIn count the distinct peers this host dialed and was
+	// dialed by; PortsDialed and PortsServed count the distinct remote
+	// ports it contacted and local ports it accepted connections on.
+	FanOut      int    `json:"fanOut"`
+	FanIn       int    `json:"fanIn"`
+	PortsDialed int    `json:"portsDialed"`
+	PortsServed int    `json:"portsServed"`
+	DeviceType  string `json:"deviceType,omitempty"`
+	// HostID links the asset to the stable cross-capture host identity,
+	// assigned by correlation after the analysis completes; 0 until then.
+	HostID      int64     `json:"hostId,omitempty"`
+	PacketsSent int64     `json:"packetsSent"`
+	PacketsRecv int64     `json:"packetsRecv"`
+	BytesSent   int64     `json:"bytesSent"`
+	BytesRecv   int64     `json:"bytesRecv"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// Connection is an aggregated flow between two endpoints.
+type Connection struct {
+	ID          int64  `json:"id"`
+	AnalysisID  int64  `json:"analysisId"`
+	Protocol    string `json:"protocol"`
+	SrcIP       string `json:"srcIp"`
+	SrcPort     int    `json:"srcPort"`
+	DstIP       string `json:"dstIp"`
+	DstPort     int    `json:"dstPort"`
+	Service     string `json:"service,omitempty"`
+	AppProtocol string `json:"appProtocol,omitempty"`
+	AppMessages int64  `json:"appMessages,omitempty"`
+	PacketsSent int64  `json:"packetsSent"`
+	PacketsRecv int64  `json:"packetsRecv"`
+	BytesSent   int64  `json:"bytesSent"`
+	BytesRecv   int64  `json:"bytesRecv"`
+	// RetransPackets and RetransBytes total the TCP retransmissions seen
+	// on the flow; they are excluded from the counters above so lossy
+	// links do not inflate byte totals.
+	RetransPackets int64     `json:"retransPackets,omitempty"`
+	RetransBytes   int64     `json:"retransBytes,omitempty"`
+	FirstSeen      time.Time `json:"firstSeen"`
+	LastSeen       time.Time `json:"lastSeen"`
+	// Metadata holds application attributes protocol modules attached to
+	// the flow (http.host, tls.sni, dns.qname, ...).
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// AssetService is a port a host was passively observed listening on.
+type AssetService struct {
+	ID         int64  `json:"id"`
+	AnalysisID int64  `json:"analysisId"`
+	IP         string `json:"ip"`
+	Port       int    `json:"port"`
+	Protocol   string `json:"protocol"`
+	Service    string `json:"service,omitempty"`
+}
+
+// DNSQuery is a single observed DNS question with any answers seen.
+type DNSQuery struct {
+	ID         int64     `json:"id"`
+	AnalysisID int64     `json:"analysisId"`
+	SrcIP      string    `json:"srcIp"`
+	Query      string    `json:"query"`
+	QueryType  string    `json:"queryType"`
+	Answers    string    `json:"answers,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// IPMACBinding is one IP-to-MAC association learned from ARP or DHCP,
+// kept as history so conflicts and churn are visible after the fact.
+type IPMACBinding struct {
+	ID          int64     `json:"id"`
+	AnalysisID  int64     `json:"analysisId"`
+	IP          string    `json:"ip"`
+	MAC         string    `json:"mac"`
+	Source      string    `json:"source"` // arp or dhcp
+	PacketCount int64     `json:"packetCount"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// UserAgent is one distinct HTTP User-Agent observed from a source host.
+type UserAgent struct {
+	ID         int64  `json:"id"`
+	AnalysisID int64  `json:"analysisId"`
+	SrcIP      string `json:"srcIp"`
+	UserAgent  string `json:"userAgent"`
+	Count      int64  `json:"count"`
+}
+
+// BroadcastRow totals one source's broadcast or multicast traffic for one
+// protocol (arp, mdns, ssdp, netbios, llmnr, dhcp, igmp or other). SrcIP
+// holds the MAC for protocols without an IP layer.
+type BroadcastRow struct {
+	ID         int64  `json:"id"`
+	AnalysisID int64  `json:"analysisId"`
+	SrcIP      string `json:"srcIp"`
+	Protocol   string `json:"protocol"`
+	Packets    int64  `json:"packets"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// DNSServerHealth summarizes how one DNS server behaved across the
+// capture: volume, latency percentiles and error-response rates.
+type DNSServerHealth struct {
+	ID         int64   `json:"id"`
+	AnalysisID int64   `json:"analysisId"`
+	ServerIP   string  `json:"serverIp"`
+	Queries    int64   `json:"queries"`
+	Responses  int64   `json:"responses"`
+	Servfail   int64   `json:"servfail"`
+	Nxdomain   int64   `json:"nxdomain"`
+	Clients    int64   `json:"clients"`
+	MedianMs   float64 `json:"medianMs"`
+	P95Ms      float64 `json:"p95Ms"`
+}
+
+// SubnetLatency is the TCP handshake round-trip between two subnets as
+// seen at the capture point, one row per directed pair of the latency
+// matrix.
+type SubnetLatency struct {
+	ID         int64   `json:"id"`
+	AnalysisID int64   `json:"analysisId"`
+	SrcSubnet  string  `json:"srcSubnet"`
+	DstSubnet  string  `json:"dstSubnet"`
+	Samples    int64   `json:"samples"`
+	MedianMs   float64 `json:"medianMs"`
+	P95Ms      float64 `json:"p95Ms"`
+}
+
+// ProtocolCount is one node of the protocol hierarchy: the slash-joined
+// layer path ("eth/ip/tcp/http") with the packets and bytes that reached
+// that depth.
+type ProtocolCount struct {
+	ID         int64  `json:"id"`
+	AnalysisID int64  `json:"analysisId"`
+	Path       string `json:"path"`
+	Packets    int64  `json:"packets"`
+	Bytes      int64  `json:"bytes"`
+}
+
+// ServiceDuration aggregates connection durations for one service:
+// session count and duration percentiles in seconds.
+type ServiceDuration struct {
+	ID         int64   `json:"id"`
+	AnalysisID int64   `json:"analysisId"`
+	Service    string  `json:"service"`
+	Sessions   int64   `json:"sessions"`
+	MedianS    float64 `json:"medianS"`
+	P95S       float64 `json:"p95S"`
+	MaxS       float64 `json:"maxS"`
+}
+
+// AccountUsage maps one account to one authentication path: the domain,
+// username and workstation NTLM discloses in cleartext, plus the hosts
+// the authentication ran between. No credential material is stored.
+type AccountUsage struct {
+	ID          int64     `json:"id"`
+	AnalysisID  int64     `json:"analysisId"`
+	Domain      string    `json:"domain,omitempty"`
+	Username    string    `json:"username"`
+	Workstation string    `json:"workstation,omitempty"`
+	SrcIP       string    `json:"srcIp"`
+	DstIP       string    `json:"dstIp"`
+	Protocol    string    `json:"protocol"` // smb or http
+	Count       int64     `json:"count"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// MulticastMembership is one host's IGMP relationship with one multicast
+// group: how often it joined and left, and where it ended the capture.
+type MulticastMembership struct {
+	ID         int64     `json:"id"`
+	AnalysisID int64     `json:"analysisId"`
+	HostIP     string    `json:"hostIp"`
+	Group      string    `json:"group"`
+	Joins      int64     `json:"joins"`
+	Leaves     int64     `json:"leaves"`
+	State      string    `json:"state"` // joined or left
+	FirstSeen  time.Time `json:"firstSeen"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+// Finding is a detection raised during analysis.
+type Finding struct {
+	ID          int64     `json:"id"`
+	AnalysisID  int64     `json:"analysisId"`
+	Severity    string    `json:"severity"`
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	SrcIP       string    `json:"srcIp,omitempty"`
+	DstIP       string    `json:"dstIp,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Suppressed marks the finding a false positive, either by hand or
+	// because a suppression rule matched when the analysis was saved.
+	Suppressed     bool   `json:"suppressed,omitempty"`
+	SuppressReason string `json:"suppressReason,omitempty"`
+}
+
+// Suppression is a user's standing rule to auto-suppress findings: any
+// finding matching the (possibly empty) type and source IP is marked a
+// false positive in every future analysis.
+type Suppression struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"userId"`
+	Type      string    `json:"type,omitempty"`
+	SrcIP     string    `json:"srcIp,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Matches reports whether a finding falls under the rule. Empty fields
+// match anything, but a rule with no criteria at all matches nothing.
+func (s Suppression) Matches(f Finding) bool {
+	if s.Type == "" && s.SrcIP == "" {
+		return false
+	}
+	if s.Type != "" && s.Type != f.Type {
+		return false
+	}
+	if s.SrcIP != "" && s.SrcIP != f.SrcIP {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted wraps any Storage and encrypts objects at rest with AES-GCM,
+// for environments with data-at-rest requirements. Captures are sealed in
+// 64 KiB chunks so multi-gigabyte uploads stream without buffering, and
+// decryption is transparent: the analyzer and export paths read through
+// Open exactly as they would from a plain store. The key is server
+// managed, either a raw 256-bit key or derived per object from a
+// passphrase with scrypt; see NewEncrypted and NewEncryptedPassphrase.
+type Encrypted struct {
+	inner      Storage
+	key        []byte // raw key mode; nil in passphrase mode
+	passphrase string
+}
+
+// Object layout: a fixed header followed by sealed chunks.
+//
+//	magic (8) | key mode (1) | scrypt salt (16) | base nonce (12)
+//
+// Each chunk encrypts up to encChunkSize plaintext bytes under a nonce
+// formed by XORing the chunk counter into the base nonce, which makes
+// reordered or replayed chunks fail authentication. The final chunk is
+// sealed with a distinct additional-data tag so truncation at a chunk
+// boundary is also detected.
+const (
+	encMagic     = "PCAPENC\x01"
+	encChunkSize = 64 * 1024
+	encSaltLen   = 16
+	encNonceLen  = 12
+	encOverhead  = 16
+	encHeaderLen = len(encMagic) + 1 + encSaltLen + encNonceLen
+
+	encKeyModeRaw  = 0
+	encKeyModePass = 1
+)
+
+// Additional-data tags distinguishing the final chunk from the rest.
+var (
+	encAADMiddle = []byte{0}
+	encAADFinal  = []byte{1}
+)
+
+// NewEncrypted wraps inner with encryption under a raw 256-bit key.
+func NewEncrypted(inner Storage, key []byte) (*Encrypted, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	return &Encrypted{inner: inner, key: key}, nil
+}
+
+// NewEncryptedPassphrase wraps inner with encryption under a key derived
+// from the passphrase with scrypt, salted per object.
+func NewEncryptedPassphrase(inner Storage, passphrase string) *Encrypted {
+	return &Encrypted{inner: inner, passphrase: passphrase}
+}
+
+// objectKey returns the AES key for one object given its header salt.
+func (e *Encrypted) objectKey(salt []byte) ([]byte, error) {
+	if e.key != nil {
+		return e.key, nil
+	}
+	return scrypt.Key([]byte(e.passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func (e *Encrypted) keyMode() byte {
+	if e.key != nil {
+		return encKeyModeRaw
+	}
+	return encKeyModePass
+}
+
+// chunkNonce folds the chunk counter into the base nonce.
+func chunkNonce(base []byte, counter uint32) []byte {
+	nonce := make([]byte, encNonceLen)
+	copy(nonce, base)
+	var ctr [4]byte
+	binary.BigEndian.PutUint32(ctr[:], counter)
+	for i, b := range ctr {
+		nonce[encNonceLen-4+i] ^= b
+	}
+	return nonce
+}
+
+// encryptedSize is the ciphertext size for a plaintext of the given size:
+// the header plus one authentication tag per chunk. The final chunk holds
+// the remainder and may be empty, so even a truncated-to-nothing object
+// fails authentication.
+func encryptedSize(size int64) int64 {
+	chunks := size/encChunkSize + 1
+	return int64(encHeaderLen) + size + chunks*encOverhead
+}
+
+// Save implements Storage, sealing r chunk by chunk into the inner store.
+func (e *Encrypted) Save(name string, r io.Reader, size int64) error {
+	salt := make([]byte, encSaltLen)
+	baseNonce := make([]byte, encNonceLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(baseNonce); err != nil {
+		return err
+	}
+	key, err := e.objectKey(salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(encryptTo(pw, gcm, e.keyMode(), salt, baseNonce, r))
+	}()
+	if err := e.inner.Save(name, pr, encryptedSize(size)); err != nil {
+		// Unblock the sealing goroutine if the inner store stopped reading.
+		pr.CloseWithError(err)
+		return err
+	}
+	return nil
+}
+
+// encryptTo writes the header and sealed chunks of plain to w.
+func encryptTo(w io.Writer, gcm cipher.AEAD, mode byte, salt, baseNonce []byte, plain io.Reader) error {
+	header := make([]byte, 0, encHeaderLen)
+	header = append(header, encMagic...)
+	header = append(header, mode)
+	header = append(header, salt...)
+	header = append(header, baseNonce...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	// Full chunks are sealed as middle chunks; the remainder — possibly
+	// empty — always goes out as the final chunk, so every object ends
+	// with a short chunk the reader can recognize.
+	buf := make([]byte, encChunkSize)
+	var counter uint32
+	for {
+		n, rerr := io.ReadFull(plain, buf)
+		if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+			return rerr
+		}
+		final := rerr != nil
+		aad := encAADMiddle
+		if final {
+			aad = encAADFinal
+		}
+		sealed := gcm.Seal(nil, chunkNonce(baseNonce, counter), buf[:n], aad)
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+		counter++
+	}
+}
+
+// Open implements Storage, returning a reader that decrypts and
+// authenticates the object chunk by chunk.
+func (e *Encrypted) Open(name string) (io.ReadCloser, error) {
+	rc, err := e.inner.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderLen)
+	if _, err := io.ReadFull(rc, header); err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("read encryption header of %q: %w", name, err)
+	}
+	if string(header[:len(encMagic)]) != encMagic {
+		rc.Close()
+		return nil, fmt.Errorf("object %q is not encrypted with this format", name)
+	}
+	salt := header[len(encMagic)+1 : len(encMagic)+1+encSaltLen]
+	baseNonce := header[encHeaderLen-encNonceLen:]
+	key, err := e.objectKey(salt)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return &decryptReader{rc: rc, gcm: gcm, baseNonce: baseNonce}, nil
+}
+
+// Delete implements Storage.
+func (e *Encrypted) Delete(name string) error {
+	return e.inner.Delete(name)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptReader opens sealed chunks as they are consumed. A chunk that
+// fails authentication, a missing final chunk or trailing garbage all
+// surface as read errors.
+type decryptReader struct {
+	rc        io.ReadCloser
+	gcm       cipher.AEAD
+	baseNonce []byte
+	counter   uint32
+	plain     bytes.Reader
+	done      bool
+	err       error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for d.plain.Len() == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.nextChunk(); err != nil {
+			d.err = err
+			return 0, err
+		}
+	}
+	return d.plain.Read(p)
+}
+
+// nextChunk reads and opens one sealed chunk.
+func (d *decryptReader) nextChunk() error {
+	sealed := make([]byte, encChunkSize+encOverhead)
+	n, err := io.ReadFull(d.rc, sealed)
+	switch err {
+	case nil:
+	case io.EOF:
+		return fmt.Errorf("encrypted object truncated: final chunk missing")
+	case io.ErrUnexpectedEOF:
+		// Short read: this is the final chunk.
+	default:
+		return err
+	}
+	// The final chunk holds less than a full chunk of plaintext, so a
+	// short read marks it; a full-size read is always a middle chunk.
+	aad := encAADMiddle
+	if err == io.ErrUnexpectedEOF {
+		aad = encAADFinal
+	}
+	plain, aerr := d.gcm.Open(nil, chunkNonce(d.baseNonce, d.counter), sealed[:n], aad)
+	if aerr != nil {
+		return fmt.Errorf("decrypt chunk %d: %w", d.counter, aerr)
+	}
+	if err == io.ErrUnexpectedEOF {
+		d.done = true
+	}
+	d.counter++
+	d.plain.Reset(plain)
+	return nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.rc.Close()
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ObjectKey builds a collision-proof storage name for an uploaded capture:
+// a per-user prefix keeps users' objects apart, and a random component
+// makes simultaneous uploads of the same filename safe. The original
+// filename stays on the Analysis record; only the sanitized form reaches
+// the object name.
+func ObjectKey(userID int64, filename string) string {
+	var b [16]byte
+	random := "fallback"
+	if _, err := rand.Read(b[:]); err == nil {
+		random = hex.EncodeToString(b[:])
+	}
+	return fmt.Sprintf("%d/%s_%s", userID, random, SanitizeFilename(filename))
+}
+
+// SanitizeFilename reduces a client-supplied filename to a safe single
+// path element: separators, parent references and control characters are
+// stripped, so a crafted multipart filename cannot escape the storage
+// directory.
+func SanitizeFilename(name string) string {
+	// Take the last path element under either separator convention.
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			// Control characters have no business in a filename.
+		case r == '/' || r == '\\' || r == ':':
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	cleaned := strings.TrimLeft(b.String(), ".")
+	if cleaned == "" {
+		return "capture"
+	}
+	return cleaned
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain name", "capture.pcap", "capture.pcap"},
+		{"parent traversal", "../../etc/passwd", "passwd"},
+		{"backslash traversal", `..\..\boot.ini`, "boot.ini"},
+		{"absolute path", "/var/dumps/x.pcap", "x.pcap"},
+		{"windows drive path", `C:\dumps\x.pcap`, "x.pcap"},
+		{"mixed separators", `dir\sub/evil.pcap`, "evil.pcap"},
+		{"control characters", "bad\x00name\x1f.pcap", "badname.pcap"},
+		{"delete character", "bad\x7fname.pcap", "badname.pcap"},
+		{"colon replaced", "net:cap.pcap", "net_cap.pcap"},
+		{"leading dot", ".hidden.pcap", "hidden.pcap"},
+		{"dots only", "...", "capture"},
+		{"empty", "", "capture"},
+		{"trailing separator", "dumps/", "capture"},
+		{"all stripped", "\x01\x02", "capture"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SanitizeFilename(tc.in); got != tc.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+var objectKeyPattern = regexp.MustCompile(`^42/[0-9a-f]{32}_evil\.pcap$`)
+
+func TestObjectKeyStructure(t *testing.T) {
+	key := ObjectKey(42, "../evil.pcap")
+	if !objectKeyPattern.MatchString(key) {
+		t.Errorf("ObjectKey = %q, want userID/random_sanitized form", key)
+	}
+	// Only the first element may be a separator: the sanitized filename
+	// must not introduce further path structure.
+	if strings.Count(key, "/") != 1 {
+		t.Errorf("ObjectKey = %q, want exactly one path separator", key)
+	}
+}
+
+func TestObjectKeyUniquePerCall(t *testing.T) {
+	a := ObjectKey(7, "same.pcap")
+	b := ObjectKey(7, "same.pcap")
+	if a == b {
+		t.Errorf("two uploads of the same name share key %q", a)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local stores objects as plain files in a directory.
+type Local struct {
+	dir string
+}
+
+// NewLocal creates the directory if needed and returns a Local store.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// Path returns the on-disk location of an object, letting the analyzer
+// read local captures without an extra copy. Object names may contain
+// subdirectories (per-user prefixes); rooting the join first strips any
+// ".." elements so a hostile name cannot escape the storage directory.
+func (l *Local) Path(name string) string {
+	clean := filepath.Join("/", filepath.FromSlash(name))
+	return filepath.Join(l.dir, clean)
+}
+
+// Save implements Storage.
+func (l *Local) Save(name string, r io.Reader, size int64) error {
+	path := l.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	return f.Close()
+}
+
+// Open implements Storage.
+func (l *Local) Open(name string) (io.ReadCloser, error) {
+	return os.Open(l.Path(name))
+}
+
+// Delete implements Storage.
+func (l *Local) Delete(name string) error {
+	err := os.Remove(l.Path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
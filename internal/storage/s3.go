@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3 talks to an S3-compatible object store (AWS S3, MinIO, Ceph RGW)
+// using path-style requests and Signature Version 4. Only the handful of
+// operations the service needs are implemented, which keeps us free of the
+// AWS SDK dependency tree.
+type S3 struct {
+	endpoint  string // e.g. https://minio.internal:9000
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3 returns a store for the given bucket. The endpoint must include
+// the scheme.
+func NewS3(endpoint, bucket, region, accessKey, secretKey string) *S3 {
+	return &S3{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+// Save implements Storage.
+func (s *S3) Save(name string, r io.Reader, size int64) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(name), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.apiError("put", name, resp)
+	}
+	return nil
+}
+
+// Open implements Storage.
+func (s *S3) Open(name string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.apiError("get", name, resp)
+	}
+	return resp.Body, nil
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(name string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(name), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return s.apiError("delete", name, resp)
+	}
+	return nil
+}
+
+func (s *S3) objectURL(name string) string {
+	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, url.PathEscape(name))
+}
+
+func (s *S3) apiError(op, name string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %q: %s: %s", op, name, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// unsignedPayload skips payload hashing so uploads can stream. The
+// connection to the object store should be TLS-protected in production.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sign adds AWS Signature Version 4 headers to req.
+func (s *S3) sign(req *http.Request) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", unsignedPayload)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		host, unsignedPayload, amzDate)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, s.region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256(
+		hmacSHA256(
+			hmacSHA256(
+				hmacSHA256([]byte("AWS4"+s.secretKey), dateStamp),
+				s.region),
+			"s3"),
+		"aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,17 @@
+// Package storage abstracts where uploaded capture files live, so the
+// service can run with local disk or an S3-compatible object store.
+package storage
+
+import "io"
+
+// Storage persists uploaded capture files under opaque object names.
+type Storage interface {
+	// Save writes size bytes from r under name, replacing any existing
+	// object.
+	Save(name string, r io.Reader, size int64) error
+	// Open returns a reader for the named object.
+	Open(name string) (io.ReadCloser, error)
+	// Delete removes the named object. Deleting a missing object is not
+	// an error.
+	Delete(name string) error
+}
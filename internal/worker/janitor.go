@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+const (
+	// defaultTrashRetentionDays is how long trashed analyses survive
+	// before the janitor purges them, unless TRASH_RETENTION_DAYS says
+	// otherwise.
+	defaultTrashRetentionDays = 7
+
+	janitorInterval = time.Hour
+)
+
+// Janitor permanently removes analyses whose trash retention has expired,
+// deleting the capture file along with the database rows.
+type Janitor struct {
+	db    *database.DB
+	store storage.Storage
+
+	retention time.Duration
+	quit      chan struct{}
+}
+
+// NewJanitor builds a janitor over the given database and capture store.
+func NewJanitor(db *database.DB, store storage.Storage) *Janitor {
+	days := envIntVar("TRASH_RETENTION_DAYS", defaultTrashRetentionDays)
+	if days < 1 {
+		days = 1
+	}
+	return &Janitor{
+		db:        db,
+		store:     store,
+		retention: time.Duration(days) * 24 * time.Hour,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start runs one purge pass immediately and then hourly in the
+// background.
+func (j *Janitor) Start() {
+	go func() {
+		j.purge()
+		ticker := time.NewTicker(janitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.quit:
+				return
+			case <-ticker.C:
+				j.purge()
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop.
+func (j *Janitor) Stop() {
+	close(j.quit)
+}
+
+func (j *Janitor) purge() {
+	expired, err := j.db.ListExpiredTrash(context.Background(), time.Now().Add(-j.retention))
+	if err != nil {
+		slog.Error("janitor: list expired trash", "err", err)
+		return
+	}
+	for _, a := range expired {
+		if err := j.store.Delete(a.FilePath); err != nil {
+			slog.Warn("janitor: delete capture", "analysis_id", a.ID, "err", err)
+		}
+		if err := j.db.PurgeAnalysis(context.Background(), a.ID); err != nil {
+			slog.Error("janitor: purge analysis", "analysis_id", a.ID, "err", err)
+			continue
+		}
+		slog.Info("janitor: purged analysis", "analysis_id", a.ID, "filename", a.Filename)
+	}
+}
@@ -0,0 +1,288 @@
+//go:build linux
+
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/afpacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// defaultLiveRotate is how long one live chunk collects packets before it
+// is sealed and queued for analysis, unless LIVE_ROTATE_SECONDS says
+// otherwise.
+const defaultLiveRotate = 60 * time.Second
+
+// livePollTimeout bounds how long a capture worker blocks in poll(), so
+// Stop is honored promptly even on a silent interface.
+const livePollTimeout = 100 * time.Millisecond
+
+// liveSnapLen is the snap length written to chunk file headers.
+const liveSnapLen = 65535
+
+// LiveCapture ingests traffic mirrored from a packet broker straight off
+// a network interface, for deployments that tap production traffic rather
+// than upload files. It reads via AF_PACKET TPACKETv3 ring buffers,
+// fanned out across LIVE_FANOUT capture workers by flow hash, and writes
+// rotating pcap chunks that flow through the ordinary storage, queue and
+// analysis pipeline — each chunk becomes a normal analysis owned by
+// LIVE_USER. PF_RING deployments can feed the drop-directory watcher with
+// their existing capture tooling instead; only AF_PACKET is native.
+type LiveCapture struct {
+	db    *database.DB
+	store storage.Storage
+	pool  *WorkerPool
+
+	iface   string
+	userID  int64
+	rotate  time.Duration
+	workers int
+	fanout  uint16 // AF_PACKET fanout group id shared by the workers
+
+	// mu guards the current chunk; capture workers append to it
+	// concurrently and rotation swaps it out.
+	mu      sync.Mutex
+	file    *os.File
+	writer  *pcapgo.Writer
+	opened  time.Time
+	packets int64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewLiveCapture builds a live ingester for LIVE_IFACE, capturing as the
+// LIVE_USER account. It returns nil (and logs) when either is unset or
+// the user does not exist, so callers can treat live mode as simply
+// disabled.
+func NewLiveCapture(db *database.DB, store storage.Storage, pool *WorkerPool) *LiveCapture {
+	iface := os.Getenv("LIVE_IFACE")
+	if iface == "" {
+		return nil
+	}
+	username := os.Getenv("LIVE_USER")
+	if username == "" {
+		slog.Error("live capture: LIVE_IFACE is set but LIVE_USER is not")
+		return nil
+	}
+	user, err := db.GetUserByUsername(context.Background(), username)
+	if err != nil || user == nil {
+		slog.Error("live capture: unknown LIVE_USER", "username", username, "err", err)
+		return nil
+	}
+	rotate := defaultLiveRotate
+	if secs := envIntVar("LIVE_ROTATE_SECONDS", 0); secs > 0 {
+		rotate = time.Duration(secs) * time.Second
+	}
+	workers := envIntVar("LIVE_FANOUT", 2)
+	if workers < 1 {
+		workers = 1
+	}
+	return &LiveCapture{
+		db:      db,
+		store:   store,
+		pool:    pool,
+		iface:   iface,
+		userID:  user.ID,
+		rotate:  rotate,
+		workers: workers,
+		fanout:  uint16(os.Getpid()),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start opens the first chunk and launches the capture workers plus a
+// rotation timer. Opening the interface requires CAP_NET_RAW.
+func (lc *LiveCapture) Start() error {
+	if err := lc.openChunk(); err != nil {
+		return err
+	}
+	slog.Info("live capture: ingesting from interface",
+		"iface", lc.iface, "workers", lc.workers, "rotate", lc.rotate)
+	for i := 0; i < lc.workers; i++ {
+		handle, err := afpacket.NewTPacket(
+			afpacket.OptInterface(lc.iface),
+			afpacket.TPacketVersion3,
+			afpacket.OptPollTimeout(livePollTimeout),
+		)
+		if err != nil {
+			lc.Stop()
+			return fmt.Errorf("open %s: %w", lc.iface, err)
+		}
+		if lc.workers > 1 {
+			if err := handle.SetFanout(afpacket.FanoutHash, lc.fanout); err != nil {
+				handle.Close()
+				lc.Stop()
+				return fmt.Errorf("set fanout on %s: %w", lc.iface, err)
+			}
+		}
+		lc.wg.Add(1)
+		go lc.capture(i, handle)
+	}
+	lc.wg.Add(1)
+	go lc.rotateLoop()
+	return nil
+}
+
+// Stop ends capturing and seals the in-progress chunk.
+func (lc *LiveCapture) Stop() {
+	select {
+	case <-lc.quit:
+	default:
+		close(lc.quit)
+	}
+	lc.wg.Wait()
+	lc.sealChunk()
+}
+
+// capture drains one fanout socket into the current chunk.
+func (lc *LiveCapture) capture(id int, handle *afpacket.TPacket) {
+	defer lc.wg.Done()
+	defer handle.Close()
+	for {
+		select {
+		case <-lc.quit:
+			return
+		default:
+		}
+		data, ci, err := handle.ZeroCopyReadPacketData()
+		if err == afpacket.ErrTimeout {
+			continue
+		}
+		if err != nil {
+			slog.Error("live capture: read", "worker", id, "err", err)
+			return
+		}
+		lc.writePacket(ci, data)
+	}
+}
+
+// rotateLoop seals chunks on schedule even when traffic pauses, so a
+// burst is not stuck behind a quiet period.
+func (lc *LiveCapture) rotateLoop() {
+	defer lc.wg.Done()
+	ticker := time.NewTicker(lc.rotate)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lc.quit:
+			return
+		case <-ticker.C:
+			lc.sealChunk()
+		}
+	}
+}
+
+// writePacket appends one packet, sealing the chunk first if it has been
+// collecting for a full rotation interval.
+func (lc *LiveCapture) writePacket(ci gopacket.CaptureInfo, data []byte) {
+	lc.mu.Lock()
+	if lc.writer != nil && lc.packets > 0 && time.Since(lc.opened) >= lc.rotate {
+		lc.sealChunkLocked()
+	}
+	if lc.writer == nil {
+		if err := lc.openChunkLocked(); err != nil {
+			lc.mu.Unlock()
+			slog.Error("live capture: open chunk", "err", err)
+			return
+		}
+	}
+	if err := lc.writer.WritePacket(ci, data); err != nil {
+		slog.Error("live capture: write packet", "err", err)
+	} else {
+		lc.packets++
+	}
+	lc.mu.Unlock()
+}
+
+// openChunk starts a fresh chunk file.
+func (lc *LiveCapture) openChunk() error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.openChunkLocked()
+}
+
+func (lc *LiveCapture) openChunkLocked() error {
+	f, err := os.CreateTemp("", "live-chunk-*.pcap")
+	if err != nil {
+		return err
+	}
+	w := pcapgo.NewWriter(f)
+	if err := w.WriteFileHeader(liveSnapLen, layers.LinkTypeEthernet); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	lc.file, lc.writer = f, w
+	lc.opened = time.Now()
+	lc.packets = 0
+	return nil
+}
+
+// sealChunk closes the current chunk and queues it for analysis. Empty
+// chunks are recycled in place rather than ingested.
+func (lc *LiveCapture) sealChunk() {
+	lc.mu.Lock()
+	lc.sealChunkLocked()
+	lc.mu.Unlock()
+}
+
+func (lc *LiveCapture) sealChunkLocked() {
+	if lc.file == nil || lc.packets == 0 {
+		if lc.file != nil {
+			lc.opened = time.Now()
+		}
+		return
+	}
+	f := lc.file
+	packets := lc.packets
+	lc.file, lc.writer = nil, nil
+	lc.packets = 0
+
+	// Ingestion hits storage and the database; do it off the capture path.
+	go func() {
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if err := lc.ingest(f, packets); err != nil {
+			slog.Error("live capture: ingest chunk", "err", err)
+		}
+	}()
+}
+
+// ingest stores one sealed chunk and queues its analysis, mirroring the
+// drop-directory watcher.
+func (lc *LiveCapture) ingest(f *os.File, packets int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("live-%s-%s.pcap", lc.iface, time.Now().UTC().Format("20060102T150405Z"))
+	key := storage.ObjectKey(lc.userID, storage.SanitizeFilename(name))
+	if err := lc.store.Save(key, f, info.Size()); err != nil {
+		return fmt.Errorf("store capture: %w", err)
+	}
+	id, err := lc.db.CreateAnalysis(context.Background(), lc.userID, name, key, info.Size())
+	if err != nil {
+		lc.store.Delete(key)
+		return fmt.Errorf("create analysis: %w", err)
+	}
+	lc.pool.Enqueue(lc.userID, id, key, analyzer.Options{})
+	slog.Info("live capture: ingested chunk",
+		"file", name, "analysis_id", id, "packets", packets)
+	return nil
+}
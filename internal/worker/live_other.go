@@ -0,0 +1,30 @@
+//go:build !linux
+
+package worker
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// LiveCapture requires AF_PACKET and therefore Linux; on other platforms
+// it only exists so main can compile.
+type LiveCapture struct{}
+
+// NewLiveCapture returns nil on non-Linux platforms, logging when live
+// capture was requested anyway.
+func NewLiveCapture(db *database.DB, store storage.Storage, pool *WorkerPool) *LiveCapture {
+	if os.Getenv("LIVE_IFACE") != "" {
+		slog.Error("live capture: AF_PACKET ingestion requires Linux")
+	}
+	return nil
+}
+
+// Start implements the Linux LiveCapture surface.
+func (lc *LiveCapture) Start() error { return nil }
+
+// Stop implements the Linux LiveCapture surface.
+func (lc *LiveCapture) Stop() {}
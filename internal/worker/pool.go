@@ -0,0 +1,496 @@
+// Package worker runs queued analyses in the background.
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/events"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// Worker count limits and autoscaler cadence. WORKER_MIN and WORKER_MAX
+// override the defaults.
+const (
+	defaultMinWorkers = 2
+	defaultMaxWorkers = 8
+	scaleInterval     = 5 * time.Second
+)
+
+// defaultUserConcurrency is how many analyses one user may have
+// processing at the same time; the rest wait in the user's sub-queue.
+// WORKER_USER_CONCURRENCY overrides it (0 disables the cap), and
+// WORKER_USER_CONCURRENCY_OVERRIDES ("userID:limit,userID:limit") grants
+// individual accounts — typically admins — a higher allowance.
+const defaultUserConcurrency = 2
+
+// Job identifies one queued analysis. Attempt counts completed
+// processing attempts, so a job requeued after a transient failure knows
+// how many retries it has left.
+type Job struct {
+	UserID     int64
+	AnalysisID int64
+	FilePath   string
+	Opts       analyzer.Options
+	Attempt    int
+}
+
+// PoolStats is a snapshot of the pool for metrics and debugging.
+type PoolStats struct {
+	Workers    int   `json:"workers"`
+	QueueDepth int   `json:"queueDepth"`
+	ScaleUps   int64 `json:"scaleUps"`
+	ScaleDowns int64 `json:"scaleDowns"`
+}
+
+// WorkerPool runs queued analyses through the analyzer. Jobs land in
+// per-user sub-queues and a dispatcher hands them to workers round-robin
+// across users, so one user queueing dozens of captures cannot starve
+// everyone else. A supervisor grows the pool towards maxWorkers while
+// jobs are backed up and shrinks it back to minWorkers when the queues
+// drain.
+type WorkerPool struct {
+	db       *database.DB
+	store    storage.Storage
+	JobQueue chan Job // dispatch channel the workers consume
+
+	minWorkers int
+	maxWorkers int
+	quit       chan struct{} // tokens telling one idle worker to exit
+
+	qmu        sync.Mutex
+	userQueues map[int64][]Job
+	userOrder  []int64       // round-robin rotation of users with jobs
+	running    map[int64]int // analyses currently processing per user
+	jobReady   chan struct{} // wakes the dispatcher after Enqueue
+
+	userLimit     int           // per-user concurrency cap, 0 = unlimited
+	userOverrides map[int64]int // per-account caps taking precedence
+
+	mu           sync.Mutex
+	workers      int
+	pendingQuits int // quit tokens sent but not yet consumed by a worker
+	nextID       int
+	scaleUps     int64
+	scaleDowns   int64
+}
+
+// NewWorkerPool creates a pool backed by db, reading captures from store.
+func NewWorkerPool(db *database.DB, store storage.Storage) *WorkerPool {
+	min := envIntVar("WORKER_MIN", defaultMinWorkers)
+	max := envIntVar("WORKER_MAX", defaultMaxWorkers)
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &WorkerPool{
+		db:            db,
+		store:         store,
+		JobQueue:      make(chan Job), // unbuffered so dispatch order is demand-driven
+		minWorkers:    min,
+		maxWorkers:    max,
+		quit:          make(chan struct{}, defaultMaxWorkers),
+		userQueues:    make(map[int64][]Job),
+		running:       make(map[int64]int),
+		jobReady:      make(chan struct{}, 1),
+		userLimit:     envIntVar("WORKER_USER_CONCURRENCY", defaultUserConcurrency),
+		userOverrides: parseUserOverrides(os.Getenv("WORKER_USER_CONCURRENCY_OVERRIDES")),
+	}
+}
+
+// parseUserOverrides reads "userID:limit" pairs separated by commas; bad
+// entries are logged and skipped.
+func parseUserOverrides(spec string) map[int64]int {
+	out := make(map[int64]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		idStr, limitStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			slog.Warn("concurrency override needs userID:limit", "entry", entry)
+			continue
+		}
+		id, err1 := strconv.ParseInt(idStr, 10, 64)
+		limit, err2 := strconv.Atoi(limitStr)
+		if err1 != nil || err2 != nil || limit < 0 {
+			slog.Warn("concurrency override not parseable", "entry", entry)
+			continue
+		}
+		out[id] = limit
+	}
+	return out
+}
+
+// limitFor returns the concurrency cap for one user, 0 meaning no cap.
+func (p *WorkerPool) limitFor(userID int64) int {
+	if n, ok := p.userOverrides[userID]; ok {
+		return n
+	}
+	return p.userLimit
+}
+
+// envIntVar reads an integer environment variable, falling back on
+// absence or garbage.
+func envIntVar(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// Start launches the minimum worker set, the dispatcher and the
+// autoscaler.
+func (p *WorkerPool) Start() {
+	for i := 0; i < p.minWorkers; i++ {
+		p.spawn()
+	}
+	go p.dispatch()
+	go p.autoscale()
+}
+
+// Enqueue queues an analysis for processing on the owner's sub-queue.
+func (p *WorkerPool) Enqueue(userID, analysisID int64, filePath string, opts analyzer.Options) {
+	p.enqueueJob(Job{
+		UserID:     userID,
+		AnalysisID: analysisID,
+		FilePath:   filePath,
+		Opts:       opts,
+	})
+}
+
+// enqueueJob places a job on its owner's sub-queue and wakes the
+// dispatcher. Retries come through here directly so the attempt counter
+// survives the round trip.
+func (p *WorkerPool) enqueueJob(job Job) {
+	p.qmu.Lock()
+	if _, ok := p.userQueues[job.UserID]; !ok {
+		p.userOrder = append(p.userOrder, job.UserID)
+	}
+	p.userQueues[job.UserID] = append(p.userQueues[job.UserID], job)
+	p.qmu.Unlock()
+
+	select {
+	case p.jobReady <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch feeds workers one job at a time, rotating across users.
+func (p *WorkerPool) dispatch() {
+	for range p.jobReady {
+		for {
+			job, ok := p.nextJob()
+			if !ok {
+				break
+			}
+			p.JobQueue <- job
+		}
+	}
+}
+
+// nextJob pops the head of the next user's sub-queue in round-robin
+// order. Users whose queue empties leave the rotation until they enqueue
+// again; users at their concurrency cap stay in the rotation but hold
+// their jobs until release frees a slot.
+func (p *WorkerPool) nextJob() (Job, bool) {
+	p.qmu.Lock()
+	defer p.qmu.Unlock()
+
+	for scanned, n := 0, len(p.userOrder); scanned < n && len(p.userOrder) > 0; {
+		user := p.userOrder[0]
+		p.userOrder = p.userOrder[1:]
+		queue := p.userQueues[user]
+		if len(queue) == 0 {
+			delete(p.userQueues, user)
+			continue
+		}
+		if limit := p.limitFor(user); limit > 0 && p.running[user] >= limit {
+			p.userOrder = append(p.userOrder, user)
+			scanned++
+			continue
+		}
+		job := queue[0]
+		if len(queue) == 1 {
+			delete(p.userQueues, user)
+		} else {
+			p.userQueues[user] = queue[1:]
+			p.userOrder = append(p.userOrder, user)
+		}
+		p.running[user]++
+		return job, true
+	}
+	return Job{}, false
+}
+
+// release frees one of a user's concurrency slots after a job finishes
+// and wakes the dispatcher for any jobs that were held back.
+func (p *WorkerPool) release(userID int64) {
+	p.qmu.Lock()
+	if p.running[userID] > 1 {
+		p.running[userID]--
+	} else {
+		delete(p.running, userID)
+	}
+	p.qmu.Unlock()
+
+	select {
+	case p.jobReady <- struct{}{}:
+	default:
+	}
+}
+
+// pending counts jobs waiting in all sub-queues.
+func (p *WorkerPool) pending() int {
+	p.qmu.Lock()
+	defer p.qmu.Unlock()
+	n := 0
+	for _, q := range p.userQueues {
+		n += len(q)
+	}
+	return n
+}
+
+// Stats returns a snapshot of pool size, backlog and scaling counters.
+func (p *WorkerPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{
+		Workers:    p.workers,
+		QueueDepth: p.pending(),
+		ScaleUps:   p.scaleUps,
+		ScaleDowns: p.scaleDowns,
+	}
+}
+
+// autoscale periodically resizes the pool: one worker up per tick while
+// jobs are waiting (and memory allows), one down per tick once the queue
+// is empty again. Every decision is logged with the pool state.
+func (p *WorkerPool) autoscale() {
+	for range time.Tick(scaleInterval) {
+		depth := p.pending()
+		p.mu.Lock()
+		workers := p.workers
+		p.mu.Unlock()
+
+		switch {
+		case depth > 0 && workers < p.maxWorkers:
+			if !memoryAllowsGrowth() {
+				slog.Warn("worker scale-up skipped: memory pressure",
+					"workers", workers, "queue_depth", depth)
+				continue
+			}
+			p.spawn()
+			p.mu.Lock()
+			p.scaleUps++
+			p.mu.Unlock()
+			slog.Info("worker pool scaled up", "workers", workers+1, "queue_depth", depth)
+		case depth == 0 && workers > p.minWorkers:
+			// A token stays queued until a worker consumes it, so count
+			// the outstanding ones: while every worker is busy on a long
+			// job, repeated idle ticks must not stack up enough tokens to
+			// shrink the pool below minWorkers once the jobs finish.
+			p.mu.Lock()
+			if p.workers-p.pendingQuits > p.minWorkers {
+				select {
+				case p.quit <- struct{}{}:
+					p.pendingQuits++
+					slog.Info("worker pool scaling down", "workers", workers, "queue_depth", depth)
+				default:
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// memoryAllowsGrowth applies the optional WORKER_MEM_SOFT_LIMIT_MB cap on
+// heap usage before adding workers; without the cap growth is always
+// allowed.
+func memoryAllowsGrowth() bool {
+	limitMB := envIntVar("WORKER_MEM_SOFT_LIMIT_MB", 0)
+	if limitMB <= 0 {
+		return true
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc < uint64(limitMB)*1024*1024
+}
+
+// spawn starts one more worker goroutine.
+func (p *WorkerPool) spawn() {
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.workers++
+	p.mu.Unlock()
+	go p.run(id)
+}
+
+// exit records that a worker is gone; tookToken marks exits that
+// consumed an autoscaler quit token.
+func (p *WorkerPool) exit(tookToken bool) {
+	p.mu.Lock()
+	p.workers--
+	p.scaleDowns++
+	if tookToken {
+		p.pendingQuits--
+	}
+	p.mu.Unlock()
+}
+
+func (p *WorkerPool) run(id int) {
+	for {
+		var job Job
+		select {
+		case <-p.quit:
+			p.exit(true)
+			return
+		case j, ok := <-p.JobQueue:
+			if !ok {
+				p.exit(false)
+				return
+			}
+			job = j
+		}
+		p.process(id, job)
+	}
+}
+
+// process runs one job to completion or failure.
+func (p *WorkerPool) process(id int, job Job) {
+	defer p.release(job.UserID)
+	// One correlation ID per job ties together all log lines for a
+	// single processing attempt, even across reprocessing runs.
+	logger := slog.With(
+		"worker", id,
+		"analysis_id", job.AnalysisID,
+		"correlation_id", newCorrelationID(),
+	)
+	logger.Info("starting analysis")
+	// Background jobs are not tied to any HTTP request lifetime.
+	ctx := context.Background()
+	// Claim the analysis with a guarded transition: if it is no longer
+	// pending, another worker already picked up a duplicate queue entry
+	// for it, and running it again would overwrite their results.
+	claimed, err := p.db.TransitionAnalysisStatus(ctx, job.AnalysisID, "pending", "processing", "")
+	if err != nil {
+		logger.Error("set status", "err", err)
+		return
+	}
+	if !claimed {
+		logger.Warn("analysis no longer pending, skipping duplicate job")
+		return
+	}
+	events.Default.Publish(job.AnalysisID, "status", "processing")
+	path, cleanup, err := p.materialize(job.FilePath)
+	if err != nil {
+		p.fail(logger, job, err)
+		return
+	}
+	err = analyzer.AnalyzePCAPOpts(ctx, p.db, job.AnalysisID, path, job.Opts)
+	cleanup()
+	if err != nil {
+		p.fail(logger, job, err)
+		return
+	}
+	// Correlation failure should not fail the analysis; the results are
+	// already saved and the next capture will correlate again.
+	if err := p.db.CorrelateHosts(ctx, job.UserID, job.AnalysisID); err != nil {
+		logger.Warn("correlate hosts", "err", err)
+	}
+	done, err := p.db.TransitionAnalysisStatus(ctx, job.AnalysisID, "processing", "completed", "")
+	if err != nil {
+		logger.Error("set status", "err", err)
+		return
+	}
+	if !done {
+		logger.Warn("analysis no longer processing, not marking completed")
+		return
+	}
+	events.Default.Publish(job.AnalysisID, "status", "completed")
+	logger.Info("analysis completed")
+}
+
+// fail handles a processing failure. Transient errors requeue the job
+// after an exponential backoff until the retry budget runs out; anything
+// else, or the final exhausted attempt, marks the analysis as failed.
+func (p *WorkerPool) fail(logger *slog.Logger, job Job, err error) {
+	if isTransientError(err) && job.Attempt < maxRetries() {
+		delay := retryBackoff(job.Attempt)
+		job.Attempt++
+		logger.Warn("analysis failed transiently, retrying",
+			"err", err, "attempt", job.Attempt, "delay", delay)
+		ok, terr := p.db.TransitionAnalysisStatus(context.Background(), job.AnalysisID, "processing", "pending", "")
+		if terr != nil || !ok {
+			// The analysis is no longer ours to retry.
+			logger.Warn("retry abandoned: analysis no longer processing", "err", terr)
+			return
+		}
+		events.Default.Publish(job.AnalysisID, "status", "pending")
+		time.AfterFunc(delay, func() { p.enqueueJob(job) })
+		return
+	}
+	logger.Error("analysis failed", "err", err)
+	if ok, terr := p.db.TransitionAnalysisStatus(context.Background(), job.AnalysisID, "processing", "failed", err.Error()); terr != nil || !ok {
+		logger.Warn("could not mark analysis failed", "err", terr)
+		return
+	}
+	events.Default.Publish(job.AnalysisID, "status", "failed")
+}
+
+// newCorrelationID returns a short random hex identifier.
+func newCorrelationID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// materialize makes the capture available as a local file. Plain local
+// storage is read in place; remote and encrypted objects are copied — and
+// transparently decrypted — to a temp file that the returned cleanup func
+// removes.
+func (p *WorkerPool) materialize(name string) (string, func(), error) {
+	if l, ok := p.store.(*storage.Local); ok {
+		return l.Path(name), func() {}, nil
+	}
+	rc, err := p.store.Open(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("open capture from storage: %w", err)
+	}
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "pcap-analyze-*")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", nil, fmt.Errorf("copy capture from storage: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	return tmp.Name(), func() { os.Remove(tmp.Name()) }, nil
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+)
+
+func TestNextJobRoundRobinAcrossUsers(t *testing.T) {
+	p := NewWorkerPool(nil, nil)
+
+	// User 1 queues three captures before user 2 queues one.
+	p.Enqueue(1, 101, "a.pcap", analyzer.Options{})
+	p.Enqueue(1, 102, "b.pcap", analyzer.Options{})
+	p.Enqueue(1, 103, "c.pcap", analyzer.Options{})
+	p.Enqueue(2, 201, "d.pcap", analyzer.Options{})
+
+	var order []int64
+	for {
+		job, ok := p.nextJob()
+		if !ok {
+			break
+		}
+		order = append(order, job.AnalysisID)
+		p.release(job.UserID)
+	}
+
+	want := []int64{101, 201, 102, 103}
+	if len(order) != len(want) {
+		t.Fatalf("dispatched %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("dispatched %v, want %v", order, want)
+		}
+	}
+	if p.pending() != 0 {
+		t.Errorf("pending = %d after draining", p.pending())
+	}
+}
+
+func TestNextJobHonorsUserConcurrencyLimit(t *testing.T) {
+	p := NewWorkerPool(nil, nil)
+	p.userLimit = 1
+
+	p.Enqueue(1, 101, "a.pcap", analyzer.Options{})
+	p.Enqueue(1, 102, "b.pcap", analyzer.Options{})
+	p.Enqueue(2, 201, "c.pcap", analyzer.Options{})
+
+	first, ok := p.nextJob()
+	if !ok || first.AnalysisID != 101 {
+		t.Fatalf("first job = %+v, want analysis 101", first)
+	}
+	// User 1 is at the cap; the next slot goes to user 2.
+	second, ok := p.nextJob()
+	if !ok || second.AnalysisID != 201 {
+		t.Fatalf("second job = %+v, want analysis 201", second)
+	}
+	if job, ok := p.nextJob(); ok {
+		t.Fatalf("dispatched %+v while both users capped", job)
+	}
+
+	p.release(1)
+	third, ok := p.nextJob()
+	if !ok || third.AnalysisID != 102 {
+		t.Fatalf("after release job = %+v, want analysis 102", third)
+	}
+}
+
+func TestNextJobUserOverride(t *testing.T) {
+	p := NewWorkerPool(nil, nil)
+	p.userLimit = 1
+	p.userOverrides = map[int64]int{7: 3}
+
+	for i := int64(0); i < 3; i++ {
+		p.Enqueue(7, 700+i, "a.pcap", analyzer.Options{})
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := p.nextJob(); !ok {
+			t.Fatalf("job %d held back despite override", i)
+		}
+	}
+}
+
+func TestParseUserOverrides(t *testing.T) {
+	got := parseUserOverrides("1:8, 5:4,bogus,2:x")
+	if len(got) != 2 || got[1] != 8 || got[5] != 4 {
+		t.Errorf("parseUserOverrides = %v, want {1:8 5:4}", got)
+	}
+}
+
+func TestNextJobEmpty(t *testing.T) {
+	p := NewWorkerPool(nil, nil)
+	if _, ok := p.nextJob(); ok {
+		t.Error("nextJob on empty pool should report no work")
+	}
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// Retry limits for transiently failed analyses. WORKER_MAX_RETRIES and
+// WORKER_RETRY_BACKOFF_SECONDS override the defaults; the backoff doubles
+// with each attempt.
+const (
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 30 * time.Second
+)
+
+func maxRetries() int {
+	return envIntVar("WORKER_MAX_RETRIES", defaultMaxRetries)
+}
+
+func retryBackoff(attempt int) time.Duration {
+	base := defaultRetryBackoff
+	if n := envIntVar("WORKER_RETRY_BACKOFF_SECONDS", 0); n > 0 {
+		base = time.Duration(n) * time.Second
+	}
+	return base << attempt
+}
+
+// transientErrors are substrings of error messages that indicate the
+// environment, not the capture, was at fault: a busy SQLite database,
+// exhausted descriptors or flaky I/O. A retry has a real chance of
+// succeeding. Decode errors from a corrupt pcap never match and stay
+// permanent.
+var transientErrors = []string{
+	"database is locked",
+	"database table is locked",
+	"resource temporarily unavailable",
+	"too many open files",
+	"i/o timeout",
+	"connection reset",
+	"temporary failure",
+}
+
+// isTransientError reports whether err looks recoverable by retrying.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if os.IsTimeout(err) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range transientErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
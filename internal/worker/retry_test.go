@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIsTransientError(t *testing.T) {
+	transient := []error{
+		errors.New("database is locked"),
+		errors.New("open capture from storage: read tcp: i/o timeout"),
+		errors.New("too many open files"),
+	}
+	for _, err := range transient {
+		if !isTransientError(err) {
+			t.Errorf("isTransientError(%q) = false, want true", err)
+		}
+	}
+	permanent := []error{
+		nil,
+		errors.New("unknown magic 0xdeadbeef"),
+		errors.New("pcap: file too short"),
+	}
+	for _, err := range permanent {
+		if isTransientError(err) {
+			t.Errorf("isTransientError(%v) = true, want false", err)
+		}
+	}
+}
+
+func TestRetryBackoffDoubles(t *testing.T) {
+	if got := retryBackoff(0); got != defaultRetryBackoff {
+		t.Errorf("retryBackoff(0) = %s, want %s", got, defaultRetryBackoff)
+	}
+	if got := retryBackoff(2); got != 4*defaultRetryBackoff {
+		t.Errorf("retryBackoff(2) = %s, want %s", got, 4*defaultRetryBackoff)
+	}
+	t.Setenv("WORKER_RETRY_BACKOFF_SECONDS", "5")
+	if got := retryBackoff(1); got != 10*time.Second {
+		t.Errorf("retryBackoff(1) with override = %s, want 10s", got)
+	}
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/analyzer"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+)
+
+// defaultWatchInterval is how often the watcher polls the drop directory,
+// unless WATCH_INTERVAL_SECONDS says otherwise.
+const defaultWatchInterval = 10 * time.Second
+
+// Watcher polls a drop directory and ingests new capture files as the
+// configured user, so rotating sniffers (tcpdump -G, dumpcap -b) feed the
+// analyzer continuously. A file is ingested once its size has been stable
+// for a full polling interval, which skips the file the sniffer is still
+// writing; ingested files are removed from the drop directory because the
+// capture now lives in the store.
+type Watcher struct {
+	db    *database.DB
+	store storage.Storage
+	pool  *WorkerPool
+
+	dir      string
+	userID   int64
+	interval time.Duration
+
+	// sizes remembers each candidate's size from the previous scan.
+	sizes map[string]int64
+	quit  chan struct{}
+}
+
+// NewWatcher builds a watcher over WATCH_DIR, ingesting as the WATCH_USER
+// account. It returns nil (and logs) when either is unset or the user
+// does not exist, so callers can treat watch mode as simply disabled.
+func NewWatcher(db *database.DB, store storage.Storage, pool *WorkerPool) *Watcher {
+	dir := os.Getenv("WATCH_DIR")
+	if dir == "" {
+		return nil
+	}
+	username := os.Getenv("WATCH_USER")
+	if username == "" {
+		slog.Error("watcher: WATCH_DIR is set but WATCH_USER is not")
+		return nil
+	}
+	user, err := db.GetUserByUsername(context.Background(), username)
+	if err != nil || user == nil {
+		slog.Error("watcher: unknown WATCH_USER", "username", username, "err", err)
+		return nil
+	}
+	secs := envIntVar("WATCH_INTERVAL_SECONDS", 0)
+	interval := defaultWatchInterval
+	if secs > 0 {
+		interval = time.Duration(secs) * time.Second
+	}
+	return &Watcher{
+		db:       db,
+		store:    store,
+		pool:     pool,
+		dir:      dir,
+		userID:   user.ID,
+		interval: interval,
+		sizes:    make(map[string]int64),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start polls the drop directory in the background until Stop.
+func (w *Watcher) Start() {
+	slog.Info("watcher: ingesting from directory", "dir", w.dir, "interval", w.interval)
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.quit:
+				return
+			case <-ticker.C:
+				w.scan()
+			}
+		}
+	}()
+}
+
+// Stop ends the background loop.
+func (w *Watcher) Stop() {
+	close(w.quit)
+}
+
+// scan ingests every capture file whose size is unchanged since the
+// previous pass.
+func (w *Watcher) scan() {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		slog.Error("watcher: read directory", "dir", w.dir, "err", err)
+		return
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !isCaptureFile(name) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		seen[name] = true
+		prev, known := w.sizes[name]
+		w.sizes[name] = info.Size()
+		if !known || prev != info.Size() {
+			// New or still growing; pick it up next pass.
+			continue
+		}
+		if err := w.ingest(name, info.Size()); err != nil {
+			slog.Error("watcher: ingest", "file", name, "err", err)
+			continue
+		}
+		delete(w.sizes, name)
+	}
+	// Forget files that disappeared between scans.
+	for name := range w.sizes {
+		if !seen[name] {
+			delete(w.sizes, name)
+		}
+	}
+}
+
+// ingest copies one drop file into the store, queues its analysis and
+// removes the original.
+func (w *Watcher) ingest(name string, size int64) error {
+	path := filepath.Join(w.dir, name)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	key := storage.ObjectKey(w.userID, storage.SanitizeFilename(name))
+	if err := w.store.Save(key, f, size); err != nil {
+		return fmt.Errorf("store capture: %w", err)
+	}
+	id, err := w.db.CreateAnalysis(context.Background(), w.userID, name, key, size)
+	if err != nil {
+		w.store.Delete(key)
+		return fmt.Errorf("create analysis: %w", err)
+	}
+	w.pool.Enqueue(w.userID, id, key, analyzer.Options{})
+	if err := os.Remove(path); err != nil {
+		slog.Warn("watcher: remove ingested file", "file", name, "err", err)
+	}
+	slog.Info("watcher: ingested capture", "file", name, "analysis_id", id)
+	return nil
+}
+
+// isCaptureFile reports whether the filename has a capture extension.
+func isCaptureFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pcap", ".pcapng", ".cap":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// pcap-analyzer is a self-hosted network capture analysis service. Users
+// upload PCAP files over a REST API; background workers extract assets,
+// flows, DNS activity and findings into SQLite.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/api"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/database"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/logging"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/storage"
+	"github.com/kedar-kulkarni/pcap-analyzer/internal/worker"
+)
+
+func main() {
+	logging.Setup()
+
+	dbPath := envOr("DB_PATH", "pcap-analyzer.db")
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		slog.Error("open database", "err", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	store, err := buildStorage()
+	if err != nil {
+		slog.Error("init storage", "err", err)
+		os.Exit(1)
+	}
+
+	pool := worker.NewWorkerPool(db, store)
+	pool.Start()
+
+	janitor := worker.NewJanitor(db, store)
+	janitor.Start()
+	defer janitor.Stop()
+
+	if watcher := worker.NewWatcher(db, store, pool); watcher != nil {
+		watcher.Start()
+		defer watcher.Stop()
+	}
+
+	if live := worker.NewLiveCapture(db, store, pool); live != nil {
+		if err := live.Start(); err != nil {
+			slog.Error("start live capture", "err", err)
+			os.Exit(1)
+		}
+		defer live.Stop()
+	}
+
+	server := api.NewServer(db, pool, store)
+	addr := envOr("LISTEN_ADDR", ":8080")
+	srv := &http.Server{Addr: addr, Handler: server.Router()}
+	cert, key := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	useTLS := cert != "" && key != ""
+	if useTLS {
+		// With a client CA configured, clients presenting a certificate
+		// are verified against it and authenticated by their common name.
+		if caFile := os.Getenv("TLS_CLIENT_CA_FILE"); caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				slog.Error("read client CA", "err", err)
+				os.Exit(1)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				slog.Error("read client CA", "err", fmt.Errorf("no certificates in %s", caFile))
+				os.Exit(1)
+			}
+			srv.TLSConfig = &tls.Config{
+				ClientCAs:  pool,
+				ClientAuth: tls.VerifyClientCertIfGiven,
+			}
+		}
+	}
+
+	// On SIGTERM stop accepting connections and let in-flight requests
+	// (uploads, long queries) drain before the process exits; stragglers
+	// are cut off after SHUTDOWN_TIMEOUT_SECONDS.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		slog.Info("shutting down")
+		timeout := time.Duration(envIntOr("SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("shutdown", "err", err)
+		}
+	}()
+
+	slog.Info("listening", "addr", addr, "tls", useTLS)
+	var serveErr error
+	if useTLS {
+		serveErr = srv.ListenAndServeTLS(cert, key)
+	} else {
+		serveErr = srv.ListenAndServe()
+	}
+	if serveErr != nil && serveErr != http.ErrServerClosed {
+		slog.Error("server", "err", serveErr)
+		os.Exit(1)
+	}
+	<-done
+}
+
+// buildStorage picks the capture store from STORAGE_BACKEND: "local"
+// (default) keeps files under UPLOAD_DIR, "s3" talks to an S3-compatible
+// endpoint configured via S3_* variables. Either backend is wrapped with
+// at-rest encryption when STORAGE_ENCRYPTION_KEY (64 hex characters) or
+// STORAGE_ENCRYPTION_PASSPHRASE is set.
+func buildStorage() (storage.Storage, error) {
+	var store storage.Storage
+	switch backend := envOr("STORAGE_BACKEND", "local"); backend {
+	case "local":
+		local, err := storage.NewLocal(envOr("UPLOAD_DIR", "uploads"))
+		if err != nil {
+			return nil, err
+		}
+		store = local
+	case "s3":
+		endpoint := os.Getenv("S3_ENDPOINT")
+		bucket := os.Getenv("S3_BUCKET")
+		if endpoint == "" || bucket == "" {
+			return nil, fmt.Errorf("s3 backend requires S3_ENDPOINT and S3_BUCKET")
+		}
+		store = storage.NewS3(endpoint, bucket, envOr("S3_REGION", "us-east-1"),
+			os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+
+	if hexKey := os.Getenv("STORAGE_ENCRYPTION_KEY"); hexKey != "" {
+		key, err := hex.DecodeString(hexKey)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("STORAGE_ENCRYPTION_KEY must be 64 hex characters (a 256-bit key)")
+		}
+		return storage.NewEncrypted(store, key)
+	}
+	if pass := os.Getenv("STORAGE_ENCRYPTION_PASSPHRASE"); pass != "" {
+		return storage.NewEncryptedPassphrase(store, pass), nil
+	}
+	return store, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envIntOr(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}